@@ -0,0 +1,340 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+
+	"github.com/spf13/cobra"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+)
+
+var (
+	runIDOnce     sync.Once
+	overrides     configurationOverrides
+	sinkSpecs     []string
+	notifierSpecs []string
+)
+
+// configurationOverrides replace the global configuration of a spec at runtime, so the same
+// manifest can run in a fast and a patient mode. Entries with their own configuration keep it.
+type configurationOverrides struct {
+	interval         time.Duration
+	successThreshold int
+	failureThreshold int
+}
+
+func addConfigurationFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&overrides.interval, "interval", 0, "Override the interval of spec.configuration")
+	cmd.Flags().IntVar(&overrides.successThreshold, "success-threshold", 0, "Override the success threshold of spec.configuration")
+	cmd.Flags().IntVar(&overrides.failureThreshold, "failure-threshold", 0, "Override the failure threshold of spec.configuration")
+}
+
+func applyConfigurationOverrides(spec *v1alpha1.ClusterValidation) {
+	cfg := &spec.Spec.Configuration
+	if overrides.interval > 0 {
+		log.Infof("overriding interval %v with %v", cfg.Interval, overrides.interval)
+		cfg.Interval = overrides.interval.String()
+	}
+	if overrides.successThreshold > 0 {
+		log.Infof("overriding success threshold %v with %v", cfg.SuccessThreshold, overrides.successThreshold)
+		cfg.SuccessThreshold = overrides.successThreshold
+	}
+	if overrides.failureThreshold > 0 {
+		log.Infof("overriding failure threshold %v with %v", cfg.FailureThreshold, overrides.failureThreshold)
+		cfg.FailureThreshold = overrides.failureThreshold
+	}
+}
+
+func loadValidationSpec(file, preset string) *v1alpha1.ClusterValidation {
+	if file == "" && preset == "" {
+		log.Fatal("--filename or --preset is required")
+	}
+
+	if file != "" && preset != "" {
+		log.Fatal("--filename and --preset are mutually exclusive")
+	}
+
+	if preset != "" {
+		if signatureOptions.PublicKey != "" {
+			log.Warnf("preset '%v' ships in the binary and is not verified with --public-key", preset)
+		}
+		spec, err := client.ParsePreset(preset)
+		if err != nil {
+			log.Fatalf("failed to load preset: %v", err)
+		}
+		applyDefaultNamespace(spec)
+		return spec
+	}
+
+	spec, err := client.ParseSignedValidationSpec(file, signatureOptions)
+	if err != nil {
+		log.Fatalf("failed to parse validation spec from file: %v", err)
+	}
+	applyDefaultNamespace(spec)
+	return spec
+}
+
+// applyDefaultNamespace sets the namespace selected by the kubectl flags, when running as a
+// kubectl plugin, on workload tests that do not set one.
+func applyDefaultNamespace(spec *v1alpha1.ClusterValidation) {
+	if defaultNamespace == "" {
+		return
+	}
+	for i := range spec.Spec.WorkloadTests {
+		if spec.Spec.WorkloadTests[i].Namespace == "" {
+			spec.Spec.WorkloadTests[i].Namespace = defaultNamespace
+		}
+	}
+}
+
+// addSinkFlag registers the --sink flag, the sinks are created by loadSinks.
+func addSinkFlag(cmd *cobra.Command, defaults []string) {
+	cmd.Flags().StringArrayVar(&sinkSpecs, "sink", defaults, fmt.Sprintf("Sink to write the report of every run to, as name or name=target, may be repeated %v", client.Sinks()))
+}
+
+func loadSinks() []client.ResultSink {
+	var (
+		resultSinks = make([]client.ResultSink, 0, len(sinkSpecs))
+	)
+
+	for _, spec := range sinkSpecs {
+		sink, err := client.NewSink(spec)
+		if err != nil {
+			log.Fatalf("failed to create sink: %v", err)
+		}
+		resultSinks = append(resultSinks, sink)
+	}
+	return resultSinks
+}
+
+// addNotifierFlag registers the --notify flag, the notifiers are created by loadNotifiers.
+func addNotifierFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&notifierSpecs, "notify", nil, fmt.Sprintf("Notifier to tell about failures and completed runs, as name or name=target, may be repeated %v", client.Notifiers()))
+}
+
+// loadNotifiers creates the notifiers given with --notify and the ones configured in the spec.
+func loadNotifiers(validation *v1alpha1.ClusterValidation) []client.Notifier {
+	var (
+		resultNotifiers = make([]client.Notifier, 0, len(notifierSpecs))
+	)
+
+	for _, spec := range notifierSpecs {
+		n, err := client.NewNotifier(spec)
+		if err != nil {
+			log.Fatalf("failed to create notifier: %v", err)
+		}
+		resultNotifiers = append(resultNotifiers, n)
+	}
+
+	specNotifiers, err := client.SpecNotifiers(validation)
+	if err != nil {
+		log.Fatalf("failed to create notifier: %v", err)
+	}
+	return append(resultNotifiers, specNotifiers...)
+}
+
+func loadSuppressions(file string) []v1alpha1.Suppression {
+	if file == "" {
+		return nil
+	}
+
+	suppressions, err := client.ParseSuppressions(file)
+	if err != nil {
+		log.Fatalf("failed to load suppressions: %v", err)
+	}
+	return suppressions
+}
+
+// loadCheckpoint returns the checkpoint file to write and the checkpoint to resume from, a resumed
+// run keeps writing to the checkpoint it resumed from unless told otherwise.
+func loadCheckpoint(file, resume string) (string, *client.Checkpoint) {
+	if resume == "" {
+		return file, nil
+	}
+
+	checkpoint, err := client.ReadCheckpoint(resume)
+	if err != nil {
+		log.Fatalf("failed to load checkpoint: %v", err)
+	}
+	log.Infof("resuming from checkpoint '%v' written at %v", resume, checkpoint.Written)
+
+	if file == "" {
+		file = resume
+	}
+	return file, checkpoint
+}
+
+func kubernetesClients() (dynamic.Interface, *rest.RESTClient) {
+	return kubernetesClientsFor(clientOptions.Context)
+}
+
+// kubernetesClientsFor returns clients for a kubeconfig context, all clients of a run share its run ID.
+func kubernetesClientsFor(context string) (dynamic.Interface, *rest.RESTClient) {
+	client.RegisterRequestMetrics()
+
+	runIDOnce.Do(func() {
+		if clientOptions.RunID == "" {
+			clientOptions.RunID = utilrand.String(10)
+		}
+		log.AddHook(&runIDHook{runID: clientOptions.RunID})
+	})
+
+	opts := clientOptions
+	opts.Context = context
+	c, r, err := client.KubernetesClients(opts)
+	if err != nil {
+		log.Fatalf("failed to create kubernetes clients: %v", err)
+	}
+	return c, r
+}
+
+// metadataClient returns a client for metadata-only lists with the credentials of
+// kubernetesClients, it must be called after it so requests carry the same run ID.
+func metadataClient() metadata.Interface {
+	m, err := client.KubernetesMetadataClient(clientOptions)
+	if err != nil {
+		log.Fatalf("failed to create kubernetes metadata client: %v", err)
+	}
+	return m
+}
+
+// runIDHook adds the run ID to every log entry, so logs can be correlated with the API
+// server audit log entries of the same run.
+type runIDHook struct {
+	runID string
+}
+
+func (h *runIDHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *runIDHook) Fire(entry *log.Entry) error {
+	entry.Data["run"] = h.runID
+	return nil
+}
+
+// identifyCluster defaults the cluster name of the spec to the kubeconfig context when detect is
+// set, and adds the cluster identity to every log entry.
+func identifyCluster(spec *v1alpha1.ClusterValidation, detect bool) {
+	cluster := &spec.Spec.Cluster
+	if cluster.Name == "" && detect {
+		cluster.Name = client.ContextName(clientOptions)
+	}
+	if cluster.Name == "" && len(cluster.Labels) == 0 {
+		return
+	}
+	log.AddHook(&clusterHook{name: cluster.Name, labels: labels.Set(cluster.Labels).String()})
+}
+
+// clusterHook adds the cluster name and labels to every log entry, so logs of fleet-wide runs
+// can be attributed to their cluster.
+type clusterHook struct {
+	name   string
+	labels string
+}
+
+func (h *clusterHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *clusterHook) Fire(entry *log.Entry) error {
+	if h.name != "" {
+		entry.Data["cluster"] = h.name
+	}
+	if h.labels != "" {
+		entry.Data["clusterLabels"] = h.labels
+	}
+	return nil
+}
+
+func setLogLevel(level uint32) {
+	if level > 0 && level <= 6 {
+		log.SetLevel(log.Level(level))
+	} else {
+		log.SetLevel(log.Level(defaultLoggingLevel))
+	}
+}
+
+// stopOnSignal stops the validation when the process is interrupted and sends the signal on
+// the returned channel. A second signal deletes the objects created by active checks and
+// workload tests and exits immediately.
+func stopOnSignal(v *client.Validator) <-chan os.Signal {
+	var (
+		sigs     = make(chan os.Signal, 2)
+		received = make(chan os.Signal, 1)
+	)
+
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		log.Warnf("received %v, stopping validation", sig)
+		received <- sig
+		v.Stop()
+
+		sig = <-sigs
+		log.Warnf("received %v again, cleaning up workloads and exiting", sig)
+		v.CleanupWorkloads()
+		os.Exit(signalExitCode(sig))
+	}()
+	return received
+}
+
+// signalExitCode follows the shell convention of exiting with 128 plus the signal number.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// reportProgress logs how far every validation got, the partial summary is written to the sinks.
+func reportProgress(progress []client.ValidationProgress) {
+	for _, p := range progress {
+		log.Warnf("validation of '%v' %v after %v attempts (%v/%v successful, %v/%v failed)",
+			p.Name, strings.ToLower(string(p.Status)), p.Attempts, p.Successes, p.SuccessThreshold, p.Failures, p.FailureThreshold)
+	}
+}
+
+// reportTimings logs how long every validation took, slowest first, to help tune intervals and
+// thresholds and to spot consistently slow components.
+func reportTimings(progress []client.ValidationProgress) {
+	sort.SliceStable(progress, func(i, j int) bool {
+		return progress[i].Duration > progress[j].Duration
+	})
+
+	for _, p := range progress {
+		firstSuccess := "never succeeded"
+		if p.TimeToFirstSuccess > 0 {
+			firstSuccess = fmt.Sprintf("first success after %v", p.TimeToFirstSuccess.Round(time.Millisecond))
+		}
+		log.Infof("timing of '%v': %v over %v attempts, %v evaluating, %v",
+			p.Name, p.Duration.Round(time.Millisecond), p.Attempts, p.EvaluationTime.Round(time.Millisecond), firstSuccess)
+	}
+}
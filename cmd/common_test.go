@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+)
+
+func Test_ApplyConfigurationOverrides(t *testing.T) {
+	var (
+		configured = v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 5, Interval: "10s"}
+	)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected v1alpha1.ValidationConfiguration
+	}{
+		{
+			name:     "unset flags keep the spec",
+			args:     []string{},
+			expected: configured,
+		},
+		{
+			name:     "interval",
+			args:     []string{"--interval", "1m30s"},
+			expected: v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 5, Interval: "1m30s"},
+		},
+		{
+			name:     "success threshold",
+			args:     []string{"--success-threshold", "1"},
+			expected: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 5, Interval: "10s"},
+		},
+		{
+			name:     "failure threshold",
+			args:     []string{"--failure-threshold", "20"},
+			expected: v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 20, Interval: "10s"},
+		},
+		{
+			name:     "all flags",
+			args:     []string{"--interval", "2s", "--success-threshold", "2", "--failure-threshold", "2"},
+			expected: v1alpha1.ValidationConfiguration{SuccessThreshold: 2, FailureThreshold: 2, Interval: "2s"},
+		},
+		{
+			name:     "zero values keep the spec",
+			args:     []string{"--interval", "0s", "--success-threshold", "0", "--failure-threshold", "0"},
+			expected: configured,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			overrides = configurationOverrides{}
+			defer func() { overrides = configurationOverrides{} }()
+
+			cmd := &cobra.Command{}
+			addConfigurationFlags(cmd)
+			g.Expect(cmd.ParseFlags(tc.args)).To(gomega.Succeed())
+
+			spec := &v1alpha1.ClusterValidation{Spec: v1alpha1.ClusterValidationSpec{Configuration: configured}}
+			applyConfigurationOverrides(spec)
+			g.Expect(spec.Spec.Configuration).To(gomega.Equal(tc.expected))
+		})
+	}
+
+	// entries with their own configuration keep it
+	g := gomega.NewWithT(t)
+	overrides = configurationOverrides{successThreshold: 1}
+	defer func() { overrides = configurationOverrides{} }()
+	own := v1alpha1.ValidationConfiguration{SuccessThreshold: 9}
+	spec := &v1alpha1.ClusterValidation{Spec: v1alpha1.ClusterValidationSpec{
+		Configuration: configured,
+		Resources:     []v1alpha1.ClusterResource{{Name: "nodes", APIVersion: "v1", Configuration: own}},
+	}}
+	applyConfigurationOverrides(spec)
+	g.Expect(spec.Spec.Configuration.SuccessThreshold).To(gomega.Equal(1))
+	g.Expect(spec.Spec.Resources[0].Configuration).To(gomega.Equal(own))
+}
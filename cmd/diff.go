@@ -0,0 +1,77 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keikoproj/cluster-validator/pkg/builtin"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "diff runs a spec against two clusters or snapshots and reports diverging outcomes and field values",
+	Run: func(cmd *cobra.Command, args []string) {
+		if diffBefore == "" || diffAfter == "" {
+			log.Fatal("--before and --after are required")
+		}
+		spec := loadValidationSpec(specFile, preset)
+		setLogLevel(logLevel)
+
+		d, err := client.DiffTargets(spec, diffTarget(diffBefore), diffTarget(diffAfter))
+		if err != nil {
+			log.Fatalf("diff failed: %v", err)
+		}
+		if d.Empty() {
+			log.Infof("no divergences between '%v' and '%v'", d.Before, d.After)
+			return
+		}
+		fmt.Print(d.String())
+		os.Exit(1)
+	},
+}
+
+var (
+	diffBefore string
+	diffAfter  string
+)
+
+// diffTarget resolves a diff target, a directory written by the snapshot command or otherwise
+// the name of a kubeconfig context.
+func diffTarget(target string) client.DiffTarget {
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		c, err := client.LoadSnapshot(target)
+		if err != nil {
+			log.Fatalf("failed to load snapshot: %v", err)
+		}
+		return client.DiffTarget{Name: target, Kubernetes: c}
+	}
+
+	c, r := kubernetesClientsFor(target)
+	return client.DiffTarget{Name: target, Kubernetes: c, RESTClient: r}
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVarP(&specFile, "filename", "f", "", "Path to cluster validation manifest file (yaml)")
+	diffCmd.Flags().StringVar(&preset, "preset", "", fmt.Sprintf("Name of a built-in validation preset to compare with instead of a manifest file %v", builtin.Presets()))
+	diffCmd.Flags().StringVar(&diffBefore, "before", "", "Snapshot directory or kubeconfig context to compare from")
+	diffCmd.Flags().StringVar(&diffAfter, "after", "", "Snapshot directory or kubeconfig context to compare to")
+	diffCmd.Flags().Uint32Var(&logLevel, "verbosity", defaultLoggingLevel, "Logging verbosity 1-6")
+}
@@ -0,0 +1,104 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/keikoproj/cluster-validator/pkg/export"
+
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export converts a validation spec into manifests for other tools",
+}
+
+var exportGatekeeperCmd = &cobra.Command{
+	Use:   "gatekeeper",
+	Short: "gatekeeper exports field and annotation validations as Gatekeeper ConstraintTemplate/Constraint manifests",
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportSpecFile == "" {
+			log.Fatal("--filename is required")
+		}
+
+		spec, err := client.ParseValidationSpec(exportSpecFile)
+		if err != nil {
+			log.Fatalf("failed to parse validation spec from file: %v", err)
+		}
+
+		out, err := export.Gatekeeper(spec)
+		if err != nil {
+			log.Fatalf("failed to export gatekeeper manifests: %v", err)
+		}
+		fmt.Print(string(out))
+	},
+}
+
+var exportGrafanaCmd = &cobra.Command{
+	Use:   "grafana",
+	Short: "grafana exports a Grafana dashboard with one panel per validation, driven by the metrics of the serve command",
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportSpecFile == "" {
+			log.Fatal("--filename is required")
+		}
+
+		spec, err := client.ParseValidationSpec(exportSpecFile)
+		if err != nil {
+			log.Fatalf("failed to parse validation spec from file: %v", err)
+		}
+
+		out, err := export.Grafana(spec)
+		if err != nil {
+			log.Fatalf("failed to export grafana dashboard: %v", err)
+		}
+		fmt.Println(string(out))
+	},
+}
+
+var exportPrometheusRulesCmd = &cobra.Command{
+	Use:   "prometheus-rules",
+	Short: "prometheus-rules exports a PrometheusRule alerting on validations that keep failing past their threshold",
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportSpecFile == "" {
+			log.Fatal("--filename is required")
+		}
+
+		spec, err := client.ParseValidationSpec(exportSpecFile)
+		if err != nil {
+			log.Fatalf("failed to parse validation spec from file: %v", err)
+		}
+
+		out, err := export.PrometheusRules(spec)
+		if err != nil {
+			log.Fatalf("failed to export prometheus rules: %v", err)
+		}
+		fmt.Print(string(out))
+	},
+}
+
+var (
+	exportSpecFile string
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.PersistentFlags().StringVarP(&exportSpecFile, "filename", "f", "", "Path to cluster validation manifest file (yaml)")
+	exportCmd.AddCommand(exportGatekeeperCmd)
+	exportCmd.AddCommand(exportGrafanaCmd)
+	exportCmd.AddCommand(exportPrometheusRulesCmd)
+}
@@ -0,0 +1,117 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/keikoproj/cluster-validator/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+var exprCmd = &cobra.Command{
+	Use:   "expr",
+	Short: "expr evaluates a jq or Go template assertion against resources from a file, without a cluster",
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			language   = client.ExpressionLanguageJQ
+			expression = exprJQ
+			failed     bool
+		)
+
+		if (exprJQ == "") == (exprTemplate == "") {
+			log.Fatal("exactly one of --jq and --template is required")
+		}
+		if exprTemplate != "" {
+			language, expression = client.ExpressionLanguageGoTemplate, exprTemplate
+		}
+		if exprFile == "" {
+			log.Fatal("--filename is required")
+		}
+
+		objects, err := loadExpressionObjects(exprFile)
+		if err != nil {
+			log.Fatalf("failed to load resources: %v", err)
+		}
+		for _, obj := range objects {
+			name := obj.GetName()
+			if obj.GetNamespace() != "" {
+				name = obj.GetNamespace() + "/" + name
+			}
+			if err := client.TestExpression(language, expression, exprExpected, obj.Object); err != nil {
+				fmt.Printf("FAIL %v: %v\n", name, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("PASS %v\n", name)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	exprJQ       string
+	exprTemplate string
+	exprExpected string
+	exprFile     string
+)
+
+// loadExpressionObjects reads a YAML or JSON resource, or the items of a list as written by
+// `kubectl get -o yaml`, from a file or stdin for "-".
+func loadExpressionObjects(path string) ([]unstructured.Unstructured, error) {
+	var (
+		data []byte
+		err  error
+		obj  = make(map[string]interface{})
+	)
+
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse '%v': %v", path, err)
+	}
+
+	u := unstructured.Unstructured{Object: obj}
+	if !strings.HasSuffix(u.GetKind(), "List") {
+		return []unstructured.Unstructured{u}, nil
+	}
+	list, err := u.ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the items of '%v': %v", path, err)
+	}
+	return list.Items, nil
+}
+
+func init() {
+	rootCmd.AddCommand(exprCmd)
+	exprCmd.Flags().StringVar(&exprJQ, "jq", "", "jq expression to evaluate against every resource, as in the jq assertions of a spec")
+	exprCmd.Flags().StringVar(&exprTemplate, "template", "", "Go template to render against every resource, as in the goTemplates assertions of a spec")
+	exprCmd.Flags().StringVar(&exprExpected, "expected", "", "Output the Go template must render, it must be truthy when not set")
+	exprCmd.Flags().StringVarP(&exprFile, "filename", "f", "", "Path to a YAML or JSON resource or list of resources, - reads stdin")
+}
@@ -0,0 +1,62 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keikoproj/cluster-validator/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "init generates a starter validation spec",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !initFromCluster {
+			log.Fatal("--from-cluster is required")
+		}
+
+		c, _ := kubernetesClients()
+		setLogLevel(logLevel)
+
+		spec, err := client.GenerateBaseline(c, client.BaselineOptions{Name: initName, Namespaces: initNamespaces})
+		if err != nil {
+			log.Fatalf("failed to generate spec: %v", err)
+		}
+
+		out, err := yaml.Marshal(spec)
+		if err != nil {
+			log.Fatalf("failed to marshal spec: %v", err)
+		}
+		fmt.Print(string(out))
+	},
+}
+
+var (
+	initFromCluster bool
+	initName        string
+	initNamespaces  []string
+)
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().BoolVar(&initFromCluster, "from-cluster", false, "Generate the spec from the current healthy state of the cluster")
+	initCmd.Flags().StringVar(&initName, "name", "baseline", "Name of the generated spec")
+	initCmd.Flags().StringSliceVar(&initNamespaces, "namespaces", nil, "Namespaces of the system workloads to include, defaults to kube-system")
+	initCmd.Flags().Uint32Var(&logLevel, "verbosity", defaultLoggingLevel, "Logging verbosity 1-6")
+}
@@ -0,0 +1,62 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	defaultNamespace string
+)
+
+// ExecutePlugin runs the commands as the kubectl-cluster_validator plugin. The plugin takes the
+// kubeconfig flags of kubectl, e.g. --context, --cluster, --user and --namespace, instead of the
+// client flags of the standalone binary.
+func ExecutePlugin() {
+	var (
+		overrides = &clientcmd.ConfigOverrides{}
+	)
+
+	pluginCmd := &cobra.Command{
+		Use:   "cluster-validator",
+		Short: rootCmd.Short,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			clientOptions.Overrides = overrides
+			defaultNamespace = client.DefaultNamespace(clientOptions)
+		},
+	}
+
+	flags := pluginCmd.PersistentFlags()
+	flags.StringVar(&clientOptions.Kubeconfig, clientcmd.RecommendedConfigPathFlag, "", "Path to the kubeconfig file to use for CLI requests")
+	clientcmd.BindOverrideFlags(overrides, flags, clientcmd.RecommendedConfigOverrideFlags(""))
+	for _, name := range []string{"token-file", "user-agent", "run-id", "public-key", "signature"} {
+		flags.AddFlag(rootCmd.PersistentFlags().Lookup(name))
+	}
+
+	// usage is shown as invoked through kubectl, e.g. "kubectl cluster-validator validate"
+	usage := strings.NewReplacer("{{.UseLine}}", "kubectl {{.UseLine}}", "{{.CommandPath}}", "kubectl {{.CommandPath}}")
+	pluginCmd.SetUsageTemplate(usage.Replace(pluginCmd.UsageTemplate()))
+
+	pluginCmd.AddCommand(rootCmd.Commands()...)
+	if err := pluginCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/keikoproj/cluster-validator/pkg/client"
 	"github.com/spf13/cobra"
 )
 
@@ -24,6 +25,22 @@ var rootCmd = &cobra.Command{
 	Short: "cluster-validator executes validations against a Kubernetes cluster",
 }
 
+var (
+	clientOptions    client.ClientOptions
+	signatureOptions client.SignatureOptions
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&clientOptions.Kubeconfig, "kubeconfig", "", "Path to a kubeconfig file, defaults to the in-cluster configuration or $KUBECONFIG")
+	rootCmd.PersistentFlags().StringVar(&clientOptions.Context, "context", "", "Name of the kubeconfig context to use")
+	rootCmd.PersistentFlags().StringVar(&clientOptions.Token, "token", "", "Bearer token to authenticate with instead of the kubeconfig credentials")
+	rootCmd.PersistentFlags().StringVar(&clientOptions.TokenFile, "token-file", "", "Path to a bearer token file, re-read periodically so rotated tokens are picked up")
+	rootCmd.PersistentFlags().StringVar(&clientOptions.UserAgent, "user-agent", "", "Suffix appended to the User-Agent of every API request")
+	rootCmd.PersistentFlags().StringVar(&clientOptions.RunID, "run-id", "", "ID of this run, sent with every API request and logged, defaults to a random ID")
+	rootCmd.PersistentFlags().StringVar(&signatureOptions.PublicKey, "public-key", "", "Path to a PEM public key, spec files are only used when their detached signature verifies against it")
+	rootCmd.PersistentFlags().StringVar(&signatureOptions.Signature, "signature", "", "Path or URL of the detached signature of the spec file, defaults to the spec path with a .sig suffix")
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
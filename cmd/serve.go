@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keikoproj/cluster-validator/pkg/builtin"
+	"github.com/keikoproj/cluster-validator/pkg/server"
+
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serve runs validations on demand through an HTTP hook, e.g. between upgrade-manager batches",
+	Run: func(cmd *cobra.Command, args []string) {
+		spec := loadValidationSpec(specFile, preset)
+		applyConfigurationOverrides(spec)
+		identifyCluster(spec, true)
+		c, r := kubernetesClients()
+		setLogLevel(logLevel)
+
+		s := server.NewServer(spec, c, r, listenAddress)
+		s.Metadata = metadataClient()
+		s.Preflight = preflight
+		s.InformerCache = informerCache
+		s.StreamLists = streamLists
+		s.Suppressions = loadSuppressions(suppressionsFile)
+		s.MaxRunDuration = maxRunDuration
+		s.Sinks = loadSinks()
+		s.Notifiers = loadNotifiers(spec)
+		s.Debounce = debounce
+		s.RollingUpgradeNamespaces = rollingUpgradeNamespaces
+		if watch {
+			go func() {
+				if err := s.Watch(make(chan struct{})); err != nil {
+					log.Fatalf("failed to watch resources: %v", err)
+				}
+			}()
+		}
+		if err := s.Start(); err != nil {
+			log.Fatalf("server failed: %v", err)
+		}
+	},
+}
+
+var (
+	listenAddress  string
+	maxRunDuration time.Duration
+	watch          bool
+	debounce       time.Duration
+
+	rollingUpgradeNamespaces []string
+)
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVarP(&specFile, "filename", "f", "", "Path to cluster validation manifest file (yaml)")
+	serveCmd.Flags().StringVar(&preset, "preset", "", fmt.Sprintf("Name of a built-in validation preset to serve instead of a manifest file %v", builtin.Presets()))
+	serveCmd.Flags().StringVar(&listenAddress, "listen", ":8080", "Address to serve the validation hook on")
+	serveCmd.Flags().BoolVar(&preflight, "preflight", true, "Verify the validator has all permissions required by the spec before validating")
+	serveCmd.Flags().BoolVar(&informerCache, "informer-cache", false, "Serve resource validations from watch-backed informer caches instead of listing every interval")
+	serveCmd.Flags().BoolVar(&streamLists, "stream-lists", false, "Evaluate resource validations page by page while listing instead of keeping full lists in memory")
+	serveCmd.Flags().StringVar(&suppressionsFile, "suppressions", "", "Path to a suppression list of known failures to report as suppressed instead of failing")
+	serveCmd.Flags().DurationVar(&maxRunDuration, "max-run-duration", 30*time.Minute, "Fail the /healthz liveness endpoint when a validation run takes longer than this, 0 disables it")
+	serveCmd.Flags().BoolVar(&watch, "watch", false, "Re-validate the resource entries and groups affected by a change to a watched resource, without waiting for a request")
+	serveCmd.Flags().DurationVar(&debounce, "debounce", 10*time.Second, "How long --watch batches changes after the first one before re-validating")
+	serveCmd.Flags().StringSliceVar(&rollingUpgradeNamespaces, "rollingupgrade-namespaces", nil, "Namespaces whose RollingUpgrades /validate may annotate with its result, annotating is refused when empty")
+	addConfigurationFlags(serveCmd)
+	addSinkFlag(serveCmd, nil)
+	addNotifierFlag(serveCmd)
+	serveCmd.Flags().Uint32Var(&logLevel, "verbosity", defaultLoggingLevel, "Logging verbosity 1-6")
+}
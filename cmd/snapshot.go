@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keikoproj/cluster-validator/pkg/builtin"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/keikoproj/cluster-validator/pkg/client/clienttest"
+
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "snapshot captures the resources a spec validates for offline evaluation and bug reports",
+	Run: func(cmd *cobra.Command, args []string) {
+		spec := loadValidationSpec(specFile, preset)
+		c, r := kubernetesClients()
+		setLogLevel(logLevel)
+
+		if snapshotRecord {
+			if err := clienttest.Record(spec, c, r, snapshotOutput); err != nil {
+				log.Fatalf("recording failed: %v", err)
+			}
+			log.Infof("recorded fixture to %v, replay it in tests with clienttest.Replay", snapshotOutput)
+			return
+		}
+
+		v := client.NewValidator(c, spec, r)
+		files, err := v.Snapshot(snapshotOutput)
+		if err != nil {
+			log.Fatalf("snapshot failed: %v", err)
+		}
+		log.Infof("captured %v resources to %v, validate them offline with --from-snapshot %v", len(files), snapshotOutput, snapshotOutput)
+	},
+}
+
+var (
+	snapshotOutput string
+	snapshotRecord bool
+)
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.Flags().StringVarP(&specFile, "filename", "f", "", "Path to cluster validation manifest file (yaml)")
+	snapshotCmd.Flags().StringVar(&preset, "preset", "", fmt.Sprintf("Name of a built-in validation preset to capture resources for %v", builtin.Presets()))
+	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "snapshot", "Directory to write the captured resources to")
+	snapshotCmd.Flags().BoolVar(&snapshotRecord, "record", false, "Also evaluate the spec once and record the raw API responses, for replay in tests with the clienttest package")
+	snapshotCmd.Flags().Uint32Var(&logLevel, "verbosity", defaultLoggingLevel, "Logging verbosity 1-6")
+}
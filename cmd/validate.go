@@ -13,8 +13,15 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
 	log "github.com/sirupsen/logrus"
 
+	"github.com/keikoproj/cluster-validator/pkg/builtin"
 	"github.com/keikoproj/cluster-validator/pkg/client"
 
 	"github.com/spf13/cobra"
@@ -26,46 +33,131 @@ var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "validate validates a given cluster",
 	Run: func(cmd *cobra.Command, args []string) {
-		if specFile == "" {
-			log.Fatal("--filename is required")
-		}
+		spec := loadValidationSpec(specFile, preset)
+		applyConfigurationOverrides(spec)
+		identifyCluster(spec, snapshotDir == "")
+		setLogLevel(logLevel)
 
-		spec, err := client.ParseValidationSpec(specFile)
-		if err != nil {
-			log.Fatalf("failed to parse validation spec from file: %v", err)
+		var v *client.Validator
+		if snapshotDir != "" {
+			c, err := client.LoadSnapshot(snapshotDir)
+			if err != nil {
+				log.Fatalf("failed to load snapshot: %v", err)
+			}
+			v = client.NewValidator(c, spec, nil)
+		} else {
+			c, r := kubernetesClients()
+			v = client.NewValidator(c, spec, r)
+			v.Metadata = metadataClient()
+			v.Preflight = preflight
 		}
-
-		c, err := client.GetKubernetesDynamicClient()
-		if err != nil {
-			log.Fatalf("failed to create dynamic client: %v", err)
+		v.InformerCache = informerCache || eventDriven
+		v.EventDriven = eventDriven
+		v.StreamLists = streamLists
+		v.Suppressions = loadSuppressions(suppressionsFile)
+		v.CheckpointFile, v.Resume = loadCheckpoint(checkpointFile, resumeFile)
+		v.Heartbeat = heartbeat
+		applyOutputFormat(cmd.Flags().Changed("output"))
+		if junitReport != "" {
+			sinkSpecs = append(sinkSpecs, fmt.Sprintf("junit=%v", junitReport))
 		}
-
-		r, err := client.GetRESTClient()
-		if err != nil {
-			log.Fatalf("failed to create REST client: %v", err)
+		if resultsFile != "" {
+			sinkSpecs = append(sinkSpecs, fmt.Sprintf("results=%v", resultsFile))
 		}
-
-		if logLevel > 0 && logLevel <= 6 {
-			log.SetLevel(log.Level(logLevel))
-		} else {
-			log.SetLevel(log.Level(defaultLoggingLevel))
+		if pushgatewayURL != "" {
+			sinkSpecs = append(sinkSpecs, fmt.Sprintf("pushgateway=%v", pushgatewayURL))
+		}
+		if reportFile != "" {
+			sinkSpecs = append(sinkSpecs, fmt.Sprintf("%v=%v", reportFormat, reportFile))
+		}
+		v.Sinks = loadSinks()
+		v.Notifiers = loadNotifiers(spec)
+		v.ContinueOnError = continueOnError
+		v.Timeout = timeout
+		interrupted := stopOnSignal(v)
+		err := v.Validate()
+		reportTimings(v.Progress())
+		log.Infof("API usage: %v", client.RequestStatistics())
+		if errors.Is(err, client.ErrInterrupted) {
+			v.CleanupWorkloads()
+			reportProgress(v.Progress())
+			os.Exit(signalExitCode(<-interrupted))
 		}
-
-		v := client.NewValidator(c, spec, r)
-		err = v.Validate()
 		if err != nil {
+			v.CleanupWorkloads()
 			log.Fatalf("validation failed: %v", client.ToValidationError(err).Message)
 		}
 	},
 }
 
 var (
-	specFile string
-	logLevel uint32
+	specFile        string
+	preset          string
+	logLevel        uint32
+	preflight       bool
+	informerCache   bool
+	streamLists     bool
+	eventDriven     bool
+	continueOnError bool
+
+	suppressionsFile string
+	checkpointFile   string
+	resumeFile       string
+	snapshotDir      string
+	heartbeat        time.Duration
+	timeout          time.Duration
+	outputFormat     string
+	junitReport      string
+	resultsFile      string
+	reportFile       string
+	reportFormat     string
+	pushgatewayURL   string
 )
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
-	validateCmd.Flags().StringVar(&specFile, "filename", "", "Path to cluster validation manifest file (yaml)")
+	validateCmd.Flags().StringVarP(&specFile, "filename", "f", "", "Path to cluster validation manifest file (yaml)")
+	validateCmd.Flags().StringVar(&preset, "preset", "", fmt.Sprintf("Name of a built-in validation preset to run instead of a manifest file %v", builtin.Presets()))
+	validateCmd.Flags().BoolVar(&preflight, "preflight", true, "Verify the validator has all permissions required by the spec before validating")
+	validateCmd.Flags().BoolVar(&informerCache, "informer-cache", false, "Serve resource validations from watch-backed informer caches instead of listing every interval")
+	validateCmd.Flags().BoolVar(&streamLists, "stream-lists", false, "Evaluate resource validations page by page while listing instead of keeping full lists in memory")
+	validateCmd.Flags().BoolVar(&eventDriven, "event-driven", false, "Re-evaluate failing resource validations as soon as their resources change instead of waiting for their interval, implies --informer-cache")
+	validateCmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "Run all validations to completion when a required validation fails and report every failure")
+	validateCmd.Flags().StringVar(&suppressionsFile, "suppressions", "", "Path to a suppression list of known failures to report as suppressed instead of failing")
+	validateCmd.Flags().StringVar(&checkpointFile, "checkpoint", "", "Path to periodically write the run state to, for use with --resume")
+	validateCmd.Flags().StringVar(&resumeFile, "resume", "", "Path to a checkpoint of a previous run to continue from, the checkpoint keeps being written there unless --checkpoint is set")
+	validateCmd.Flags().StringVar(&snapshotDir, "from-snapshot", "", "Path to a directory written by the snapshot command to validate offline instead of against the cluster")
+	addConfigurationFlags(validateCmd)
+	addSinkFlag(validateCmd, []string{"stdout"})
+	validateCmd.Flags().StringVarP(&outputFormat, "output", "o", "json", "Format of the report printed to stdout, one of json|yaml, logs are written to stderr")
+	validateCmd.Flags().StringVar(&junitReport, "junit-report", "", "Path to write a JUnit XML report to, with one test case per validation")
+	validateCmd.Flags().StringVar(&resultsFile, "results-file", "", "Path to write a record of every validation to, with its timing, attempts, final state and error reasons (json, or yaml by extension)")
+	validateCmd.Flags().StringVar(&reportFile, "report-file", "", "Path to write a Markdown or HTML report to, with the failing resources grouped by reason")
+	validateCmd.Flags().StringVar(&reportFormat, "report-format", client.DocumentFormatMarkdown, "Format of the --report-file report, one of markdown|html")
+	validateCmd.Flags().StringVar(&pushgatewayURL, "pushgateway-url", "", "URL of a Prometheus Pushgateway to push the metrics of the run to before exiting")
+	addNotifierFlag(validateCmd)
+	validateCmd.Flags().DurationVar(&timeout, "timeout", 0, "Fail the run when it has not finished after this long, 0 disables it")
+	validateCmd.Flags().DurationVar(&heartbeat, "heartbeat", 30*time.Second, "How often to log a status line per pending validation, 0 disables it")
 	validateCmd.Flags().Uint32Var(&logLevel, "verbosity", defaultLoggingLevel, "Logging verbosity 1-6")
 }
+
+// applyOutputFormat prints the report in the format given with --output. The stdout sink is
+// added when --output is set explicitly but the sinks do not include it.
+func applyOutputFormat(explicit bool) {
+	var (
+		found bool
+	)
+
+	for i, spec := range sinkSpecs {
+		switch {
+		case spec == "stdout":
+			sinkSpecs[i] = fmt.Sprintf("stdout=%v", outputFormat)
+			found = true
+		case strings.HasPrefix(spec, "stdout="):
+			found = true
+		}
+	}
+	if explicit && !found {
+		sinkSpecs = append(sinkSpecs, fmt.Sprintf("stdout=%v", outputFormat))
+	}
+}
@@ -0,0 +1,370 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClusterCheck is a validation implemented in code for cases that cannot be expressed
+// with field, condition or aggregate selectors, exactly one check type must be set.
+type ClusterCheck struct {
+	Name          string                  `json:"name"`
+	ID            string                  `json:"id,omitempty"`
+	Remediation   string                  `json:"remediation,omitempty"`
+	DocsURL       string                  `json:"docsURL,omitempty"`
+	Owner         string                  `json:"owner,omitempty"`
+	Team          string                  `json:"team,omitempty"`
+	Runbook       string                  `json:"runbook,omitempty"`
+	Required      bool                    `json:"required"`
+	DependsOn     []string                `json:"dependsOn,omitempty"`
+	Configuration ValidationConfiguration `json:"configuration,omitempty"`
+
+	CNICoverage           *DaemonSetCoverageCheck     `json:"cniCoverage,omitempty"`
+	DaemonSetCoverage     *DaemonSetCoverageCheck     `json:"daemonSetCoverage,omitempty"`
+	ServiceEndpoints      *ServiceEndpointsCheck      `json:"serviceEndpoints,omitempty"`
+	LoadBalancer          *LoadBalancerCheck          `json:"loadBalancer,omitempty"`
+	NetworkPolicy         *NetworkPolicyCheck         `json:"networkPolicy,omitempty"`
+	PVCProvisioning       *PVCProvisioningCheck       `json:"pvcProvisioning,omitempty"`
+	AccessReview          *AccessReviewCheck          `json:"accessReview,omitempty"`
+	WebhookCA             *WebhookCACheck             `json:"webhookCA,omitempty"`
+	FeatureGates          *FeatureGatesCheck          `json:"featureGates,omitempty"`
+	VersionSkew           *VersionSkewCheck           `json:"versionSkew,omitempty"`
+	JobRecency            *JobRecencyCheck            `json:"jobRecency,omitempty"`
+	CrashLoop             *CrashLoopCheck             `json:"crashLoop,omitempty"`
+	PendingPods           *PendingPodsCheck           `json:"pendingPods,omitempty"`
+	FailedPods            *FailedPodsCheck            `json:"failedPods,omitempty"`
+	ObjectCount           *ObjectCountCheck           `json:"objectCount,omitempty"`
+	Script                *ScriptCheck                `json:"script,omitempty"`
+	TerminatingNamespaces *TerminatingNamespacesCheck `json:"terminatingNamespaces,omitempty"`
+	OrphanedVolumes       *OrphanedVolumesCheck       `json:"orphanedVolumes,omitempty"`
+	ZoneBalance           *ZoneBalanceCheck           `json:"zoneBalance,omitempty"`
+	CapacityMix           *CapacityMixCheck           `json:"capacityMix,omitempty"`
+	InstanceGroupNodes    *InstanceGroupNodesCheck    `json:"instanceGroupNodes,omitempty"`
+}
+
+func (c *ClusterCheck) GetConfiguration() ValidationConfiguration {
+	return c.Configuration
+}
+
+func (c *ClusterCheck) SuccessThreshold(globalCfg ValidationConfiguration) int {
+	return successThreshold(c.GetConfiguration(), globalCfg)
+}
+
+func (c *ClusterCheck) FailureThreshold(globalCfg ValidationConfiguration) int {
+	return failureThreshold(c.GetConfiguration(), globalCfg)
+}
+
+func (c *ClusterCheck) Interval(globalCfg ValidationConfiguration) time.Duration {
+	return interval(c.GetConfiguration(), globalCfg)
+}
+
+func (c *ClusterCheck) ValidationTimeout(globalCfg ValidationConfiguration) time.Duration {
+	return timeout(c.GetConfiguration(), globalCfg)
+}
+
+// DaemonSetCoverageCheck compares the ready pods of a daemonset, e.g. a CNI or device plugin,
+// against the ready, schedulable nodes the daemonset can run on, taking its node selector and
+// tolerations into account. NodeSelector further restricts the nodes that must be covered,
+// for daemonsets scheduled through node affinity, and every covered node must advertise a
+// non-zero allocatable quantity of each of Resources, e.g. nvidia.com/gpu.
+type DaemonSetCoverageCheck struct {
+	Namespace    string   `json:"namespace,omitempty"`
+	DaemonSet    string   `json:"daemonSet"`
+	NodeSelector string   `json:"nodeSelector,omitempty"`
+	Resources    []string `json:"resources,omitempty"`
+}
+
+// ServiceEndpointsCheck requires every service in scope to have at least MinReady ready
+// endpoints across its EndpointSlices, an empty namespace selects all namespaces.
+type ServiceEndpointsCheck struct {
+	Namespace     string          `json:"namespace,omitempty"`
+	Names         *SelectionScope `json:"names,omitempty"`
+	LabelSelector string          `json:"labelSelector,omitempty"`
+	MinReady      int             `json:"minReady,omitempty"`
+}
+
+const (
+	LoadBalancerKindIngress = "Ingress"
+	LoadBalancerKindService = "Service"
+)
+
+// LoadBalancerCheck requires ingresses and LoadBalancer services in scope to have a provisioned
+// hostname or IP in status.loadBalancer, optionally probing every address over HTTP.
+type LoadBalancerCheck struct {
+	Kinds         []string           `json:"kinds,omitempty"`
+	Namespace     string             `json:"namespace,omitempty"`
+	Names         *SelectionScope    `json:"names,omitempty"`
+	LabelSelector string             `json:"labelSelector,omitempty"`
+	Probe         *LoadBalancerProbe `json:"probe,omitempty"`
+}
+
+// LoadBalancerProbe is an HTTP request sent through each load balancer address, a response
+// is successful when its status is one of Codes, or below 400 when no codes are given.
+type LoadBalancerProbe struct {
+	Scheme string `json:"scheme,omitempty"`
+	Port   int    `json:"port,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Codes  []int  `json:"codes,omitempty"`
+}
+
+func (c *LoadBalancerCheck) GetKinds() []string {
+	if len(c.Kinds) == 0 {
+		return []string{LoadBalancerKindIngress, LoadBalancerKindService}
+	}
+	return c.Kinds
+}
+
+// NetworkPolicyCheck runs a receiver and a sender probe pod for every path and requires
+// allowed paths to connect and denied paths to time out. Probe pods are always deleted.
+type NetworkPolicyCheck struct {
+	Image   string              `json:"image,omitempty"`
+	Timeout string              `json:"timeout,omitempty"`
+	Paths   []NetworkPolicyPath `json:"paths"`
+}
+
+type NetworkPolicyPath struct {
+	Name    string        `json:"name"`
+	From    ProbeEndpoint `json:"from"`
+	To      ProbeEndpoint `json:"to"`
+	Port    int           `json:"port,omitempty"`
+	Allowed bool          `json:"allowed"`
+}
+
+// ProbeEndpoint is the namespace and labels a probe pod is created with, so that
+// it is selected by the network policies under test.
+type ProbeEndpoint struct {
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// PVCProvisioningCheck creates a claim per storage class and requires it to become Bound
+// within the timeout, optionally mounting it in a consumer pod. A consumer pod is always
+// created for classes with WaitForFirstConsumer binding. Created objects are always deleted.
+type PVCProvisioningCheck struct {
+	Namespace      string   `json:"namespace"`
+	StorageClasses []string `json:"storageClasses"`
+	Size           string   `json:"size,omitempty"`
+	Consumer       bool     `json:"consumer,omitempty"`
+	Image          string   `json:"image,omitempty"`
+	Timeout        string   `json:"timeout,omitempty"`
+}
+
+// AccessReviewCheck issues a SubjectAccessReview per rule for the given subject and requires
+// the outcome to match whether the rule is expected to be allowed. A service account is
+// given as "namespace/name".
+type AccessReviewCheck struct {
+	User           string       `json:"user,omitempty"`
+	Groups         []string     `json:"groups,omitempty"`
+	ServiceAccount string       `json:"serviceAccount,omitempty"`
+	Rules          []AccessRule `json:"rules"`
+}
+
+type AccessRule struct {
+	Verb        string `json:"verb"`
+	Group       string `json:"group,omitempty"`
+	Resource    string `json:"resource"`
+	Subresource string `json:"subresource,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Allowed     bool   `json:"allowed"`
+}
+
+func (r *AccessRule) String() string {
+	resource := r.Resource
+	if r.Group != "" {
+		resource = fmt.Sprintf("%v.%v", resource, r.Group)
+	}
+	if r.Subresource != "" {
+		resource = fmt.Sprintf("%v/%v", resource, r.Subresource)
+	}
+	if r.Name != "" {
+		resource = fmt.Sprintf("%v/%v", resource, r.Name)
+	}
+	if r.Namespace != "" {
+		return fmt.Sprintf("%v %v in %v", r.Verb, resource, r.Namespace)
+	}
+	return fmt.Sprintf("%v %v", r.Verb, resource)
+}
+
+// WebhookCACheck decodes the caBundle of webhook configurations and APIServices and flags
+// bundles that are invalid, expired or expire within MinValidity. With VerifyServing the
+// serving certificate of each backend is fetched and verified against its caBundle, this
+// requires network access to the backends, e.g. when running inside the cluster.
+type WebhookCACheck struct {
+	Names         *SelectionScope `json:"names,omitempty"`
+	MinValidity   string          `json:"minValidity,omitempty"`
+	VerifyServing bool            `json:"verifyServing,omitempty"`
+}
+
+// FeatureGatesCheck asserts API server feature gates, as reported by the kubernetes_feature_enabled
+// metric, and kubelet configuration fields from every node's /configz, e.g.
+// ".kubeletconfig.featureGates.GracefulNodeShutdown".
+type FeatureGatesCheck struct {
+	Enabled       []string        `json:"enabled,omitempty"`
+	Disabled      []string        `json:"disabled,omitempty"`
+	KubeletFields []FieldSelector `json:"kubeletFields,omitempty"`
+}
+
+// VersionSkewCheck flags nodes whose kubelet is newer than the API server or older by more
+// than MaxMinorSkew minor versions. Without a bound the supported skew policy applies,
+// three minor versions from 1.28 and two before.
+type VersionSkewCheck struct {
+	MaxMinorSkew int `json:"maxMinorSkew,omitempty"`
+}
+
+// JobRecencyCheck requires CronJobs in scope to have a successful run within SuccessWithin,
+// based on status.lastSuccessfulTime, and at most MaxFailedJobs failed Jobs each. Suspended
+// CronJobs and CronJobs younger than SuccessWithin are not required to have succeeded.
+type JobRecencyCheck struct {
+	Namespace     string          `json:"namespace,omitempty"`
+	Names         *SelectionScope `json:"names,omitempty"`
+	LabelSelector string          `json:"labelSelector,omitempty"`
+	SuccessWithin string          `json:"successWithin"`
+	MaxFailedJobs int             `json:"maxFailedJobs,omitempty"`
+}
+
+// CrashLoopCheck counts containers of pods in the scoped namespaces that are in CrashLoopBackOff
+// or, when MaxRestarts is set, have restarted more than MaxRestarts times, and fails when more
+// than MaxUnhealthy containers are counted.
+type CrashLoopCheck struct {
+	Namespaces   *SelectionScope `json:"namespaces,omitempty"`
+	MaxRestarts  int32           `json:"maxRestarts,omitempty"`
+	MaxUnhealthy int             `json:"maxUnhealthy,omitempty"`
+}
+
+// PendingPodsCheck flags pods in the scoped namespaces that have been Pending for longer than
+// MaxPending since they were created.
+type PendingPodsCheck struct {
+	Namespaces *SelectionScope `json:"namespaces,omitempty"`
+	MaxPending string          `json:"maxPending"`
+}
+
+// FailedPodsCheck counts pods in the scoped namespaces in the Failed phase, including pods evicted
+// under node pressure, and fails when more than MaxFailed are counted. When Within is set, only
+// pods that failed within that window are counted.
+type FailedPodsCheck struct {
+	Namespaces *SelectionScope `json:"namespaces,omitempty"`
+	MaxFailed  int             `json:"maxFailed,omitempty"`
+	Within     string          `json:"within,omitempty"`
+}
+
+// ObjectCountCheck fails when the cluster holds more than MaxTotal objects of a resource, or a
+// namespace in scope holds more than MaxPerNamespace of them.
+type ObjectCountCheck struct {
+	APIVersion      string          `json:"apiVersion"`
+	Resource        string          `json:"resource"`
+	Namespaces      *SelectionScope `json:"namespaces,omitempty"`
+	MaxTotal        int             `json:"maxTotal,omitempty"`
+	MaxPerNamespace int             `json:"maxPerNamespace,omitempty"`
+}
+
+// ScriptCheck passes the objects of a resource in scope to a Starlark Source defining
+// validate(objects), for logic too complex for selectors. The function returns a list of
+// failures, each a message or a dict with a "message" and optionally the "object" it is about,
+// and an empty list or None when the objects are valid.
+type ScriptCheck struct {
+	APIVersion    string          `json:"apiVersion"`
+	Resource      string          `json:"resource"`
+	Namespaces    *SelectionScope `json:"namespaces,omitempty"`
+	Names         *SelectionScope `json:"names,omitempty"`
+	LabelSelector string          `json:"labelSelector,omitempty"`
+	Source        string          `json:"source"`
+}
+
+// TerminatingNamespacesCheck flags namespaces in scope that have been Terminating for longer
+// than OlderThan, reporting the finalizers blocking their deletion.
+type TerminatingNamespacesCheck struct {
+	Names     *SelectionScope `json:"names,omitempty"`
+	OlderThan string          `json:"olderThan,omitempty"`
+}
+
+// OrphanedVolumesCheck flags PersistentVolumes in one of Phases, Released and Failed by default,
+// for longer than OlderThan. The age is taken from status.lastPhaseTransitionTime when the
+// API server reports it and from the creation time otherwise.
+type OrphanedVolumesCheck struct {
+	Phases         []string        `json:"phases,omitempty"`
+	StorageClasses *SelectionScope `json:"storageClasses,omitempty"`
+	OlderThan      string          `json:"olderThan,omitempty"`
+}
+
+func (c *OrphanedVolumesCheck) GetPhases() []string {
+	if len(c.Phases) == 0 {
+		return []string{"Released", "Failed"}
+	}
+	return c.Phases
+}
+
+// ZoneBalanceCheck asserts that ready nodes matching LabelSelector are spread across the values
+// of TopologyKey, topology.kubernetes.io/zone by default. Every zone must hold at least
+// MinPercent of the nodes and zones may differ by at most MaxSkew nodes. Zones lists the
+// zones that are expected to have nodes, a zone without nodes is otherwise not noticed.
+type ZoneBalanceCheck struct {
+	LabelSelector string   `json:"labelSelector,omitempty"`
+	TopologyKey   string   `json:"topologyKey,omitempty"`
+	Zones         []string `json:"zones,omitempty"`
+	MinPercent    int      `json:"minPercent,omitempty"`
+	MaxSkew       int      `json:"maxSkew,omitempty"`
+}
+
+func (c *ZoneBalanceCheck) GetTopologyKey() string {
+	if c.TopologyKey == "" {
+		return "topology.kubernetes.io/zone"
+	}
+	return c.TopologyKey
+}
+
+// CapacityMixCheck groups ready nodes matching LabelSelector by the value of CapacityLabel,
+// karpenter.sh/capacity-type by default, and bounds the share and count of every capacity.
+// Use e.g. eks.amazonaws.com/capacityType for managed node groups, or node.kubernetes.io/instance-type
+// with patterns such as "m5.*" to bound instance families.
+type CapacityMixCheck struct {
+	LabelSelector string          `json:"labelSelector,omitempty"`
+	CapacityLabel string          `json:"capacityLabel,omitempty"`
+	Capacities    []CapacityBound `json:"capacities"`
+}
+
+// CapacityBound requires the nodes whose capacity label matches the Value pattern to hold
+// between MinPercent and MaxPercent of all nodes in scope, and to number at least MinNodes.
+// An empty Value matches nodes without the capacity label. A zero MaxPercent is unbounded.
+type CapacityBound struct {
+	Value      string `json:"value"`
+	MinPercent int    `json:"minPercent,omitempty"`
+	MaxPercent int    `json:"maxPercent,omitempty"`
+	MinNodes   int    `json:"minNodes,omitempty"`
+}
+
+func (c *CapacityMixCheck) GetCapacityLabel() string {
+	if c.CapacityLabel == "" {
+		return "karpenter.sh/capacity-type"
+	}
+	return c.CapacityLabel
+}
+
+// InstanceGroupNodesCheck counts the ready nodes of every keikoproj instance-manager
+// InstanceGroup in scope, by the value of NodeLabel, node.kubernetes.io/instancegroup by default,
+// which must hold the name of the InstanceGroup. An InstanceGroup must have at least the minSize
+// and at most the maxSize of its provisioner configuration, e.g. spec.eks, ready nodes.
+type InstanceGroupNodesCheck struct {
+	Namespaces *SelectionScope `json:"namespaces,omitempty"`
+	Names      *SelectionScope `json:"names,omitempty"`
+	NodeLabel  string          `json:"nodeLabel,omitempty"`
+}
+
+func (c *InstanceGroupNodesCheck) GetNodeLabel() string {
+	if c.NodeLabel == "" {
+		return "node.kubernetes.io/instancegroup"
+	}
+	return c.NodeLabel
+}
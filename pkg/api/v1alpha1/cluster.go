@@ -28,18 +28,125 @@ func (c *ClusterValidation) GetConfiguration() ValidationConfiguration {
 }
 
 type ClusterValidationSpec struct {
+	// Cluster identifies the validated cluster in logs, reports and notifications
+	Cluster       ClusterIdentity         `json:"cluster,omitempty"`
+	Bundles       []BundleReference       `json:"bundles,omitempty"`
+	Templates     []ValidationTemplate    `json:"templates,omitempty"`
 	Resources     []ClusterResource       `json:"resources"`
+	Groups        []ValidationGroup       `json:"groups,omitempty"`
+	Checks        []ClusterCheck          `json:"checks,omitempty"`
+	WorkloadTests []WorkloadTest          `json:"workloadTests,omitempty"`
 	Endpoints     EndpointsSpec           `json:"endpoints"`
 	Configuration ValidationConfiguration `json:"configuration"`
+	// MaintenanceWindows downgrade failures to warnings during scheduled maintenance
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	// Notifications configure notifiers in addition to the ones given with --notify
+	Notifications []Notification `json:"notifications,omitempty"`
+}
+
+// Notification configures a notifier by its registered name, e.g. slack, and its target. A
+// target that is a secret, such as a webhook URL, can be read from an environment variable.
+type Notification struct {
+	Name      string `json:"name"`
+	Target    string `json:"target,omitempty"`
+	TargetEnv string `json:"targetEnv,omitempty"`
+}
+
+// ClusterIdentity attributes results to a cluster in fleet-wide runs. The name defaults to the
+// kubeconfig context the validator runs against.
+type ClusterIdentity struct {
+	Name   string            `json:"name,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type BundleReference struct {
+	Builtin string `json:"builtin"`
+}
+
+type ValidationTemplate struct {
+	Name          string                  `json:"name"`
+	Parameters    []TemplateParameter     `json:"parameters,omitempty"`
+	Configuration ValidationConfiguration `json:"configuration,omitempty"`
+	Fields        []FieldSelector         `json:"fields,omitempty"`
+	Annotations   []AnnotationSelector    `json:"annotations,omitempty"`
+	Conditions    []ResourceCondition     `json:"conditions,omitempty"`
+	Aggregates    []AggregateSelector     `json:"aggregates,omitempty"`
+}
+
+type TemplateParameter struct {
+	Name    string `json:"name"`
+	Default string `json:"default,omitempty"`
+}
+
+type TemplateReference struct {
+	Name string            `json:"name"`
+	Args map[string]string `json:"args,omitempty"`
 }
 
 type EndpointsSpec struct {
 	Cluster []ClusterEndpoint `json:"cluster"`
 	HTTP    []HTTPEndpoint    `json:"http"`
+	// Transport tunes the HTTP client shared by HTTP endpoint validations and load balancer probes
+	Transport *HTTPTransport `json:"transport,omitempty"`
+}
+
+// HTTPTransport configures the connection pool of the HTTP client, durations are given as
+// e.g. "5s" and unset options keep their defaults.
+type HTTPTransport struct {
+	// Timeout bounds a whole request including reading the response, defaults to 30s
+	Timeout string `json:"timeout,omitempty"`
+	// DialTimeout and TLSHandshakeTimeout bound connection setup, default to 10s
+	DialTimeout         string `json:"dialTimeout,omitempty"`
+	TLSHandshakeTimeout string `json:"tlsHandshakeTimeout,omitempty"`
+	// KeepAlive is the TCP keep-alive period, defaults to 30s, DisableKeepAlives closes every
+	// connection after one request
+	KeepAlive         string `json:"keepAlive,omitempty"`
+	DisableKeepAlives bool   `json:"disableKeepAlives,omitempty"`
+	// IdleConnTimeout is how long an idle pooled connection is kept, defaults to 90s
+	IdleConnTimeout string `json:"idleConnTimeout,omitempty"`
+	// MaxIdleConns and MaxIdleConnsPerHost size the pool, default to 100 and 10
+	MaxIdleConns        int `json:"maxIdleConns,omitempty"`
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+	// DisableHTTP2 only negotiates HTTP/1.1 over TLS
+	DisableHTTP2 bool `json:"disableHTTP2,omitempty"`
 }
 
 type ValidationConfiguration struct {
 	SuccessThreshold int    `json:"successThreshold"`
 	FailureThreshold int    `json:"failureThreshold"`
 	Interval         string `json:"interval"`
+	// MaxInterval enables adaptive intervals in the global configuration, intervals are stretched
+	// up to MaxInterval while the API server is slow or throttling requests
+	MaxInterval string `json:"maxInterval,omitempty"`
+	// Timeout stops retrying a validation once it has run this long and fails it, unless it
+	// already met its success threshold. It is unlimited by default.
+	Timeout string `json:"timeout,omitempty"`
+	// Backoff grows the interval after consecutive failed attempts
+	Backoff *BackoffConfig `json:"backoff,omitempty"`
+	// Jitter randomizes every interval by up to this percentage of it in either direction, so
+	// validations with the same interval do not poll the API server in lockstep
+	Jitter int `json:"jitter,omitempty"`
+}
+
+// BackoffConfig multiplies the interval by Factor for every consecutive failed attempt after
+// the first, up to MaxInterval. A successful attempt resets the interval.
+type BackoffConfig struct {
+	// Factor defaults to 2 and can be fractional, e.g. 1.5
+	Factor      float64 `json:"factor,omitempty"`
+	MaxInterval string  `json:"maxInterval"`
+}
+
+// MaintenanceWindow downgrades failures of the validations with the given IDs, or of all
+// validations when none are given, to warnings while the window is open. Validations are still
+// evaluated and reported. A window either recurs on a cron Schedule and stays open for Duration,
+// or is a fixed range from Start to End given as RFC3339 timestamps.
+type MaintenanceWindow struct {
+	Name     string   `json:"name"`
+	IDs      []string `json:"ids,omitempty"`
+	Schedule string   `json:"schedule,omitempty"`
+	Duration string   `json:"duration,omitempty"`
+	// TimeZone of the schedule, e.g. "America/Los_Angeles", defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+	Start    string `json:"start,omitempty"`
+	End      string `json:"end,omitempty"`
 }
@@ -13,98 +13,119 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type ClusterEndpoint struct {
 	Name          string                  `json:"name"`
+	ID            string                  `json:"id,omitempty"`
+	Remediation   string                  `json:"remediation,omitempty"`
+	DocsURL       string                  `json:"docsURL,omitempty"`
+	Owner         string                  `json:"owner,omitempty"`
+	Team          string                  `json:"team,omitempty"`
+	Runbook       string                  `json:"runbook,omitempty"`
 	Required      bool                    `json:"required"`
+	DependsOn     []string                `json:"dependsOn,omitempty"`
 	Configuration ValidationConfiguration `json:"configuration,omitempty"`
 	URI           string                  `json:"uri,omitempty"`
+	// Hedge sends a second request when the first did not respond within this delay, e.g. "500ms",
+	// and takes the first successful response
+	Hedge string `json:"hedge,omitempty"`
+	// Replicas gets the URI from every API server replica individually instead of through the
+	// load balancer
+	Replicas *APIServerReplicas `json:"replicas,omitempty"`
+	// JQ asserts on the JSON response, of every replica when Replicas is set
+	JQ []JQAssertion `json:"jq,omitempty"`
+}
+
+// APIServerReplicas are resolved from the endpoints of the default/kubernetes Service unless
+// Addresses are given.
+type APIServerReplicas struct {
+	// Addresses of the replicas, e.g. "https://10.0.1.10:6443"
+	Addresses []string `json:"addresses,omitempty"`
 }
 
 type HTTPEndpoint struct {
 	Name          string                  `json:"name"`
+	ID            string                  `json:"id,omitempty"`
+	Remediation   string                  `json:"remediation,omitempty"`
+	DocsURL       string                  `json:"docsURL,omitempty"`
+	Owner         string                  `json:"owner,omitempty"`
+	Team          string                  `json:"team,omitempty"`
+	Runbook       string                  `json:"runbook,omitempty"`
 	Required      bool                    `json:"required"`
+	DependsOn     []string                `json:"dependsOn,omitempty"`
 	Configuration ValidationConfiguration `json:"configuration,omitempty"`
 	URL           string                  `json:"url,omitempty"`
 	Codes         []int                   `json:"codes,omitempty"`
+	// Hedge sends a second request when the first did not respond within this delay, e.g. "500ms",
+	// and takes the first response
+	Hedge string `json:"hedge,omitempty"`
 }
 
 type ClusterResource struct {
 	Name          string                  `json:"name"`
+	ID            string                  `json:"id,omitempty"`
+	Remediation   string                  `json:"remediation,omitempty"`
+	DocsURL       string                  `json:"docsURL,omitempty"`
+	Owner         string                  `json:"owner,omitempty"`
+	Team          string                  `json:"team,omitempty"`
+	Runbook       string                  `json:"runbook,omitempty"`
 	APIVersion    string                  `json:"apiVersion"`
+	Kind          string                  `json:"kind,omitempty"`
 	Required      bool                    `json:"required"`
+	DependsOn     []string                `json:"dependsOn,omitempty"`
+	Templates     []TemplateReference     `json:"templates,omitempty"`
 	Configuration ValidationConfiguration `json:"configuration,omitempty"`
 	Namespaces    *SelectionScope         `json:"namespaces,omitempty"`
 	Names         *SelectionScope         `json:"names,omitempty"`
+	Labels        *metav1.LabelSelector   `json:"labels,omitempty"`
+	FieldSelector string                  `json:"fieldSelector,omitempty"`
 	Fields        []FieldSelector         `json:"fields,omitempty"`
 	Annotations   []AnnotationSelector    `json:"annotations,omitempty"`
 	Conditions    []ResourceCondition     `json:"conditions,omitempty"`
+	Aggregates    []AggregateSelector     `json:"aggregates,omitempty"`
+	GroupBy       *GroupBySelector        `json:"groupBy,omitempty"`
+	// JQ asserts on every matched resource
+	JQ []JQAssertion `json:"jq,omitempty"`
+	// GoTemplates render every matched resource and assert on the output
+	GoTemplates []GoTemplateAssertion `json:"goTemplates,omitempty"`
+	// Sharding lists and validates a namespace-scoped resource one group of namespaces at a time
+	Sharding *ShardingConfig `json:"sharding,omitempty"`
+	// Canary validates a subset of the matched resources first and the full set only once the subset passes
+	Canary *CanaryConfig `json:"canary,omitempty"`
+	// PerGroup validates the resources of every group, e.g. every node group, as a separate validation
+	PerGroup *PerGroupConfig `json:"perGroup,omitempty"`
 }
 
 func (r *ClusterResource) SuccessThreshold(globalCfg ValidationConfiguration) int {
-	var (
-		resourceCfg = r.GetConfiguration()
-	)
-	if resourceCfg.SuccessThreshold > 0 {
-		return resourceCfg.SuccessThreshold
-	}
-	return globalCfg.SuccessThreshold
+	return successThreshold(r.GetConfiguration(), globalCfg)
 }
 
 func (r *ClusterResource) FailureThreshold(globalCfg ValidationConfiguration) int {
-	var (
-		resourceCfg = r.GetConfiguration()
-	)
-	if resourceCfg.FailureThreshold > 0 {
-		return resourceCfg.FailureThreshold
-	}
-	return globalCfg.FailureThreshold
+	return failureThreshold(r.GetConfiguration(), globalCfg)
 }
 
 func (r *HTTPEndpoint) SuccessThreshold(globalCfg ValidationConfiguration) int {
-	var (
-		resourceCfg = r.GetConfiguration()
-	)
-	if resourceCfg.SuccessThreshold > 0 {
-		return resourceCfg.SuccessThreshold
-	}
-	return globalCfg.SuccessThreshold
+	return successThreshold(r.GetConfiguration(), globalCfg)
 }
 
 func (r *HTTPEndpoint) FailureThreshold(globalCfg ValidationConfiguration) int {
-	var (
-		resourceCfg = r.GetConfiguration()
-	)
-	if resourceCfg.FailureThreshold > 0 {
-		return resourceCfg.FailureThreshold
-	}
-	return globalCfg.FailureThreshold
+	return failureThreshold(r.GetConfiguration(), globalCfg)
 }
 
 func (r *ClusterEndpoint) SuccessThreshold(globalCfg ValidationConfiguration) int {
-	var (
-		resourceCfg = r.GetConfiguration()
-	)
-	if resourceCfg.SuccessThreshold > 0 {
-		return resourceCfg.SuccessThreshold
-	}
-	return globalCfg.SuccessThreshold
+	return successThreshold(r.GetConfiguration(), globalCfg)
 }
 
 func (r *ClusterEndpoint) FailureThreshold(globalCfg ValidationConfiguration) int {
-	var (
-		resourceCfg = r.GetConfiguration()
-	)
-	if resourceCfg.FailureThreshold > 0 {
-		return resourceCfg.FailureThreshold
-	}
-	return globalCfg.FailureThreshold
+	return failureThreshold(r.GetConfiguration(), globalCfg)
 }
 
 func (c *ClusterResource) GetConfiguration() ValidationConfiguration {
@@ -120,54 +141,79 @@ func (c *ClusterEndpoint) GetConfiguration() ValidationConfiguration {
 }
 
 func (r *ClusterResource) Interval(globalCfg ValidationConfiguration) time.Duration {
-	var (
-		resourceCfg = r.GetConfiguration()
-	)
+	return interval(r.GetConfiguration(), globalCfg)
+}
 
-	if resourceCfg.Interval != "" {
-		d, err := time.ParseDuration(resourceCfg.Interval)
-		if err != nil {
-			log.Warnf("failed to parse duration '%v', using default of 1s", resourceCfg.Interval)
-			return time.Second * 1
-		}
-		return d
-	} else {
-		d, err := time.ParseDuration(globalCfg.Interval)
-		if err != nil {
-			log.Warnf("failed to parse duration '%v', using default of 1s", globalCfg.Interval)
-			return time.Second * 1
-		}
-		return d
-	}
+func (r *ClusterResource) ValidationTimeout(globalCfg ValidationConfiguration) time.Duration {
+	return timeout(r.GetConfiguration(), globalCfg)
 }
 
 func (r *ClusterEndpoint) Interval(globalCfg ValidationConfiguration) time.Duration {
-	var (
-		resourceCfg = r.GetConfiguration()
-	)
+	return interval(r.GetConfiguration(), globalCfg)
+}
 
-	if resourceCfg.Interval != "" {
-		d, err := time.ParseDuration(resourceCfg.Interval)
-		if err != nil {
-			log.Warnf("failed to parse duration '%v', using default of 1s", resourceCfg.Interval)
-			return time.Second * 1
-		}
-		return d
-	} else {
-		d, err := time.ParseDuration(globalCfg.Interval)
-		if err != nil {
-			log.Warnf("failed to parse duration '%v', using default of 1s", globalCfg.Interval)
-			return time.Second * 1
-		}
-		return d
-	}
+func (r *ClusterEndpoint) ValidationTimeout(globalCfg ValidationConfiguration) time.Duration {
+	return timeout(r.GetConfiguration(), globalCfg)
 }
 
 func (r *HTTPEndpoint) Interval(globalCfg ValidationConfiguration) time.Duration {
-	var (
-		resourceCfg = r.GetConfiguration()
-	)
+	return interval(r.GetConfiguration(), globalCfg)
+}
+
+func (r *HTTPEndpoint) ValidationTimeout(globalCfg ValidationConfiguration) time.Duration {
+	return timeout(r.GetConfiguration(), globalCfg)
+}
+
+type ValidationGroup struct {
+	Name          string                  `json:"name"`
+	ID            string                  `json:"id,omitempty"`
+	Remediation   string                  `json:"remediation,omitempty"`
+	DocsURL       string                  `json:"docsURL,omitempty"`
+	Owner         string                  `json:"owner,omitempty"`
+	Team          string                  `json:"team,omitempty"`
+	Runbook       string                  `json:"runbook,omitempty"`
+	Required      bool                    `json:"required"`
+	DependsOn     []string                `json:"dependsOn,omitempty"`
+	Configuration ValidationConfiguration `json:"configuration,omitempty"`
+	AllOf         []ClusterResource       `json:"allOf,omitempty"`
+	AnyOf         []ClusterResource       `json:"anyOf,omitempty"`
+}
+
+func (g *ValidationGroup) GetConfiguration() ValidationConfiguration {
+	return g.Configuration
+}
+
+func (g *ValidationGroup) SuccessThreshold(globalCfg ValidationConfiguration) int {
+	return successThreshold(g.GetConfiguration(), globalCfg)
+}
+
+func (g *ValidationGroup) FailureThreshold(globalCfg ValidationConfiguration) int {
+	return failureThreshold(g.GetConfiguration(), globalCfg)
+}
+
+func (g *ValidationGroup) Interval(globalCfg ValidationConfiguration) time.Duration {
+	return interval(g.GetConfiguration(), globalCfg)
+}
 
+func (g *ValidationGroup) ValidationTimeout(globalCfg ValidationConfiguration) time.Duration {
+	return timeout(g.GetConfiguration(), globalCfg)
+}
+
+func successThreshold(resourceCfg, globalCfg ValidationConfiguration) int {
+	if resourceCfg.SuccessThreshold > 0 {
+		return resourceCfg.SuccessThreshold
+	}
+	return globalCfg.SuccessThreshold
+}
+
+func failureThreshold(resourceCfg, globalCfg ValidationConfiguration) int {
+	if resourceCfg.FailureThreshold > 0 {
+		return resourceCfg.FailureThreshold
+	}
+	return globalCfg.FailureThreshold
+}
+
+func interval(resourceCfg, globalCfg ValidationConfiguration) time.Duration {
 	if resourceCfg.Interval != "" {
 		d, err := time.ParseDuration(resourceCfg.Interval)
 		if err != nil {
@@ -175,24 +221,116 @@ func (r *HTTPEndpoint) Interval(globalCfg ValidationConfiguration) time.Duration
 			return time.Second * 1
 		}
 		return d
-	} else {
-		d, err := time.ParseDuration(globalCfg.Interval)
-		if err != nil {
-			log.Warnf("failed to parse duration '%v', using default of 1s", globalCfg.Interval)
-			return time.Second * 1
-		}
-		return d
 	}
+
+	d, err := time.ParseDuration(globalCfg.Interval)
+	if err != nil {
+		log.Warnf("failed to parse duration '%v', using default of 1s", globalCfg.Interval)
+		return time.Second * 1
+	}
+	return d
 }
 
+// timeout returns the timeout of a validation, or zero when it is unlimited.
+func timeout(resourceCfg, globalCfg ValidationConfiguration) time.Duration {
+	value := resourceCfg.Timeout
+	if value == "" {
+		value = globalCfg.Timeout
+	}
+	if value == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Warnf("failed to parse timeout '%v', validation is not timed out", value)
+		return 0
+	}
+	return d
+}
+
+type FieldDecoding string
+
+const (
+	FieldDecodingBase64 FieldDecoding = "base64"
+)
+
+type Quantifier string
+
+const (
+	QuantifierAll  Quantifier = "All"
+	QuantifierAny  Quantifier = "Any"
+	QuantifierNone Quantifier = "None"
+)
+
 type FieldSelector struct {
-	Path   string   `json:"path"`
-	Values []string `json:"values"`
+	Path       string        `json:"path"`
+	Values     []string      `json:"values"`
+	OlderThan  string        `json:"olderThan,omitempty"`
+	NewerThan  string        `json:"newerThan,omitempty"`
+	Decode     FieldDecoding `json:"decode,omitempty"`
+	Quantifier Quantifier    `json:"quantifier,omitempty"`
+	Unique     bool          `json:"unique,omitempty"`
+	EqualsPath string        `json:"equalsPath,omitempty"`
+}
+
+// JQAssertion passes when the jq Expression only yields values other than false and null, as
+// with jq -e. Message describes the assertion in failure reports instead of the expression.
+type JQAssertion struct {
+	Expression string `json:"expression"`
+	Message    string `json:"message,omitempty"`
+}
+
+// GetMessage returns the message of the assertion, or its expression when no message is set.
+func (a *JQAssertion) GetMessage() string {
+	if a.Message != "" {
+		return a.Message
+	}
+	return a.Expression
+}
+
+// GoTemplateAssertion renders Template, a Go template with sprig functions, against a resource.
+// The assertion passes when the output equals Expected, or when Expected is not set and the
+// output is truthy, i.e. not empty, "false", "0" or "<no value>". Surrounding whitespace is ignored.
+type GoTemplateAssertion struct {
+	Template string `json:"template"`
+	Expected string `json:"expected,omitempty"`
+	Message  string `json:"message,omitempty"`
 }
 
+// GetMessage returns the message of the assertion, or its template when no message is set.
+func (a *GoTemplateAssertion) GetMessage() string {
+	if a.Message != "" {
+		return a.Message
+	}
+	return a.Template
+}
+
+// GetPath returns the JSONPath of the selector, dropping a trailing "=value" suffix
+// while leaving comparisons inside filter expressions such as [?(@.type=="Ready")] intact.
 func (f *FieldSelector) GetPath() string {
-	s := strings.Split(f.Path, "=")
-	return s[0]
+	var (
+		depth int
+		quote rune
+	)
+
+	for i, c := range f.Path {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '(' || c == '{':
+			depth++
+		case c == ']' || c == ')' || c == '}':
+			depth--
+		case c == '=' && depth <= 0:
+			return f.Path[:i]
+		}
+	}
+	return f.Path
 }
 
 func (f *FieldSelector) GetValues() []string {
@@ -202,6 +340,75 @@ func (f *FieldSelector) GetValues() []string {
 	return []string{"*"}
 }
 
+// GetQuantifier returns whether all, any or none of the values found at the path must match, defaulting to all.
+func (f *FieldSelector) GetQuantifier() Quantifier {
+	for _, q := range []Quantifier{QuantifierAny, QuantifierNone} {
+		if strings.EqualFold(string(f.Quantifier), string(q)) {
+			return q
+		}
+	}
+	return QuantifierAll
+}
+
+type AggregateFunction string
+
+const (
+	AggregateFunctionSum   AggregateFunction = "sum"
+	AggregateFunctionAvg   AggregateFunction = "avg"
+	AggregateFunctionMin   AggregateFunction = "min"
+	AggregateFunctionMax   AggregateFunction = "max"
+	AggregateFunctionCount AggregateFunction = "count"
+)
+
+type AggregateSelector struct {
+	Function AggregateFunction `json:"function"`
+	Path     string            `json:"path,omitempty"`
+	Values   []string          `json:"values,omitempty"`
+	Operator string            `json:"operator"`
+	Value    string            `json:"value"`
+	// OlderThan restricts count to values that are timestamps older than the given duration
+	OlderThan string `json:"olderThan,omitempty"`
+}
+
+func (a *AggregateSelector) String() string {
+	var (
+		path = a.Path
+	)
+	if len(a.Values) > 0 {
+		path = fmt.Sprintf("%v=%v", path, strings.Join(a.Values, "|"))
+	}
+	if a.OlderThan != "" {
+		path = fmt.Sprintf("%v olderThan %v", path, a.OlderThan)
+	}
+	return fmt.Sprintf("%v(%v) %v %v", strings.ToLower(string(a.Function)), path, a.Operator, a.Value)
+}
+
+// PerGroupConfig splits the matched resources of an entry by the value of Label, e.g.
+// node.kubernetes.io/instancegroup. Every group passes or fails on its own thresholds and is
+// reported as "<name>/<group>", with the id "<id>/<group>". Groups are discovered when the
+// validation starts, Groups lists groups that are expected to exist. A group without resources
+// fails its attempts, resources without the label are not validated. Configurations overrides
+// the thresholds and interval of individual groups.
+type PerGroupConfig struct {
+	Label          string                             `json:"label"`
+	Groups         []string                           `json:"groups,omitempty"`
+	Configurations map[string]ValidationConfiguration `json:"configurations,omitempty"`
+}
+
+type GroupBySelector struct {
+	Label      string              `json:"label,omitempty"`
+	Path       string              `json:"path,omitempty"`
+	Groups     []string            `json:"groups,omitempty"`
+	Aggregates []AggregateSelector `json:"aggregates,omitempty"`
+}
+
+func (g *GroupBySelector) String() string {
+	if g.Label != "" {
+		return fmt.Sprintf("label %v", g.Label)
+	}
+	return fmt.Sprintf("path %v", g.Path)
+}
+
 type AnnotationOperator string
 
 const (
@@ -215,13 +422,58 @@ type AnnotationSelector struct {
 	Operator AnnotationOperator `json:"operator,omitempty"`
 }
 
+// GetOperator returns the operator, defaulting to Equal when a value is given and Exists otherwise.
+func (a *AnnotationSelector) GetOperator() AnnotationOperator {
+	switch {
+	case a.Operator != "":
+		return a.Operator
+	case a.Value != "":
+		return AnnotationOperatorEqual
+	default:
+		return AnnotationOperatorExists
+	}
+}
+
 type SelectionScope struct {
 	Include []string `json:"include"`
 	Exclude []string `json:"exclude"`
 }
 
+// ShardingConfig partitions the namespaces in scope of a resource entry into shards that are
+// listed and validated independently on a bounded pool of workers.
+type ShardingConfig struct {
+	// Shards is the number of namespace partitions, defaults to the number of workers
+	Shards int `json:"shards,omitempty"`
+	// Workers bounds the number of shards processed at the same time, defaults to 4
+	Workers int `json:"workers,omitempty"`
+}
+
+func (s *ShardingConfig) GetWorkers() int {
+	if s.Workers > 0 {
+		return s.Workers
+	}
+	return 4
+}
+
+func (s *ShardingConfig) GetShards() int {
+	if s.Shards > 0 {
+		return s.Shards
+	}
+	return s.GetWorkers()
+}
+
+// CanaryConfig selects the resources validated in the canary stage, those matching LabelSelector
+// or all resources when it is empty, reduced to Percent of them when set.
+type CanaryConfig struct {
+	LabelSelector string `json:"labelSelector,omitempty"`
+	Percent       int    `json:"percent,omitempty"`
+}
+
 type ResourceCondition struct {
 	Type   string                 `json:"type,omitempty"`
 	Status corev1.ConditionStatus `json:"status,omitempty"`
 	Path   string                 `json:"path,omitempty"`
+	// GracePeriod skips resources created more recently than the given duration (e.g. "15m"),
+	// so a condition is only required once a resource had time to converge.
+	GracePeriod string `json:"gracePeriod,omitempty"`
 }
@@ -0,0 +1,30 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// SuppressionList is the file format of --suppressions.
+type SuppressionList struct {
+	Suppressions []Suppression `json:"suppressions"`
+}
+
+// Suppression marks known failures as suppressed until it expires. With only an ID, every
+// failure of the entry with that ID is suppressed. With Resources, failures of resources whose
+// name or namespace/name matches one of the patterns are suppressed, within the entry with ID
+// when one is given or within all entries otherwise.
+type Suppression struct {
+	ID        string   `json:"id,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	// Expires is a date (2006-01-02) or RFC3339 timestamp after which the suppression no longer applies
+	Expires string `json:"expires,omitempty"`
+}
@@ -0,0 +1,58 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+)
+
+// WorkloadTest applies the given manifests, waits until all resource validations pass
+// against the cluster and deletes the manifests again, every attempt is a full cycle.
+type WorkloadTest struct {
+	Name          string                  `json:"name"`
+	ID            string                  `json:"id,omitempty"`
+	Remediation   string                  `json:"remediation,omitempty"`
+	DocsURL       string                  `json:"docsURL,omitempty"`
+	Owner         string                  `json:"owner,omitempty"`
+	Team          string                  `json:"team,omitempty"`
+	Runbook       string                  `json:"runbook,omitempty"`
+	Required      bool                    `json:"required"`
+	DependsOn     []string                `json:"dependsOn,omitempty"`
+	Configuration ValidationConfiguration `json:"configuration,omitempty"`
+	// Namespace is set on namespaced manifests that do not specify one
+	Namespace string                   `json:"namespace,omitempty"`
+	Manifests []map[string]interface{} `json:"manifests"`
+	Resources []ClusterResource        `json:"resources,omitempty"`
+	// Timeout bounds how long resource validations are retried after the manifests are applied
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func (t *WorkloadTest) GetConfiguration() ValidationConfiguration {
+	return t.Configuration
+}
+
+func (t *WorkloadTest) SuccessThreshold(globalCfg ValidationConfiguration) int {
+	return successThreshold(t.GetConfiguration(), globalCfg)
+}
+
+func (t *WorkloadTest) FailureThreshold(globalCfg ValidationConfiguration) int {
+	return failureThreshold(t.GetConfiguration(), globalCfg)
+}
+
+func (t *WorkloadTest) Interval(globalCfg ValidationConfiguration) time.Duration {
+	return interval(t.GetConfiguration(), globalCfg)
+}
+
+func (t *WorkloadTest) ValidationTimeout(globalCfg ValidationConfiguration) time.Duration {
+	return timeout(t.GetConfiguration(), globalCfg)
+}
@@ -0,0 +1,80 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package builtin contains the curated validation bundles shipped with cluster-validator.
+package builtin
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+var (
+	//go:embed bundles/*.yaml
+	bundles embed.FS
+
+	//go:embed presets/*.yaml
+	presets embed.FS
+)
+
+// Bundles returns the names of all built-in bundles.
+func Bundles() []string {
+	return names(bundles, "bundles")
+}
+
+// Bundle returns the validations of a built-in bundle.
+func Bundle(name string) (*v1alpha1.ClusterValidationSpec, error) {
+	data, err := bundles.ReadFile(path.Join("bundles", name+".yaml"))
+	if err != nil {
+		return nil, errors.Errorf("unknown builtin bundle '%v', available bundles are %v", name, Bundles())
+	}
+
+	spec := &v1alpha1.ClusterValidationSpec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal builtin bundle '%v'", name)
+	}
+	return spec, nil
+}
+
+// Presets returns the names of all built-in presets.
+func Presets() []string {
+	return names(presets, "presets")
+}
+
+// Preset returns the raw manifest of a built-in preset, a complete validation spec.
+func Preset(name string) ([]byte, error) {
+	data, err := presets.ReadFile(path.Join("presets", name+".yaml"))
+	if err != nil {
+		return nil, errors.Errorf("unknown preset '%v', available presets are %v", name, Presets())
+	}
+	return data, nil
+}
+
+func names(fs embed.FS, dir string) []string {
+	entries, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	list := make([]string, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,41 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builtin
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func Test_Bundles(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(Bundles()).To(gomega.ContainElements("coredns", "kube-proxy", "cni"))
+
+	for _, name := range Bundles() {
+		bundle, err := Bundle(name)
+		g.Expect(err).NotTo(gomega.HaveOccurred(), name)
+		g.Expect(len(bundle.Resources)+len(bundle.Groups)+len(bundle.Endpoints.Cluster)).To(gomega.BeNumerically(">", 0), name)
+	}
+
+	_, err := Bundle("missing")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_Presets(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(Presets()).To(gomega.ContainElements("conformance-lite", "eks", "monitoring"))
+
+	_, err := Preset("missing")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -0,0 +1,313 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// aggregateEvaluation accumulates the aggregates and group aggregates of a resource entry over
+// resources added one at a time, so the resources do not have to be kept.
+type aggregateEvaluation struct {
+	aggregates []*aggregateState
+	groupBy    *v1alpha1.GroupBySelector
+	groups     map[string][]*aggregateState
+	groupErr   error
+}
+
+func newAggregateEvaluation(r v1alpha1.ClusterResource) *aggregateEvaluation {
+	var (
+		e = &aggregateEvaluation{groupBy: r.GroupBy, groups: make(map[string][]*aggregateState)}
+	)
+
+	for _, agg := range r.Aggregates {
+		e.aggregates = append(e.aggregates, newAggregateState(agg))
+	}
+	if r.GroupBy != nil && r.GroupBy.Label == "" && r.GroupBy.Path == "" {
+		e.groupErr = errors.New("groupBy requires a label or a path")
+	}
+	return e
+}
+
+// add counts a resource towards the aggregates, and towards the aggregates of its group.
+// Resources without a group key are not grouped.
+func (e *aggregateEvaluation) add(resource unstructured.Unstructured) {
+	for _, state := range e.aggregates {
+		state.add(resource)
+	}
+
+	if e.groupBy == nil || e.groupErr != nil {
+		return
+	}
+	var key string
+	if e.groupBy.Label != "" {
+		key = resource.GetLabels()[e.groupBy.Label]
+	} else {
+		values, err := getJsonPathValues(resource, e.groupBy.Path)
+		if err != nil {
+			e.groupErr = errors.Wrapf(err, "failed to evaluate path '%v' on '%v'", e.groupBy.Path, namespacedName(resource))
+			return
+		}
+		key = strings.Join(values, ",")
+	}
+	if key == "" {
+		return
+	}
+	for _, state := range e.group(key) {
+		state.add(resource)
+	}
+}
+
+// group returns the aggregates of a group, starting them when the group is new.
+func (e *aggregateEvaluation) group(key string) []*aggregateState {
+	states, ok := e.groups[key]
+	if !ok {
+		for _, agg := range e.groupBy.Aggregates {
+			states = append(states, newAggregateState(agg))
+		}
+		e.groups[key] = states
+	}
+	return states
+}
+
+// results returns the failed aggregates. Group aggregates are evaluated independently for every
+// group, including expected groups with no members.
+func (e *aggregateEvaluation) results() []AggregateValidationResult {
+	var (
+		failedValidations = make([]AggregateValidationResult, 0)
+	)
+
+	for _, state := range e.aggregates {
+		if result, ok := evaluateAggregate(state, ""); !ok {
+			failedValidations = append(failedValidations, result)
+		}
+	}
+
+	if e.groupBy == nil {
+		return failedValidations
+	}
+	if e.groupErr != nil {
+		result := NewAggregateValidationResult(fmt.Sprintf("groupBy %v", e.groupBy))
+		result.Code = FailureCodeAggregateError
+		result.Error = e.groupErr.Error()
+		return append(failedValidations, result)
+	}
+
+	for _, expected := range e.groupBy.Groups {
+		e.group(expected)
+	}
+	names := make([]string, 0, len(e.groups))
+	for name := range e.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, state := range e.groups[name] {
+			if result, ok := evaluateAggregate(state, name); !ok {
+				failedValidations = append(failedValidations, result)
+			}
+		}
+	}
+
+	return failedValidations
+}
+
+func evaluateAggregate(state *aggregateState, group string) (AggregateValidationResult, bool) {
+	var (
+		agg    = state.agg
+		result = NewAggregateValidationResult(agg.String())
+	)
+	result.Group = group
+
+	value, err := state.value()
+	if err != nil {
+		result.Code = FailureCodeAggregateError
+		result.Error = err.Error()
+		return result, false
+	}
+	result.Value = value
+
+	ok, err := compareAggregate(value, agg.Operator, agg.Value)
+	switch {
+	case err != nil:
+		result.Code = FailureCodeAggregateError
+		result.Error = err.Error()
+	case !ok && group != "":
+		result.Error = fmt.Sprintf("aggregate value %v for group '%v' does not satisfy %v %v", value, group, agg.Operator, agg.Value)
+	case !ok:
+		result.Error = fmt.Sprintf("aggregate value %v does not satisfy %v %v", value, agg.Operator, agg.Value)
+	}
+
+	return result, result.Error == ""
+}
+
+// aggregateState is the running value of an aggregate function. count counts the resources
+// that have at least one value at the path matching its values and age, or all resources when
+// no path is given. The other functions keep the count, sum, minimum and maximum of the numeric
+// values at the path.
+type aggregateState struct {
+	agg      v1alpha1.AggregateSelector
+	function v1alpha1.AggregateFunction
+	patterns []string
+	count    float64
+	sum      float64
+	min      float64
+	max      float64
+	err      error
+}
+
+func newAggregateState(agg v1alpha1.AggregateSelector) *aggregateState {
+	var (
+		state = &aggregateState{
+			agg:      agg,
+			function: v1alpha1.AggregateFunction(strings.ToLower(string(agg.Function))),
+			patterns: agg.Values,
+			min:      math.Inf(1),
+			max:      math.Inf(-1),
+		}
+	)
+
+	if len(state.patterns) == 0 {
+		state.patterns = []string{"?*"}
+	}
+	return state
+}
+
+// add accumulates a resource, the first error is kept and stops the aggregate.
+func (s *aggregateState) add(resource unstructured.Unstructured) {
+	if s.err != nil {
+		return
+	}
+	if s.function == v1alpha1.AggregateFunctionCount {
+		s.err = s.addCount(resource)
+		return
+	}
+	if s.agg.Path == "" {
+		return
+	}
+
+	values, err := getJsonPathValues(resource, s.agg.Path)
+	if err != nil {
+		s.err = errors.Wrapf(err, "failed to evaluate path '%v' on '%v'", s.agg.Path, namespacedName(resource))
+		return
+	}
+	for _, val := range values {
+		if val == "" {
+			continue
+		}
+		n, err := expr.Quantity(val)
+		if err != nil {
+			s.err = errors.Wrapf(err, "value at '%v' on '%v' is not numeric", s.agg.Path, namespacedName(resource))
+			return
+		}
+		s.count++
+		s.sum += n
+		s.min = math.Min(s.min, n)
+		s.max = math.Max(s.max, n)
+	}
+}
+
+func (s *aggregateState) addCount(resource unstructured.Unstructured) error {
+	if s.agg.Path == "" {
+		s.count++
+		return nil
+	}
+
+	values, err := getJsonPathValues(resource, s.agg.Path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to evaluate path '%v' on '%v'", s.agg.Path, namespacedName(resource))
+	}
+	for _, val := range values {
+		if !matchInPatterns(s.patterns, val) {
+			continue
+		}
+		if s.agg.OlderThan != "" {
+			older, err := expr.OlderThan(val, s.agg.OlderThan)
+			if err != nil {
+				return errors.Wrapf(err, "value at '%v' on '%v' cannot be compared", s.agg.Path, namespacedName(resource))
+			}
+			if !older {
+				continue
+			}
+		}
+		s.count++
+		break
+	}
+	return nil
+}
+
+// value returns the aggregate over all resources added so far.
+func (s *aggregateState) value() (float64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.function == v1alpha1.AggregateFunctionCount {
+		return s.count, nil
+	}
+
+	if s.agg.Path == "" {
+		return 0, errors.Errorf("aggregate function '%v' requires a path", s.agg.Function)
+	}
+
+	if s.count == 0 {
+		if s.function == v1alpha1.AggregateFunctionSum {
+			return 0, nil
+		}
+		return 0, errors.Errorf("no values found at '%v' to aggregate", s.agg.Path)
+	}
+
+	switch s.function {
+	case v1alpha1.AggregateFunctionSum:
+		return s.sum, nil
+	case v1alpha1.AggregateFunctionAvg:
+		return s.sum / s.count, nil
+	case v1alpha1.AggregateFunctionMin:
+		return s.min, nil
+	case v1alpha1.AggregateFunctionMax:
+		return s.max, nil
+	default:
+		return 0, errors.Errorf("unsupported aggregate function '%v'", s.agg.Function)
+	}
+}
+
+func compareAggregate(value float64, operator, expected string) (bool, error) {
+	target, err := expr.Quantity(expected)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid aggregate comparison value")
+	}
+
+	switch strings.TrimSpace(operator) {
+	case ">":
+		return value > target, nil
+	case ">=":
+		return value >= target, nil
+	case "<":
+		return value < target, nil
+	case "<=":
+		return value <= target, nil
+	case "==", "=":
+		return value == target, nil
+	case "!=":
+		return value != target, nil
+	default:
+		return false, errors.Errorf("unsupported aggregate operator '%v'", operator)
+	}
+}
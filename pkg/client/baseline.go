@@ -0,0 +1,220 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	crdGVR        = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+)
+
+// BaselineOptions controls the spec generated from a live cluster.
+type BaselineOptions struct {
+	// Name is the name of the generated spec
+	Name string
+	// Namespaces are the namespaces whose deployments and daemonsets are considered system
+	// workloads, defaults to kube-system
+	Namespaces []string
+}
+
+func (o BaselineOptions) namespaces() []string {
+	if len(o.Namespaces) == 0 {
+		return []string{metav1.NamespaceSystem}
+	}
+	return o.Namespaces
+}
+
+// GenerateBaseline inspects a cluster and returns a starter spec that passes against its current
+// healthy state: ready nodes, available system deployments and daemonsets, and custom resources
+// with a standard Ready condition. Resources that are unhealthy right now are excluded with a
+// warning rather than baked into the baseline.
+func GenerateBaseline(c dynamic.Interface, opts BaselineOptions) (*v1alpha1.ClusterValidation, error) {
+	var (
+		v    = NewValidator(c, &v1alpha1.ClusterValidation{}, nil)
+		spec = &v1alpha1.ClusterValidation{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1alpha1", Kind: "ClusterValidator"},
+			ObjectMeta: metav1.ObjectMeta{Name: opts.Name},
+			Spec: v1alpha1.ClusterValidationSpec{
+				Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 30, Interval: "2s"},
+				Resources:     make([]v1alpha1.ClusterResource, 0),
+			},
+		}
+	)
+
+	nodes, err := v.listNamespaceResources(nodeGVR, "")
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := baselineReady(nodes, "nodes", "v1", string(corev1.NodeReady), false); ok {
+		spec.Spec.Resources = append(spec.Spec.Resources, r)
+	}
+
+	for _, namespace := range opts.namespaces() {
+		deployments, err := v.listNamespaceResources(deploymentGVR, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if r, ok := baselineReady(deployments, "deployments", "apps/v1", "Available", false); ok {
+			r.ID = fmt.Sprintf("%v-%v", r.Name, namespace)
+			r.Namespaces = &v1alpha1.SelectionScope{Include: []string{namespace}}
+			spec.Spec.Resources = append(spec.Spec.Resources, r)
+		}
+
+		daemonSets, err := v.listNamespaceResources(daemonSetGVR, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if r, ok := baselineDaemonSets(daemonSets); ok {
+			r.ID = fmt.Sprintf("%v-%v", r.Name, namespace)
+			r.Namespaces = &v1alpha1.SelectionScope{Include: []string{namespace}}
+			spec.Spec.Resources = append(spec.Spec.Resources, r)
+		}
+	}
+
+	crds, err := v.listNamespaceResources(crdGVR, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, crd := range crds {
+		gvr, kind, ok := crdStorageVersion(crd)
+		if !ok {
+			continue
+		}
+		resources, err := v.listNamespaceResources(gvr, "")
+		if err != nil {
+			log.Warnf("skipping custom resource '%v': %v", gvr, err)
+			continue
+		}
+		if r, ok := baselineReady(resources, gvr.Resource, gvr.GroupVersion().String(), "Ready", true); ok {
+			r.Kind = kind
+			spec.Spec.Resources = append(spec.Spec.Resources, r)
+		}
+	}
+
+	return spec, nil
+}
+
+// baselineReady returns an entry requiring the condition on all resources but those that do not
+// have it true right now, and a count of at least as many resources. When optional is set, an
+// entry is only returned if some resource reports the condition at all.
+func baselineReady(resources []unstructured.Unstructured, name, apiVersion, condition string, optional bool) (v1alpha1.ClusterResource, bool) {
+	var (
+		healthy, unhealthy []string
+		reported           bool
+	)
+
+	for _, resource := range resources {
+		status, found := conditionStatus(resource, condition)
+		reported = reported || found
+		if status == string(corev1.ConditionTrue) {
+			healthy = append(healthy, resource.GetName())
+			continue
+		}
+		log.Warnf("excluding %v '%v' from the baseline, condition %v is '%v'", name, namespacedName(resource), condition, status)
+		unhealthy = append(unhealthy, resource.GetName())
+	}
+
+	if len(healthy) == 0 || (optional && !reported) {
+		return v1alpha1.ClusterResource{}, false
+	}
+
+	r := v1alpha1.ClusterResource{
+		Name:       name,
+		APIVersion: apiVersion,
+		Required:   true,
+		Conditions: []v1alpha1.ResourceCondition{{Path: "status.conditions", Type: condition, Status: corev1.ConditionTrue}},
+		Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: ">=", Value: fmt.Sprint(len(healthy))}},
+	}
+	if len(unhealthy) > 0 {
+		sort.Strings(unhealthy)
+		r.Names = &v1alpha1.SelectionScope{Include: []string{"*"}, Exclude: unhealthy}
+	}
+	return r, true
+}
+
+// baselineDaemonSets returns an entry requiring no unavailable pods for the daemonsets that
+// currently have none.
+func baselineDaemonSets(daemonSets []unstructured.Unstructured) (v1alpha1.ClusterResource, bool) {
+	var (
+		healthy []string
+	)
+
+	for _, ds := range daemonSets {
+		unavailable, _, _ := unstructured.NestedInt64(ds.Object, "status", "numberUnavailable")
+		if unavailable > 0 {
+			log.Warnf("excluding daemonsets '%v' from the baseline, %v pods are unavailable", namespacedName(ds), unavailable)
+			continue
+		}
+		healthy = append(healthy, ds.GetName())
+	}
+
+	if len(healthy) == 0 {
+		return v1alpha1.ClusterResource{}, false
+	}
+	sort.Strings(healthy)
+
+	return v1alpha1.ClusterResource{
+		Name:       "daemonsets",
+		APIVersion: "apps/v1",
+		Required:   true,
+		Names:      &v1alpha1.SelectionScope{Include: healthy},
+		Fields:     []v1alpha1.FieldSelector{{Path: ".status.numberUnavailable", Values: []string{"", "0"}}},
+		Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: ">=", Value: fmt.Sprint(len(healthy))}},
+	}, true
+}
+
+// conditionStatus returns the status of a condition in status.conditions and whether it was found.
+func conditionStatus(u unstructured.Unstructured, conditionType string) (string, bool) {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || !strings.EqualFold(fmt.Sprint(condition["type"]), conditionType) {
+			continue
+		}
+		return fmt.Sprint(condition["status"]), true
+	}
+	return "", false
+}
+
+// crdStorageVersion returns the resource and kind of the storage version of an established CRD.
+func crdStorageVersion(crd unstructured.Unstructured) (schema.GroupVersionResource, string, bool) {
+	if status, _ := conditionStatus(crd, "Established"); status != string(corev1.ConditionTrue) {
+		return schema.GroupVersionResource{}, "", false
+	}
+
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok || version["storage"] != true || version["served"] != true {
+			continue
+		}
+		return schema.GroupVersionResource{Group: group, Version: fmt.Sprint(version["name"]), Resource: plural}, kind, true
+	}
+	return schema.GroupVersionResource{}, "", false
+}
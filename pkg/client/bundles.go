@@ -0,0 +1,47 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/builtin"
+)
+
+// expandBundles appends the templates, resources, groups, checks and endpoints of every
+// referenced built-in bundle to the spec, each bundle is included at most once.
+func expandBundles(spec *v1alpha1.ClusterValidation) error {
+	var (
+		included = make(map[string]bool)
+	)
+
+	for _, ref := range spec.Spec.Bundles {
+		if included[ref.Builtin] {
+			continue
+		}
+		included[ref.Builtin] = true
+
+		bundle, err := builtin.Bundle(ref.Builtin)
+		if err != nil {
+			return err
+		}
+
+		spec.Spec.Templates = append(spec.Spec.Templates, bundle.Templates...)
+		spec.Spec.Resources = append(spec.Spec.Resources, bundle.Resources...)
+		spec.Spec.Groups = append(spec.Spec.Groups, bundle.Groups...)
+		spec.Spec.Checks = append(spec.Spec.Checks, bundle.Checks...)
+		spec.Spec.Endpoints.Cluster = append(spec.Spec.Endpoints.Cluster, bundle.Endpoints.Cluster...)
+		spec.Spec.Endpoints.HTTP = append(spec.Spec.Endpoints.HTTP, bundle.Endpoints.HTTP...)
+	}
+	spec.Spec.Bundles = nil
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"hash/fnv"
+	"sort"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// validateCanary validates the canary subset of the resources and, only when it passes, the
+// full set. A failing canary fails the attempt with the results of the subset. Aggregates and
+// groups describe the full set and are only evaluated on it.
+func (v *Validator) validateCanary(r v1alpha1.ClusterResource, resources []unstructured.Unstructured) (ValidationSummary, error) {
+	var (
+		perResource = r
+	)
+	perResource.Aggregates, perResource.GroupBy = nil, nil
+
+	canary, err := canaryResources(r.Canary, resources)
+	if err != nil {
+		return ValidationSummary{}, fatalError{err}
+	}
+
+	summary, err := v.validateResources(perResource, canary)
+	if err != nil {
+		return summary, errors.Wrapf(err, "canary of %v/%v resources failed", len(canary), len(resources))
+	}
+	log.Infof("canary of %v/%v '%v' resources passed, validating all resources", len(canary), len(resources), r.Name)
+
+	return v.validateResources(r, resources)
+}
+
+// canaryResources returns the resources matching the canary selector, reduced to its percentage.
+// The percentage is taken in the order of a hash of the resource names, so the same resources
+// stay in the canary while others are added or removed.
+func canaryResources(canary *v1alpha1.CanaryConfig, resources []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	var (
+		selected = make([]unstructured.Unstructured, 0)
+	)
+
+	selector, err := labels.Parse(canary.LabelSelector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid canary label selector '%v'", canary.LabelSelector)
+	}
+	for _, resource := range resources {
+		if selector.Matches(labels.Set(resource.GetLabels())) {
+			selected = append(selected, resource)
+		}
+	}
+
+	if canary.Percent <= 0 || canary.Percent >= 100 {
+		return selected, nil
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return canaryHash(selected[i]) < canaryHash(selected[j])
+	})
+	count := (len(selected)*canary.Percent + 99) / 100
+	return selected[:count], nil
+}
+
+func canaryHash(u unstructured.Unstructured) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(namespacedName(u)))
+	return h.Sum32()
+}
+
+// validateCanaries rejects canary configurations that can never select resources.
+func validateCanaries(spec *v1alpha1.ClusterValidation) error {
+	resources := spec.Spec.Resources
+	for _, g := range spec.Spec.Groups {
+		resources = append(resources, append(g.AllOf, g.AnyOf...)...)
+	}
+
+	for _, r := range resources {
+		if r.Canary == nil {
+			continue
+		}
+		if r.Canary.Percent < 0 || r.Canary.Percent > 100 {
+			return errors.Errorf("canary percent of resource '%v' must be between 0 and 100", r.Name)
+		}
+		if _, err := labels.Parse(r.Canary.LabelSelector); err != nil {
+			return errors.Wrapf(err, "invalid canary label selector of resource '%v'", r.Name)
+		}
+		if r.Sharding != nil {
+			return errors.Errorf("resource '%v' cannot combine canary and sharding", r.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+)
+
+var (
+	// checkpointInterval is how often the checkpoint file is written while validating
+	checkpointInterval = 10 * time.Second
+)
+
+// Checkpoint is the state of a validation run as written to a checkpoint file.
+type Checkpoint struct {
+	Spec string
+	// SpecDigest is a hash of the spec the checkpoint was written for
+	SpecDigest string
+	Written    time.Time
+	Progress   []ValidationProgress
+}
+
+// ReadCheckpoint reads a checkpoint file written by a previous run.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	var (
+		checkpoint = &Checkpoint{}
+	)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read checkpoint '%v'", path)
+	}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal checkpoint '%v'", path)
+	}
+	return checkpoint, nil
+}
+
+// writeCheckpoint replaces the checkpoint file with the current progress, through a temporary
+// file so a run killed while writing never leaves a truncated checkpoint behind.
+func (v *Validator) writeCheckpoint() error {
+	checkpoint := Checkpoint{
+		Spec:       v.Validation.GetName(),
+		SpecDigest: specDigest(v.Validation),
+		Written:    time.Now().UTC(),
+		Progress:   v.Progress(),
+	}
+
+	data, err := json.MarshalIndent(checkpoint, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal checkpoint")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(v.CheckpointFile), filepath.Base(v.CheckpointFile)+".*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create checkpoint")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write checkpoint")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to write checkpoint")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), v.CheckpointFile), "failed to write checkpoint")
+}
+
+// checkpointPeriodically writes the checkpoint file every checkpointInterval until done is closed.
+func (v *Validator) checkpointPeriodically(done <-chan struct{}) {
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := v.writeCheckpoint(); err != nil {
+				log.Warnf("failed to write checkpoint: %v", err)
+			}
+		}
+	}
+}
+
+// specDigest returns a hash of the spec of a validation, empty if it cannot be marshalled.
+func specDigest(validation *v1alpha1.ClusterValidation) string {
+	data, err := json.Marshal(validation.Spec)
+	if err != nil {
+		return ""
+	}
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
+
+// checkResume drops the checkpoint to resume from when it was written for a different spec, so a
+// changed spec starts fresh instead of carrying over progress of validations that changed.
+func (v *Validator) checkResume() {
+	if v.Resume == nil {
+		return
+	}
+	if digest := specDigest(v.Validation); digest == "" || v.Resume.SpecDigest != digest {
+		log.Warnf("checkpoint was written for a different spec than '%v', starting fresh", v.Validation.GetName())
+		v.Resume = nil
+	}
+}
+
+// resumedProgress returns the progress a resumed run recorded for a validation. Validations are
+// matched by ID, or by name when they have no ID and the name is unique within the checkpoint.
+func (v *Validator) resumedProgress(name, id string) *ValidationProgress {
+	var (
+		match *ValidationProgress
+	)
+
+	if v.Resume == nil {
+		return nil
+	}
+
+	for i, p := range v.Resume.Progress {
+		if p.ID != id || (id == "" && p.Name != name) {
+			continue
+		}
+		if match != nil {
+			log.Warnf("checkpoint has more than one validation '%v', not resuming it", name)
+			return nil
+		}
+		match = &v.Resume.Progress[i]
+	}
+	return match
+}
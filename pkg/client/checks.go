@@ -0,0 +1,195 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	nodeGVR      = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	podGVR       = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	daemonSetGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}
+	serviceGVR   = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	sliceGVR     = schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}
+	ingressGVR   = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	pvGVR        = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}
+	pvcGVR       = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	scGVR        = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}
+	sarGVR       = schema.GroupVersionResource{Group: "authorization.k8s.io", Version: "v1", Resource: "subjectaccessreviews"}
+	jobGVR       = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	cronJobGVR   = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}
+
+	validatingWebhookGVR = schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"}
+	mutatingWebhookGVR   = schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"}
+	apiServiceGVR        = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+	instanceGroupGVR     = schema.GroupVersionResource{Group: "instancemgr.keikoproj.io", Version: "v1alpha1", Resource: "instancegroups"}
+)
+
+// validateClusterCheck runs a code based check with the same threshold semantics as resources.
+func (v *Validator) validateClusterCheck(c v1alpha1.ClusterCheck) bool {
+	log.Infof("validating check '%v'", c.Name)
+
+	evaluate := func() (ValidationSummary, error) {
+		return v.evaluateCheck(c)
+	}
+
+	onFailure := func(summary ValidationSummary) ValidationError {
+		return ValidationError{
+			ID:               c.ID,
+			Remediation:      c.Remediation,
+			DocsURL:          c.DocsURL,
+			Owner:            c.Owner,
+			Team:             c.Team,
+			Runbook:          c.Runbook,
+			Message:          errors.Errorf("failure threshold met for check '%v'", c.Name),
+			CheckValidations: summary.CheckValidation,
+		}
+	}
+
+	return v.runValidation(c.Name, c.ID, c.Required, &c, v.suppressed(c.Name, c.ID, evaluate), onFailure)
+}
+
+func (v *Validator) evaluateCheck(c v1alpha1.ClusterCheck) (ValidationSummary, error) {
+	var (
+		result = NewCheckValidationResult(c.Name)
+		err    error
+	)
+	result.ID = c.ID
+
+	switch {
+	case c.CNICoverage != nil:
+		err = v.checkDaemonSetCoverage(c.CNICoverage, &result)
+	case c.DaemonSetCoverage != nil:
+		err = v.checkDaemonSetCoverage(c.DaemonSetCoverage, &result)
+	case c.ServiceEndpoints != nil:
+		err = v.checkServiceEndpoints(c.ServiceEndpoints, &result)
+	case c.LoadBalancer != nil:
+		err = v.checkLoadBalancer(c.LoadBalancer, &result)
+	case c.NetworkPolicy != nil:
+		err = v.checkNetworkPolicy(c.NetworkPolicy, &result)
+	case c.PVCProvisioning != nil:
+		err = v.checkPVCProvisioning(c.PVCProvisioning, &result)
+	case c.AccessReview != nil:
+		err = v.checkAccessReview(c.AccessReview, &result)
+	case c.WebhookCA != nil:
+		err = v.checkWebhookCA(c.WebhookCA, &result)
+	case c.FeatureGates != nil:
+		err = v.checkFeatureGates(c.FeatureGates, &result)
+	case c.VersionSkew != nil:
+		err = v.checkVersionSkew(c.VersionSkew, &result)
+	case c.JobRecency != nil:
+		err = v.checkJobRecency(c.JobRecency, &result)
+	case c.CrashLoop != nil:
+		err = v.checkCrashLoop(c.CrashLoop, &result)
+	case c.PendingPods != nil:
+		err = v.checkPendingPods(c.PendingPods, &result)
+	case c.FailedPods != nil:
+		err = v.checkFailedPods(c.FailedPods, &result)
+	case c.ObjectCount != nil:
+		err = v.checkObjectCount(c.ObjectCount, &result)
+	case c.Script != nil:
+		err = v.checkScript(c.Script, &result)
+	case c.TerminatingNamespaces != nil:
+		err = v.checkTerminatingNamespaces(c.TerminatingNamespaces, &result)
+	case c.OrphanedVolumes != nil:
+		err = v.checkOrphanedVolumes(c.OrphanedVolumes, &result)
+	case c.ZoneBalance != nil:
+		err = v.checkZoneBalance(c.ZoneBalance, &result)
+	case c.CapacityMix != nil:
+		err = v.checkCapacityMix(c.CapacityMix, &result)
+	case c.InstanceGroupNodes != nil:
+		err = v.checkInstanceGroupNodes(c.InstanceGroupNodes, &result)
+	default:
+		return ValidationSummary{}, fatalError{errors.Errorf("check '%v' does not define a check type", c.Name)}
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	if result.Error != "" || len(result.ResourceErrors) > 0 {
+		return ValidationSummary{CheckValidation: []CheckValidationResult{result}}, errors.Errorf("check '%v' failed", c.Name)
+	}
+	return ValidationSummary{}, nil
+}
+
+func (v *Validator) listNodes(labelSelector string) ([]corev1.Node, error) {
+	var (
+		nodes = make([]corev1.Node, 0)
+	)
+
+	list, err := v.Kubernetes.Resource(nodeGVR).List(context.Background(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nodes, errors.Wrap(err, "failed to list nodes")
+	}
+
+	for _, item := range list.Items {
+		node := corev1.Node{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &node); err != nil {
+			return nodes, errors.Wrapf(err, "failed to convert node '%v'", item.GetName())
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func (v *Validator) listPods(namespace string) ([]corev1.Pod, error) {
+	var (
+		pods = make([]corev1.Pod, 0)
+	)
+
+	list, err := v.Kubernetes.Resource(podGVR).Namespace(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return pods, errors.Wrapf(err, "failed to list pods in namespace '%v'", namespace)
+	}
+
+	for _, item := range list.Items {
+		pod := corev1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &pod); err != nil {
+			return pods, errors.Wrapf(err, "failed to convert pod '%v'", namespacedName(item))
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+func nodeReady(node corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func podReady(pod corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
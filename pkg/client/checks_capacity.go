@@ -0,0 +1,69 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+)
+
+// checkCapacityMix counts ready nodes per capacity label value and flags capacity bounds
+// whose matching nodes fall outside their share or below their minimum count.
+func (v *Validator) checkCapacityMix(check *v1alpha1.CapacityMixCheck, result *CheckValidationResult) error {
+	var (
+		key        = check.GetCapacityLabel()
+		capacities = make([]string, 0)
+	)
+
+	nodes, err := v.listNodes(check.LabelSelector)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if nodeReady(node) {
+			capacities = append(capacities, node.Labels[key])
+		}
+	}
+
+	total := len(capacities)
+	if total == 0 {
+		result.Error = "no ready nodes matched the check scope"
+		return nil
+	}
+
+	for _, bound := range check.Capacities {
+		var count int
+		for _, capacity := range capacities {
+			if patternMatch(bound.Value, capacity) {
+				count++
+			}
+		}
+
+		object := fmt.Sprintf("%v (%v nodes)", bound.Value, count)
+		if count*100 < bound.MinPercent*total {
+			reason := fmt.Sprintf("capacity has less than %v%% of %v ready nodes", bound.MinPercent, total)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], object)
+		}
+		if bound.MaxPercent > 0 && count*100 > bound.MaxPercent*total {
+			reason := fmt.Sprintf("capacity has more than %v%% of %v ready nodes", bound.MaxPercent, total)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], object)
+		}
+		if count < bound.MinNodes {
+			reason := fmt.Sprintf("capacity has fewer than %v ready nodes", bound.MinNodes)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], object)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// daemonSetTolerations are added to every daemonset pod by the daemonset controller.
+var daemonSetTolerations = []corev1.Toleration{
+	{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists},
+	{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists},
+	{Key: corev1.TaintNodeDiskPressure, Operator: corev1.TolerationOpExists},
+	{Key: corev1.TaintNodeMemoryPressure, Operator: corev1.TolerationOpExists},
+	{Key: corev1.TaintNodePIDPressure, Operator: corev1.TolerationOpExists},
+	{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists},
+	{Key: corev1.TaintNodeNetworkUnavailable, Operator: corev1.TolerationOpExists},
+}
+
+// checkDaemonSetCoverage flags ready, schedulable nodes the daemonset should run on but where
+// none of its pods are ready or a required resource is not allocatable, and a daemonset
+// numberReady lower than the number of such nodes.
+func (v *Validator) checkDaemonSetCoverage(check *v1alpha1.DaemonSetCoverageCheck, result *CheckValidationResult) error {
+	var (
+		namespace = check.Namespace
+		eligible  int
+		readyPods = make(map[string]bool)
+	)
+
+	if namespace == "" {
+		namespace = metav1.NamespaceSystem
+	}
+
+	obj, err := v.Kubernetes.Resource(daemonSetGVR).Namespace(namespace).Get(context.Background(), check.DaemonSet, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get daemonset '%v/%v'", namespace, check.DaemonSet)
+	}
+	ds := appsv1.DaemonSet{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &ds); err != nil {
+		return errors.Wrapf(err, "failed to convert daemonset '%v/%v'", namespace, check.DaemonSet)
+	}
+
+	nodes, err := v.listNodes(check.NodeSelector)
+	if err != nil {
+		return err
+	}
+
+	pods, err := v.listPods(namespace)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if metav1.IsControlledBy(&pod, &ds) && podReady(pod) {
+			readyPods[pod.Spec.NodeName] = true
+		}
+	}
+
+	tolerations := append(append([]corev1.Toleration{}, daemonSetTolerations...), ds.Spec.Template.Spec.Tolerations...)
+	selector := labels.SelectorFromSet(ds.Spec.Template.Spec.NodeSelector)
+	for _, node := range nodes {
+		if !nodeReady(node) || node.Spec.Unschedulable {
+			continue
+		}
+		if !selector.Matches(labels.Set(node.Labels)) || !toleratesTaints(tolerations, node.Spec.Taints) {
+			continue
+		}
+
+		eligible++
+		if !readyPods[node.Name] {
+			reason := fmt.Sprintf("no ready pod of daemonset '%v/%v' on ready node", namespace, check.DaemonSet)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], node.Name)
+		}
+		for _, name := range check.Resources {
+			if quantity, ok := node.Status.Allocatable[corev1.ResourceName(name)]; !ok || quantity.IsZero() {
+				reason := fmt.Sprintf("resource '%v' is not allocatable on ready node", name)
+				result.ResourceErrors[reason] = append(result.ResourceErrors[reason], node.Name)
+			}
+		}
+	}
+
+	if int(ds.Status.NumberReady) < eligible {
+		reason := fmt.Sprintf("numberReady %v is lower than %v ready schedulable nodes", ds.Status.NumberReady, eligible)
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], fmt.Sprintf("%v/%v", namespace, check.DaemonSet))
+	}
+	return nil
+}
+
+// toleratesTaints reports whether all scheduling taints are tolerated, PreferNoSchedule taints are ignored.
+func toleratesTaints(tolerations []corev1.Toleration, taints []corev1.Taint) bool {
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+
+		var tolerated bool
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
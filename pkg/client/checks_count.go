@@ -0,0 +1,126 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/pager"
+)
+
+// checkObjectCount guards etcd against runaway controllers by limiting the number of objects
+// of a resource in the cluster and per namespace.
+func (v *Validator) checkObjectCount(check *v1alpha1.ObjectCountCheck, result *CheckValidationResult) error {
+	var (
+		gvr          = groupVersionResource(check.APIVersion, check.Resource)
+		total        int
+		perNamespace map[string]int
+		err          error
+	)
+
+	if check.MaxTotal <= 0 && check.MaxPerNamespace <= 0 {
+		return errors.New("objectCount requires maxTotal or maxPerNamespace")
+	}
+
+	if check.MaxPerNamespace <= 0 && check.Namespaces == nil {
+		total, err = v.countObjects(gvr)
+	} else {
+		total, perNamespace, err = v.countObjectsPerNamespace(gvr, check.Namespaces)
+	}
+	if err != nil {
+		return err
+	}
+
+	if check.MaxPerNamespace > 0 {
+		reason := fmt.Sprintf("namespace has more than %v %v", check.MaxPerNamespace, check.Resource)
+		namespaces := make([]string, 0)
+		for ns, n := range perNamespace {
+			if n > check.MaxPerNamespace {
+				namespaces = append(namespaces, ns)
+			}
+		}
+		sort.Strings(namespaces)
+		for _, ns := range namespaces {
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], fmt.Sprintf("%v (%v)", ns, perNamespace[ns]))
+		}
+	}
+
+	if check.MaxTotal > 0 && total > check.MaxTotal {
+		result.Error = fmt.Sprintf("%v %v found, expected at most %v", total, check.Resource, check.MaxTotal)
+	}
+	return nil
+}
+
+// countObjects counts the objects of a resource with a single list call of one item, using the
+// remaining item count reported by the API server. It falls back to paging through all objects
+// when the count is not reported.
+func (v *Validator) countObjects(gvr schema.GroupVersionResource) (int, error) {
+	start := time.Now()
+	list, err := v.Kubernetes.Resource(gvr).List(context.Background(), metav1.ListOptions{Limit: 1})
+	v.observeRequest(start, err)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to count '%v'", gvr)
+	}
+
+	switch {
+	case list.GetRemainingItemCount() != nil:
+		return len(list.Items) + int(*list.GetRemainingItemCount()), nil
+	case list.GetContinue() == "":
+		return len(list.Items), nil
+	default:
+		total, _, err := v.countObjectsPerNamespace(gvr, nil)
+		return total, err
+	}
+}
+
+// countObjectsPerNamespace pages through the objects of a resource without keeping them, and
+// counts the objects in scope in total and per namespace.
+func (v *Validator) countObjectsPerNamespace(gvr schema.GroupVersionResource, scope *v1alpha1.SelectionScope) (int, map[string]int, error) {
+	var (
+		total  int
+		counts = make(map[string]int)
+	)
+
+	p := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		start := time.Now()
+		list, err := v.Kubernetes.Resource(gvr).List(ctx, opts)
+		v.observeRequest(start, err)
+		return list, err
+	})
+	p.PageSize = listPageSize
+
+	err := p.EachListItem(context.Background(), metav1.ListOptions{}, func(obj runtime.Object) error {
+		o, ok := obj.(metav1.Object)
+		if !ok {
+			return errors.Errorf("unexpected list item type %T", obj)
+		}
+		if !inSelectionScope(scope, o.GetNamespace()) {
+			return nil
+		}
+		total++
+		counts[o.GetNamespace()]++
+		return nil
+	})
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to count '%v'", gvr)
+	}
+	return total, counts, nil
+}
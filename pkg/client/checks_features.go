@@ -0,0 +1,142 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	featureMetricRegex = regexp.MustCompile(`^kubernetes_feature_enabled\{(.*)\}\s+(\S+)`)
+	featureNameRegex   = regexp.MustCompile(`(?:^|,)name="([^"]*)"`)
+)
+
+// checkFeatureGates flags API server feature gates not in the expected state and kubelet
+// configuration fields that do not match on some nodes.
+func (v *Validator) checkFeatureGates(check *v1alpha1.FeatureGatesCheck, result *CheckValidationResult) error {
+	if len(check.Enabled)+len(check.Disabled) > 0 {
+		if err := v.checkAPIServerFeatures(check, result); err != nil {
+			return err
+		}
+	}
+
+	if len(check.KubeletFields) > 0 {
+		if err := v.checkKubeletConfig(check, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Validator) checkAPIServerFeatures(check *v1alpha1.FeatureGatesCheck, result *CheckValidationResult) error {
+	out, err := rawGet(v.RESTClient, "/metrics")
+	if err != nil {
+		return errors.Wrap(err, "failed to get API server metrics")
+	}
+
+	features := parseFeatureMetrics(out.String())
+	for _, name := range check.Enabled {
+		var reason string
+		enabled, found := features[name]
+		switch {
+		case !found:
+			reason = "feature gate is not known to the API server"
+		case !enabled:
+			reason = "feature gate is disabled"
+		default:
+			continue
+		}
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+	}
+	for _, name := range check.Disabled {
+		if features[name] {
+			reason := "feature gate is enabled"
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+		}
+	}
+	return nil
+}
+
+// parseFeatureMetrics reads the kubernetes_feature_enabled gauge from a metrics exposition.
+func parseFeatureMetrics(metrics string) map[string]bool {
+	var (
+		features = make(map[string]bool)
+		scanner  = bufio.NewScanner(strings.NewReader(metrics))
+	)
+
+	for scanner.Scan() {
+		match := featureMetricRegex.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		name := featureNameRegex.FindStringSubmatch(match[1])
+		if name == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		features[name[1]] = value == 1
+	}
+	return features
+}
+
+func (v *Validator) checkKubeletConfig(check *v1alpha1.FeatureGatesCheck, result *CheckValidationResult) error {
+	var (
+		configs = make([]unstructured.Unstructured, 0)
+	)
+
+	nodes, err := v.listNodes("")
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if !nodeReady(node) {
+			continue
+		}
+		out, err := rawGet(v.RESTClient, fmt.Sprintf("/api/v1/nodes/%v/proxy/configz", node.Name))
+		if err != nil {
+			reason := fmt.Sprintf("failed to get kubelet configz: %v", err)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], node.Name)
+			continue
+		}
+
+		config := unstructured.Unstructured{Object: map[string]interface{}{}}
+		if err := json.Unmarshal(out.Bytes(), &config.Object); err != nil {
+			reason := "kubelet configz is not valid JSON"
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], node.Name)
+			continue
+		}
+		config.SetName(node.Name)
+		configs = append(configs, config)
+	}
+
+	fields := v.validateFields(v1alpha1.ClusterResource{Name: "kubelet configz", Fields: check.KubeletFields}, configs)
+	for _, f := range fields {
+		for reason, names := range f.ResourceErrors {
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], names...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const defaultInstanceGroupProvisioner = "eks"
+
+// checkInstanceGroupNodes counts the ready nodes of every InstanceGroup in scope by the node
+// label and flags InstanceGroups with fewer ready nodes than their minSize or more than their
+// maxSize.
+func (v *Validator) checkInstanceGroupNodes(check *v1alpha1.InstanceGroupNodesCheck, result *CheckValidationResult) error {
+	var (
+		key   = check.GetNodeLabel()
+		scope = v1alpha1.ClusterResource{Name: instanceGroupGVR.Resource, Namespaces: check.Namespaces, Names: check.Names}
+		ready = make(map[string]int)
+	)
+
+	start := time.Now()
+	list, err := v.Kubernetes.Resource(instanceGroupGVR).List(context.Background(), metav1.ListOptions{})
+	v.observeRequest(start, err)
+	if err != nil {
+		return errors.Wrap(err, "failed to list instancegroups")
+	}
+	groups := scopeResources(scope, list.Items)
+	if len(groups) == 0 {
+		result.Error = "no instancegroups matched the check scope"
+		return nil
+	}
+
+	nodes, err := v.listNodes(key)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if nodeReady(node) {
+			ready[node.Labels[key]]++
+		}
+	}
+
+	for _, group := range groups {
+		minSize, maxSize, ok := instanceGroupSize(group)
+		if !ok {
+			continue
+		}
+
+		count := ready[group.GetName()]
+		object := fmt.Sprintf("%v (%v ready nodes)", namespacedName(group), count)
+		if count < minSize {
+			reason := fmt.Sprintf("instancegroup has fewer ready nodes than its minSize %v", minSize)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], object)
+		}
+		if maxSize > 0 && count > maxSize {
+			reason := fmt.Sprintf("instancegroup has more ready nodes than its maxSize %v", maxSize)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], object)
+		}
+	}
+	return nil
+}
+
+// instanceGroupSize returns the minSize and maxSize of the provisioner configuration of an
+// InstanceGroup, e.g. spec.eks or spec.eks-managed. Fargate InstanceGroups have no nodes and
+// are not sized.
+func instanceGroupSize(group unstructured.Unstructured) (int, int, bool) {
+	provisioner, _, _ := unstructured.NestedString(group.Object, "spec", "provisioner")
+	if provisioner == "" {
+		provisioner = defaultInstanceGroupProvisioner
+	}
+	minSize, found := nestedSize(group, provisioner, "minSize")
+	if !found {
+		return 0, 0, false
+	}
+	maxSize, _ := nestedSize(group, provisioner, "maxSize")
+	return minSize, maxSize, true
+}
+
+// nestedSize reads a size from the spec, objects read from snapshots hold numbers as floats.
+func nestedSize(group unstructured.Unstructured, fields ...string) (int, bool) {
+	value, found, _ := unstructured.NestedFieldNoCopy(group.Object, append([]string{"spec"}, fields...)...)
+	switch n := value.(type) {
+	case int64:
+		return int(n), found
+	case float64:
+		return int(n), found
+	default:
+		return 0, false
+	}
+}
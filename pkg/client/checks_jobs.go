@@ -0,0 +1,111 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkJobRecency flags CronJobs in scope without a recent successful run or with too many
+// failed Jobs, which shows whether scheduled platform tasks survived a change.
+func (v *Validator) checkJobRecency(check *v1alpha1.JobRecencyCheck, result *CheckValidationResult) error {
+	var (
+		failed  = make(map[types.UID]int)
+		matched int
+	)
+
+	window, err := expr.ParseDuration(check.SuccessWithin)
+	if err != nil {
+		return err
+	}
+
+	cronJobs, err := v.Kubernetes.Resource(cronJobGVR).Namespace(check.Namespace).List(context.Background(), metav1.ListOptions{LabelSelector: check.LabelSelector})
+	if err != nil {
+		return errors.Wrap(err, "failed to list cronjobs")
+	}
+
+	jobs, err := v.Kubernetes.Resource(jobGVR).Namespace(check.Namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list jobs")
+	}
+
+	for _, item := range jobs.Items {
+		job := batchv1.Job{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &job); err != nil {
+			return errors.Wrapf(err, "failed to convert job '%v'", namespacedName(item))
+		}
+		owner := metav1.GetControllerOf(&job)
+		if owner == nil || owner.Kind != "CronJob" || !jobFailed(job) {
+			continue
+		}
+		failed[owner.UID]++
+	}
+
+	now := expr.Now()
+	for _, item := range cronJobs.Items {
+		cronJob := batchv1.CronJob{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &cronJob); err != nil {
+			return errors.Wrapf(err, "failed to convert cronjob '%v'", namespacedName(item))
+		}
+		if !inSelectionScope(check.Names, cronJob.Name) {
+			continue
+		}
+
+		matched++
+		if count := failed[cronJob.UID]; count > check.MaxFailedJobs {
+			reason := fmt.Sprintf("cronjob has %v failed jobs, expected at most %v", count, check.MaxFailedJobs)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], namespacedName(item))
+		}
+
+		if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend {
+			continue
+		}
+		if now.Sub(cronJob.CreationTimestamp.Time) < window {
+			continue
+		}
+
+		last := cronJob.Status.LastSuccessfulTime
+		switch {
+		case last == nil:
+			reason := "cronjob has never completed successfully"
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], namespacedName(item))
+		case now.Sub(last.Time) > window:
+			reason := fmt.Sprintf("cronjob has not completed successfully within %v", check.SuccessWithin)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], namespacedName(item))
+		}
+	}
+
+	if matched == 0 {
+		result.Error = "no cronjobs matched the check scope"
+	}
+	return nil
+}
+
+func jobFailed(job batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// checkLoadBalancer flags ingresses and LoadBalancer services that have no provisioned
+// address yet, and addresses that do not answer the optional probe.
+func (v *Validator) checkLoadBalancer(check *v1alpha1.LoadBalancerCheck, result *CheckValidationResult) error {
+	var (
+		matched int
+	)
+
+	for _, kind := range check.GetKinds() {
+		var gvr = serviceGVR
+		switch kind {
+		case v1alpha1.LoadBalancerKindIngress:
+			gvr = ingressGVR
+		case v1alpha1.LoadBalancerKindService:
+		default:
+			return errors.Errorf("unsupported load balancer kind '%v'", kind)
+		}
+
+		list, err := v.Kubernetes.Resource(gvr).Namespace(check.Namespace).List(context.Background(), metav1.ListOptions{LabelSelector: check.LabelSelector})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list %v", gvr.Resource)
+		}
+
+		for _, item := range list.Items {
+			if !inSelectionScope(check.Names, item.GetName()) {
+				continue
+			}
+			if kind == v1alpha1.LoadBalancerKindService {
+				if tp, _, _ := unstructured.NestedString(item.Object, "spec", "type"); tp != "LoadBalancer" {
+					continue
+				}
+			}
+
+			matched++
+			name := fmt.Sprintf("%v %v", strings.ToLower(kind), namespacedName(item))
+			addresses := loadBalancerAddresses(item)
+			if len(addresses) == 0 {
+				reason := "status.loadBalancer has no hostname or IP"
+				result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+				continue
+			}
+
+			if check.Probe == nil {
+				continue
+			}
+			for _, addr := range addresses {
+				if reason := v.probeLoadBalancer(check.Probe, addr, ingressHost(item)); reason != "" {
+					result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+				}
+			}
+		}
+	}
+
+	if matched == 0 {
+		result.Error = "no ingresses or LoadBalancer services matched the check scope"
+	}
+	return nil
+}
+
+func loadBalancerAddresses(u unstructured.Unstructured) []string {
+	var (
+		addresses = make([]string, 0)
+	)
+
+	ingress, _, _ := unstructured.NestedSlice(u.Object, "status", "loadBalancer", "ingress")
+	for _, i := range ingress {
+		entry, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if hostname, _ := entry["hostname"].(string); hostname != "" {
+			addresses = append(addresses, hostname)
+		} else if ip, _ := entry["ip"].(string); ip != "" {
+			addresses = append(addresses, ip)
+		}
+	}
+	return addresses
+}
+
+// ingressHost returns the first host rule of an ingress, which is sent as the probe Host header.
+func ingressHost(u unstructured.Unstructured) string {
+	rules, _, _ := unstructured.NestedSlice(u.Object, "spec", "rules")
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if host, _ := rule["host"].(string); host != "" && !strings.Contains(host, "*") {
+			return host
+		}
+	}
+	return ""
+}
+
+func (v *Validator) probeLoadBalancer(probe *v1alpha1.LoadBalancerProbe, address, host string) string {
+	var (
+		scheme = probe.Scheme
+		path   = probe.Path
+	)
+
+	if scheme == "" {
+		scheme = "http"
+	}
+	if path == "" || !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if probe.Port > 0 {
+		address = net.JoinHostPort(address, strconv.Itoa(probe.Port))
+	} else if strings.Contains(address, ":") {
+		address = "[" + address + "]"
+	}
+
+	url := fmt.Sprintf("%v://%v%v", scheme, address, path)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Sprintf("invalid probe request: %v", err)
+	}
+	if host != "" {
+		req.Host = host
+	}
+
+	resp, err := v.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Sprintf("probe of %v failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if expectedStatus(probe.Codes, resp.StatusCode) {
+		return ""
+	}
+	return fmt.Sprintf("probe of %v returned unexpected status %v", url, resp.StatusCode)
+}
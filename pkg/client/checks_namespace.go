@@ -0,0 +1,108 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// checkTerminatingNamespaces flags namespaces stuck in Terminating, grouped by the finalizers
+// that are still blocking them.
+func (v *Validator) checkTerminatingNamespaces(check *v1alpha1.TerminatingNamespacesCheck, result *CheckValidationResult) error {
+	var (
+		olderThan time.Duration
+	)
+
+	if check.OlderThan != "" {
+		d, err := expr.ParseDuration(check.OlderThan)
+		if err != nil {
+			return err
+		}
+		olderThan = d
+	}
+
+	list, err := v.Kubernetes.Resource(namespaceGVR).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list namespaces")
+	}
+
+	now := expr.Now()
+	for _, item := range list.Items {
+		ns := corev1.Namespace{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &ns); err != nil {
+			return errors.Wrapf(err, "failed to convert namespace '%v'", item.GetName())
+		}
+		if ns.Status.Phase != corev1.NamespaceTerminating || !inSelectionScope(check.Names, ns.Name) {
+			continue
+		}
+		if ns.DeletionTimestamp != nil && now.Sub(ns.DeletionTimestamp.Time) <= olderThan {
+			continue
+		}
+
+		reason := "namespace is stuck terminating"
+		if finalizers := namespaceFinalizers(ns); len(finalizers) > 0 {
+			reason = fmt.Sprintf("%v, blocked by finalizers %v", reason, strings.Join(finalizers, ", "))
+		}
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], ns.Name)
+	}
+	return nil
+}
+
+// namespaceFinalizers returns the namespace's own finalizers together with the finalizers of
+// content remaining in it, as reported by the namespace controller.
+func namespaceFinalizers(ns corev1.Namespace) []string {
+	var (
+		seen       = make(map[string]bool)
+		finalizers = make([]string, 0)
+	)
+
+	add := func(f string) {
+		f = strings.TrimSpace(f)
+		if f != "" && !seen[f] {
+			seen[f] = true
+			finalizers = append(finalizers, f)
+		}
+	}
+
+	for _, f := range ns.Spec.Finalizers {
+		add(string(f))
+	}
+	for _, f := range ns.Finalizers {
+		add(f)
+	}
+	for _, c := range ns.Status.Conditions {
+		if c.Type != corev1.NamespaceFinalizersRemaining || c.Status != corev1.ConditionTrue {
+			continue
+		}
+		// e.g. "Some content in the namespace has finalizers remaining: foo.io/cleanup in 2 resource instances"
+		if i := strings.Index(c.Message, ": "); i >= 0 {
+			for _, part := range strings.Split(c.Message[i+2:], ",") {
+				add(strings.SplitN(strings.TrimSpace(part), " ", 2)[0])
+			}
+		}
+	}
+
+	sort.Strings(finalizers)
+	return finalizers
+}
@@ -0,0 +1,157 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// checkNetworkPolicy probes every path with a receiver and sender pod and flags paths
+// where the connection outcome does not match whether the path should be allowed.
+func (v *Validator) checkNetworkPolicy(check *v1alpha1.NetworkPolicyCheck, result *CheckValidationResult) error {
+	var (
+		w       = v.newWorkloadSet()
+		timeout = 5 * time.Second
+		image   = check.Image
+	)
+	defer w.cleanup()
+
+	if check.Timeout != "" {
+		d, err := expr.ParseDuration(check.Timeout)
+		if err != nil {
+			return err
+		}
+		timeout = d
+	}
+	if image == "" {
+		image = workloadImage
+	}
+
+	for _, path := range check.Paths {
+		connected, err := probeNetworkPath(w, path, image, timeout)
+		if err != nil {
+			return errors.Wrapf(err, "failed to probe path '%v'", path.Name)
+		}
+
+		switch {
+		case path.Allowed && !connected:
+			reason := "allowed path did not connect"
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], path.Name)
+		case !path.Allowed && connected:
+			reason := "denied path connected"
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], path.Name)
+		}
+	}
+	return nil
+}
+
+// probeNetworkPath starts a receiver pod serving HTTP and a sender pod fetching from it,
+// the sender succeeds only when it connected within the timeout.
+func probeNetworkPath(w *workloadSet, path v1alpha1.NetworkPolicyPath, image string, timeout time.Duration) (bool, error) {
+	var (
+		port = path.Port
+	)
+
+	if port == 0 {
+		port = 8080
+	}
+
+	receiver := probePod(workloadName("receiver"), path.To, image, []string{"httpd", "-f", "-p", strconv.Itoa(port), "-h", "/etc"})
+	receiver.Spec.Containers[0].Ports = []corev1.ContainerPort{{ContainerPort: int32(port)}}
+	receiver.Spec.Containers[0].ReadinessProbe = &corev1.Probe{
+		ProbeHandler:  corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(port)}},
+		PeriodSeconds: 1,
+	}
+	if _, err := w.create(podGVR, receiver); err != nil {
+		return false, err
+	}
+
+	// the sender only starts once the receiver accepts connections, a sender started earlier
+	// would report an allowed path as denied
+	u, err := w.waitFor(podGVR, receiver.Namespace, receiver.Name, workloadStartupTimeout, func(u *unstructured.Unstructured) (bool, error) {
+		pod := corev1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &pod); err != nil {
+			return false, err
+		}
+		if pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded {
+			return false, errors.Errorf("receiver pod '%v' exited with phase %v", pod.Name, pod.Status.Phase)
+		}
+		return podReady(pod) && pod.Status.PodIP != "", nil
+	})
+	if err != nil {
+		return false, err
+	}
+	ip, _, _ := unstructured.NestedString(u.Object, "status", "podIP")
+
+	seconds := strconv.Itoa(int(timeout.Seconds() + 0.5))
+	url := fmt.Sprintf("http://%v/hostname", hostPort(ip, port))
+	sender := probePod(workloadName("sender"), path.From, image, []string{"wget", "-q", "-T", seconds, "-O", "/dev/null", url})
+	if _, err := w.create(podGVR, sender); err != nil {
+		return false, err
+	}
+
+	u, err = w.waitFor(podGVR, sender.Namespace, sender.Name, workloadStartupTimeout+timeout, func(u *unstructured.Unstructured) (bool, error) {
+		phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+		return phase == string(corev1.PodSucceeded) || phase == string(corev1.PodFailed), nil
+	})
+	if err != nil {
+		return false, err
+	}
+	phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+	return phase == string(corev1.PodSucceeded), nil
+}
+
+func probePod(name string, endpoint v1alpha1.ProbeEndpoint, image string, command []string) *corev1.Pod {
+	var (
+		labels = make(map[string]string)
+	)
+
+	for k, v := range endpoint.Labels {
+		labels[k] = v
+	}
+
+	return &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: endpoint.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:    "probe",
+					Image:   image,
+					Command: command,
+				},
+			},
+		},
+	}
+}
+
+func hostPort(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -0,0 +1,179 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const crashLoopBackOff = "CrashLoopBackOff"
+
+// checkCrashLoop counts crash looping and frequently restarting containers across the scoped
+// namespaces, the result only fails once the count is above the allowed number.
+func (v *Validator) checkCrashLoop(check *v1alpha1.CrashLoopCheck, result *CheckValidationResult) error {
+	var (
+		unhealthy = make(map[string][]string)
+		count     int
+	)
+
+	pods, err := v.listPods("")
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if !inSelectionScope(check.Namespaces, pod.Namespace) {
+			continue
+		}
+
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			var reason string
+			switch {
+			case status.State.Waiting != nil && status.State.Waiting.Reason == crashLoopBackOff:
+				reason = "container is in CrashLoopBackOff"
+			case check.MaxRestarts > 0 && status.RestartCount > check.MaxRestarts:
+				reason = fmt.Sprintf("container restarted more than %v times", check.MaxRestarts)
+			default:
+				continue
+			}
+			count++
+			unhealthy[reason] = append(unhealthy[reason], fmt.Sprintf("%v/%v/%v", pod.Namespace, pod.Name, status.Name))
+		}
+	}
+
+	if count > check.MaxUnhealthy {
+		for reason, containers := range unhealthy {
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], containers...)
+		}
+		result.Error = fmt.Sprintf("%v unhealthy containers found, expected at most %v", count, check.MaxUnhealthy)
+	}
+	return nil
+}
+
+// checkPendingPods flags pods pending for longer than allowed, grouped by why they are not
+// scheduled so capacity and scheduling issues are visible in the result.
+func (v *Validator) checkPendingPods(check *v1alpha1.PendingPodsCheck, result *CheckValidationResult) error {
+	maxPending, err := expr.ParseDuration(check.MaxPending)
+	if err != nil {
+		return err
+	}
+
+	pods, err := v.listPods("")
+	if err != nil {
+		return err
+	}
+
+	now := expr.Now()
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodPending || !inSelectionScope(check.Namespaces, pod.Namespace) {
+			continue
+		}
+		if now.Sub(pod.CreationTimestamp.Time) <= maxPending {
+			continue
+		}
+
+		reason := fmt.Sprintf("pod has been pending for more than %v (%v)", check.MaxPending, pendingReason(pod))
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], fmt.Sprintf("%v/%v", pod.Namespace, pod.Name))
+	}
+	return nil
+}
+
+// checkFailedPods counts failed and evicted pods across the scoped namespaces, grouped by the
+// reason they failed, the result only fails once the count is above the allowed number.
+func (v *Validator) checkFailedPods(check *v1alpha1.FailedPodsCheck, result *CheckValidationResult) error {
+	var (
+		failed = make(map[string][]string)
+		within time.Duration
+		count  int
+	)
+
+	if check.Within != "" {
+		d, err := expr.ParseDuration(check.Within)
+		if err != nil {
+			return err
+		}
+		within = d
+	}
+
+	pods, err := v.listPods("")
+	if err != nil {
+		return err
+	}
+
+	now := expr.Now()
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodFailed || !inSelectionScope(check.Namespaces, pod.Namespace) {
+			continue
+		}
+		if within > 0 && now.Sub(failedAt(pod)) > within {
+			continue
+		}
+
+		reason := pod.Status.Reason
+		if reason == "" {
+			reason = string(corev1.PodFailed)
+		}
+		count++
+		failed[reason] = append(failed[reason], fmt.Sprintf("%v/%v", pod.Namespace, pod.Name))
+	}
+
+	if count > check.MaxFailed {
+		for reason, names := range failed {
+			key := fmt.Sprintf("pod is %v", reason)
+			result.ResourceErrors[key] = append(result.ResourceErrors[key], names...)
+		}
+		result.Error = fmt.Sprintf("%v failed pods found, expected at most %v", count, check.MaxFailed)
+	}
+	return nil
+}
+
+// failedAt estimates when a pod failed from its last terminated container or condition change,
+// falling back to its creation time.
+func failedAt(pod corev1.Pod) time.Time {
+	var (
+		last = pod.CreationTimestamp.Time
+	)
+
+	for _, status := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		if t := status.State.Terminated; t != nil && t.FinishedAt.Time.After(last) {
+			last = t.FinishedAt.Time
+		}
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.LastTransitionTime.Time.After(last) {
+			last = c.LastTransitionTime.Time
+		}
+	}
+	return last
+}
+
+// pendingReason is the reason a pod is not scheduled, or the reason its containers are waiting.
+func pendingReason(pod corev1.Pod) string {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason != "" {
+			return c.Reason
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			return status.State.Waiting.Reason
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,134 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// checkPVCProvisioning provisions a claim from every listed storage class and flags
+// classes whose claim did not become Bound, or whose consumer pod did not start.
+func (v *Validator) checkPVCProvisioning(check *v1alpha1.PVCProvisioningCheck, result *CheckValidationResult) error {
+	var (
+		w       = v.newWorkloadSet()
+		timeout = 5 * time.Minute
+		size    = check.Size
+		image   = check.Image
+	)
+	defer w.cleanup()
+
+	if check.Timeout != "" {
+		d, err := expr.ParseDuration(check.Timeout)
+		if err != nil {
+			return err
+		}
+		timeout = d
+	}
+	if size == "" {
+		size = "1Gi"
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return errors.Wrapf(err, "invalid claim size '%v'", size)
+	}
+	if image == "" {
+		image = workloadImage
+	}
+
+	for _, class := range check.StorageClasses {
+		sc, err := v.Kubernetes.Resource(scGVR).Get(context.Background(), class, metav1.GetOptions{})
+		if err != nil {
+			reason := fmt.Sprintf("failed to get storage class: %v", err)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], class)
+			continue
+		}
+		mode, _, _ := unstructured.NestedString(sc.Object, "volumeBindingMode")
+		consumer := check.Consumer || mode == string(storagev1.VolumeBindingWaitForFirstConsumer)
+
+		if reason := provisionClaim(w, check.Namespace, class, quantity, consumer, image, timeout); reason != "" {
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], class)
+		}
+	}
+	return nil
+}
+
+func provisionClaim(w *workloadSet, namespace, class string, size resource.Quantity, consumer bool, image string, timeout time.Duration) string {
+	claim := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workloadName("pvc"),
+			Namespace: namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &class,
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+	if _, err := w.create(pvcGVR, claim); err != nil {
+		return err.Error()
+	}
+
+	var pod *corev1.Pod
+	if consumer {
+		pod = probePod(workloadName("pvc-consumer"), v1alpha1.ProbeEndpoint{Namespace: namespace}, image, []string{"sh", "-c", "touch /data/probe"})
+		pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "data", MountPath: "/data"}}
+		pod.Spec.Volumes = []corev1.Volume{
+			{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim.Name},
+				},
+			},
+		}
+		if _, err := w.create(podGVR, pod); err != nil {
+			return err.Error()
+		}
+	}
+
+	_, err := w.waitFor(pvcGVR, namespace, claim.Name, timeout, func(u *unstructured.Unstructured) (bool, error) {
+		phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+		return phase == string(corev1.ClaimBound), nil
+	})
+	if err != nil {
+		return "claim did not become Bound"
+	}
+
+	if pod == nil {
+		return ""
+	}
+	u, err := w.waitFor(podGVR, namespace, pod.Name, timeout, func(u *unstructured.Unstructured) (bool, error) {
+		phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+		return phase == string(corev1.PodSucceeded) || phase == string(corev1.PodFailed), nil
+	})
+	if err != nil {
+		return "consumer pod did not complete"
+	}
+	if phase, _, _ := unstructured.NestedString(u.Object, "status", "phase"); phase != string(corev1.PodSucceeded) {
+		return "consumer pod failed to write to the volume"
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// checkAccessReview flags rules whose SubjectAccessReview outcome does not match the expectation.
+func (v *Validator) checkAccessReview(check *v1alpha1.AccessReviewCheck, result *CheckValidationResult) error {
+	var (
+		user   = check.User
+		groups = append([]string{}, check.Groups...)
+	)
+
+	if check.ServiceAccount != "" {
+		parts := strings.SplitN(check.ServiceAccount, "/", 2)
+		if len(parts) != 2 {
+			return errors.Errorf("service account '%v' must be given as namespace/name", check.ServiceAccount)
+		}
+		user = fmt.Sprintf("system:serviceaccount:%v:%v", parts[0], parts[1])
+		groups = append(groups, "system:serviceaccounts", fmt.Sprintf("system:serviceaccounts:%v", parts[0]))
+	}
+
+	if user == "" && len(groups) == 0 {
+		return errors.New("access review requires a user, groups or a service account")
+	}
+
+	subject := user
+	if subject == "" {
+		subject = strings.Join(groups, ",")
+	}
+
+	for _, rule := range check.Rules {
+		review := &authorizationv1.SubjectAccessReview{
+			TypeMeta: metav1.TypeMeta{Kind: "SubjectAccessReview", APIVersion: "authorization.k8s.io/v1"},
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   user,
+				Groups: groups,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Verb:        rule.Verb,
+					Group:       rule.Group,
+					Resource:    rule.Resource,
+					Subresource: rule.Subresource,
+					Namespace:   rule.Namespace,
+					Name:        rule.Name,
+				},
+			},
+		}
+
+		allowed, err := v.reviewAccess(review)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case rule.Allowed && !allowed:
+			reason := fmt.Sprintf("'%v' is denied but expected to be allowed", subject)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], rule.String())
+		case !rule.Allowed && allowed:
+			reason := fmt.Sprintf("'%v' is allowed but expected to be denied", subject)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], rule.String())
+		}
+	}
+	return nil
+}
+
+func (v *Validator) reviewAccess(review *authorizationv1.SubjectAccessReview) (bool, error) {
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(review)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to convert access review")
+	}
+
+	out, err := v.Kubernetes.Resource(sarGVR).Create(context.Background(), &unstructured.Unstructured{Object: raw}, metav1.CreateOptions{})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create subject access review")
+	}
+
+	allowed, _, _ := unstructured.NestedBool(out.Object, "status", "allowed")
+	return allowed, nil
+}
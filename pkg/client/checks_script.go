@@ -0,0 +1,299 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	scriptFunction = "validate"
+	// scriptMaxSteps bounds the work of a script so a runaway loop fails the check instead of
+	// blocking the run
+	scriptMaxSteps = 50000000
+)
+
+// checkScript runs the validate function of a Starlark script on the objects in scope. Objects
+// are listed in pages, per namespace when the check includes namespaces by name, and only the
+// objects in scope are kept.
+func (v *Validator) checkScript(check *v1alpha1.ScriptCheck, result *CheckValidationResult) error {
+	var (
+		scope    = v1alpha1.ClusterResource{Name: check.Resource, APIVersion: check.APIVersion, Namespaces: check.Namespaces, Names: check.Names}
+		objects  = make([]starlark.Value, 0)
+		failures = make([]string, 0)
+	)
+
+	selector, err := metav1.ParseToLabelSelector(check.LabelSelector)
+	if err != nil {
+		return errors.Wrapf(err, "invalid label selector '%v'", check.LabelSelector)
+	}
+	scope.Labels = selector
+
+	err = eachNamespaceResource(scope, v.eachSelectedResource, func(u unstructured.Unstructured) {
+		if inResourceScope(scope, u) {
+			objects = append(objects, toStarlark(u.Object))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	thread := &starlark.Thread{Name: result.Check}
+	thread.SetMaxExecutionSteps(scriptMaxSteps)
+	globals, err := starlark.ExecFile(thread, result.Check, check.Source, scriptBuiltins)
+	if err != nil {
+		return errors.Wrap(err, "failed to load script")
+	}
+	validate, ok := globals[scriptFunction].(starlark.Callable)
+	if !ok {
+		return errors.Errorf("script does not define a %v function", scriptFunction)
+	}
+
+	out, err := starlark.Call(thread, validate, starlark.Tuple{starlark.NewList(objects)}, nil)
+	if err != nil {
+		return errors.Wrap(err, "script failed")
+	}
+
+	switch out := out.(type) {
+	case starlark.NoneType:
+	case *starlark.List:
+		for i := 0; i < out.Len(); i++ {
+			message, object, err := scriptFailure(out.Index(i))
+			if err != nil {
+				return err
+			}
+			if object == "" {
+				failures = append(failures, message)
+				continue
+			}
+			result.ResourceErrors[message] = append(result.ResourceErrors[message], object)
+		}
+	default:
+		return errors.Errorf("%v returned %v, expected a list of failures or None", scriptFunction, out.Type())
+	}
+
+	for _, objects := range result.ResourceErrors {
+		sort.Strings(objects)
+	}
+	if len(failures) > 0 {
+		result.Error = strings.Join(failures, "; ")
+	}
+	return nil
+}
+
+// scriptFailure reads a failure returned by a script, a message or a dict with a message and
+// optionally the object it is about.
+func scriptFailure(value starlark.Value) (string, string, error) {
+	var (
+		message, object string
+	)
+
+	switch value := value.(type) {
+	case starlark.String:
+		return string(value), "", nil
+	case *starlark.Dict:
+		for key, target := range map[string]*string{"message": &message, "object": &object} {
+			v, found, err := value.Get(starlark.String(key))
+			if err != nil || !found {
+				continue
+			}
+			s, ok := starlark.AsString(v)
+			if !ok {
+				return "", "", errors.Errorf("failure %v must be a string, found %v", key, v.Type())
+			}
+			*target = s
+		}
+		if message == "" {
+			return "", "", errors.Errorf("failure %v has no message", value)
+		}
+		return message, object, nil
+	default:
+		return "", "", errors.Errorf("failure must be a string or a dict, found %v", value.Type())
+	}
+}
+
+// validateScripts rejects scripts that do not parse, so syntax errors surface when the spec
+// is loaded rather than as failed attempts.
+func validateScripts(spec *v1alpha1.ClusterValidation) error {
+	for _, c := range spec.Spec.Checks {
+		if c.Script == nil {
+			continue
+		}
+		if _, err := syntax.Parse(c.Name, c.Script.Source, 0); err != nil {
+			return errors.Wrapf(err, "invalid script of check '%v'", c.Name)
+		}
+	}
+	return nil
+}
+
+// scriptBuiltins exposes the expression helpers to scripts under snake_case names, durations
+// and timestamps are returned in seconds.
+var scriptBuiltins = starlark.StringDict{
+	"quantity": scriptStringBuiltin("quantity", []string{"s"}, func(args []string) (starlark.Value, error) {
+		q, err := expr.Quantity(args[0])
+		return starlark.Float(q), err
+	}),
+	"compare_quantity": scriptStringBuiltin("compare_quantity", []string{"a", "b"}, func(args []string) (starlark.Value, error) {
+		c, err := expr.CompareQuantity(args[0], args[1])
+		return starlark.MakeInt(c), err
+	}),
+	"duration": scriptStringBuiltin("duration", []string{"s"}, func(args []string) (starlark.Value, error) {
+		d, err := expr.ParseDuration(args[0])
+		return starlark.Float(d.Seconds()), err
+	}),
+	"timestamp": scriptStringBuiltin("timestamp", []string{"s"}, func(args []string) (starlark.Value, error) {
+		t, err := expr.ParseTimestamp(args[0])
+		return starlark.Float(t.Unix()), err
+	}),
+	"since": scriptStringBuiltin("since", []string{"timestamp"}, func(args []string) (starlark.Value, error) {
+		d, err := expr.Since(args[0])
+		return starlark.Float(d.Seconds()), err
+	}),
+	"until": scriptStringBuiltin("until", []string{"timestamp"}, func(args []string) (starlark.Value, error) {
+		d, err := expr.Until(args[0])
+		return starlark.Float(d.Seconds()), err
+	}),
+	"older_than": scriptStringBuiltin("older_than", []string{"timestamp", "duration"}, func(args []string) (starlark.Value, error) {
+		older, err := expr.OlderThan(args[0], args[1])
+		return starlark.Bool(older), err
+	}),
+	"newer_than": scriptStringBuiltin("newer_than", []string{"timestamp", "duration"}, func(args []string) (starlark.Value, error) {
+		newer, err := expr.NewerThan(args[0], args[1])
+		return starlark.Bool(newer), err
+	}),
+	"semver": scriptStringBuiltin("semver", []string{"s"}, func(args []string) (starlark.Value, error) {
+		v, err := expr.Semver(args[0])
+		return starlark.String(v), err
+	}),
+	"semver_compare": scriptStringBuiltin("semver_compare", []string{"a", "b"}, func(args []string) (starlark.Value, error) {
+		c, err := expr.SemverCompare(args[0], args[1])
+		return starlark.MakeInt(c), err
+	}),
+	"label": scriptMetadataBuiltin("label", "labels", func(obj map[string]interface{}, key string) starlark.Value {
+		return starlark.String(expr.Label(obj, key))
+	}),
+	"has_label": scriptMetadataBuiltin("has_label", "labels", func(obj map[string]interface{}, key string) starlark.Value {
+		return starlark.Bool(expr.HasLabel(obj, key))
+	}),
+	"annotation": scriptMetadataBuiltin("annotation", "annotations", func(obj map[string]interface{}, key string) starlark.Value {
+		return starlark.String(expr.Annotation(obj, key))
+	}),
+	"has_annotation": scriptMetadataBuiltin("has_annotation", "annotations", func(obj map[string]interface{}, key string) starlark.Value {
+		return starlark.Bool(expr.HasAnnotation(obj, key))
+	}),
+}
+
+// scriptStringBuiltin defines a builtin taking the named string parameters.
+func scriptStringBuiltin(name string, params []string, fn func([]string) (starlark.Value, error)) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var (
+			values = make([]string, len(params))
+			pairs  = make([]interface{}, 0, 2*len(params))
+		)
+		for i, param := range params {
+			pairs = append(pairs, param, &values[i])
+		}
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, pairs...); err != nil {
+			return nil, err
+		}
+		return fn(values)
+	})
+}
+
+// scriptMetadataBuiltin defines a builtin taking an object and the key of one of its labels or
+// annotations.
+func scriptMetadataBuiltin(name, field string, fn func(map[string]interface{}, string) starlark.Value) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var (
+			obj *starlark.Dict
+			key string
+		)
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "obj", &obj, "key", &key); err != nil {
+			return nil, err
+		}
+		return fn(scriptMetadata(obj, field), key), nil
+	})
+}
+
+// scriptMetadata returns the labels or annotations of a script object as an object the
+// expression helpers accept.
+func scriptMetadata(obj *starlark.Dict, field string) map[string]interface{} {
+	var (
+		values = make(map[string]interface{})
+	)
+
+	if metadata, ok, _ := obj.Get(starlark.String("metadata")); ok {
+		if metadata, ok := metadata.(*starlark.Dict); ok {
+			if entries, ok, _ := metadata.Get(starlark.String(field)); ok {
+				if entries, ok := entries.(*starlark.Dict); ok {
+					for _, item := range entries.Items() {
+						k, kok := starlark.AsString(item[0])
+						v, vok := starlark.AsString(item[1])
+						if kok && vok {
+							values[k] = v
+						}
+					}
+				}
+			}
+		}
+	}
+	return map[string]interface{}{"metadata": map[string]interface{}{field: values}}
+}
+
+// toStarlark converts an unstructured object to Starlark values, numbers stay integers where
+// the object has integers.
+func toStarlark(value interface{}) starlark.Value {
+	switch value := value.(type) {
+	case nil:
+		return starlark.None
+	case bool:
+		return starlark.Bool(value)
+	case string:
+		return starlark.String(value)
+	case int64:
+		return starlark.MakeInt64(value)
+	case int:
+		return starlark.MakeInt(value)
+	case float64:
+		return starlark.Float(value)
+	case []interface{}:
+		items := make([]starlark.Value, 0, len(value))
+		for _, item := range value {
+			items = append(items, toStarlark(item))
+		}
+		return starlark.NewList(items)
+	case map[string]interface{}:
+		dict := starlark.NewDict(len(value))
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			_ = dict.SetKey(starlark.String(k), toStarlark(value[k]))
+		}
+		return dict
+	default:
+		return starlark.String(fmt.Sprintf("%v", value))
+	}
+}
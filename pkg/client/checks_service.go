@@ -0,0 +1,105 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkServiceEndpoints flags services in scope with fewer ready endpoints than required,
+// which catches services that select nothing while their workloads report ready.
+func (v *Validator) checkServiceEndpoints(check *v1alpha1.ServiceEndpointsCheck, result *CheckValidationResult) error {
+	var (
+		minReady = check.MinReady
+		ready    = make(map[types.NamespacedName]map[string]bool)
+		matched  int
+	)
+
+	if minReady < 1 {
+		minReady = 1
+	}
+
+	services, err := v.Kubernetes.Resource(serviceGVR).Namespace(check.Namespace).List(context.Background(), metav1.ListOptions{LabelSelector: check.LabelSelector})
+	if err != nil {
+		return errors.Wrap(err, "failed to list services")
+	}
+
+	slices, err := v.Kubernetes.Resource(sliceGVR).Namespace(check.Namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list endpointslices")
+	}
+
+	for _, item := range slices.Items {
+		slice := discoveryv1.EndpointSlice{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &slice); err != nil {
+			return errors.Wrapf(err, "failed to convert endpointslice '%v'", namespacedName(item))
+		}
+
+		key := types.NamespacedName{Namespace: slice.Namespace, Name: slice.Labels[discoveryv1.LabelServiceName]}
+		if key.Name == "" {
+			continue
+		}
+		if ready[key] == nil {
+			ready[key] = make(map[string]bool)
+		}
+		for _, ep := range slice.Endpoints {
+			// a nil ready condition must be interpreted as ready
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			ready[key][endpointKey(ep)] = true
+		}
+	}
+
+	for _, item := range services.Items {
+		svc := corev1.Service{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &svc); err != nil {
+			return errors.Wrapf(err, "failed to convert service '%v'", namespacedName(item))
+		}
+		if svc.Spec.Type == corev1.ServiceTypeExternalName || !inSelectionScope(check.Names, svc.Name) {
+			continue
+		}
+
+		matched++
+		count := len(ready[types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}])
+		if count < minReady {
+			reason := fmt.Sprintf("service has %v ready endpoints, expected at least %v", count, minReady)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], namespacedName(item))
+		}
+	}
+
+	if matched == 0 {
+		result.Error = "no services matched the check scope"
+	}
+	return nil
+}
+
+// endpointKey identifies an endpoint across the per address family slices of a service.
+func endpointKey(ep discoveryv1.Endpoint) string {
+	if ep.TargetRef != nil && ep.TargetRef.UID != "" {
+		return string(ep.TargetRef.UID)
+	}
+	if len(ep.Addresses) > 0 {
+		return ep.Addresses[0]
+	}
+	return ""
+}
@@ -0,0 +1,1401 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func _mockObject(cl *fake.FakeDynamicClient, gvr schema.GroupVersionResource, obj runtime.Object) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		panic(err)
+	}
+	unstructuredObj := &unstructured.Unstructured{Object: u}
+	_, err = cl.Resource(gvr).Namespace(unstructuredObj.GetNamespace()).Create(context.Background(), unstructuredObj, metav1.CreateOptions{})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func _mockCheckValidator(cl *fake.FakeDynamicClient, checks ...v1alpha1.ClusterCheck) *Validator {
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{
+				SuccessThreshold: 1,
+				FailureThreshold: 1,
+				Interval:         "1ms",
+			},
+			Checks: checks,
+		},
+	}
+	return NewValidator(cl, spec, nil)
+}
+
+func _mockTaintedNode(cl *fake.FakeDynamicClient, name string, taints ...corev1.Taint) {
+	_mockObject(cl, NodeGVR, &corev1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	})
+}
+
+func _mockDaemonSet(cl *fake.FakeDynamicClient, name, namespace string, numberReady int32, tolerations ...corev1.Toleration) *appsv1.DaemonSet {
+	ds := &appsv1.DaemonSet{
+		TypeMeta:   metav1.TypeMeta{Kind: "DaemonSet", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID(name + "-uid")},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Tolerations: tolerations}},
+		},
+		Status: appsv1.DaemonSetStatus{NumberReady: numberReady},
+	}
+	_mockObject(cl, DaemonSetGVR, ds)
+	return ds
+}
+
+func _mockOwnedPod(cl *fake.FakeDynamicClient, name, node string, owner metav1.Object, ownerKind string, ready bool) {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	controller := true
+	_mockObject(cl, PodGVR, &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: owner.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: ownerKind, Name: owner.GetName(), UID: owner.GetUID(), Controller: &controller},
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	})
+}
+
+func Test_PositiveCNICoverageCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockTaintedNode(dynamic, "node-1")
+	_mockTaintedNode(dynamic, "node-2", corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule})
+	ds := _mockDaemonSet(dynamic, "aws-node", "kube-system", 1)
+	_mockOwnedPod(dynamic, "aws-node-1", "node-1", ds, "DaemonSet", true)
+
+	v := _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:        "cni",
+		Required:    true,
+		CNICoverage: &v1alpha1.DaemonSetCoverageCheck{DaemonSet: "aws-node"},
+	})
+	g.Expect(v.Validate()).To(gomega.Succeed())
+}
+
+func Test_NegativeCNICoverageCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockTaintedNode(dynamic, "node-1")
+	_mockTaintedNode(dynamic, "node-2", corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule})
+	ds := _mockDaemonSet(dynamic, "aws-node", "kube-system", 1, corev1.Toleration{Operator: corev1.TolerationOpExists})
+	_mockOwnedPod(dynamic, "aws-node-1", "node-1", ds, "DaemonSet", true)
+
+	v := _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:        "cni",
+		Required:    true,
+		CNICoverage: &v1alpha1.DaemonSetCoverageCheck{DaemonSet: "aws-node"},
+	})
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations).To(gomega.HaveLen(1))
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.ContainElement([]string{"node-2"}))
+}
+
+func _mockGPUNode(cl *fake.FakeDynamicClient, name string, gpus string) {
+	node := &corev1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"nvidia.com/gpu.present": "true"}},
+		Status: corev1.NodeStatus{
+			Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			Allocatable: corev1.ResourceList{},
+		},
+	}
+	if gpus != "" {
+		node.Status.Allocatable["nvidia.com/gpu"] = resource.MustParse(gpus)
+	}
+	_mockObject(cl, NodeGVR, node)
+}
+
+func Test_DaemonSetCoverageCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockTaintedNode(dynamic, "node-1")
+	_mockGPUNode(dynamic, "gpu-1", "4")
+	ds := _mockDaemonSet(dynamic, "nvidia-device-plugin", "kube-system", 1)
+	_mockOwnedPod(dynamic, "nvidia-device-plugin-1", "gpu-1", ds, "DaemonSet", true)
+
+	check := v1alpha1.ClusterCheck{
+		Name:     "device plugin",
+		Required: true,
+		DaemonSetCoverage: &v1alpha1.DaemonSetCoverageCheck{
+			DaemonSet:    "nvidia-device-plugin",
+			NodeSelector: "nvidia.com/gpu.present=true",
+			Resources:    []string{"nvidia.com/gpu"},
+		},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockGPUNode(dynamic, "gpu-2", "0")
+	v = _mockCheckValidator(dynamic, check)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	failures := ToValidationError(err).CheckValidations[0].ResourceErrors
+	g.Expect(failures).To(gomega.HaveKeyWithValue("no ready pod of daemonset 'kube-system/nvidia-device-plugin' on ready node", []string{"gpu-2"}))
+	g.Expect(failures).To(gomega.HaveKeyWithValue("resource 'nvidia.com/gpu' is not allocatable on ready node", []string{"gpu-2"}))
+}
+
+func _mockService(cl *fake.FakeDynamicClient, name, namespace string, readyEndpoints ...bool) {
+	_mockObject(cl, ServiceGVR, &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": name}},
+	})
+
+	slice := &discoveryv1.EndpointSlice{
+		TypeMeta: metav1.TypeMeta{Kind: "EndpointSlice", APIVersion: "discovery.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-abcde",
+			Namespace: namespace,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: name},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+	}
+	for i, ready := range readyEndpoints {
+		ready := ready
+		slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+			Addresses:  []string{fmt.Sprintf("10.0.0.%v", i+1)},
+			Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+		})
+	}
+	_mockObject(cl, SliceGVR, slice)
+}
+
+func Test_PositiveServiceEndpointsCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockService(dynamic, "api", "default", true, true, false)
+	_mockService(dynamic, "typo", "default")
+
+	v := _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:             "api endpoints",
+		Required:         true,
+		ServiceEndpoints: &v1alpha1.ServiceEndpointsCheck{Namespace: "default", LabelSelector: "app=api", MinReady: 2},
+	})
+	g.Expect(v.Validate()).To(gomega.Succeed())
+}
+
+func Test_NegativeServiceEndpointsCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockService(dynamic, "api", "default", true, true, false)
+	_mockService(dynamic, "typo", "default")
+
+	v := _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:             "endpoints",
+		Required:         true,
+		ServiceEndpoints: &v1alpha1.ServiceEndpointsCheck{Namespace: "default"},
+	})
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.ContainElement([]string{"default/typo"}))
+
+	v = _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:             "missing",
+		Required:         true,
+		ServiceEndpoints: &v1alpha1.ServiceEndpointsCheck{Namespace: "default", Names: &v1alpha1.SelectionScope{Include: []string{"missing"}}},
+	})
+	g.Expect(v.Validate()).NotTo(gomega.Succeed())
+}
+
+func _mockLoadBalancerService(cl *fake.FakeDynamicClient, name, namespace, ip string) {
+	svc := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	if ip != "" {
+		svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: ip}}
+	}
+	_mockObject(cl, ServiceGVR, svc)
+}
+
+func Test_PositiveLoadBalancerCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ts := _mockServer(t, "ok", http.StatusOK)
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	dynamic := _fakeDynamicClient()
+	_mockLoadBalancerService(dynamic, "gateway", "default", u.Hostname())
+	_mockObject(dynamic, IngressGVR, &networkingv1.Ingress{
+		TypeMeta:   metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: "web.example.com"}}},
+		Status: networkingv1.IngressStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{IP: u.Hostname()}},
+		}},
+	})
+
+	v := _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:     "load balancers",
+		Required: true,
+		LoadBalancer: &v1alpha1.LoadBalancerCheck{
+			Namespace: "default",
+			Probe:     &v1alpha1.LoadBalancerProbe{Port: port, Codes: []int{http.StatusOK}},
+		},
+	})
+	g.Expect(v.Validate()).To(gomega.Succeed())
+}
+
+func Test_NegativeLoadBalancerCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockLoadBalancerService(dynamic, "gateway", "default", "")
+
+	v := _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:         "load balancers",
+		Required:     true,
+		LoadBalancer: &v1alpha1.LoadBalancerCheck{Kinds: []string{v1alpha1.LoadBalancerKindService}},
+	})
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.ContainElement([]string{"service default/gateway"}))
+}
+
+// _mockPodRuntime completes pods created by active checks, senders succeed when
+// connect returns true for the sender pod.
+func _mockPodRuntime(cl *fake.FakeDynamicClient, connect func(sender *unstructured.Unstructured) bool) {
+	cl.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		u := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		switch {
+		case strings.Contains(u.GetName(), "receiver"):
+			_ = unstructured.SetNestedField(u.Object, string(corev1.PodRunning), "status", "phase")
+			_ = unstructured.SetNestedField(u.Object, "10.0.0.1", "status", "podIP")
+			_ = unstructured.SetNestedSlice(u.Object, []interface{}{
+				map[string]interface{}{"type": string(corev1.PodReady), "status": string(corev1.ConditionTrue)},
+			}, "status", "conditions")
+		case connect(u):
+			_ = unstructured.SetNestedField(u.Object, string(corev1.PodSucceeded), "status", "phase")
+		default:
+			_ = unstructured.SetNestedField(u.Object, string(corev1.PodFailed), "status", "phase")
+		}
+		return false, nil, nil
+	})
+}
+
+func _networkPolicyCheck() v1alpha1.ClusterCheck {
+	return v1alpha1.ClusterCheck{
+		Name:     "network policies",
+		Required: true,
+		NetworkPolicy: &v1alpha1.NetworkPolicyCheck{
+			Paths: []v1alpha1.NetworkPolicyPath{
+				{
+					Name:    "frontend to backend",
+					From:    v1alpha1.ProbeEndpoint{Namespace: "frontend"},
+					To:      v1alpha1.ProbeEndpoint{Namespace: "backend", Labels: map[string]string{"app": "api"}},
+					Allowed: true,
+				},
+				{
+					Name: "default to backend",
+					From: v1alpha1.ProbeEndpoint{Namespace: "default"},
+					To:   v1alpha1.ProbeEndpoint{Namespace: "backend", Labels: map[string]string{"app": "api"}},
+				},
+			},
+		},
+	}
+}
+
+func Test_PositiveNetworkPolicyCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	workloadPollInterval = time.Millisecond
+	dynamic := _fakeDynamicClient()
+	_mockPodRuntime(dynamic, func(sender *unstructured.Unstructured) bool {
+		return sender.GetNamespace() == "frontend"
+	})
+
+	v := _mockCheckValidator(dynamic, _networkPolicyCheck())
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	pods, err := dynamic.Resource(PodGVR).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(pods.Items).To(gomega.BeEmpty())
+}
+
+func Test_NegativeNetworkPolicyCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	workloadPollInterval = time.Millisecond
+	dynamic := _fakeDynamicClient()
+	_mockPodRuntime(dynamic, func(sender *unstructured.Unstructured) bool {
+		return true
+	})
+
+	v := _mockCheckValidator(dynamic, _networkPolicyCheck())
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.HaveKeyWithValue("denied path connected", []string{"default to backend"}))
+}
+
+func Test_NetworkPolicyCheckWaitsForReceiver(t *testing.T) {
+	g := gomega.NewWithT(t)
+	workloadPollInterval = time.Millisecond
+	defer func(timeout time.Duration) { workloadStartupTimeout = timeout }(workloadStartupTimeout)
+	workloadStartupTimeout = 20 * time.Millisecond
+	dynamic := _fakeDynamicClient()
+
+	// the receiver runs but never passes its readiness probe
+	var senders int32
+	dynamic.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		u := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		if strings.Contains(u.GetName(), "sender") {
+			atomic.AddInt32(&senders, 1)
+			return false, nil, nil
+		}
+		containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "containers")
+		g.Expect(containers).To(gomega.ContainElement(gomega.HaveKey("readinessProbe")))
+		_ = unstructured.SetNestedField(u.Object, string(corev1.PodRunning), "status", "phase")
+		_ = unstructured.SetNestedField(u.Object, "10.0.0.1", "status", "podIP")
+		return false, nil, nil
+	})
+
+	v := _mockCheckValidator(dynamic, _networkPolicyCheck())
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].Error).To(gomega.ContainSubstring("timed out"))
+	g.Expect(atomic.LoadInt32(&senders)).To(gomega.BeZero())
+}
+
+func _mockStorageClass(cl *fake.FakeDynamicClient, name string, mode storagev1.VolumeBindingMode) {
+	_mockObject(cl, SCGVR, &storagev1.StorageClass{
+		TypeMeta:          metav1.TypeMeta{Kind: "StorageClass", APIVersion: "storage.k8s.io/v1"},
+		ObjectMeta:        metav1.ObjectMeta{Name: name},
+		Provisioner:       "ebs.csi.aws.com",
+		VolumeBindingMode: &mode,
+	})
+}
+
+// _mockProvisioner binds claims of the given storage classes on creation.
+func _mockProvisioner(cl *fake.FakeDynamicClient, classes ...string) {
+	cl.PrependReactor("create", "persistentvolumeclaims", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		u := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		class, _, _ := unstructured.NestedString(u.Object, "spec", "storageClassName")
+		for _, c := range classes {
+			if c == class {
+				_ = unstructured.SetNestedField(u.Object, string(corev1.ClaimBound), "status", "phase")
+			}
+		}
+		return false, nil, nil
+	})
+}
+
+func Test_PVCProvisioningCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	workloadPollInterval = time.Millisecond
+	dynamic := _fakeDynamicClient()
+	_mockStorageClass(dynamic, "gp3", storagev1.VolumeBindingWaitForFirstConsumer)
+	_mockStorageClass(dynamic, "broken", storagev1.VolumeBindingImmediate)
+	_mockProvisioner(dynamic, "gp3")
+	_mockPodRuntime(dynamic, func(sender *unstructured.Unstructured) bool {
+		return true
+	})
+
+	check := v1alpha1.ClusterCheck{
+		Name:            "storage",
+		Required:        true,
+		PVCProvisioning: &v1alpha1.PVCProvisioningCheck{Namespace: "default", StorageClasses: []string{"gp3"}, Timeout: "10ms"},
+	}
+	g.Expect(_mockCheckValidator(dynamic, check).Validate()).To(gomega.Succeed())
+
+	check.PVCProvisioning.StorageClasses = []string{"gp3", "broken"}
+	err := _mockCheckValidator(dynamic, check).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.HaveKeyWithValue("claim did not become Bound", []string{"broken"}))
+
+	for _, gvr := range []schema.GroupVersionResource{PVCGVR, PodGVR} {
+		list, err := dynamic.Resource(gvr).List(context.Background(), metav1.ListOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(list.Items).To(gomega.BeEmpty())
+	}
+}
+
+// _mockRESTMapper maps the kinds used by workload tests, including an irregular plural.
+func _mockRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.AddSpecific(JobGVR.GroupVersion().WithKind("Job"), JobGVR, JobGVR.GroupVersion().WithResource("job"), meta.RESTScopeNamespace)
+	mapper.AddSpecific(schema.GroupVersionKind{Group: "birds.io", Version: "v1", Kind: "Goose"},
+		schema.GroupVersionResource{Group: "birds.io", Version: "v1", Resource: "geese"},
+		schema.GroupVersionResource{Group: "birds.io", Version: "v1", Resource: "goose"}, meta.RESTScopeNamespace)
+	return mapper
+}
+
+func Test_PositiveWorkloadTest(t *testing.T) {
+	g := gomega.NewWithT(t)
+	workloadPollInterval = time.Millisecond
+	dynamic := _fakeDynamicClient()
+	dynamic.PrependReactor("create", "jobs", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		u := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		_ = unstructured.SetNestedSlice(u.Object, []interface{}{
+			map[string]interface{}{"type": "Complete", "status": "True"},
+		}, "status", "conditions")
+		return false, nil, nil
+	})
+
+	v := _mockValidator("workload_validation.yaml", dynamic, nil)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	jobs, err := dynamic.Resource(JobGVR).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(jobs.Items).To(gomega.BeEmpty())
+}
+
+func Test_NegativeWorkloadTest(t *testing.T) {
+	g := gomega.NewWithT(t)
+	workloadPollInterval = time.Millisecond
+	dynamic := _fakeDynamicClient()
+
+	v := _mockValidator("workload_validation.yaml", dynamic, nil)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).ConditionValidations).NotTo(gomega.BeEmpty())
+
+	jobs, err := dynamic.Resource(JobGVR).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(jobs.Items).To(gomega.BeEmpty())
+}
+
+func Test_WorkloadTestStoppedOnFailure(t *testing.T) {
+	g := gomega.NewWithT(t)
+	workloadPollInterval = time.Millisecond
+	dynamic := _fakeDynamicClient()
+	_mockNode(dynamic, "node-1", true)
+
+	var created int32
+	dynamic.PrependReactor("create", "jobs", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		atomic.AddInt32(&created, 1)
+		return false, nil, nil
+	})
+
+	// the job never completes, the workload test is still waiting on it when the nodes fail
+	v := _mockValidator("workload_validation.yaml", dynamic, nil)
+	v.Validation.Spec.WorkloadTests[0].Timeout = "1m"
+	v.Validation.Spec.Resources = []v1alpha1.ClusterResource{
+		{
+			Name:          "nodes",
+			APIVersion:    "v1",
+			Required:      true,
+			Configuration: v1alpha1.ValidationConfiguration{FailureThreshold: 3, Interval: "20ms"},
+			Fields:        []v1alpha1.FieldSelector{{Path: ".metadata.name", Values: []string{"node-2"}}},
+		},
+	}
+	err := v.Validate()
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("nodes")))
+	g.Expect(atomic.LoadInt32(&created)).To(gomega.BeNumerically(">", 0))
+
+	// the workload test cleaned up after itself before Validate returned
+	v.RLock()
+	g.Expect(v.workloads).To(gomega.BeEmpty())
+	v.RUnlock()
+	jobs, err := dynamic.Resource(JobGVR).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(jobs.Items).To(gomega.BeEmpty())
+}
+
+// _mockAuthorizer answers subject access reviews, allowing the given verbs only.
+func _mockAuthorizer(cl *fake.FakeDynamicClient, verbs ...string) {
+	cl.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		u := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		verb, _, _ := unstructured.NestedString(u.Object, "spec", "resourceAttributes", "verb")
+		allowed := false
+		for _, v := range verbs {
+			allowed = allowed || v == verb
+		}
+		_ = unstructured.SetNestedField(u.Object, allowed, "status", "allowed")
+		return true, u, nil
+	})
+}
+
+func Test_AccessReviewCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockAuthorizer(dynamic, "get", "list")
+
+	check := v1alpha1.ClusterCheck{
+		Name:     "rbac",
+		Required: true,
+		AccessReview: &v1alpha1.AccessReviewCheck{
+			ServiceAccount: "kube-system/cluster-autoscaler",
+			Rules: []v1alpha1.AccessRule{
+				{Verb: "list", Resource: "nodes", Allowed: true},
+				{Verb: "delete", Resource: "nodes"},
+			},
+		},
+	}
+	g.Expect(_mockCheckValidator(dynamic, check).Validate()).To(gomega.Succeed())
+
+	check.AccessReview.Rules = append(check.AccessReview.Rules, v1alpha1.AccessRule{Verb: "patch", Group: "apps", Resource: "deployments", Namespace: "kube-system", Allowed: true})
+	err := _mockCheckValidator(dynamic, check).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.HaveKeyWithValue(
+		"'system:serviceaccount:kube-system:cluster-autoscaler' is denied but expected to be allowed",
+		[]string{"patch deployments.apps in kube-system"},
+	))
+}
+
+func Test_Preflight(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	dynamic.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		u := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		resource, _, _ := unstructured.NestedString(u.Object, "spec", "resourceAttributes", "resource")
+		_ = unstructured.SetNestedField(u.Object, resource != "daemonsets" && resource != "pods", "status", "allowed")
+		return true, u, nil
+	})
+
+	v := _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:        "cni",
+		Required:    true,
+		CNICoverage: &v1alpha1.DaemonSetCoverageCheck{DaemonSet: "aws-node"},
+	})
+	v.Preflight = true
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors["missing permission"]).To(gomega.ConsistOf(
+		"get daemonsets.apps in kube-system",
+		"list pods in kube-system",
+	))
+}
+
+const _featureMetrics = `# HELP kubernetes_feature_enabled [BETA] This metric records the data about the stage and enablement of a k8s feature.
+# TYPE kubernetes_feature_enabled gauge
+kubernetes_feature_enabled{name="APIPriorityAndFairness",stage="BETA"} 1
+kubernetes_feature_enabled{name="InPlacePodVerticalScaling",stage="ALPHA"} 0
+`
+
+func Test_FeatureGatesCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(_featureMetrics))
+	})
+	mux.HandleFunc("/api/v1/nodes/node-1/proxy/configz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"kubeletconfig":{"featureGates":{"GracefulNodeShutdown":true}}}`))
+	})
+	mux.HandleFunc("/api/v1/nodes/node-2/proxy/configz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"kubeletconfig":{}}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	dynamic := _fakeDynamicClient()
+	_mockNode(dynamic, "node-1", true)
+
+	check := v1alpha1.ClusterCheck{
+		Name:     "features",
+		Required: true,
+		FeatureGates: &v1alpha1.FeatureGatesCheck{
+			Enabled:       []string{"APIPriorityAndFairness"},
+			Disabled:      []string{"InPlacePodVerticalScaling"},
+			KubeletFields: []v1alpha1.FieldSelector{{Path: ".kubeletconfig.featureGates.GracefulNodeShutdown", Values: []string{"true"}}},
+		},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	v.RESTClient = _mockRESTClient(ts.URL)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockNode(dynamic, "node-2", true)
+	check.FeatureGates.Enabled = append(check.FeatureGates.Enabled, "InPlacePodVerticalScaling", "Unknown")
+	v = _mockCheckValidator(dynamic, check)
+	v.RESTClient = _mockRESTClient(ts.URL)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	failures := ToValidationError(err).CheckValidations[0].ResourceErrors
+	g.Expect(failures).To(gomega.HaveKeyWithValue("feature gate is disabled", []string{"InPlacePodVerticalScaling"}))
+	g.Expect(failures).To(gomega.HaveKeyWithValue("feature gate is not known to the API server", []string{"Unknown"}))
+	g.Expect(failures).To(gomega.ContainElement([]string{"node-2"}))
+}
+
+func _mockKubeletNode(cl *fake.FakeDynamicClient, name, kubeletVersion string) {
+	_mockObject(cl, NodeGVR, &corev1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{KubeletVersion: kubeletVersion},
+		},
+	})
+}
+
+func Test_VersionSkewCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"major":"1","minor":"28","gitVersion":"v1.28.4-eks-8cb36c9"}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	dynamic := _fakeDynamicClient()
+	_mockKubeletNode(dynamic, "node-1", "v1.28.3-eks-e71965b")
+	_mockKubeletNode(dynamic, "node-2", "v1.25.16-eks-e71965b")
+
+	check := v1alpha1.ClusterCheck{
+		Name:        "skew",
+		Required:    true,
+		VersionSkew: &v1alpha1.VersionSkewCheck{},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	v.RESTClient = _mockRESTClient(ts.URL)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockKubeletNode(dynamic, "node-3", "v1.29.0")
+	check.VersionSkew.MaxMinorSkew = 1
+	v = _mockCheckValidator(dynamic, check)
+	v.RESTClient = _mockRESTClient(ts.URL)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	failures := ToValidationError(err).CheckValidations[0].ResourceErrors
+	g.Expect(failures).To(gomega.HaveKeyWithValue("kubelet is more than 1 minor versions older than API server 1.28.4", []string{"node-2"}))
+	g.Expect(failures).To(gomega.HaveKeyWithValue("kubelet is newer than API server 1.28.4", []string{"node-3"}))
+}
+
+func _mockCronJob(cl *fake.FakeDynamicClient, name string, age time.Duration, lastSuccess *time.Duration) *batchv1.CronJob {
+	cronJob := &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{Kind: "CronJob", APIVersion: "batch/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "kube-system",
+			UID:               types.UID(name + "-uid"),
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+	}
+	if lastSuccess != nil {
+		t := metav1.NewTime(time.Now().Add(-*lastSuccess))
+		cronJob.Status.LastSuccessfulTime = &t
+	}
+	_mockObject(cl, CronJobGVR, cronJob)
+	return cronJob
+}
+
+func _mockFailedJob(cl *fake.FakeDynamicClient, name string, owner *batchv1.CronJob) {
+	_mockObject(cl, JobGVR, &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{Kind: "Job", APIVersion: "batch/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       owner.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(owner, batchv1.SchemeGroupVersion.WithKind("CronJob"))},
+		},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+		},
+	})
+}
+
+func Test_JobRecencyCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	hour := time.Hour
+	stale := 48 * time.Hour
+
+	dynamic := _fakeDynamicClient()
+	backup := _mockCronJob(dynamic, "etcd-backup", 30*stale, &hour)
+	_mockCronJob(dynamic, "new-job", time.Minute, nil)
+	_mockFailedJob(dynamic, "etcd-backup-1", backup)
+
+	check := v1alpha1.ClusterCheck{
+		Name:     "cronjobs",
+		Required: true,
+		JobRecency: &v1alpha1.JobRecencyCheck{
+			Namespace:     "kube-system",
+			SuccessWithin: "1d",
+			MaxFailedJobs: 1,
+		},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockCronJob(dynamic, "cleanup", 30*stale, &stale)
+	_mockCronJob(dynamic, "never", 30*stale, nil)
+	_mockFailedJob(dynamic, "etcd-backup-2", backup)
+	v = _mockCheckValidator(dynamic, check)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	failures := ToValidationError(err).CheckValidations[0].ResourceErrors
+	g.Expect(failures).To(gomega.HaveKeyWithValue("cronjob has 2 failed jobs, expected at most 1", []string{"kube-system/etcd-backup"}))
+	g.Expect(failures).To(gomega.HaveKeyWithValue("cronjob has not completed successfully within 1d", []string{"kube-system/cleanup"}))
+	g.Expect(failures).To(gomega.HaveKeyWithValue("cronjob has never completed successfully", []string{"kube-system/never"}))
+}
+
+func _mockContainerPod(cl *fake.FakeDynamicClient, name, namespace string, statuses ...corev1.ContainerStatus) {
+	_mockObject(cl, PodGVR, &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: statuses},
+	})
+}
+
+func Test_CrashLoopCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	crashing := corev1.ContainerStatus{
+		Name:         "app",
+		RestartCount: 3,
+		State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}
+
+	dynamic := _fakeDynamicClient()
+	_mockContainerPod(dynamic, "healthy", "default", corev1.ContainerStatus{Name: "app", State: runningContainer})
+	_mockContainerPod(dynamic, "crashing", "default", crashing)
+	_mockContainerPod(dynamic, "ignored", "sandbox", crashing)
+
+	check := v1alpha1.ClusterCheck{
+		Name:     "crash loops",
+		Required: true,
+		CrashLoop: &v1alpha1.CrashLoopCheck{
+			Namespaces:   &v1alpha1.SelectionScope{Include: []string{"*"}, Exclude: []string{"sandbox"}},
+			MaxRestarts:  5,
+			MaxUnhealthy: 1,
+		},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockContainerPod(dynamic, "restarting", "kube-system", corev1.ContainerStatus{Name: "sidecar", RestartCount: 10, State: runningContainer})
+	v = _mockCheckValidator(dynamic, check)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	result := ToValidationError(err).CheckValidations[0]
+	g.Expect(result.Error).To(gomega.Equal("2 unhealthy containers found, expected at most 1"))
+	g.Expect(result.ResourceErrors).To(gomega.HaveKeyWithValue("container is in CrashLoopBackOff", []string{"default/crashing/app"}))
+	g.Expect(result.ResourceErrors).To(gomega.HaveKeyWithValue("container restarted more than 5 times", []string{"kube-system/restarting/sidecar"}))
+}
+
+func _mockPendingPod(cl *fake.FakeDynamicClient, name string, age time.Duration, conditions ...corev1.PodCondition) {
+	_mockObject(cl, PodGVR, &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(time.Now().Add(-age))},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending, Conditions: conditions},
+	})
+}
+
+func Test_PendingPodsCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockPendingPod(dynamic, "new", time.Minute)
+
+	check := v1alpha1.ClusterCheck{
+		Name:        "pending",
+		Required:    true,
+		PendingPods: &v1alpha1.PendingPodsCheck{MaxPending: "10m"},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockPendingPod(dynamic, "stuck", time.Hour, corev1.PodCondition{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable"})
+	v = _mockCheckValidator(dynamic, check)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.Equal(map[string][]string{
+		"pod has been pending for more than 10m (Unschedulable)": {"default/stuck"},
+	}))
+}
+
+func _mockFailedPod(cl *fake.FakeDynamicClient, name, reason string, age time.Duration) {
+	_mockObject(cl, PodGVR, &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(time.Now().Add(-age))},
+		Status:     corev1.PodStatus{Phase: corev1.PodFailed, Reason: reason},
+	})
+}
+
+func Test_FailedPodsCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockFailedPod(dynamic, "old-evicted", "Evicted", 48*time.Hour)
+	_mockFailedPod(dynamic, "evicted", "Evicted", time.Minute)
+	_mockFailedPod(dynamic, "failed", "", 5*time.Minute)
+	_mockPod(dynamic, "running", "default", true, runningContainer)
+
+	check := v1alpha1.ClusterCheck{
+		Name:       "failed pods",
+		Required:   true,
+		FailedPods: &v1alpha1.FailedPodsCheck{MaxFailed: 2, Within: "1h"},
+	}
+	g.Expect(_mockCheckValidator(dynamic, check).Validate()).To(gomega.Succeed())
+
+	check.FailedPods.Within = ""
+	err := _mockCheckValidator(dynamic, check).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	result := ToValidationError(err).CheckValidations[0]
+	g.Expect(result.Error).To(gomega.Equal("3 failed pods found, expected at most 2"))
+	g.Expect(result.ResourceErrors).To(gomega.HaveKeyWithValue("pod is Evicted", gomega.ConsistOf("default/old-evicted", "default/evicted")))
+	g.Expect(result.ResourceErrors).To(gomega.HaveKeyWithValue("pod is Failed", []string{"default/failed"}))
+}
+
+func _mockTerminatingNamespace(cl *fake.FakeDynamicClient, name string, age time.Duration, conditions ...corev1.NamespaceCondition) {
+	deleted := metav1.NewTime(time.Now().Add(-age))
+	_mockObject(cl, NamespaceGVR, &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{Kind: "Namespace", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, DeletionTimestamp: &deleted},
+		Spec:       corev1.NamespaceSpec{Finalizers: []corev1.FinalizerName{corev1.FinalizerKubernetes}},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating, Conditions: conditions},
+	})
+}
+
+func Test_TerminatingNamespacesCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockTerminatingNamespace(dynamic, "deleting", time.Minute)
+
+	check := v1alpha1.ClusterCheck{
+		Name:                  "terminating",
+		Required:              true,
+		TerminatingNamespaces: &v1alpha1.TerminatingNamespacesCheck{OlderThan: "15m"},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockTerminatingNamespace(dynamic, "ci-1234", time.Hour, corev1.NamespaceCondition{
+		Type:    corev1.NamespaceFinalizersRemaining,
+		Status:  corev1.ConditionTrue,
+		Message: "Some content in the namespace has finalizers remaining: elbv2.k8s.aws/resources in 1 resource instances, foregroundDeletion in 2 resource instances",
+	})
+	v = _mockCheckValidator(dynamic, check)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.Equal(map[string][]string{
+		"namespace is stuck terminating, blocked by finalizers elbv2.k8s.aws/resources, foregroundDeletion, kubernetes": {"ci-1234"},
+	}))
+}
+
+func _mockVolume(cl *fake.FakeDynamicClient, name string, phase corev1.PersistentVolumePhase, policy corev1.PersistentVolumeReclaimPolicy, age time.Duration) {
+	_mockObject(cl, PVGVR, &corev1.PersistentVolume{
+		TypeMeta:   metav1.TypeMeta{Kind: "PersistentVolume", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(time.Now().Add(-age))},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: policy,
+			StorageClassName:              "gp3",
+			ClaimRef:                      &corev1.ObjectReference{Namespace: "default", Name: name + "-claim"},
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: phase},
+	})
+}
+
+func Test_OrphanedVolumesCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockVolume(dynamic, "pv-bound", corev1.VolumeBound, corev1.PersistentVolumeReclaimRetain, 48*time.Hour)
+	_mockVolume(dynamic, "pv-recent", corev1.VolumeReleased, corev1.PersistentVolumeReclaimRetain, time.Minute)
+
+	check := v1alpha1.ClusterCheck{
+		Name:            "orphaned volumes",
+		Required:        true,
+		OrphanedVolumes: &v1alpha1.OrphanedVolumesCheck{OlderThan: "1h"},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockVolume(dynamic, "pv-released", corev1.VolumeReleased, corev1.PersistentVolumeReclaimRetain, 48*time.Hour)
+	_mockVolume(dynamic, "pv-failed", corev1.VolumeFailed, corev1.PersistentVolumeReclaimDelete, 48*time.Hour)
+	v = _mockCheckValidator(dynamic, check)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.Equal(map[string][]string{
+		"volume is Released with reclaim policy Retain": {"pv-released (claim default/pv-released-claim, storageClass gp3)"},
+		"volume is Failed with reclaim policy Delete":   {"pv-failed (claim default/pv-failed-claim, storageClass gp3)"},
+	}))
+}
+
+func _mockZoneNode(cl *fake.FakeDynamicClient, name, zone string) {
+	_mockObject(cl, NodeGVR, &corev1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"topology.kubernetes.io/zone": zone}},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	})
+}
+
+func Test_ZoneBalanceCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockZoneNode(dynamic, "node-1", "us-west-2a")
+	_mockZoneNode(dynamic, "node-2", "us-west-2b")
+	_mockZoneNode(dynamic, "node-3", "us-west-2c")
+
+	check := v1alpha1.ClusterCheck{
+		Name:     "zones",
+		Required: true,
+		ZoneBalance: &v1alpha1.ZoneBalanceCheck{
+			Zones:      []string{"us-west-2a", "us-west-2b", "us-west-2c"},
+			MinPercent: 25,
+			MaxSkew:    1,
+		},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockZoneNode(dynamic, "node-4", "us-west-2a")
+	_mockZoneNode(dynamic, "node-5", "us-west-2a")
+	_mockZoneNode(dynamic, "node-6", "us-west-2b")
+	check.ZoneBalance.Zones = append(check.ZoneBalance.Zones, "us-west-2d")
+	v = _mockCheckValidator(dynamic, check)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	failures := ToValidationError(err).CheckValidations[0].ResourceErrors
+	g.Expect(failures).To(gomega.HaveKeyWithValue("zone has less than 25% of 6 ready nodes", []string{"us-west-2c (1 nodes)", "us-west-2d (0 nodes)"}))
+	g.Expect(failures).To(gomega.HaveKeyWithValue("node count differs by more than 1 between zones", gomega.HaveLen(4)))
+}
+
+func _mockCapacityNode(cl *fake.FakeDynamicClient, name, capacity string) {
+	_mockObject(cl, NodeGVR, &corev1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"karpenter.sh/capacity-type": capacity}},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	})
+}
+
+func Test_CapacityMixCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockCapacityNode(dynamic, "node-1", "on-demand")
+	_mockCapacityNode(dynamic, "node-2", "on-demand")
+	_mockCapacityNode(dynamic, "node-3", "spot")
+	_mockCapacityNode(dynamic, "node-4", "spot")
+
+	check := v1alpha1.ClusterCheck{
+		Name:     "capacity",
+		Required: true,
+		CapacityMix: &v1alpha1.CapacityMixCheck{
+			Capacities: []v1alpha1.CapacityBound{
+				{Value: "on-demand", MinPercent: 25, MinNodes: 2},
+				{Value: "spot", MaxPercent: 50},
+			},
+		},
+	}
+	v := _mockCheckValidator(dynamic, check)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	_mockCapacityNode(dynamic, "node-5", "spot")
+	_mockCapacityNode(dynamic, "node-6", "spot")
+	_mockCapacityNode(dynamic, "node-7", "spot")
+	_mockCapacityNode(dynamic, "node-8", "spot")
+	_mockCapacityNode(dynamic, "node-9", "spot")
+	check.CapacityMix.Capacities[0].MinNodes = 3
+	v = _mockCheckValidator(dynamic, check)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	failures := ToValidationError(err).CheckValidations[0].ResourceErrors
+	g.Expect(failures).To(gomega.Equal(map[string][]string{
+		"capacity has less than 25% of 9 ready nodes": {"on-demand (2 nodes)"},
+		"capacity has fewer than 3 ready nodes":       {"on-demand (2 nodes)"},
+		"capacity has more than 50% of 9 ready nodes": {"spot (7 nodes)"},
+	}))
+}
+
+func _mockInstanceGroup(cl *fake.FakeDynamicClient, name, provisioner string, minSize, maxSize int64) {
+	group := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "instancemgr.keikoproj.io/v1alpha1",
+		"kind":       "InstanceGroup",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "instance-manager"},
+		"spec": map[string]interface{}{
+			"provisioner": provisioner,
+			provisioner:   map[string]interface{}{"minSize": minSize, "maxSize": maxSize},
+		},
+	}}
+	if _, err := cl.Resource(instanceGroupGVR).Namespace("instance-manager").Create(context.Background(), group, metav1.CreateOptions{}); err != nil {
+		panic(err)
+	}
+}
+
+func Test_InstanceGroupNodesCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockInstanceGroup(dynamic, "system", "eks", 2, 3)
+	_mockInstanceGroup(dynamic, "gpu", "eks-managed", 1, 1)
+	_mockLabeledNode(dynamic, "node-1", true, map[string]string{"node.kubernetes.io/instancegroup": "system"})
+	_mockLabeledNode(dynamic, "node-2", true, map[string]string{"node.kubernetes.io/instancegroup": "system"})
+	_mockLabeledNode(dynamic, "node-3", true, map[string]string{"node.kubernetes.io/instancegroup": "gpu"})
+
+	check := v1alpha1.ClusterCheck{
+		Name:               "instancegroup-nodes",
+		Required:           true,
+		InstanceGroupNodes: &v1alpha1.InstanceGroupNodesCheck{},
+	}
+	g.Expect(_mockCheckValidator(dynamic, check).Validate()).To(gomega.Succeed())
+
+	// a node that is not ready does not count, one too many does
+	_mockLabeledNode(dynamic, "node-4", false, map[string]string{"node.kubernetes.io/instancegroup": "system"})
+	_mockLabeledNode(dynamic, "node-5", true, map[string]string{"node.kubernetes.io/instancegroup": "gpu"})
+	_ = dynamic.Tracker().Delete(NodeGVR, "", "node-2")
+	err := _mockCheckValidator(dynamic, check).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].ResourceErrors).To(gomega.Equal(map[string][]string{
+		"instancegroup has fewer ready nodes than its minSize 2": {"instance-manager/system (1 ready nodes)"},
+		"instancegroup has more ready nodes than its maxSize 1":  {"instance-manager/gpu (2 ready nodes)"},
+	}))
+
+	// groups out of scope are not counted
+	check.InstanceGroupNodes.Names = &v1alpha1.SelectionScope{Exclude: []string{"system", "gpu"}}
+	err = _mockCheckValidator(dynamic, check).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].Error).To(gomega.Equal("no instancegroups matched the check scope"))
+}
+
+func Test_MonitoringPreset(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockNode(dynamic, "node-1", true)
+	_mockDaemonSet(dynamic, "kube-prometheus-stack-prometheus-node-exporter", "monitoring", 1)
+	_mockObject(dynamic, DeploymentGVR, &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-prometheus-stack-kube-state-metrics", Namespace: "monitoring"},
+		Status: appsv1.DeploymentStatus{
+			Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}},
+		},
+	})
+	_mockObject(dynamic, ServiceGVR, &corev1.Service{
+		TypeMeta: metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-prometheus-stack-kube-state-metrics",
+			Namespace: "monitoring",
+			Labels:    map[string]string{"app.kubernetes.io/name": "kube-state-metrics"},
+		},
+	})
+
+	spec, err := ParsePreset("monitoring")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	spec.Spec.Configuration = v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"}
+
+	// the cluster does not serve the optional Prometheus stack, the API server is not part of the fake cluster
+	dynamic.PrependReactor("list", "prometheuses", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+	spec.Spec.Endpoints = v1alpha1.EndpointsSpec{}
+
+	err = NewValidator(dynamic, spec, nil).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].Check).To(gomega.Equal("kube-state-metrics endpoints"))
+
+	ready := true
+	_mockObject(dynamic, SliceGVR, &discoveryv1.EndpointSlice{
+		TypeMeta: metav1.TypeMeta{Kind: "EndpointSlice", APIVersion: "discovery.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-prometheus-stack-kube-state-metrics-abcde",
+			Namespace: "monitoring",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "kube-prometheus-stack-kube-state-metrics"},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}}},
+	})
+	v := NewValidator(dynamic, spec, nil)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+	statuses := make(map[string]ValidationStatus)
+	for _, p := range v.Progress() {
+		statuses[p.Name] = p.Status
+	}
+	g.Expect(statuses).To(gomega.HaveKeyWithValue("prometheuses", ValidationStatusWarning))
+
+	// required entries for resources the cluster does not serve still fail the run
+	for i := range spec.Spec.Resources {
+		spec.Spec.Resources[i].Required = true
+	}
+	g.Expect(NewValidator(dynamic, spec, nil).Validate()).NotTo(gomega.Succeed())
+}
+
+func Test_ObjectCountCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	for i := 0; i < 3; i++ {
+		_mockSecret(dynamic, fmt.Sprintf("runaway-%v", i), "ci", nil)
+	}
+	_mockSecret(dynamic, "token", "default", nil)
+
+	check := v1alpha1.ClusterCheck{
+		Name:        "secret count",
+		Required:    true,
+		ObjectCount: &v1alpha1.ObjectCountCheck{APIVersion: "v1", Resource: "secrets", MaxTotal: 4},
+	}
+	g.Expect(_mockCheckValidator(dynamic, check).Validate()).To(gomega.Succeed())
+
+	check.ObjectCount.MaxTotal = 3
+	check.ObjectCount.MaxPerNamespace = 2
+	err := _mockCheckValidator(dynamic, check).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	result := ToValidationError(err).CheckValidations[0]
+	g.Expect(result.Error).To(gomega.Equal("4 secrets found, expected at most 3"))
+	g.Expect(result.ResourceErrors).To(gomega.Equal(map[string][]string{
+		"namespace has more than 2 secrets": {"ci (3)"},
+	}))
+
+	check.ObjectCount = &v1alpha1.ObjectCountCheck{
+		APIVersion:      "v1",
+		Resource:        "secrets",
+		Namespaces:      &v1alpha1.SelectionScope{Include: []string{"*"}, Exclude: []string{"ci"}},
+		MaxPerNamespace: 2,
+	}
+	g.Expect(_mockCheckValidator(dynamic, check).Validate()).To(gomega.Succeed())
+}
+
+func Test_ScriptCheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockSecret(dynamic, "runaway-0", "ci", nil)
+	_mockSecret(dynamic, "runaway-1", "ci", map[string][]byte{"token": []byte("x")})
+	_mockSecret(dynamic, "token", "default", nil)
+
+	check := v1alpha1.ClusterCheck{
+		Name:     "secrets have data",
+		Required: true,
+		Script: &v1alpha1.ScriptCheck{
+			APIVersion: "v1",
+			Resource:   "secrets",
+			Namespaces: &v1alpha1.SelectionScope{Include: []string{"ci"}},
+			Source: `
+def validate(objects):
+    failures = []
+    for o in objects:
+        if not o.get("data"):
+            failures.append({"message": "secret has no data", "object": o["metadata"]["namespace"] + "/" + o["metadata"]["name"]})
+    if len(objects) > 1:
+        failures.append("%d secrets found" % len(objects))
+    return failures
+`,
+		},
+	}
+	err := _mockCheckValidator(dynamic, check).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	result := ToValidationError(err).CheckValidations[0]
+	g.Expect(result.Error).To(gomega.Equal("2 secrets found"))
+	g.Expect(result.ResourceErrors).To(gomega.Equal(map[string][]string{
+		"secret has no data": {"ci/runaway-0"},
+	}))
+
+	// namespaces included by name are listed one by one instead of across the cluster
+	listed := make([]string, 0)
+	for _, action := range dynamic.Actions() {
+		if action.GetVerb() == "list" && action.GetResource().Resource == "secrets" {
+			listed = append(listed, action.GetNamespace())
+		}
+	}
+	g.Expect(listed).To(gomega.Equal([]string{"ci"}))
+
+	check.Script.Source = "def validate(objects):\n    return [] if quantity('1Gi') > quantity('512Mi') else ['unexpected']\n"
+	g.Expect(_mockCheckValidator(dynamic, check).Validate()).To(gomega.Succeed())
+
+	// every expression helper is available to scripts
+	check.Script.Source = `
+def validate(objects):
+    failures = []
+    if compare_quantity("1Gi", "512Mi") != 1:
+        failures.append("compare_quantity")
+    if semver("v1.25.3-eks-1") != "1.25.3" or semver_compare("1.25.3", "1.26.0") != -1:
+        failures.append("semver")
+    if duration("1h") != 3600 or timestamp("1970-01-01T00:01:00Z") != 60:
+        failures.append("duration")
+    if not older_than("2006-01-02T15:04:05Z", "1h") or newer_than("2006-01-02T15:04:05Z", "1h") or until("2006-01-02T15:04:05Z") > 0:
+        failures.append("age")
+    for o in objects:
+        if has_label(o, "team") or label(o, "team") != "" or has_annotation(o, "owner") or annotation(o, "owner") != "":
+            failures.append({"message": "unexpected metadata", "object": o["metadata"]["name"]})
+    return failures
+`
+	g.Expect(_mockCheckValidator(dynamic, check).Validate()).To(gomega.Succeed())
+	g.Expect(scriptBuiltins).To(gomega.HaveLen(len(expr.Funcs())))
+
+	check.Script.Source = "def validate(objects):\n    return True\n"
+	err = _mockCheckValidator(dynamic, check).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].Error).To(gomega.ContainSubstring("expected a list of failures or None"))
+
+	_, err = parseValidationSpecData([]byte("spec:\n  checks:\n  - name: broken\n    script:\n      apiVersion: v1\n      resource: secrets\n      source: 'def validate(objects)'\n"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+}
+
+func Test_WorkloadTestResourceMapping(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			WorkloadTests: []v1alpha1.WorkloadTest{{
+				Name:      "geese",
+				Namespace: "default",
+				Manifests: []map[string]interface{}{
+					{"apiVersion": "birds.io/v1", "kind": "Goose", "metadata": map[string]interface{}{"name": "honk"}},
+				},
+				Required: true,
+			}},
+		},
+	}
+
+	// the resource comes from the mapper, guessing from the kind would create "gooses"
+	v := NewValidator(dynamic, spec, nil)
+	v.Mapper = _mockRESTMapper()
+	g.Expect(v.Validate()).To(gomega.Succeed())
+	var created []string
+	for _, action := range dynamic.Actions() {
+		if action.GetVerb() == "create" {
+			created = append(created, action.GetResource().Resource)
+		}
+	}
+	g.Expect(created).To(gomega.ConsistOf("geese"))
+
+	// kinds the mapper does not know fail the workload test
+	spec.Spec.WorkloadTests[0].Manifests[0]["kind"] = "Duck"
+	v = NewValidator(dynamic, spec, nil)
+	v.Mapper = _mockRESTMapper()
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].Error).To(gomega.ContainSubstring("failed to map kind 'Duck'"))
+}
+
+func Test_WorkloadTestWaitsForDeletion(t *testing.T) {
+	g := gomega.NewWithT(t)
+	workloadPollInterval = time.Millisecond
+	dynamic := _fakeDynamicClient()
+
+	// the job of a previous attempt is still being deleted
+	deleted := metav1.Now()
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Name: "smoke", Namespace: "default", DeletionTimestamp: &deleted, Finalizers: []string{"example.com/slow"}},
+	}
+	_mockObject(dynamic, JobGVR, job)
+
+	var gets, creates int32
+	dynamic.PrependReactor("get", "jobs", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if atomic.AddInt32(&gets, 1) == 2 {
+			_ = dynamic.Tracker().Delete(JobGVR, "default", "smoke")
+		}
+		return false, nil, nil
+	})
+	dynamic.PrependReactor("create", "jobs", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		atomic.AddInt32(&creates, 1)
+		u := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		_ = unstructured.SetNestedSlice(u.Object, []interface{}{
+			map[string]interface{}{"type": "Complete", "status": "True"},
+		}, "status", "conditions")
+		return false, nil, nil
+	})
+
+	v := _mockValidator("workload_validation.yaml", dynamic, nil)
+	v.Validation.Spec.WorkloadTests[0].Timeout = "1m"
+	g.Expect(v.Validate()).To(gomega.Succeed())
+	g.Expect(atomic.LoadInt32(&creates)).To(gomega.BeNumerically(">=", 2))
+
+	// an object that is not being deleted is not waited for
+	_mockObject(dynamic, JobGVR, &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Name: "smoke", Namespace: "default"},
+	})
+	v = _mockValidator("workload_validation.yaml", dynamic, nil)
+	v.Validation.Spec.WorkloadTests[0].Timeout = "1m"
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].Error).To(gomega.ContainSubstring("already exists"))
+
+	// an existing object that cannot be read fails with the error of reading it
+	dynamic.PrependReactor("get", "jobs", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(JobGVR.GroupResource(), "smoke", errors.New("denied"))
+	})
+	v = _mockValidator("workload_validation.yaml", dynamic, nil)
+	v.Validation.Spec.WorkloadTests[0].Timeout = "1m"
+	err = v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).CheckValidations[0].Error).To(gomega.ContainSubstring("failed to get existing jobs 'default/smoke'"))
+}
+
+func Test_WorkloadSetCleanup(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	v := NewValidator(dynamic, &v1alpha1.ClusterValidation{}, nil)
+
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Name: "smoke", Namespace: "default"},
+	}
+	w := v.newWorkloadSet()
+	_, err := w.create(JobGVR, job)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	w.cleanup()
+	_, err = dynamic.Resource(JobGVR).Namespace("default").Get(context.Background(), "smoke", metav1.GetOptions{})
+	g.Expect(apierrors.IsNotFound(err)).To(gomega.BeTrue())
+
+	// a set that was cleaned up does not create objects it would never delete
+	_, err = w.create(JobGVR, job)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("workloads were cleaned up")))
+	_, err = dynamic.Resource(JobGVR).Namespace("default").Get(context.Background(), "smoke", metav1.GetOptions{})
+	g.Expect(apierrors.IsNotFound(err)).To(gomega.BeTrue())
+}
@@ -0,0 +1,92 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// checkVersionSkew compares every kubelet version to the API server version.
+func (v *Validator) checkVersionSkew(check *v1alpha1.VersionSkewCheck, result *CheckValidationResult) error {
+	var (
+		maxSkew = check.MaxMinorSkew
+	)
+
+	server, err := v.serverVersion()
+	if err != nil {
+		return err
+	}
+
+	if maxSkew <= 0 {
+		maxSkew = 2
+		if server.AtLeast(version.MustParseGeneric("1.28")) {
+			maxSkew = 3
+		}
+	}
+
+	nodes, err := v.listNodes("")
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		kubeletVersion := node.Status.NodeInfo.KubeletVersion
+		kubelet, err := version.ParseGeneric(kubeletVersion)
+		if err != nil {
+			reason := fmt.Sprintf("invalid kubelet version '%v'", kubeletVersion)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], node.Name)
+			continue
+		}
+
+		skew := int(server.Minor()) - int(kubelet.Minor())
+		switch {
+		case kubelet.Major() != server.Major():
+			reason := fmt.Sprintf("kubelet major version differs from API server %v", server)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], node.Name)
+		case skew < 0:
+			reason := fmt.Sprintf("kubelet is newer than API server %v", server)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], node.Name)
+		case skew > maxSkew:
+			reason := fmt.Sprintf("kubelet is more than %v minor versions older than API server %v", maxSkew, server)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], node.Name)
+		}
+	}
+	return nil
+}
+
+func (v *Validator) serverVersion() (*version.Version, error) {
+	var (
+		info struct {
+			GitVersion string `json:"gitVersion"`
+		}
+	)
+
+	out, err := rawGet(v.RESTClient, "/version")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get API server version")
+	}
+	if err := json.Unmarshal(out.Bytes(), &info); err != nil {
+		return nil, errors.Wrap(err, "failed to decode API server version")
+	}
+
+	server, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid API server version '%v'", info.GitVersion)
+	}
+	return server, nil
+}
@@ -0,0 +1,92 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// checkOrphanedVolumes flags persistent volumes left behind by their claims, grouped by phase
+// and reclaim policy so leaked storage that will never be reclaimed stands out.
+func (v *Validator) checkOrphanedVolumes(check *v1alpha1.OrphanedVolumesCheck, result *CheckValidationResult) error {
+	var (
+		olderThan time.Duration
+	)
+
+	if check.OlderThan != "" {
+		d, err := expr.ParseDuration(check.OlderThan)
+		if err != nil {
+			return err
+		}
+		olderThan = d
+	}
+
+	list, err := v.Kubernetes.Resource(pvGVR).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list persistentvolumes")
+	}
+
+	now := expr.Now()
+	for _, item := range list.Items {
+		pv := corev1.PersistentVolume{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &pv); err != nil {
+			return errors.Wrapf(err, "failed to convert persistentvolume '%v'", item.GetName())
+		}
+		if !matchInPatterns(check.GetPhases(), string(pv.Status.Phase)) || !inSelectionScope(check.StorageClasses, pv.Spec.StorageClassName) {
+			continue
+		}
+
+		since := pv.CreationTimestamp.Time
+		if transition, ok, _ := unstructured.NestedString(item.Object, "status", "lastPhaseTransitionTime"); ok {
+			if t, err := expr.ParseTimestamp(transition); err == nil {
+				since = t
+			}
+		}
+		if now.Sub(since) <= olderThan {
+			continue
+		}
+
+		reason := fmt.Sprintf("volume is %v with reclaim policy %v", pv.Status.Phase, pv.Spec.PersistentVolumeReclaimPolicy)
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], volumeDescription(pv))
+	}
+	return nil
+}
+
+// volumeDescription names a volume together with the claim it was bound to.
+func volumeDescription(pv corev1.PersistentVolume) string {
+	var (
+		details = make([]string, 0)
+	)
+
+	if ref := pv.Spec.ClaimRef; ref != nil {
+		details = append(details, fmt.Sprintf("claim %v/%v", ref.Namespace, ref.Name))
+	}
+	if pv.Spec.StorageClassName != "" {
+		details = append(details, fmt.Sprintf("storageClass %v", pv.Spec.StorageClassName))
+	}
+	if len(details) == 0 {
+		return pv.Name
+	}
+	return fmt.Sprintf("%v (%v)", pv.Name, strings.Join(details, ", "))
+}
@@ -0,0 +1,235 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// servingDialTimeout bounds the TLS handshake with webhook backends.
+	servingDialTimeout = 5 * time.Second
+)
+
+// caBackend is a caBundle and the backend whose serving certificate it must verify.
+type caBackend struct {
+	name     string
+	caBundle string
+	host     string
+	address  string
+}
+
+// checkWebhookCA flags webhook and APIService caBundles that are invalid, expire soon or
+// do not verify the serving certificate of their backend.
+func (v *Validator) checkWebhookCA(check *v1alpha1.WebhookCACheck, result *CheckValidationResult) error {
+	var (
+		minValidity time.Duration
+		backends    = make([]caBackend, 0)
+	)
+
+	if check.MinValidity != "" {
+		d, err := expr.ParseDuration(check.MinValidity)
+		if err != nil {
+			return err
+		}
+		minValidity = d
+	}
+
+	for _, gvr := range []schema.GroupVersionResource{validatingWebhookGVR, mutatingWebhookGVR, apiServiceGVR} {
+		list, err := v.Kubernetes.Resource(gvr).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list %v", gvr.Resource)
+		}
+		for _, item := range list.Items {
+			if !inSelectionScope(check.Names, item.GetName()) {
+				continue
+			}
+			if gvr == apiServiceGVR {
+				backends = append(backends, apiServiceBackends(item)...)
+			} else {
+				backends = append(backends, webhookBackends(gvr, item)...)
+			}
+		}
+	}
+
+	for _, b := range backends {
+		if reason := verifyCABackend(b, minValidity, check.VerifyServing); reason != "" {
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], b.name)
+		}
+	}
+	return nil
+}
+
+func webhookBackends(gvr schema.GroupVersionResource, u unstructured.Unstructured) []caBackend {
+	var (
+		backends = make([]caBackend, 0)
+		kind     = strings.TrimSuffix(gvr.Resource, "s")
+	)
+
+	webhooks, _, _ := unstructured.NestedSlice(u.Object, "webhooks")
+	for _, w := range webhooks {
+		webhook, ok := w.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(webhook, "name")
+		b := caBackend{name: fmt.Sprintf("%v %v: %v", kind, u.GetName(), name)}
+		b.caBundle, _, _ = unstructured.NestedString(webhook, "clientConfig", "caBundle")
+
+		if rawURL, found, _ := unstructured.NestedString(webhook, "clientConfig", "url"); found {
+			if parsed, err := url.Parse(rawURL); err == nil {
+				b.host = parsed.Hostname()
+				port := parsed.Port()
+				if port == "" {
+					port = "443"
+				}
+				b.address = net.JoinHostPort(b.host, port)
+			}
+		} else if svc, found, _ := unstructured.NestedMap(webhook, "clientConfig", "service"); found {
+			b.host, b.address = serviceBackend(svc)
+		}
+		backends = append(backends, b)
+	}
+	return backends
+}
+
+func apiServiceBackends(u unstructured.Unstructured) []caBackend {
+	svc, found, _ := unstructured.NestedMap(u.Object, "spec", "service")
+	if !found {
+		// served locally by the API server
+		return nil
+	}
+	if skip, _, _ := unstructured.NestedBool(u.Object, "spec", "insecureSkipTLSVerify"); skip {
+		return nil
+	}
+
+	b := caBackend{name: fmt.Sprintf("apiservice %v", u.GetName())}
+	b.caBundle, _, _ = unstructured.NestedString(u.Object, "spec", "caBundle")
+	b.host, b.address = serviceBackend(svc)
+	return []caBackend{b}
+}
+
+func serviceBackend(svc map[string]interface{}) (string, string) {
+	var (
+		port = int64(443)
+	)
+
+	name, _, _ := unstructured.NestedString(svc, "name")
+	namespace, _, _ := unstructured.NestedString(svc, "namespace")
+	if p, found, _ := unstructured.NestedInt64(svc, "port"); found {
+		port = p
+	}
+	host := fmt.Sprintf("%v.%v.svc", name, namespace)
+	return host, net.JoinHostPort(host, strconv.FormatInt(port, 10))
+}
+
+func verifyCABackend(b caBackend, minValidity time.Duration, verifyServing bool) string {
+	if b.caBundle == "" {
+		return "caBundle is empty"
+	}
+
+	pemData, err := base64.StdEncoding.DecodeString(b.caBundle)
+	if err != nil {
+		return "caBundle is not valid base64"
+	}
+
+	certs, err := parseCertificates(pemData)
+	if err != nil || len(certs) == 0 {
+		return "caBundle contains no valid PEM certificates"
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		if reason := certificateValidity("CA certificate", cert, minValidity); reason != "" {
+			return reason
+		}
+		pool.AddCert(cert)
+	}
+
+	if !verifyServing || b.address == "" {
+		return ""
+	}
+
+	dialer := &net.Dialer{Timeout: servingDialTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", b.address, &tls.Config{InsecureSkipVerify: true, ServerName: b.host})
+	if err != nil {
+		return fmt.Sprintf("failed to fetch serving certificate: %v", err)
+	}
+	defer conn.Close()
+
+	chain := conn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return "backend presented no serving certificate"
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := chain[0].Verify(x509.VerifyOptions{Roots: pool, Intermediates: intermediates, DNSName: b.host}); err != nil {
+		return fmt.Sprintf("serving certificate does not verify against caBundle: %v", err)
+	}
+	return certificateValidity("serving certificate", chain[0], minValidity)
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var (
+		certs = make([]*x509.Certificate, 0)
+	)
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return certs, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func certificateValidity(description string, cert *x509.Certificate, minValidity time.Duration) string {
+	now := expr.Now()
+	switch {
+	case now.After(cert.NotAfter):
+		return fmt.Sprintf("%v '%v' expired at %v", description, cert.Subject.CommonName, cert.NotAfter.UTC().Format(time.RFC3339))
+	case now.Before(cert.NotBefore):
+		return fmt.Sprintf("%v '%v' is not valid before %v", description, cert.Subject.CommonName, cert.NotBefore.UTC().Format(time.RFC3339))
+	case now.Add(minValidity).After(cert.NotAfter):
+		return fmt.Sprintf("%v '%v' expires at %v, within %v", description, cert.Subject.CommonName, cert.NotAfter.UTC().Format(time.RFC3339), minValidity)
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/onsi/gomega"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+type _certificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func _mockCertificate(name string, notAfter time.Time, parent *_certificate) _certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	return _certificate{cert: cert, key: key, der: der}
+}
+
+func _mockWebhookConfiguration(cl *fake.FakeDynamicClient, name, url string, ca _certificate) {
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.der})
+	_mockObject(cl, ValidatingWebhookGVR, &admissionregistrationv1.ValidatingWebhookConfiguration{
+		TypeMeta:   metav1.TypeMeta{Kind: "ValidatingWebhookConfiguration", APIVersion: "admissionregistration.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{
+				Name:         name + ".example.com",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{URL: &url, CABundle: caBundle},
+			},
+		},
+	})
+}
+
+func _mockTLSServer(serving _certificate) *httptest.Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{serving.der}, PrivateKey: serving.key}}}
+	ts.StartTLS()
+	return ts
+}
+
+func Test_PositiveWebhookCACheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ca := _mockCertificate("webhook-ca", time.Now().Add(365*24*time.Hour), nil)
+	ts := _mockTLSServer(_mockCertificate("webhook", time.Now().Add(90*24*time.Hour), &ca))
+	defer ts.Close()
+
+	dynamic := _fakeDynamicClient()
+	_mockWebhookConfiguration(dynamic, "policy", ts.URL+"/validate", ca)
+
+	v := _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:      "webhooks",
+		Required:  true,
+		WebhookCA: &v1alpha1.WebhookCACheck{MinValidity: "7d", VerifyServing: true},
+	})
+	g.Expect(v.Validate()).To(gomega.Succeed())
+}
+
+func Test_NegativeWebhookCACheck(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ca := _mockCertificate("webhook-ca", time.Now().Add(365*24*time.Hour), nil)
+	rotated := _mockCertificate("rotated-ca", time.Now().Add(365*24*time.Hour), nil)
+	expiring := _mockCertificate("expiring-ca", time.Now().Add(24*time.Hour), nil)
+	ts := _mockTLSServer(_mockCertificate("webhook", time.Now().Add(90*24*time.Hour), &rotated))
+	defer ts.Close()
+
+	dynamic := _fakeDynamicClient()
+	_mockWebhookConfiguration(dynamic, "mismatched", ts.URL+"/validate", ca)
+	_mockWebhookConfiguration(dynamic, "expiring", ts.URL+"/validate", expiring)
+
+	v := _mockCheckValidator(dynamic, v1alpha1.ClusterCheck{
+		Name:      "webhooks",
+		Required:  true,
+		WebhookCA: &v1alpha1.WebhookCACheck{MinValidity: "7d", VerifyServing: true},
+	})
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	var failed []string
+	for _, names := range ToValidationError(err).CheckValidations[0].ResourceErrors {
+		failed = append(failed, names...)
+	}
+	g.Expect(failed).To(gomega.ConsistOf(
+		"validatingwebhookconfiguration mismatched: mismatched.example.com",
+		"validatingwebhookconfiguration expiring: expiring.example.com",
+	))
+}
@@ -0,0 +1,83 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+)
+
+// checkZoneBalance counts ready nodes per zone and flags zones below the minimum share and
+// a spread between the largest and smallest zone above the allowed skew.
+func (v *Validator) checkZoneBalance(check *v1alpha1.ZoneBalanceCheck, result *CheckValidationResult) error {
+	var (
+		key   = check.GetTopologyKey()
+		zones = make(map[string]int)
+		total int
+	)
+
+	for _, zone := range check.Zones {
+		zones[zone] = 0
+	}
+
+	nodes, err := v.listNodes(check.LabelSelector)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		zone, ok := node.Labels[key]
+		if !nodeReady(node) || !ok {
+			continue
+		}
+		zones[zone]++
+		total++
+	}
+
+	if total == 0 {
+		result.Error = fmt.Sprintf("no ready nodes with label '%v' matched the check scope", key)
+		return nil
+	}
+
+	names := make([]string, 0, len(zones))
+	for zone := range zones {
+		names = append(names, zone)
+	}
+	sort.Strings(names)
+
+	min, max := total, 0
+	for _, zone := range names {
+		count := zones[zone]
+		if count < min {
+			min = count
+		}
+		if count > max {
+			max = count
+		}
+
+		if percent := count * 100 / total; percent < check.MinPercent {
+			reason := fmt.Sprintf("zone has less than %v%% of %v ready nodes", check.MinPercent, total)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], fmt.Sprintf("%v (%v nodes)", zone, count))
+		}
+	}
+
+	if check.MaxSkew > 0 && max-min > check.MaxSkew {
+		reason := fmt.Sprintf("node count differs by more than %v between zones", check.MaxSkew)
+		for _, zone := range names {
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], fmt.Sprintf("%v (%v nodes)", zone, zones[zone]))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clienttest records the cluster state a ClusterValidation reads into fixtures and
+// replays it through a validator, so specs can be unit tested without a live cluster.
+//
+// A fixture is a snapshot directory, as written by the snapshot command, plus the recorded
+// responses of the raw API requests made by cluster endpoints and checks, and of the requests
+// made by HTTP endpoints.
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// ResponsesFile holds the recorded raw API responses within a fixture directory
+	ResponsesFile = "responses.json"
+)
+
+// Response is a recorded raw API or HTTP response, raw API responses are keyed by request URI
+// and HTTP responses by URL.
+type Response struct {
+	Code int    `json:"code"`
+	Body string `json:"body"`
+}
+
+// Fixture serves recorded cluster state, Close releases it.
+type Fixture struct {
+	Kubernetes *fake.FakeDynamicClient
+	RESTClient *rest.RESTClient
+	HTTPClient *http.Client
+	server     *httptest.Server
+}
+
+// Result is the outcome of every validation of a spec replayed against a fixture.
+type Result struct {
+	Validations []client.ValidationProgress
+	required    map[string]bool
+}
+
+// Record evaluates the spec once against a live cluster and writes the resources it reads and
+// the raw API responses it receives to dir.
+func Record(spec *v1alpha1.ClusterValidation, c dynamic.Interface, r *rest.RESTClient, dir string) error {
+	var (
+		recorder = &recorder{responses: make(map[string]Response)}
+	)
+
+	if _, err := client.NewValidator(c, spec, r).Snapshot(dir); err != nil {
+		return err
+	}
+
+	if r != nil {
+		recorded := *r
+		recorded.Client = &http.Client{Transport: recorder.wrap(r.Client.Transport, requestURI), Timeout: r.Client.Timeout}
+		r = &recorded
+	}
+	v := client.NewValidator(c, spec, r)
+	httpClient := *v.HTTPClient
+	httpClient.Transport = recorder.wrap(httpClient.Transport, requestURL)
+	v.HTTPClient = &httpClient
+	if _, err := client.EvaluateValidator(v); err != nil && !errors.Is(err, client.ErrInterrupted) {
+		log.Warnf("recording run failed: %v", err)
+	}
+
+	out, err := json.MarshalIndent(recorder.responses, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal recorded responses")
+	}
+	path := filepath.Join(dir, ResponsesFile)
+	if err := ioutil.WriteFile(path, out, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write recorded responses to '%v'", path)
+	}
+	log.Infof("recorded %v responses to %v", len(recorder.responses), path)
+	return nil
+}
+
+// Load serves the fixture recorded in dir. Requests that were not recorded are answered with
+// 404 Not Found.
+func Load(dir string) (*Fixture, error) {
+	var (
+		responses = make(map[string]Response)
+	)
+
+	kubernetes, err := client.LoadSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, ResponsesFile))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, errors.Wrapf(err, "could not read recorded responses of '%v'", dir)
+	default:
+		if err := json.Unmarshal(data, &responses); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse recorded responses of '%v'", dir)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		resp, ok := responses[req.URL.RequestURI()]
+		if !ok {
+			http.Error(w, "not recorded", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(resp.Code)
+		_, _ = io.WriteString(w, resp.Body)
+	}))
+
+	restClient, err := rest.RESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &schema.GroupVersion{Version: "v1"},
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+	})
+	if err != nil {
+		server.Close()
+		return nil, errors.Wrap(err, "failed to create replay client")
+	}
+
+	httpClient := &http.Client{Transport: &replayer{responses: responses}}
+	return &Fixture{Kubernetes: kubernetes, RESTClient: restClient, HTTPClient: httpClient, server: server}, nil
+}
+
+// Close stops serving the recorded responses.
+func (f *Fixture) Close() {
+	f.server.Close()
+}
+
+// Evaluate runs every validation of the spec once against the fixture.
+func (f *Fixture) Evaluate(spec *v1alpha1.ClusterValidation) (Result, error) {
+	var (
+		result = Result{required: make(map[string]bool)}
+	)
+
+	v := client.NewValidator(f.Kubernetes, spec, f.RESTClient)
+	v.HTTPClient = f.HTTPClient
+	progress, err := client.EvaluateValidator(v)
+	result.Validations = progress
+	for _, r := range spec.Spec.Resources {
+		result.required[r.Name] = r.Required
+		result.required[r.ID] = r.Required
+	}
+	for _, g := range spec.Spec.Groups {
+		result.required[g.Name] = g.Required
+		result.required[g.ID] = g.Required
+	}
+	for _, c := range spec.Spec.Checks {
+		result.required[c.Name] = c.Required
+		result.required[c.ID] = c.Required
+	}
+	for _, w := range spec.Spec.WorkloadTests {
+		result.required[w.Name] = w.Required
+		result.required[w.ID] = w.Required
+	}
+	for _, e := range spec.Spec.Endpoints.Cluster {
+		result.required[e.Name] = e.Required
+		result.required[e.ID] = e.Required
+	}
+	for _, e := range spec.Spec.Endpoints.HTTP {
+		result.required[e.Name] = e.Required
+		result.required[e.ID] = e.Required
+	}
+	return result, err
+}
+
+// Replay evaluates the spec against the fixture recorded in dir, failing the test when the
+// fixture cannot be loaded or the spec cannot be evaluated.
+func Replay(t testing.TB, dir string, spec *v1alpha1.ClusterValidation) Result {
+	t.Helper()
+
+	fixture, err := Load(dir)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+	t.Cleanup(fixture.Close)
+
+	result, err := fixture.Evaluate(spec)
+	if err != nil {
+		t.Fatalf("failed to evaluate spec: %v", err)
+	}
+	return result
+}
+
+// Status returns the status of the validation with the given ID, or name when no validation
+// has that ID, and an empty status when there is none.
+func (r Result) Status(key string) client.ValidationStatus {
+	for _, p := range r.Validations {
+		if p.ID == key {
+			return p.Status
+		}
+	}
+	for _, p := range r.Validations {
+		if p.Name == key {
+			return p.Status
+		}
+	}
+	return ""
+}
+
+// Passed returns true when no required validation failed, i.e. when validating the recorded
+// cluster would succeed.
+func (r Result) Passed() bool {
+	return len(r.Failed()) == 0
+}
+
+// Failed returns the IDs, or names, of the required validations that failed.
+func (r Result) Failed() []string {
+	var (
+		failed = make([]string, 0)
+	)
+
+	for _, p := range r.Validations {
+		if p.Status == client.ValidationStatusSucceeded || p.Status == client.ValidationStatusWarning {
+			continue
+		}
+		key := p.ID
+		if key == "" {
+			key = p.Name
+		}
+		if r.required[key] {
+			failed = append(failed, key)
+		}
+	}
+	return failed
+}
+
+// recorder captures the responses to GET requests.
+type recorder struct {
+	sync.Mutex
+	responses map[string]Response
+}
+
+// recordingTransport records the responses of a transport in its recorder under key(req).
+type recordingTransport struct {
+	rec  *recorder
+	next http.RoundTripper
+	key  func(*http.Request) string
+}
+
+func requestURI(req *http.Request) string {
+	return req.URL.RequestURI()
+}
+
+func requestURL(req *http.Request) string {
+	return req.URL.String()
+}
+
+func (rec *recorder) wrap(next http.RoundTripper, key func(*http.Request) string) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{rec: rec, next: next, key: key}
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.rec.Lock()
+	t.rec.responses[t.key(req)] = Response{Code: resp.StatusCode, Body: string(body)}
+	t.rec.Unlock()
+	return resp, nil
+}
+
+// replayer answers HTTP requests with the responses recorded for their URL.
+type replayer struct {
+	responses map[string]Response
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	resp, ok := r.responses[requestURL(req)]
+	if !ok {
+		http.Error(rec, "not recorded", http.StatusNotFound)
+	} else {
+		rec.WriteHeader(resp.Code)
+		_, _ = io.WriteString(rec, resp.Body)
+	}
+	out := rec.Result()
+	out.Request = req
+	return out, nil
+}
@@ -0,0 +1,150 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clienttest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+)
+
+func _mockSpec(phase string) *v1alpha1.ClusterValidation {
+	return &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 3, Interval: "1s"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "namespaces",
+					ID:         "namespaces-active",
+					APIVersion: "v1",
+					Required:   true,
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{phase}}},
+				},
+			},
+			Endpoints: v1alpha1.EndpointsSpec{
+				Cluster: []v1alpha1.ClusterEndpoint{
+					{Name: "readyz", URI: "/readyz?verbose", Required: true},
+					{Name: "livez", URI: "/livez"},
+				},
+			},
+		},
+	}
+}
+
+// _mockCluster records a fake cluster with an active namespace, a healthy /readyz and a failing /livez.
+func _mockCluster(t *testing.T, spec *v1alpha1.ClusterValidation) string {
+	dynamic := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		namespaceGVR: "NamespaceList",
+	})
+	ns := &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"phase": "Active"}}}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName("default")
+	if _, err := dynamic.Resource(namespaceGVR).Create(context.Background(), ns, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/readyz":
+			fmt.Fprint(w, "ok")
+		default:
+			http.Error(w, "unhealthy", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	restClient, err := rest.RESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &schema.GroupVersion{Version: "v1"},
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := Record(spec, dynamic, restClient, dir); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func Test_RecordAndReplay(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := _mockCluster(t, _mockSpec("active"))
+
+	// the cluster is gone, the fixture replays its state
+	result := Replay(t, dir, _mockSpec("active"))
+	g.Expect(result.Passed()).To(gomega.BeTrue())
+	g.Expect(result.Status("namespaces-active")).To(gomega.Equal(client.ValidationStatusSucceeded))
+	g.Expect(result.Status("readyz")).To(gomega.Equal(client.ValidationStatusSucceeded))
+	g.Expect(result.Status("livez")).To(gomega.Equal(client.ValidationStatusFailed))
+
+	result = Replay(t, dir, _mockSpec("terminating"))
+	g.Expect(result.Passed()).To(gomega.BeFalse())
+	g.Expect(result.Failed()).To(gomega.ConsistOf("namespaces-active"))
+
+	// requests that were not recorded fail
+	spec := _mockSpec("active")
+	spec.Spec.Endpoints.Cluster[0].URI = "/readyz?include=etcd"
+	result = Replay(t, dir, spec)
+	g.Expect(result.Failed()).To(gomega.ConsistOf("readyz"))
+}
+
+func Test_RecordAndReplayHTTPEndpoints(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthy" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	spec := _mockSpec("active")
+	spec.Spec.Endpoints.Cluster = nil
+	spec.Spec.Endpoints.HTTP = []v1alpha1.HTTPEndpoint{
+		{Name: "healthy", URL: server.URL + "/healthy", Required: true},
+		{Name: "broken", URL: server.URL + "/broken"},
+	}
+	dir := _mockCluster(t, spec)
+	server.Close()
+
+	// the server is gone, the fixture replays its responses
+	result := Replay(t, dir, spec)
+	g.Expect(result.Passed()).To(gomega.BeTrue())
+	g.Expect(result.Status("healthy")).To(gomega.Equal(client.ValidationStatusSucceeded))
+	g.Expect(result.Status("broken")).To(gomega.Equal(client.ValidationStatusFailed))
+
+	// URLs that were not recorded fail
+	spec.Spec.Endpoints.HTTP[0].URL = server.URL + "/other"
+	result = Replay(t, dir, spec)
+	g.Expect(result.Failed()).To(gomega.ConsistOf("healthy"))
+}
@@ -0,0 +1,258 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// dependencyGraph tracks the outcome of the entries of a run by ID, so entries can wait for the
+// entries they depend on. Entries sharing an ID are done once all of them finished, and only
+// succeed when all of them succeeded.
+type dependencyGraph struct {
+	sync.Mutex
+	entries map[string]*dependencyEntry
+}
+
+type dependencyEntry struct {
+	pending int
+	failed  bool
+	done    chan struct{}
+}
+
+func newDependencyGraph(objs []interface{}) *dependencyGraph {
+	var (
+		graph = &dependencyGraph{entries: make(map[string]*dependencyEntry)}
+	)
+
+	for _, obj := range objs {
+		_, id, _, _ := entryDependencies(obj)
+		if id == "" {
+			continue
+		}
+		if _, ok := graph.entries[id]; !ok {
+			graph.entries[id] = &dependencyEntry{done: make(chan struct{})}
+		}
+		graph.entries[id].pending++
+	}
+	return graph
+}
+
+// finish records the outcome of an entry.
+func (g *dependencyGraph) finish(id string, succeeded bool) {
+	g.Lock()
+	defer g.Unlock()
+
+	entry, ok := g.entries[id]
+	if !ok {
+		return
+	}
+	if !succeeded {
+		entry.failed = true
+	}
+	entry.pending--
+	if entry.pending == 0 {
+		close(entry.done)
+	}
+}
+
+// await blocks until the given entries are done and returns the first of them that did not
+// succeed. IDs of entries that are not part of the run are ignored. It returns false when the
+// run was stopped while waiting.
+func (g *dependencyGraph) await(dependsOn []string, stop <-chan struct{}) (string, bool) {
+	for _, id := range dependsOn {
+		entry, ok := g.entries[id]
+		if !ok {
+			continue
+		}
+		select {
+		case <-entry.done:
+		case <-stop:
+			return "", false
+		}
+
+		g.Lock()
+		failed := entry.failed
+		g.Unlock()
+		if failed {
+			return id, true
+		}
+	}
+	return "", true
+}
+
+// validateAfterDependencies starts the validation of an entry once the entries it depends on
+// succeeded, and skips it when one of them did not.
+func (v *Validator) validateAfterDependencies(graph *dependencyGraph, obj interface{}) {
+	var (
+		name, id, required, dependsOn = entryDependencies(obj)
+		succeeded                     bool
+	)
+	defer func() { graph.finish(id, succeeded) }()
+
+	if len(dependsOn) > 0 {
+		log.Infof("validation of '%v' waits for %v", name, dependsOn)
+	}
+	dependency, ok := graph.await(dependsOn, v.stop)
+	switch {
+	case !ok:
+		v.Waiter.Done()
+	case dependency != "":
+		v.skipValidation(name, id, required, dependency)
+	default:
+		succeeded = v.validateEntry(obj)
+	}
+}
+
+// validateEntry runs the validation of a top-level entry and returns whether it succeeded.
+func (v *Validator) validateEntry(obj interface{}) bool {
+	switch r := obj.(type) {
+	case v1alpha1.ClusterResource:
+		if r.PerGroup != nil {
+			return v.validatePerGroup(r)
+		}
+		return v.validateClusterResource(r)
+	case v1alpha1.ValidationGroup:
+		return v.validateGroup(r)
+	case v1alpha1.ClusterCheck:
+		return v.validateClusterCheck(r)
+	case v1alpha1.WorkloadTest:
+		return v.validateWorkloadTest(r)
+	case v1alpha1.ClusterEndpoint:
+		return v.validateClusterEndpoint(r)
+	case v1alpha1.HTTPEndpoint:
+		return v.validateHTTPEndpoint(r)
+	}
+	v.Waiter.Done()
+	return false
+}
+
+// skipValidation records an entry that is not validated because a dependency did not succeed,
+// a required entry fails the run with a DependencyError.
+func (v *Validator) skipValidation(name, id string, required bool, dependency string) {
+	defer v.Waiter.Done()
+
+	message := errors.Errorf("validation of '%v' skipped, dependency '%v' did not succeed", name, dependency)
+	progress := v.trackProgress(name, id, 0, 0)
+	v.updateProgress(progress, func(p *ValidationProgress) {
+		p.Status, p.LastError = ValidationStatusFailed, message.Error()
+	})
+	log.Warnf("%v %v", failEmoji, message)
+
+	if required && !v.singlePass {
+		v.reportError(DependencyError{ValidationError{
+			ID:            id,
+			Message:       message,
+			Cluster:       v.Validation.Spec.Cluster.Name,
+			ClusterLabels: v.Validation.Spec.Cluster.Labels,
+		}})
+	}
+}
+
+func entryDependencies(obj interface{}) (name, id string, required bool, dependsOn []string) {
+	switch r := obj.(type) {
+	case v1alpha1.ClusterResource:
+		return r.Name, r.ID, r.Required, r.DependsOn
+	case v1alpha1.ValidationGroup:
+		return r.Name, r.ID, r.Required, r.DependsOn
+	case v1alpha1.ClusterCheck:
+		return r.Name, r.ID, r.Required, r.DependsOn
+	case v1alpha1.WorkloadTest:
+		return r.Name, r.ID, r.Required, r.DependsOn
+	case v1alpha1.ClusterEndpoint:
+		return r.Name, r.ID, r.Required, r.DependsOn
+	case v1alpha1.HTTPEndpoint:
+		return r.Name, r.ID, r.Required, r.DependsOn
+	}
+	return "", "", false, nil
+}
+
+// validateDependencies verifies that dependsOn only references the IDs of top-level entries and
+// that the dependencies have no cycles.
+func validateDependencies(spec *v1alpha1.ClusterValidation) error {
+	var (
+		edges = make(map[string][]string)
+		ids   = make(map[string]bool)
+		state = make(map[string]int)
+	)
+
+	for _, g := range spec.Spec.Groups {
+		for _, r := range append(append([]v1alpha1.ClusterResource{}, g.AllOf...), g.AnyOf...) {
+			if len(r.DependsOn) > 0 {
+				return errors.Errorf("member '%v' of group '%v' cannot set dependsOn, set it on the group", r.Name, g.Name)
+			}
+		}
+	}
+	for _, t := range spec.Spec.WorkloadTests {
+		for _, r := range t.Resources {
+			if len(r.DependsOn) > 0 {
+				return errors.Errorf("resource '%v' of workload test '%v' cannot set dependsOn, set it on the workload test", r.Name, t.Name)
+			}
+		}
+	}
+
+	objs := validationObjects(spec)
+	for _, obj := range objs {
+		if _, id, _, _ := entryDependencies(obj); id != "" {
+			ids[id] = true
+		}
+	}
+	for _, obj := range objs {
+		name, id, _, dependsOn := entryDependencies(obj)
+		for _, dependency := range dependsOn {
+			if !ids[dependency] {
+				return errors.Errorf("'%v' depends on unknown id '%v'", name, dependency)
+			}
+			if dependency == id {
+				return errors.Errorf("'%v' depends on itself", name)
+			}
+		}
+		edges[id] = append(edges[id], dependsOn...)
+	}
+
+	// depth-first search, an entry reached again while it is being visited closes a cycle
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case 1:
+			return errors.Errorf("dependency cycle through id '%v'", id)
+		case 2:
+			return nil
+		}
+		state[id] = 1
+		for _, dependency := range edges[id] {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		state[id] = 2
+		return nil
+	}
+
+	roots := make([]string, 0, len(edges))
+	for id := range edges {
+		roots = append(roots, id)
+	}
+	sort.Strings(roots)
+	for _, id := range roots {
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
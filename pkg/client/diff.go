@@ -0,0 +1,266 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+const missingValue = "<missing>"
+
+// DiffTarget is a cluster or snapshot to run a spec against when comparing two targets.
+type DiffTarget struct {
+	Name       string
+	Kubernetes dynamic.Interface
+	RESTClient *rest.RESTClient
+}
+
+// ValidationDiff is a validation whose outcome differs between two targets.
+type ValidationDiff struct {
+	Name   string
+	ID     string
+	Before ValidationStatus
+	After  ValidationStatus
+	// Error is the last error of the validation on the target where it did not succeed
+	Error string
+}
+
+// FieldDiff is a field of a validated resource whose value differs between two targets.
+type FieldDiff struct {
+	Resource string
+	Object   string
+	Path     string
+	Before   string
+	After    string
+}
+
+// Diff holds the divergences between two targets.
+type Diff struct {
+	Before      string
+	After       string
+	Validations []ValidationDiff
+	Fields      []FieldDiff
+}
+
+// Empty returns true when both targets had the same outcomes and field values.
+func (d Diff) Empty() bool {
+	return len(d.Validations) == 0 && len(d.Fields) == 0
+}
+
+func (d Diff) String() string {
+	var (
+		b = new(strings.Builder)
+	)
+
+	for _, vd := range d.Validations {
+		name := vd.Name
+		if vd.ID != "" {
+			name = fmt.Sprintf("%v (%v)", vd.Name, vd.ID)
+		}
+		fmt.Fprintf(b, "validation '%v': %v -> %v", name, vd.Before, vd.After)
+		if vd.Error != "" {
+			fmt.Fprintf(b, ": %v", vd.Error)
+		}
+		b.WriteString("\n")
+	}
+	for _, fd := range d.Fields {
+		fmt.Fprintf(b, "%v '%v' %v: %v -> %v\n", fd.Resource, fd.Object, fd.Path, fd.Before, fd.After)
+	}
+	return b.String()
+}
+
+// targetState is the outcome of every validation and the values of the validated fields on a target.
+type targetState struct {
+	outcomes map[string]ValidationProgress
+	order    []string
+	fields   map[string]map[string]string
+}
+
+// DiffTargets runs a spec once against two targets, e.g. two clusters or the snapshots taken
+// before and after an upgrade, and returns the validations whose outcome differs and the
+// values of the fields validated by resource entries that differ.
+func DiffTargets(spec *v1alpha1.ClusterValidation, before, after DiffTarget) (Diff, error) {
+	var (
+		diff = Diff{Before: before.Name, After: after.Name}
+	)
+
+	beforeState, err := evaluateTarget(spec, before)
+	if err != nil {
+		return diff, errors.Wrapf(err, "failed to evaluate '%v'", before.Name)
+	}
+	afterState, err := evaluateTarget(spec, after)
+	if err != nil {
+		return diff, errors.Wrapf(err, "failed to evaluate '%v'", after.Name)
+	}
+
+	for _, key := range mergeKeys(beforeState.order, afterState.order) {
+		b, a := beforeState.outcomes[key], afterState.outcomes[key]
+		if b.Status == a.Status {
+			continue
+		}
+		vd := ValidationDiff{Name: b.Name, ID: b.ID, Before: b.Status, After: a.Status, Error: a.LastError}
+		if vd.Name == "" {
+			vd.Name, vd.ID = a.Name, a.ID
+		}
+		if a.Status == ValidationStatusSucceeded {
+			vd.Error = b.LastError
+		}
+		diff.Validations = append(diff.Validations, vd)
+	}
+
+	fieldKeys := make([]string, 0)
+	for key := range beforeState.fields {
+		fieldKeys = append(fieldKeys, key)
+	}
+	for key := range afterState.fields {
+		if _, ok := beforeState.fields[key]; !ok {
+			fieldKeys = append(fieldKeys, key)
+		}
+	}
+	sort.Strings(fieldKeys)
+
+	for _, key := range fieldKeys {
+		parts := strings.SplitN(key, "\x00", 2)
+		b, a := beforeState.fields[key], afterState.fields[key]
+		objects := make([]string, 0)
+		for object := range b {
+			objects = append(objects, object)
+		}
+		for object := range a {
+			if _, ok := b[object]; !ok {
+				objects = append(objects, object)
+			}
+		}
+		sort.Strings(objects)
+
+		for _, object := range objects {
+			bv, ok := b[object]
+			if !ok {
+				bv = missingValue
+			}
+			av, ok := a[object]
+			if !ok {
+				av = missingValue
+			}
+			if bv != av {
+				diff.Fields = append(diff.Fields, FieldDiff{Resource: parts[0], Object: object, Path: parts[1], Before: bv, After: av})
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+// Evaluate runs every validation of the spec once, without retrying or failing on the first
+// required validation, and returns the outcome of each.
+func Evaluate(spec *v1alpha1.ClusterValidation, c dynamic.Interface, r *rest.RESTClient) ([]ValidationProgress, error) {
+	return EvaluateValidator(NewValidator(c, spec, r))
+}
+
+// EvaluateValidator runs every validation of a validator once like Evaluate, with the clients
+// the validator was configured with, e.g. an HTTPClient that records or replays responses.
+func EvaluateValidator(v *Validator) ([]ValidationProgress, error) {
+	v.singlePass = true
+
+	err := v.Validate()
+	return v.Progress(), err
+}
+
+// evaluateTarget runs every validation of the spec once against a target, without failing on
+// the first required validation, and collects the field values of the validated resources.
+func evaluateTarget(spec *v1alpha1.ClusterValidation, target DiffTarget) (targetState, error) {
+	var (
+		v     = NewValidator(target.Kubernetes, spec, target.RESTClient)
+		state = targetState{
+			outcomes: make(map[string]ValidationProgress),
+			order:    make([]string, 0),
+			fields:   make(map[string]map[string]string),
+		}
+	)
+	v.singlePass = true
+
+	log.Infof("evaluating spec against '%v'", target.Name)
+	if err := v.Validate(); err != nil {
+		return state, err
+	}
+
+	for _, p := range v.Progress() {
+		key := p.ID
+		if key == "" {
+			key = p.Name
+		}
+		// validations without an ID may share a name, they are compared as one that only
+		// succeeds when all of them succeed
+		if existing, ok := state.outcomes[key]; ok {
+			if existing.Status != ValidationStatusSucceeded {
+				continue
+			}
+		} else {
+			state.order = append(state.order, key)
+		}
+		state.outcomes[key] = p
+	}
+
+	resources := v.GetResources()
+	for _, g := range v.GetGroups() {
+		resources = append(resources, append(g.AllOf, g.AnyOf...)...)
+	}
+	for _, r := range resources {
+		if len(r.Fields) == 0 {
+			continue
+		}
+		items, err := v.listResources(r)
+		if err != nil {
+			return state, err
+		}
+		for _, f := range r.Fields {
+			key := r.Name + "\x00" + f.GetPath()
+			if state.fields[key] == nil {
+				state.fields[key] = make(map[string]string)
+			}
+			for _, item := range scopeResources(r, items) {
+				values, err := getJsonPathValues(item, f.GetPath())
+				if err != nil {
+					continue
+				}
+				state.fields[key][namespacedName(item)] = strings.Join(values, ",")
+			}
+		}
+	}
+	return state, nil
+}
+
+func mergeKeys(a, b []string) []string {
+	var (
+		keys = append([]string{}, a...)
+		seen = make(map[string]bool)
+	)
+
+	for _, key := range a {
+		seen[key] = true
+	}
+	for _, key := range b {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
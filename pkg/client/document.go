@@ -0,0 +1,231 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DocumentFormatMarkdown = "markdown"
+	DocumentFormatHTML     = "html"
+)
+
+const markdownDocument = `# Cluster validation: {{ .Cluster }}
+
+**{{ .Outcome }}**, started {{ .Started }}, took {{ .Duration }}
+{{- if .Error }}
+
+> {{ md .Error }}{{ if .Codes }} ({{ .Codes }}){{ end }}
+{{- end }}
+
+| Validation | ID | Status | Attempts | Successes | Failures | Duration |
+|---|---|---|---|---|---|---|
+{{- range .Validations }}
+| {{ md .Name }} | {{ md .ID }} | {{ .State }} | {{ .Attempts }} | {{ .Successes }}/{{ .SuccessThreshold }} | {{ .Failures }}/{{ .FailureThreshold }} | {{ .Duration }} |
+{{- end }}
+{{- range .Failed }}
+
+## {{ md .Name }}{{ if .ID }} ({{ md .ID }}){{ end }}{{ if eq .Status "Warning" }} (warning){{ end }}
+
+{{ md .LastError }}
+{{- if .Reasons }}
+
+| Validation | Reason | Resources |
+|---|---|---|
+{{- range .Reasons }}
+| {{ md .Validation }} | {{ md .Reason }} | {{ md (join .Resources ", ") }} |
+{{- end }}
+{{- end }}
+{{- end }}
+`
+
+const htmlDocument = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Cluster validation: {{ .Cluster }}</title></head>
+<body>
+<h1>Cluster validation: {{ .Cluster }}</h1>
+<p><strong>{{ .Outcome }}</strong>, started {{ .Started }}, took {{ .Duration }}</p>
+{{- if .Error }}
+<blockquote>{{ .Error }}{{ if .Codes }} ({{ .Codes }}){{ end }}</blockquote>
+{{- end }}
+<table>
+<tr><th>Validation</th><th>ID</th><th>Status</th><th>Attempts</th><th>Successes</th><th>Failures</th><th>Duration</th></tr>
+{{- range .Validations }}
+<tr><td>{{ .Name }}</td><td>{{ .ID }}</td><td>{{ .State }}</td><td>{{ .Attempts }}</td><td>{{ .Successes }}/{{ .SuccessThreshold }}</td><td>{{ .Failures }}/{{ .FailureThreshold }}</td><td>{{ .Duration }}</td></tr>
+{{- end }}
+</table>
+{{- range .Failed }}
+<h2>{{ .Name }}{{ if .ID }} ({{ .ID }}){{ end }}{{ if eq .Status "Warning" }} (warning){{ end }}</h2>
+<p>{{ .LastError }}</p>
+{{- if .Reasons }}
+<table>
+<tr><th>Validation</th><th>Reason</th><th>Resources</th></tr>
+{{- range .Reasons }}
+<tr><td>{{ .Validation }}</td><td>{{ .Reason }}</td><td>{{ join .Resources ", " }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- end }}
+</body>
+</html>
+`
+
+func init() {
+	for _, format := range []string{DocumentFormatMarkdown, DocumentFormatHTML} {
+		format := format
+		RegisterSink(format, func(path string) (ResultSink, error) {
+			if path == "" {
+				return nil, errors.Errorf("%v sink requires a file path, e.g. %v=report.%v", format, format, documentExtension(format))
+			}
+			return &DocumentSink{Path: path, Format: format}, nil
+		})
+	}
+}
+
+// DocumentSink writes the report of a run as a Markdown or HTML document, with a table of all
+// validations and the failing resources of every failed validation grouped by reason, meant to
+// be posted to pull requests and change tickets. The file is replaced every run.
+type DocumentSink struct {
+	Path   string
+	Format string
+
+	report Report
+}
+
+type documentView struct {
+	Cluster     string
+	Outcome     string
+	Started     string
+	Duration    time.Duration
+	Error       string
+	Codes       string
+	Validations []documentValidation
+	Failed      []documentValidation
+}
+
+type documentValidation struct {
+	ValidationProgress
+	// State is the status with the number of suppressed failures, if any
+	State    string
+	Duration time.Duration
+	Reasons  []FailureReason
+}
+
+func (s *DocumentSink) Write(report Report) error {
+	s.report = report
+	return nil
+}
+
+func (s *DocumentSink) Flush() error {
+	out, err := renderDocument(s.report, s.Format)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(s.Path, out, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %v report to '%v'", s.Format, s.Path)
+	}
+	return nil
+}
+
+// renderDocument renders a report in the given document format, markdown or html.
+func renderDocument(report Report, format string) ([]byte, error) {
+	var (
+		buf  = new(bytes.Buffer)
+		view = newDocumentView(report)
+		err  error
+	)
+
+	switch format {
+	case DocumentFormatMarkdown:
+		t := template.Must(template.New(format).Funcs(template.FuncMap{
+			"md":   markdownEscape,
+			"join": strings.Join,
+		}).Parse(markdownDocument))
+		err = t.Execute(buf, view)
+	case DocumentFormatHTML:
+		t := htmltemplate.Must(htmltemplate.New(format).Funcs(htmltemplate.FuncMap{
+			"join": strings.Join,
+		}).Parse(htmlDocument))
+		err = t.Execute(buf, view)
+	default:
+		return nil, errors.Errorf("unsupported report format '%v', one of %v|%v", format, DocumentFormatMarkdown, DocumentFormatHTML)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render %v report", format)
+	}
+	return buf.Bytes(), nil
+}
+
+func newDocumentView(report Report) documentView {
+	var (
+		view = documentView{
+			Cluster:  report.Cluster.Name,
+			Outcome:  "Succeeded",
+			Started:  report.Started.UTC().Format(time.RFC3339),
+			Duration: report.Finished.Sub(report.Started).Round(time.Millisecond),
+			Error:    report.Error,
+		}
+		codes = make([]string, 0)
+	)
+
+	if view.Cluster == "" {
+		view.Cluster = "cluster-validator"
+	}
+	if !report.Success {
+		view.Outcome = "Failed"
+	}
+	for _, code := range report.Codes {
+		codes = append(codes, string(code))
+	}
+	view.Codes = strings.Join(codes, ", ")
+
+	for _, p := range report.Validations {
+		validation := documentValidation{
+			ValidationProgress: p,
+			State:              string(p.Status),
+			Duration:           p.Duration.Round(time.Millisecond),
+		}
+		if p.Suppressed > 0 {
+			validation.State = fmt.Sprintf("%v (%v suppressed)", p.Status, p.Suppressed)
+		}
+		view.Validations = append(view.Validations, validation)
+		if p.Status == ValidationStatusFailed || p.Status == ValidationStatusWarning {
+			validation.Reasons = summaryReasons(p.Summary)
+			view.Failed = append(view.Failed, validation)
+		}
+	}
+	return view
+}
+
+// markdownEscape keeps a value on a single table cell.
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "<br>")
+}
+
+func documentExtension(format string) string {
+	if format == DocumentFormatMarkdown {
+		return "md"
+	}
+	return format
+}
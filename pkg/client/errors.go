@@ -0,0 +1,289 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Sentinels for the failure classes returned by parsing and validation, for use with errors.Is.
+var (
+	ErrSpec       = errors.New("invalid validation spec")
+	ErrAccess     = errors.New("access denied")
+	ErrTimeout    = errors.New("timed out")
+	ErrThreshold  = errors.New("failure threshold met")
+	ErrDependency = errors.New("dependency did not succeed")
+
+	// ErrInterrupted is returned by Validate when the validation was stopped before it finished
+	ErrInterrupted = errors.New("validation interrupted")
+)
+
+// ValidationError carries the results of the validations that failed. It is returned wrapped
+// in one of the typed errors below and can be extracted with errors.As or ToValidationError.
+type ValidationError struct {
+	// ID, Remediation, DocsURL and the ownership metadata are copied from the spec entry that failed
+	ID          string
+	Remediation string
+	DocsURL     string
+	Owner       string
+	Team        string
+	Runbook     string
+	// Cluster and ClusterLabels identify the cluster the validation failed on
+	Cluster                    string
+	ClusterLabels              map[string]string
+	Message                    error
+	GVR                        schema.GroupVersionResource
+	FieldValidations           []FieldValidationResult
+	ConditionValidations       []ConditionValidationResult
+	AggregateValidations       []AggregateValidationResult
+	CheckValidations           []CheckValidationResult
+	ClusterEndpointValidations []ClusterEndpointValidationResult
+	HTTPEndpointValidations    []HTTPEndpointValidationResult
+}
+
+// ToValidationError returns the ValidationError within err, or a ValidationError holding err as
+// its message when there is none.
+func ToValidationError(err error) ValidationError {
+	var mErr MultiError
+	if errors.As(err, &mErr) {
+		return mErr.merge()
+	}
+	var vErr ValidationError
+	if errors.As(err, &vErr) {
+		return vErr
+	}
+	return ValidationError{Message: err}
+}
+
+func (e ValidationError) Error() string {
+	fieldValidationResult, _ := json.MarshalIndent(e.FieldValidations, "", "\t")
+	conditionValidationResult, _ := json.MarshalIndent(e.ConditionValidations, "", "\t")
+	aggregateValidationResult, _ := json.MarshalIndent(e.AggregateValidations, "", "\t")
+	checkValidationResult, _ := json.MarshalIndent(e.CheckValidations, "", "\t")
+	out := fmt.Sprintf("%v.\nGVR: %s/%s/%s.\nField Validation Results: %s\nCondition Validation Results: %s\nAggregate Validation Results: %s\nCheck Validation Results: %s", e.Message,
+		e.GVR.Group, e.GVR.Version, e.GVR.Resource, string(fieldValidationResult), string(conditionValidationResult), string(aggregateValidationResult), string(checkValidationResult))
+	if e.Remediation != "" {
+		out += fmt.Sprintf("\nRemediation: %s", e.Remediation)
+	}
+	if e.DocsURL != "" {
+		out += fmt.Sprintf("\nDocumentation: %s", e.DocsURL)
+	}
+	if owner := e.Ownership(); owner != "" {
+		out += fmt.Sprintf("\nOwner: %s", owner)
+	}
+	if e.Runbook != "" {
+		out += fmt.Sprintf("\nRunbook: %s", e.Runbook)
+	}
+	if e.Cluster != "" {
+		out += fmt.Sprintf("\nCluster: %s", e.Cluster)
+	}
+	return out
+}
+
+// Ownership describes who owns the failed entry, e.g. "coredns-oncall (platform)".
+func (e ValidationError) Ownership() string {
+	switch {
+	case e.Owner != "" && e.Team != "":
+		return fmt.Sprintf("%v (%v)", e.Owner, e.Team)
+	case e.Owner != "":
+		return e.Owner
+	default:
+		return e.Team
+	}
+}
+
+// Codes returns the distinct failure codes of the failed validation results.
+func (e ValidationError) Codes() []FailureCode {
+	var (
+		codes = make([]FailureCode, 0)
+		seen  = make(map[FailureCode]bool)
+	)
+
+	add := func(code FailureCode) {
+		if code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	for _, r := range e.FieldValidations {
+		add(r.Code)
+	}
+	for _, r := range e.ConditionValidations {
+		add(r.Code)
+	}
+	for _, r := range e.AggregateValidations {
+		add(r.Code)
+	}
+	for _, r := range e.CheckValidations {
+		add(r.Code)
+	}
+	for _, r := range e.ClusterEndpointValidations {
+		add(r.Code)
+	}
+	for _, r := range e.HTTPEndpointValidations {
+		add(r.Code)
+	}
+	return codes
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Message
+}
+
+// SpecError is returned when a validation spec cannot be parsed or expanded.
+type SpecError struct {
+	Err error
+}
+
+func (e SpecError) Error() string {
+	return e.Err.Error()
+}
+
+func (e SpecError) Unwrap() error {
+	return e.Err
+}
+
+func (e SpecError) Is(target error) bool {
+	return target == ErrSpec
+}
+
+// AccessError is returned when the validator is not permitted to read or create what the spec
+// requires, either found by the preflight check or by a forbidden request during validation.
+type AccessError struct {
+	ValidationError
+}
+
+func (e AccessError) Unwrap() error {
+	return e.ValidationError
+}
+
+func (e AccessError) Is(target error) bool {
+	return target == ErrAccess
+}
+
+// TimeoutError is returned when a request or a wait for the cluster did not finish in time.
+type TimeoutError struct {
+	ValidationError
+}
+
+func (e TimeoutError) Unwrap() error {
+	return e.ValidationError
+}
+
+func (e TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// ThresholdError is returned when a required validation reached its failure threshold.
+type ThresholdError struct {
+	ValidationError
+}
+
+func (e ThresholdError) Unwrap() error {
+	return e.ValidationError
+}
+
+func (e ThresholdError) Is(target error) bool {
+	return target == ErrThreshold
+}
+
+// DependencyError is returned when a required validation was skipped because an entry it
+// depends on did not succeed.
+type DependencyError struct {
+	ValidationError
+}
+
+func (e DependencyError) Unwrap() error {
+	return e.ValidationError
+}
+
+func (e DependencyError) Is(target error) bool {
+	return target == ErrDependency
+}
+
+// MultiError is returned when validations continue on error and more than one of them failed,
+// it holds the error of every failed validation in the order they failed.
+type MultiError struct {
+	Errors []error
+}
+
+func (e MultiError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%v validations failed:\n%v", len(e.Errors), strings.Join(messages, "\n"))
+}
+
+// Is reports whether any of the failures is of the target class.
+func (e MultiError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge combines the results of all failures into one ValidationError, its message lists the
+// message of every failure.
+func (e MultiError) merge() ValidationError {
+	var (
+		merged   = ValidationError{}
+		messages = make([]string, 0, len(e.Errors))
+	)
+
+	for _, err := range e.Errors {
+		vErr := ToValidationError(err)
+		if vErr.Message != nil {
+			messages = append(messages, vErr.Message.Error())
+		}
+		merged.FieldValidations = append(merged.FieldValidations, vErr.FieldValidations...)
+		merged.ConditionValidations = append(merged.ConditionValidations, vErr.ConditionValidations...)
+		merged.AggregateValidations = append(merged.AggregateValidations, vErr.AggregateValidations...)
+		merged.CheckValidations = append(merged.CheckValidations, vErr.CheckValidations...)
+		merged.ClusterEndpointValidations = append(merged.ClusterEndpointValidations, vErr.ClusterEndpointValidations...)
+		merged.HTTPEndpointValidations = append(merged.HTTPEndpointValidations, vErr.HTTPEndpointValidations...)
+		if merged.Cluster == "" {
+			merged.Cluster, merged.ClusterLabels = vErr.Cluster, vErr.ClusterLabels
+		}
+	}
+	merged.Message = errors.New(strings.Join(messages, "; "))
+	return merged
+}
+
+// classifyError wraps an error that aborted a validation run in the typed error of its class,
+// errors of no known class are returned unchanged.
+func classifyError(err error) error {
+	var (
+		vErr = ToValidationError(err)
+	)
+
+	switch {
+	case errors.Is(err, ErrSpec), errors.Is(err, ErrAccess), errors.Is(err, ErrTimeout), errors.Is(err, ErrThreshold), errors.Is(err, ErrDependency):
+		return err
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+		return AccessError{vErr}
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return TimeoutError{vErr}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// resourceEvaluation accumulates the results of the validations of a resource entry over its
+// resources, added one at a time. A resource is not referenced once it was added, so resources
+// can be evaluated while they are listed. Only the reasons of failed resources, the values of
+// unique fields and running aggregates are kept.
+type resourceEvaluation struct {
+	resource   v1alpha1.ClusterResource
+	fields     []FieldValidationResult
+	owners     []map[string][]string
+	jq         []FieldValidationResult
+	templates  []FieldValidationResult
+	conditions []ConditionValidationResult
+	aggregates *aggregateEvaluation
+}
+
+func newResourceEvaluation(r v1alpha1.ClusterResource) *resourceEvaluation {
+	var (
+		e = &resourceEvaluation{resource: r, aggregates: newAggregateEvaluation(r)}
+	)
+
+	for _, field := range r.Fields {
+		e.fields = append(e.fields, NewFieldValidationResult(field.Path))
+		e.owners = append(e.owners, make(map[string][]string))
+	}
+	for _, assertion := range r.JQ {
+		result := NewFieldValidationResult(assertion.GetMessage())
+		result.Code = FailureCodeJQMismatch
+		e.jq = append(e.jq, result)
+	}
+	for _, assertion := range r.GoTemplates {
+		result := NewFieldValidationResult(assertion.GetMessage())
+		result.Code = FailureCodeTemplateMismatch
+		e.templates = append(e.templates, result)
+	}
+	for _, cond := range r.Conditions {
+		e.conditions = append(e.conditions, NewConditionValidationResult(fmt.Sprintf("%v=%v", cond.Type, cond.Status)))
+	}
+	return e
+}
+
+// add evaluates every validation of the entry on a resource.
+func (e *resourceEvaluation) add(resource unstructured.Unstructured) {
+	var (
+		name = namespacedName(resource)
+	)
+
+	for i, field := range e.resource.Fields {
+		matchField(field, resource, &e.fields[i], e.owners[i])
+	}
+	for i, assertion := range e.resource.JQ {
+		if err := assertJQ(assertion, resource.Object); err != nil {
+			e.jq[i].ResourceErrors[err.Error()] = append(e.jq[i].ResourceErrors[err.Error()], name)
+		}
+	}
+	for i, assertion := range e.resource.GoTemplates {
+		if err := assertGoTemplate(assertion, resource.Object); err != nil {
+			e.templates[i].ResourceErrors[err.Error()] = append(e.templates[i].ResourceErrors[err.Error()], name)
+		}
+	}
+	for i, cond := range e.resource.Conditions {
+		matchCondition(cond, resource, &e.conditions[i])
+	}
+	e.aggregates.add(resource)
+}
+
+// failedFields returns the failed fields, with values of unique fields owned by more than one
+// resource. It is called once, after the last resource was added.
+func (e *resourceEvaluation) failedFields() []FieldValidationResult {
+	for i, field := range e.resource.Fields {
+		for val, names := range e.owners[i] {
+			if len(names) > 1 {
+				reason := fmt.Sprintf("value '%v' at '%v' is not unique across resources", val, field.Path)
+				e.fields[i].ResourceErrors[reason] = append(e.fields[i].ResourceErrors[reason], names...)
+			}
+		}
+	}
+	return failedFields(e.fields)
+}
+
+// summary returns the results of the entry over all resources added, and an error when any
+// validation failed. It is called once, after the last resource was added.
+func (e *resourceEvaluation) summary() (ValidationSummary, error) {
+	var (
+		summary = ValidationSummary{}
+		failed  bool
+	)
+
+	fields := e.failedFields()
+	fields = append(fields, failedFields(e.jq)...)
+	fields = append(fields, failedFields(e.templates)...)
+	if len(fields) > 0 {
+		summary.FieldValidation = fields
+		failed = true
+	}
+
+	conditions := failedConditions(e.conditions)
+	if len(conditions) > 0 {
+		summary.ConditionValidation = conditions
+		failed = true
+	}
+
+	aggregates := e.aggregates.results()
+	if len(aggregates) > 0 {
+		summary.AggregateValidation = aggregates
+		failed = true
+	}
+
+	if failed {
+		summary.setID(e.resource.ID)
+		return summary, errors.New("failed to validate resources")
+	}
+
+	return summary, nil
+}
+
+func failedFields(results []FieldValidationResult) []FieldValidationResult {
+	var (
+		failedValidations = make([]FieldValidationResult, 0)
+	)
+
+	for _, result := range results {
+		if len(result.ResourceErrors) > 0 {
+			failedValidations = append(failedValidations, result)
+		}
+	}
+	return failedValidations
+}
+
+func failedConditions(results []ConditionValidationResult) []ConditionValidationResult {
+	var (
+		failedValidations = make([]ConditionValidationResult, 0)
+	)
+
+	for _, result := range results {
+		if len(result.ResourceErrors) > 0 {
+			failedValidations = append(failedValidations, result)
+		}
+	}
+	return failedValidations
+}
+
+// streams reports whether a resource entry is evaluated while it is listed. Canaries need all
+// resources at once, and the informer cache holds them anyway.
+func (v *Validator) streams(r v1alpha1.ClusterResource) bool {
+	return v.StreamLists && !v.InformerCache && r.Canary == nil
+}
+
+// streamResources lists the resources of an entry and evaluates them page by page as they are
+// decoded, so only the pages being decoded are held in memory. Streamed lists are not shared with
+// other entries.
+func (v *Validator) streamResources(r v1alpha1.ClusterResource) (*resourceEvaluation, error) {
+	var (
+		e = newResourceEvaluation(r)
+	)
+
+	err := v.eachResource(r, func(u unstructured.Unstructured) {
+		if inResourceScope(r, u) {
+			e.add(u)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,43 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// ExpressionLanguage selects how TestExpression evaluates an expression.
+type ExpressionLanguage string
+
+const (
+	ExpressionLanguageJQ         ExpressionLanguage = "jq"
+	ExpressionLanguageGoTemplate ExpressionLanguage = "gotemplate"
+)
+
+// TestExpression evaluates a jq or Go template assertion against an object the way a resource
+// entry evaluates it against a matched resource, so expressions can be tested offline. The
+// expected output only applies to Go templates. It returns nil when the assertion passes.
+func TestExpression(language ExpressionLanguage, expression, expected string, obj map[string]interface{}) error {
+	switch language {
+	case ExpressionLanguageJQ:
+		if expected != "" {
+			return errors.New("an expected output is only supported for Go templates")
+		}
+		return assertJQ(v1alpha1.JQAssertion{Expression: expression}, obj)
+	case ExpressionLanguageGoTemplate:
+		return assertGoTemplate(v1alpha1.GoTemplateAssertion{Template: expression, Expected: expected}, obj)
+	default:
+		return errors.Errorf("unknown expression language '%v', expected '%v' or '%v'", language, ExpressionLanguageJQ, ExpressionLanguageGoTemplate)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+)
+
+var (
+	// goTemplates caches parsed templates, they are rendered for every resource of every attempt
+	goTemplates sync.Map
+
+	falsyTemplateOutputs = map[string]bool{"": true, "false": true, "0": true, "<no value>": true}
+)
+
+// assertGoTemplate returns an error when the rendered output does not equal the expected
+// output, or is not truthy when no output is expected.
+func assertGoTemplate(assertion v1alpha1.GoTemplateAssertion, obj map[string]interface{}) error {
+	var (
+		out = new(bytes.Buffer)
+	)
+
+	tmpl, err := parseGoTemplate(assertion.Template)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(out, obj); err != nil {
+		return errors.Wrap(err, "template rendering failed")
+	}
+
+	rendered := strings.TrimSpace(out.String())
+	if assertion.Expected != "" {
+		if expected := strings.TrimSpace(assertion.Expected); rendered != expected {
+			return errors.Errorf("template rendered '%v', expected '%v'", rendered, expected)
+		}
+		return nil
+	}
+	if falsyTemplateOutputs[strings.ToLower(rendered)] {
+		return errors.Errorf("template rendered '%v'", rendered)
+	}
+	return nil
+}
+
+func parseGoTemplate(text string) (*template.Template, error) {
+	if tmpl, ok := goTemplates.Load(text); ok {
+		return tmpl.(*template.Template), nil
+	}
+
+	tmpl, err := template.New("assertion").Funcs(goTemplateFuncs()).Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid go template '%v'", text)
+	}
+	goTemplates.Store(text, tmpl)
+	return tmpl, nil
+}
+
+// goTemplateFuncs returns the sprig functions, as known from Helm charts, along with the
+// expression helpers that sprig does not define under the same name.
+func goTemplateFuncs() template.FuncMap {
+	var (
+		funcs = sprig.TxtFuncMap()
+	)
+
+	for name, fn := range expr.Funcs() {
+		if _, ok := funcs[name]; !ok {
+			funcs[name] = fn
+		}
+	}
+	return funcs
+}
+
+// validateTemplateAssertions rejects Go templates that do not parse when the spec is loaded.
+func validateTemplateAssertions(spec *v1alpha1.ClusterValidation) error {
+	resources := spec.Spec.Resources
+	for _, g := range spec.Spec.Groups {
+		resources = append(resources, append(g.AllOf, g.AnyOf...)...)
+	}
+
+	for _, r := range resources {
+		for _, assertion := range r.GoTemplates {
+			if _, err := parseGoTemplate(assertion.Template); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// validateGroup treats an allOf/anyOf group as a single validation, each attempt
+// evaluates every member once and the group thresholds apply to the combined outcome.
+func (v *Validator) validateGroup(g v1alpha1.ValidationGroup) bool {
+	log.Infof("validating group '%v'", g.Name)
+
+	evaluate := func() (ValidationSummary, error) {
+		return v.evaluateGroup(g)
+	}
+
+	onFailure := func(summary ValidationSummary) ValidationError {
+		return ValidationError{
+			ID:                   g.ID,
+			Remediation:          g.Remediation,
+			DocsURL:              g.DocsURL,
+			Owner:                g.Owner,
+			Team:                 g.Team,
+			Runbook:              g.Runbook,
+			Message:              errors.Errorf("failure threshold met for group '%v'", g.Name),
+			FieldValidations:     summary.FieldValidation,
+			ConditionValidations: summary.ConditionValidation,
+			AggregateValidations: summary.AggregateValidation,
+		}
+	}
+
+	return v.runValidation(g.Name, g.ID, g.Required, &g, v.suppressed(g.Name, g.ID, evaluate), onFailure)
+}
+
+func (v *Validator) evaluateGroup(g v1alpha1.ValidationGroup) (ValidationSummary, error) {
+	var (
+		summary      = ValidationSummary{}
+		allOfFailed  []string
+		anyOfFailed  []string
+		anyOfSuccess bool
+	)
+
+	for _, r := range g.AllOf {
+		if s, err := v.evaluateGroupMember(r); err != nil {
+			allOfFailed = append(allOfFailed, r.Name)
+			mergeSummary(&summary, s)
+			log.Debugf("group '%v' allOf member '%v' failed -> %v", g.Name, r.Name, err)
+		}
+	}
+
+	for _, r := range g.AnyOf {
+		s, err := v.evaluateGroupMember(r)
+		if err == nil {
+			anyOfSuccess = true
+			continue
+		}
+		anyOfFailed = append(anyOfFailed, r.Name)
+		mergeSummary(&summary, s)
+		log.Debugf("group '%v' anyOf member '%v' failed -> %v", g.Name, r.Name, err)
+	}
+
+	summary.setID(g.ID)
+	switch {
+	case len(allOfFailed) > 0:
+		return summary, errors.Errorf("allOf members %v failed", allOfFailed)
+	case len(g.AnyOf) > 0 && !anyOfSuccess:
+		return summary, errors.Errorf("none of the anyOf members %v succeeded", anyOfFailed)
+	}
+	return ValidationSummary{}, nil
+}
+
+func (v *Validator) evaluateGroupMember(r v1alpha1.ClusterResource) (ValidationSummary, error) {
+	if err := v.listDynamicResource(r); err != nil {
+		return ValidationSummary{}, err
+	}
+	if r.Canary != nil {
+		return v.validateCanary(r, v.getValidationResources(r))
+	}
+	return v.validateResources(r, v.getValidationResources(r))
+}
+
+func mergeSummary(dst *ValidationSummary, src ValidationSummary) {
+	dst.FieldValidation = append(dst.FieldValidation, src.FieldValidation...)
+	dst.ConditionValidation = append(dst.ConditionValidation, src.ConditionValidation...)
+	dst.AggregateValidation = append(dst.AggregateValidation, src.AggregateValidation...)
+	dst.CheckValidation = append(dst.CheckValidation, src.CheckValidation...)
+	dst.ClusterEndpointValidation = append(dst.ClusterEndpointValidation, src.ClusterEndpointValidation...)
+	dst.HTTPEndpointValidation = append(dst.HTTPEndpointValidation, src.HTTPEndpointValidation...)
+	dst.Suppressed += src.Suppressed
+}
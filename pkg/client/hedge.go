@@ -0,0 +1,136 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+)
+
+// hedgedGet gets a URI from the API server, sending a second request when the first did not
+// respond within delay. A zero delay sends a single request.
+func hedgedGet(restClient *rest.RESTClient, uri string, delay time.Duration) (*bytes.Buffer, error) {
+	if delay <= 0 {
+		return rawGet(restClient, uri)
+	}
+	return hedge(delay, func(ctx context.Context) (*bytes.Buffer, error) {
+		return rawGetContext(ctx, restClient, uri)
+	})
+}
+
+// hedge calls get and calls it a second time when the first call did not return within delay,
+// or as soon as it failed. The first successful response wins and cancels the other call, the
+// last error is returned when both fail.
+func hedge(delay time.Duration, get func(ctx context.Context) (*bytes.Buffer, error)) (*bytes.Buffer, error) {
+	out, err := hedgeCall(delay, func(ctx context.Context) (interface{}, error) {
+		return get(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.(*bytes.Buffer), nil
+}
+
+// hedgedHTTPStatus requests a URL like getHTTPStatus, sending a second request when the first
+// did not respond within delay. A zero delay sends a single request.
+func (v *Validator) hedgedHTTPStatus(url string, delay time.Duration) (int, error) {
+	if delay <= 0 {
+		return v.getHTTPStatus(context.Background(), url)
+	}
+	status, err := hedgeCall(delay, func(ctx context.Context) (interface{}, error) {
+		return v.getHTTPStatus(ctx, url)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return status.(int), nil
+}
+
+// hedgeCall is hedge for any kind of response.
+func hedgeCall(delay time.Duration, get func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	type response struct {
+		out interface{}
+		err error
+	}
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		responses   = make(chan response, 2)
+		timer       = time.NewTimer(delay)
+		pending     int
+		hedged      bool
+		lastErr     error
+	)
+	defer cancel()
+	defer timer.Stop()
+
+	send := func() {
+		pending++
+		go func() {
+			out, err := get(ctx)
+			responses <- response{out: out, err: err}
+		}()
+	}
+
+	send()
+	for {
+		select {
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				log.Debugf("no response within %v, sending hedged request", delay)
+				send()
+			}
+		case r := <-responses:
+			pending--
+			if r.err == nil {
+				return r.out, nil
+			}
+			lastErr = r.err
+			if !hedged {
+				hedged = true
+				send()
+				continue
+			}
+			if pending == 0 {
+				return nil, lastErr
+			}
+		}
+	}
+}
+
+func validateHedges(spec *v1alpha1.ClusterValidation) error {
+	for _, e := range spec.Spec.Endpoints.Cluster {
+		if e.Hedge == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(e.Hedge); err != nil || d <= 0 {
+			return errors.Errorf("hedge of cluster endpoint '%v' must be a positive duration, got '%v'", e.Name, e.Hedge)
+		}
+	}
+	for _, e := range spec.Spec.Endpoints.HTTP {
+		if e.Hedge == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(e.Hedge); err != nil || d <= 0 {
+			return errors.Errorf("hedge of http endpoint '%v' must be a positive duration, got '%v'", e.Name, e.Hedge)
+		}
+	}
+	return nil
+}
@@ -15,20 +15,20 @@ package client
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/gobwas/glob"
 	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/jsonpath"
-	"k8s.io/kubectl/pkg/scheme"
 )
 
 func groupVersionResource(groupVersion, resource string) schema.GroupVersionResource {
@@ -53,37 +53,95 @@ func groupVersionResource(groupVersion, resource string) schema.GroupVersionReso
 	}
 }
 
-func getJsonPathValue(u unstructured.Unstructured, jsonPath string) (string, error) {
-	j := jsonpath.New("")
-	j.AllowMissingKeys(true)
-
+func jsonPathExpression(jsonPath string) string {
 	if !strings.HasPrefix(jsonPath, "{") && !strings.HasSuffix(jsonPath, "}") {
+		if !strings.HasPrefix(jsonPath, ".") && !strings.HasPrefix(jsonPath, "$") && !strings.HasPrefix(jsonPath, "[") {
+			jsonPath = "." + jsonPath
+		}
 		jsonPath = fmt.Sprintf("{%v}", jsonPath)
 	}
+	return jsonPath
+}
 
-	if err := j.Parse(jsonPath); err != nil {
-		return "", err
+// getJsonPathResults evaluates a JSONPath (including filter expressions) against a
+// resource and returns every matched value, with matched lists flattened into their items.
+func getJsonPathResults(u unstructured.Unstructured, jsonPath string) ([]interface{}, error) {
+	j := jsonpath.New("")
+	j.AllowMissingKeys(true)
+
+	if err := j.Parse(jsonPathExpression(jsonPath)); err != nil {
+		return nil, err
 	}
 
-	buf := new(bytes.Buffer)
-	if err := j.Execute(buf, u.Object); err != nil {
-		return "", err
+	results, err := j.FindResults(u.Object)
+	if err != nil {
+		return nil, err
 	}
 
-	return buf.String(), nil
+	values := make([]interface{}, 0)
+	for _, r := range results {
+		for _, v := range r {
+			if !v.IsValid() || !v.CanInterface() {
+				continue
+			}
+			switch val := v.Interface().(type) {
+			case []interface{}:
+				values = append(values, val...)
+			default:
+				values = append(values, val)
+			}
+		}
+	}
+	return values, nil
 }
 
-func unstructuredSlicePath(u unstructured.Unstructured, jsonPath string) ([]interface{}, bool, error) {
-	splitFunction := func(c rune) bool {
-		return c == '.'
+// getJsonPathValues evaluates a JSONPath against a resource and returns each matched
+// value as a string. A path that matches nothing yields a single empty value so that
+// patterns decide whether a missing field is acceptable.
+func getJsonPathValues(u unstructured.Unstructured, jsonPath string) ([]string, error) {
+	j := jsonpath.New("")
+	j.AllowMissingKeys(true)
+
+	if err := j.Parse(jsonPathExpression(jsonPath)); err != nil {
+		return []string{""}, err
 	}
-	statusPath := strings.FieldsFunc(jsonPath, splitFunction)
 
-	value, f, err := unstructured.NestedSlice(u.UnstructuredContent(), statusPath...)
+	results, err := j.FindResults(u.Object)
 	if err != nil {
-		return []interface{}{}, false, err
+		return []string{""}, err
+	}
+
+	values := make([]string, 0)
+	for _, r := range results {
+		for _, v := range r {
+			if !v.IsValid() || !v.CanInterface() {
+				continue
+			}
+			values = append(values, jsonPathValueString(v.Interface()))
+		}
+	}
+
+	if len(values) == 0 {
+		return []string{""}, nil
+	}
+	return values, nil
+}
+
+func jsonPathValueString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", val)
 	}
-	return value, f, nil
 }
 
 func patternMatch(pattern, str string) bool {
@@ -101,9 +159,132 @@ func matchInPatterns(patterns []string, str string) bool {
 	return condition
 }
 
+// matchFieldValues checks the values found at a field path against the field's patterns
+// and age bounds according to its quantifier, and returns the failure reasons.
+func matchFieldValues(field v1alpha1.FieldSelector, patterns, values []string) []string {
+	var (
+		reasons  = make([]string, 0)
+		matching = make([]string, 0)
+		failing  = make([]string, 0)
+	)
+
+	for _, val := range values {
+		val, err := decodeValue(field.Decode, val)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("field '%v' could not be decoded: %v", field.Path, err))
+			continue
+		}
+
+		var valueReasons []string
+		if !matchInPatterns(patterns, val) {
+			valueReasons = append(valueReasons, fmt.Sprintf("JSONPath values '%v' not matching '%v' in resources", patterns, val))
+		}
+		if reason := matchAge(field, val); reason != "" {
+			valueReasons = append(valueReasons, reason)
+		}
+
+		if len(valueReasons) == 0 {
+			matching = append(matching, val)
+		} else {
+			failing = append(failing, valueReasons...)
+		}
+	}
+
+	switch field.GetQuantifier() {
+	case v1alpha1.QuantifierAny:
+		if len(matching) == 0 && len(failing) > 0 {
+			reasons = append(reasons, fmt.Sprintf("none of the values at '%v' match '%v'", field.Path, patterns))
+		}
+	case v1alpha1.QuantifierNone:
+		if len(matching) > 0 {
+			reasons = append(reasons, fmt.Sprintf("values '%v' at '%v' match '%v' but none are allowed to", matching, field.Path, patterns))
+		}
+	default:
+		reasons = append(reasons, failing...)
+	}
+
+	return reasons
+}
+
+// matchFieldPath compares the values of a field with the values found at its equalsPath on the same resource.
+func matchFieldPath(u unstructured.Unstructured, field v1alpha1.FieldSelector, values []string) string {
+	expected, err := getJsonPathValues(u, field.EqualsPath)
+	if err != nil {
+		return fmt.Sprintf("field '%v' has type mismatch: %v", field.EqualsPath, err)
+	}
+
+	if strings.Join(values, " ") != strings.Join(expected, " ") {
+		return fmt.Sprintf("field '%v' value '%v' does not equal '%v' at '%v'", field.Path, strings.Join(values, " "), strings.Join(expected, " "), field.EqualsPath)
+	}
+	return ""
+}
+
+func decodeValue(decoding v1alpha1.FieldDecoding, val string) (string, error) {
+	switch decoding {
+	case "":
+		return val, nil
+	case v1alpha1.FieldDecodingBase64:
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(val))
+		if err != nil {
+			return val, err
+		}
+		return string(b), nil
+	default:
+		return val, errors.Errorf("unsupported decoding '%v'", decoding)
+	}
+}
+
+// matchAge returns a failure reason when a timestamp does not satisfy the
+// olderThan/newerThan bounds of a field selector, or an empty string otherwise.
+func matchAge(f v1alpha1.FieldSelector, ts string) string {
+	if f.OlderThan == "" && f.NewerThan == "" {
+		return ""
+	}
+
+	if ts == "" {
+		return fmt.Sprintf("field '%v' has no timestamp to compare", f.Path)
+	}
+
+	if f.OlderThan != "" {
+		ok, err := expr.OlderThan(ts, f.OlderThan)
+		if err != nil {
+			return fmt.Sprintf("field '%v' cannot be compared: %v", f.Path, err)
+		}
+		if !ok {
+			return fmt.Sprintf("field '%v' is not older than %v", f.Path, f.OlderThan)
+		}
+	}
+
+	if f.NewerThan != "" {
+		ok, err := expr.NewerThan(ts, f.NewerThan)
+		if err != nil {
+			return fmt.Sprintf("field '%v' cannot be compared: %v", f.Path, err)
+		}
+		if !ok {
+			return fmt.Sprintf("field '%v' is not newer than %v", f.Path, f.NewerThan)
+		}
+	}
+	return ""
+}
+
+// inGracePeriod reports whether a resource was created within the given duration.
+func inGracePeriod(resource unstructured.Unstructured, gracePeriod string) (bool, error) {
+	d, err := expr.ParseDuration(gracePeriod)
+	if err != nil {
+		return false, err
+	}
+	created := resource.GetCreationTimestamp()
+	if created.IsZero() {
+		return false, nil
+	}
+	return expr.Now().Sub(created.Time) < d, nil
+}
+
+// prettyPrintStruct prints the results of a validation for humans, on stderr like the logs so
+// stdout only carries the report.
 func prettyPrintStruct(st interface{}) {
 	s, _ := json.MarshalIndent(st, "", "\t")
-	fmt.Println(string(s))
+	fmt.Fprintln(os.Stderr, string(s))
 }
 
 func inSelectionScope(s *v1alpha1.SelectionScope, str string) bool {
@@ -139,8 +320,15 @@ func namespacedName(r unstructured.Unstructured) string {
 }
 
 func rawGet(restClient *rest.RESTClient, uri string) (*bytes.Buffer, error) {
+	return rawGetContext(context.TODO(), restClient, uri)
+}
+
+func rawGetContext(ctx context.Context, restClient *rest.RESTClient, uri string) (*bytes.Buffer, error) {
+	if restClient == nil {
+		return nil, errors.Errorf("cannot get '%v' without a REST client, e.g. when validating a snapshot", uri)
+	}
 	r := restClient.Get().RequestURI(uri)
-	stream, err := r.Stream(context.TODO())
+	stream, err := r.Stream(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to stream call")
 	}
@@ -153,46 +341,3 @@ func rawGet(restClient *rest.RESTClient, uri string) (*bytes.Buffer, error) {
 	}
 	return buf, nil
 }
-
-func GetRESTClient() (*rest.RESTClient, error) {
-	config, err := GetKubernetesConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	config.ContentConfig.GroupVersion = &schema.GroupVersion{Group: "", Version: "v1"}
-	config.APIPath = "/apis"
-	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
-	config.UserAgent = rest.DefaultKubernetesUserAgent()
-
-	client, err := rest.RESTClientFor(config)
-	if err != nil {
-		return nil, err
-	}
-
-	return client, nil
-}
-
-func GetKubernetesConfig() (*rest.Config, error) {
-	var config *rest.Config
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-		clientCfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
-		return clientCfg.ClientConfig()
-	}
-	return config, nil
-}
-
-func GetKubernetesDynamicClient() (dynamic.Interface, error) {
-	var config *rest.Config
-	config, err := GetKubernetesConfig()
-	if err != nil {
-		return nil, err
-	}
-	client, err := dynamic.NewForConfig(config)
-	if err != nil {
-		return nil, err
-	}
-	return client, nil
-}
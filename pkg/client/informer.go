@@ -0,0 +1,196 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	// informerSyncTimeout bounds the initial list of an informer, e.g. when watch is not permitted
+	informerSyncTimeout = 2 * time.Minute
+)
+
+// informerCache serves resources from watch-backed informers, one per GVR, shared by all
+// validations of that GVR for the lifetime of a validation run.
+type informerCache struct {
+	factory dynamicinformer.DynamicSharedInformerFactory
+	stop    chan struct{}
+	stopped bool
+	synced  map[schema.GroupVersionResource]informers.GenericInformer
+	started map[schema.GroupVersionResource]bool
+
+	// changes holds a channel per GVR that is closed and replaced whenever a resource of the GVR
+	// changes, so any number of validations can wait for the next change
+	changesMu sync.Mutex
+	changes   map[schema.GroupVersionResource]chan struct{}
+}
+
+// informerCache returns the informer cache of the run, creating it on first use. The caller
+// must hold the lock of the validator.
+func (v *Validator) informerCache() *informerCache {
+	if v.informers == nil {
+		v.informers = &informerCache{
+			factory: dynamicinformer.NewDynamicSharedInformerFactory(v.Kubernetes, 0),
+			stop:    make(chan struct{}),
+			synced:  make(map[schema.GroupVersionResource]informers.GenericInformer),
+			started: make(map[schema.GroupVersionResource]bool),
+			changes: make(map[schema.GroupVersionResource]chan struct{}),
+		}
+	}
+	return v.informers
+}
+
+// cachedInformer returns the synced informer of a GVR, starting it on first use.
+func (v *Validator) cachedInformer(gvr schema.GroupVersionResource) (informers.GenericInformer, error) {
+	v.Lock()
+	c := v.informerCache()
+	if informer, ok := c.synced[gvr]; ok {
+		v.Unlock()
+		return informer, nil
+	}
+	informer := c.factory.ForResource(gvr)
+	if !c.started[gvr] {
+		// the transform can only be set before the informer runs, later calls are rejected
+		_ = informer.Informer().SetTransform(func(obj interface{}) (interface{}, error) {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				trimResource(u)
+			}
+			return obj, nil
+		})
+		informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) { c.changed(gvr) },
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldMeta, oldErr := meta.Accessor(oldObj)
+				newMeta, newErr := meta.Accessor(newObj)
+				if oldErr == nil && newErr == nil && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+					return
+				}
+				c.changed(gvr)
+			},
+			DeleteFunc: func(obj interface{}) { c.changed(gvr) },
+		})
+		c.started[gvr] = true
+	}
+	c.factory.Start(c.stop)
+	v.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), informerSyncTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		return nil, TimeoutError{ValidationError{Message: errors.Errorf("timed out waiting for the informer cache of '%v' to sync", gvr), GVR: gvr}}
+	}
+
+	v.Lock()
+	c.synced[gvr] = informer
+	v.Unlock()
+	return informer, nil
+}
+
+// cachedResources returns all resources of a GVR from its informer cache.
+func (v *Validator) cachedResources(gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	informer, err := v.cachedInformer(gvr)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := informer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list '%v' from informer cache", gvr)
+	}
+
+	items := make([]unstructured.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			items = append(items, *u)
+		}
+	}
+	return items, nil
+}
+
+// changed wakes the validations waiting for a change to a resource of the GVR.
+func (c *informerCache) changed(gvr schema.GroupVersionResource) {
+	c.changesMu.Lock()
+	defer c.changesMu.Unlock()
+	if ch, ok := c.changes[gvr]; ok {
+		close(ch)
+		delete(c.changes, gvr)
+	}
+}
+
+// resourceChanges returns a channel that is closed on the next change to the resources of a
+// resource entry, or nil when validations are not event driven or the target is no resource entry.
+func (v *Validator) resourceChanges(target validationTarget) <-chan struct{} {
+	r, ok := target.(*v1alpha1.ClusterResource)
+	if !ok || !v.EventDriven || !v.InformerCache {
+		return nil
+	}
+
+	v.Lock()
+	c := v.informerCache()
+	v.Unlock()
+
+	gvr := groupVersionResource(r.APIVersion, r.Name)
+	c.changesMu.Lock()
+	defer c.changesMu.Unlock()
+	if _, ok := c.changes[gvr]; !ok {
+		c.changes[gvr] = make(chan struct{})
+	}
+	return c.changes[gvr]
+}
+
+// sleepUntilChange waits like sleep, but returns early with woken set when changes is closed.
+func (v *Validator) sleepUntilChange(d time.Duration, changes <-chan struct{}) (woken, ok bool) {
+	if changes == nil {
+		return false, v.sleep(d)
+	}
+	select {
+	case <-v.stop:
+		return false, false
+	case <-changes:
+		return true, true
+	case <-time.After(d):
+		return false, true
+	}
+}
+
+// stopInformers stops the watches of all informers started during the run, synced caches
+// keep serving their last state to validations that are still running.
+func (v *Validator) stopInformers() {
+	v.Lock()
+	defer v.Unlock()
+	if v.informers != nil && !v.informers.stopped {
+		close(v.informers.stop)
+		v.informers.stopped = true
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/itchyny/gojq"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+)
+
+var (
+	// jqCode caches compiled expressions, they are evaluated on every resource of every attempt
+	jqCode sync.Map
+)
+
+// assertJSONResponse evaluates jq assertions on a JSON response body.
+func assertJSONResponse(assertions []v1alpha1.JQAssertion, body []byte) error {
+	var (
+		input    interface{}
+		failures = make([]string, 0)
+	)
+
+	if len(assertions) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errors.Wrap(err, "response is not JSON")
+	}
+
+	for _, assertion := range assertions {
+		if err := assertJQ(assertion, input); err != nil {
+			failures = append(failures, fmt.Sprintf("'%v' %v", assertion.GetMessage(), err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.Errorf("jq assertions failed: %v", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// assertJQ returns an error when the expression yields false, null or nothing for the input.
+func assertJQ(assertion v1alpha1.JQAssertion, input interface{}) error {
+	var (
+		outputs int
+	)
+
+	code, err := compileJQ(assertion.Expression)
+	if err != nil {
+		return err
+	}
+
+	iter := code.Run(jqValue(input))
+	for {
+		out, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := out.(error); ok {
+			return errors.Wrap(err, "jq evaluation failed")
+		}
+		outputs++
+		if out == nil || out == false {
+			return errors.Errorf("jq assertion yielded %v", out)
+		}
+	}
+	if outputs == 0 {
+		return errors.New("jq assertion yielded no output")
+	}
+	return nil
+}
+
+func compileJQ(expression string) (*gojq.Code, error) {
+	if code, ok := jqCode.Load(expression); ok {
+		return code.(*gojq.Code), nil
+	}
+
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid jq expression '%v'", expression)
+	}
+	code, err := gojq.Compile(query, jqFunctions...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid jq expression '%v'", expression)
+	}
+	jqCode.Store(expression, code)
+	return code, nil
+}
+
+// jqFunctions exposes the expression helpers to jq under the names of expr.Funcs, except label,
+// a jq keyword, which is labelValue. A helper applies to its input, e.g.
+// `.status.capacity.memory | quantity`, further arguments are passed in parentheses, e.g.
+// `.metadata.creationTimestamp | olderThan("1h")`. Durations and timestamps are returned in
+// seconds.
+var jqFunctions = []gojq.CompilerOption{
+	jqStringFunction("quantity", 0, func(in string, _ []string) (interface{}, error) {
+		return expr.Quantity(in)
+	}),
+	jqStringFunction("compareQuantity", 1, func(in string, args []string) (interface{}, error) {
+		return expr.CompareQuantity(in, args[0])
+	}),
+	jqStringFunction("duration", 0, func(in string, _ []string) (interface{}, error) {
+		d, err := expr.ParseDuration(in)
+		return d.Seconds(), err
+	}),
+	jqStringFunction("timestamp", 0, func(in string, _ []string) (interface{}, error) {
+		t, err := expr.ParseTimestamp(in)
+		return float64(t.Unix()), err
+	}),
+	jqStringFunction("since", 0, func(in string, _ []string) (interface{}, error) {
+		d, err := expr.Since(in)
+		return d.Seconds(), err
+	}),
+	jqStringFunction("until", 0, func(in string, _ []string) (interface{}, error) {
+		d, err := expr.Until(in)
+		return d.Seconds(), err
+	}),
+	jqStringFunction("olderThan", 1, func(in string, args []string) (interface{}, error) {
+		return expr.OlderThan(in, args[0])
+	}),
+	jqStringFunction("newerThan", 1, func(in string, args []string) (interface{}, error) {
+		return expr.NewerThan(in, args[0])
+	}),
+	jqStringFunction("semver", 0, func(in string, _ []string) (interface{}, error) {
+		return expr.Semver(in)
+	}),
+	jqStringFunction("semverCompare", 1, func(in string, args []string) (interface{}, error) {
+		return expr.SemverCompare(in, args[0])
+	}),
+	jqObjectFunction("labelValue", func(obj map[string]interface{}, key string) interface{} {
+		return expr.Label(obj, key)
+	}),
+	jqObjectFunction("hasLabel", func(obj map[string]interface{}, key string) interface{} {
+		return expr.HasLabel(obj, key)
+	}),
+	jqObjectFunction("annotation", func(obj map[string]interface{}, key string) interface{} {
+		return expr.Annotation(obj, key)
+	}),
+	jqObjectFunction("hasAnnotation", func(obj map[string]interface{}, key string) interface{} {
+		return expr.HasAnnotation(obj, key)
+	}),
+}
+
+// jqStringFunction registers a helper that takes a string input and arity string arguments.
+func jqStringFunction(name string, arity int, fn func(string, []string) (interface{}, error)) gojq.CompilerOption {
+	return gojq.WithFunction(name, arity, arity, func(in interface{}, args []interface{}) interface{} {
+		s, ok := in.(string)
+		if !ok {
+			return errors.Errorf("%v cannot be applied to %v, it expects a string", name, jqType(in))
+		}
+		strs := make([]string, 0, len(args))
+		for _, arg := range args {
+			str, ok := arg.(string)
+			if !ok {
+				return errors.Errorf("%v expects string arguments, got %v", name, jqType(arg))
+			}
+			strs = append(strs, str)
+		}
+		out, err := fn(s, strs)
+		if err != nil {
+			return err
+		}
+		return out
+	})
+}
+
+// jqObjectFunction registers a helper that takes an object input and a key argument.
+func jqObjectFunction(name string, fn func(map[string]interface{}, string) interface{}) gojq.CompilerOption {
+	return gojq.WithFunction(name, 1, 1, func(in interface{}, args []interface{}) interface{} {
+		obj, ok := in.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("%v cannot be applied to %v, it expects an object", name, jqType(in))
+		}
+		key, ok := args[0].(string)
+		if !ok {
+			return errors.Errorf("%v expects a string key, got %v", name, jqType(args[0]))
+		}
+		return fn(obj, key)
+	})
+}
+
+func jqType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "number"
+	}
+}
+
+// jqValue converts the integer types of unstructured objects, which gojq does not accept.
+func jqValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case int64:
+		return int(value)
+	case int32:
+		return int(value)
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = jqValue(item)
+		}
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			m[k] = jqValue(v)
+		}
+		return m
+	default:
+		return value
+	}
+}
+
+// validateJQExpressions rejects jq expressions that do not compile when the spec is loaded.
+func validateJQExpressions(spec *v1alpha1.ClusterValidation) error {
+	var (
+		assertions = make([]v1alpha1.JQAssertion, 0)
+	)
+
+	resources := spec.Spec.Resources
+	for _, g := range spec.Spec.Groups {
+		resources = append(resources, append(g.AllOf, g.AnyOf...)...)
+	}
+	for _, r := range resources {
+		assertions = append(assertions, r.JQ...)
+	}
+	for _, e := range spec.Spec.Endpoints.Cluster {
+		assertions = append(assertions, e.JQ...)
+	}
+
+	for _, assertion := range assertions {
+		if _, err := compileJQ(assertion.Expression); err != nil {
+			return err
+		}
+	}
+	return nil
+}
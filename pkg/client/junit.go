@@ -0,0 +1,178 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	RegisterSink("junit", func(path string) (ResultSink, error) {
+		if path == "" {
+			return nil, errors.New("junit sink requires a file path, e.g. junit=report.xml")
+		}
+		return &JUnitSink{Path: path}, nil
+	})
+}
+
+// JUnitSink writes the report of a run as a JUnit XML file with one test case per validation,
+// so CI systems such as Jenkins render the results natively. The file is replaced every run.
+type JUnitSink struct {
+	Path string
+
+	report Report
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+func (s *JUnitSink) Write(report Report) error {
+	s.report = report
+	return nil
+}
+
+func (s *JUnitSink) Flush() error {
+	out, err := junitReport(s.report)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(s.Path, out, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write junit report to '%v'", s.Path)
+	}
+	return nil
+}
+
+// junitReport renders a report as a JUnit test suite named after the cluster. Failed validations
+// carry their last error and resource errors, validations that did not finish, failed during a
+// maintenance window or only succeeded because their failures were suppressed are skipped.
+func junitReport(report Report) ([]byte, error) {
+	var (
+		name  = report.Cluster.Name
+		suite = junitTestSuite{}
+	)
+
+	if name == "" {
+		name = "cluster-validator"
+	}
+	suite.Name = name
+	suite.Timestamp = report.Started.UTC().Format("2006-01-02T15:04:05")
+	suite.Time = fmt.Sprintf("%.3f", report.Finished.Sub(report.Started).Seconds())
+
+	for _, p := range report.Validations {
+		testCase := junitTestCase{
+			Name:      p.Name,
+			ClassName: name,
+			Time:      fmt.Sprintf("%.3f", p.Duration.Seconds()),
+		}
+		if p.ID != "" {
+			testCase.Name = fmt.Sprintf("%v [%v]", p.Name, p.ID)
+		}
+
+		switch {
+		case p.Status == ValidationStatusSucceeded && p.Suppressed > 0:
+			suite.Skipped++
+			testCase.Skipped = &junitSkipped{Message: fmt.Sprintf("%v failures suppressed", p.Suppressed)}
+		case p.Status == ValidationStatusSucceeded:
+		case p.Status == ValidationStatusWarning:
+			suite.Skipped++
+			testCase.Skipped = &junitSkipped{Message: fmt.Sprintf("warning: %v", p.LastError)}
+		case p.Status == ValidationStatusFailed:
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: p.LastError,
+				Type:    strings.Join(summaryCodes(p.Summary), ","),
+				Text:    summaryErrors(p.Summary),
+			}
+		default:
+			suite.Skipped++
+			testCase.Skipped = &junitSkipped{Message: fmt.Sprintf("validation did not finish (%v)", strings.ToLower(string(p.Status)))}
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	out, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal junit report")
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+func summaryCodes(s ValidationSummary) []string {
+	vErr := ValidationError{
+		FieldValidations:           s.FieldValidation,
+		ConditionValidations:       s.ConditionValidation,
+		AggregateValidations:       s.AggregateValidation,
+		CheckValidations:           s.CheckValidation,
+		ClusterEndpointValidations: s.ClusterEndpointValidation,
+		HTTPEndpointValidations:    s.HTTPEndpointValidation,
+	}
+
+	codes := make([]string, 0)
+	for _, code := range vErr.Codes() {
+		codes = append(codes, string(code))
+	}
+	return codes
+}
+
+// summaryErrors lists the failed results of a summary, one line per reason with the resources
+// it was found on.
+func summaryErrors(s ValidationSummary) string {
+	var (
+		lines = make([]string, 0)
+	)
+
+	for _, r := range summaryReasons(s) {
+		if len(r.Resources) == 0 {
+			lines = append(lines, fmt.Sprintf("%v: %v", r.Validation, r.Reason))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%v: %v: %v", r.Validation, r.Reason, strings.Join(r.Resources, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
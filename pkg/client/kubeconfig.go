@@ -0,0 +1,250 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/kubectl/pkg/scheme"
+)
+
+// ClientOptions selects the cluster and credentials the validator connects with. Without a
+// kubeconfig or context the in-cluster configuration is preferred, followed by the default
+// kubeconfig loading rules. Exec credential plugins configured in the kubeconfig, e.g.
+// aws-iam-authenticator or gke-gcloud-auth-plugin, are invoked again whenever their
+// credentials expire, and a token file is re-read periodically, so long runs keep working
+// across token rotations.
+type ClientOptions struct {
+	Kubeconfig string
+	Context    string
+	// Token replaces the credentials of the selected context with a static bearer token
+	Token string
+	// TokenFile replaces the credentials of the selected context with a bearer token file
+	TokenFile string
+	// UserAgent is appended to the default User-Agent of every request
+	UserAgent string
+	// RunID identifies a validation run, it is sent in the RunIDHeader of every request and
+	// appended to the User-Agent so it can be found in API server audit logs
+	RunID string
+	// Overrides are kubectl style overrides of the kubeconfig, e.g. the cluster, user or
+	// namespace. When set, the in-cluster configuration is only used without a kubeconfig.
+	Overrides *clientcmd.ConfigOverrides
+}
+
+// RunIDHeader is the request header carrying the run ID.
+const RunIDHeader = "X-Cluster-Validator-Run-Id"
+
+func GetKubernetesConfig() (*rest.Config, error) {
+	return GetKubernetesConfigFor(ClientOptions{})
+}
+
+func GetKubernetesConfigFor(opts ClientOptions) (*rest.Config, error) {
+	var (
+		config *rest.Config
+		err    error
+	)
+
+	if opts.Token != "" && opts.TokenFile != "" {
+		return nil, errors.New("token and token file are mutually exclusive")
+	}
+
+	if opts.Kubeconfig == "" && opts.Context == "" && opts.Overrides == nil {
+		config, err = rest.InClusterConfig()
+	}
+	if config == nil || err != nil {
+		config, err = kubeconfigLoader(opts).ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Token != "" || opts.TokenFile != "" {
+		config.BearerToken = opts.Token
+		config.BearerTokenFile = opts.TokenFile
+		config.Username, config.Password = "", ""
+		config.ExecProvider, config.AuthProvider = nil, nil
+		config.CertFile, config.KeyFile = "", ""
+		config.CertData, config.KeyData = nil, nil
+	}
+
+	config.UserAgent = userAgent(opts)
+	RecordRequests(config)
+	if opts.RunID != "" {
+		config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &headerRoundTripper{header: RunIDHeader, value: opts.RunID, rt: rt}
+		})
+	}
+	return config, nil
+}
+
+// kubeconfigLoader loads the kubeconfig selected by the options, falling back to the in-cluster
+// configuration when there is none.
+func kubeconfigLoader(opts ClientOptions) clientcmd.ClientConfig {
+	var (
+		loadingRules = clientcmd.NewDefaultClientConfigLoadingRules()
+		overrides    = &clientcmd.ConfigOverrides{}
+	)
+
+	if opts.Overrides != nil {
+		copied := *opts.Overrides
+		overrides = &copied
+	}
+	if opts.Context != "" {
+		overrides.CurrentContext = opts.Context
+	}
+	loadingRules.ExplicitPath = opts.Kubeconfig
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// DefaultNamespace returns the namespace the options select as kubectl does, i.e. the
+// namespace override, the namespace of the context or "default".
+func DefaultNamespace(opts ClientOptions) string {
+	namespace, _, err := kubeconfigLoader(opts).Namespace()
+	if err != nil || namespace == "" {
+		return "default"
+	}
+	return namespace
+}
+
+// userAgent returns the default User-Agent followed by the configured suffix and run ID,
+// e.g. "cluster-validator/v0.0.0 (linux/amd64) kubernetes/$Format upgrade-gate run/x2k9q".
+func userAgent(opts ClientOptions) string {
+	var (
+		parts = []string{rest.DefaultKubernetesUserAgent()}
+	)
+
+	if opts.UserAgent != "" {
+		parts = append(parts, opts.UserAgent)
+	}
+	if opts.RunID != "" {
+		parts = append(parts, "run/"+opts.RunID)
+	}
+	return strings.Join(parts, " ")
+}
+
+type headerRoundTripper struct {
+	header string
+	value  string
+	rt     http.RoundTripper
+}
+
+func (h *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = utilnet.CloneRequest(req)
+	req.Header.Set(h.header, h.value)
+	return h.rt.RoundTrip(req)
+}
+
+func (h *headerRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return h.rt
+}
+
+func GetRESTClient() (*rest.RESTClient, error) {
+	config, err := GetKubernetesConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewRESTClient(config)
+}
+
+// NewRESTClient returns a client for raw requests against the API server, e.g. cluster endpoints.
+func NewRESTClient(config *rest.Config) (*rest.RESTClient, error) {
+	config = rest.CopyConfig(config)
+	config.ContentConfig.GroupVersion = &schema.GroupVersion{Group: "", Version: "v1"}
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+
+	client, err := rest.RESTClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func GetKubernetesDynamicClient() (dynamic.Interface, error) {
+	config, err := GetKubernetesConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
+
+// KubernetesClients returns the dynamic and REST clients for the given options, both sharing
+// the same credentials.
+func KubernetesClients(opts ClientOptions) (dynamic.Interface, *rest.RESTClient, error) {
+	config, err := GetKubernetesConfigFor(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create dynamic client")
+	}
+
+	r, err := NewRESTClient(config)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create REST client")
+	}
+	return c, r, nil
+}
+
+// KubernetesMetadataClient returns a client that lists only the metadata of resources, for
+// entries that do not validate anything beyond it.
+func KubernetesMetadataClient(opts ClientOptions) (metadata.Interface, error) {
+	config, err := GetKubernetesConfigFor(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create metadata client")
+	}
+	return m, nil
+}
+
+// ContextName returns the kubeconfig context the options select, or an empty string when the
+// in-cluster configuration is used or no context is configured.
+func ContextName(opts ClientOptions) string {
+	if opts.Context != "" {
+		return opts.Context
+	}
+	if opts.Overrides != nil && opts.Overrides.CurrentContext != "" {
+		return opts.Overrides.CurrentContext
+	}
+	if opts.Kubeconfig == "" && opts.Overrides == nil {
+		if _, err := rest.InClusterConfig(); err == nil {
+			return ""
+		}
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = opts.Kubeconfig
+	raw, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return ""
+	}
+	return raw.CurrentContext
+}
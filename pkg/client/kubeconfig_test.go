@@ -0,0 +1,159 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const _kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: eks
+  cluster:
+    server: https://eks.example.com
+- name: gke
+  cluster:
+    server: https://gke.example.com
+users:
+- name: aws
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws-iam-authenticator
+      args: ["token", "-i", "cluster"]
+- name: gcp
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: gke-gcloud-auth-plugin
+contexts:
+- name: eks
+  context:
+    cluster: eks
+    user: aws
+- name: gke
+  context:
+    cluster: gke
+    user: gcp
+current-context: eks
+`
+
+func _mockKubeconfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(_kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_KubernetesConfigExecPlugin(t *testing.T) {
+	g := gomega.NewWithT(t)
+	path := _mockKubeconfig(t)
+
+	config, err := GetKubernetesConfigFor(ClientOptions{Kubeconfig: path})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(config.Host).To(gomega.Equal("https://eks.example.com"))
+	g.Expect(config.ExecProvider).NotTo(gomega.BeNil())
+	g.Expect(config.ExecProvider.Command).To(gomega.Equal("aws-iam-authenticator"))
+
+	config, err = GetKubernetesConfigFor(ClientOptions{Kubeconfig: path, Context: "gke"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(config.Host).To(gomega.Equal("https://gke.example.com"))
+	g.Expect(config.ExecProvider.Command).To(gomega.Equal("gke-gcloud-auth-plugin"))
+}
+
+func Test_ContextName(t *testing.T) {
+	g := gomega.NewWithT(t)
+	path := _mockKubeconfig(t)
+
+	g.Expect(ContextName(ClientOptions{Kubeconfig: path})).To(gomega.Equal("eks"))
+	g.Expect(ContextName(ClientOptions{Kubeconfig: path, Context: "gke"})).To(gomega.Equal("gke"))
+	g.Expect(ContextName(ClientOptions{Kubeconfig: filepath.Join(t.TempDir(), "missing")})).To(gomega.BeEmpty())
+}
+
+func Test_KubernetesConfigKubectlOverrides(t *testing.T) {
+	g := gomega.NewWithT(t)
+	path := _mockKubeconfig(t)
+
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: "gke",
+		Context:        clientcmdapi.Context{Namespace: "platform"},
+		AuthInfo:       clientcmdapi.AuthInfo{Impersonate: "auditor"},
+	}
+	config, err := GetKubernetesConfigFor(ClientOptions{Kubeconfig: path, Overrides: overrides})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(config.Host).To(gomega.Equal("https://gke.example.com"))
+	g.Expect(config.Impersonate.UserName).To(gomega.Equal("auditor"))
+
+	g.Expect(ContextName(ClientOptions{Kubeconfig: path, Overrides: overrides})).To(gomega.Equal("gke"))
+	g.Expect(DefaultNamespace(ClientOptions{Kubeconfig: path, Overrides: overrides})).To(gomega.Equal("platform"))
+	g.Expect(DefaultNamespace(ClientOptions{Kubeconfig: path})).To(gomega.Equal("default"))
+}
+
+func Test_KubernetesConfigTokenOverride(t *testing.T) {
+	g := gomega.NewWithT(t)
+	path := _mockKubeconfig(t)
+
+	config, err := GetKubernetesConfigFor(ClientOptions{Kubeconfig: path, TokenFile: "/var/run/secrets/token"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(config.ExecProvider).To(gomega.BeNil())
+	g.Expect(config.BearerTokenFile).To(gomega.Equal("/var/run/secrets/token"))
+
+	_, _, err = KubernetesClients(ClientOptions{Kubeconfig: path, Token: "secret"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = GetKubernetesConfigFor(ClientOptions{Kubeconfig: path, Token: "secret", TokenFile: "/var/run/secrets/token"})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_KubernetesClientsRunID(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"apiVersion":"v1","kind":"List","items":[]}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	kubeconfig := fmt.Sprintf("apiVersion: v1\nkind: Config\nclusters:\n- name: test\n  cluster:\n    server: %v\ncontexts:\n- name: test\n  context:\n    cluster: test\ncurrent-context: test\n", server.URL)
+	g.Expect(os.WriteFile(path, []byte(kubeconfig), 0600)).To(gomega.Succeed())
+
+	c, r, err := KubernetesClients(ClientOptions{Kubeconfig: path, UserAgent: "upgrade-gate", RunID: "x2k9q"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = c.Resource(NodeGVR).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = rawGet(r, "/readyz")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(headers).To(gomega.HaveLen(2))
+	for _, h := range headers {
+		g.Expect(h.Get(RunIDHeader)).To(gomega.Equal("x2k9q"))
+		g.Expect(h.Get("User-Agent")).To(gomega.HaveSuffix(" upgrade-gate run/x2k9q"))
+	}
+}
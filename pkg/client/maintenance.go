@@ -0,0 +1,233 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	cronMonths   = []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronWeekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// cronSchedule is a standard five field cron expression (minute, hour, day of month, month and
+// day of week) with each field stored as a bitset of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+func parseCron(spec string) (*cronSchedule, error) {
+	var (
+		fields = strings.Fields(spec)
+		c      = &cronSchedule{}
+		err    error
+	)
+
+	if len(fields) != 5 {
+		return nil, errors.Errorf("cron schedule '%v' must have 5 fields", spec)
+	}
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, cronWeekdays); err != nil {
+		return nil, err
+	}
+	// 7 is an alias for sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny, c.dowAny = fields[2] == "*", fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps, e.g. "1-5,*/15".
+func parseCronField(field string, min, max int, names []string) (uint64, error) {
+	var (
+		bits uint64
+	)
+
+	value := func(s string) (int, error) {
+		for i, name := range names {
+			if name != "" && strings.EqualFold(s, name) {
+				return i, nil
+			}
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, errors.Errorf("invalid cron value '%v', expected %v-%v", s, min, max)
+		}
+		return n, nil
+	}
+
+	for _, part := range strings.Split(field, ",") {
+		var (
+			base  = part
+			step  = 1
+			start = min
+			end   = max
+			err   error
+		)
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			base = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, errors.Errorf("invalid cron step in '%v'", part)
+			}
+		}
+
+		switch {
+		case base == "*":
+		case strings.Contains(base, "-"):
+			bounds := strings.SplitN(base, "-", 2)
+			if start, err = value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if end, err = value(bounds[1]); err != nil {
+				return 0, err
+			}
+		default:
+			if start, err = value(base); err != nil {
+				return 0, err
+			}
+			end = start
+			if strings.Contains(part, "/") {
+				end = max
+			}
+		}
+
+		if start > end {
+			return 0, errors.Errorf("invalid cron range '%v'", part)
+		}
+		for n := start; n <= end; n += step {
+			bits |= 1 << uint(n)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cronSchedule) matches(t time.Time) bool {
+	var (
+		dom = c.dom&(1<<uint(t.Day())) != 0
+		dow = c.dow&(1<<uint(t.Weekday())) != 0
+	)
+
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	// as in cron, a restricted day of month or day of week matches when either matches
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// validateMaintenanceWindows rejects windows that are neither a complete schedule nor a range.
+func validateMaintenanceWindows(windows []v1alpha1.MaintenanceWindow) error {
+	for _, w := range windows {
+		if _, err := windowOpen(w, time.Time{}); err != nil {
+			return errors.Wrapf(err, "invalid maintenance window '%v'", w.Name)
+		}
+	}
+	return nil
+}
+
+// windowOpen reports whether a maintenance window is open at the given time.
+func windowOpen(w v1alpha1.MaintenanceWindow, now time.Time) (bool, error) {
+	if w.Schedule == "" {
+		if w.Start == "" || w.End == "" {
+			return false, errors.New("a schedule and duration or a start and end are required")
+		}
+		start, err := expr.ParseTimestamp(w.Start)
+		if err != nil {
+			return false, err
+		}
+		end, err := expr.ParseTimestamp(w.End)
+		if err != nil {
+			return false, err
+		}
+		if !end.After(start) {
+			return false, errors.New("end must be after start")
+		}
+		return !now.Before(start) && now.Before(end), nil
+	}
+
+	schedule, err := parseCron(w.Schedule)
+	if err != nil {
+		return false, err
+	}
+	duration, err := expr.ParseDuration(w.Duration)
+	if err != nil || duration <= 0 {
+		return false, errors.Errorf("invalid duration '%v'", w.Duration)
+	}
+	location := time.UTC
+	if w.TimeZone != "" {
+		if location, err = time.LoadLocation(w.TimeZone); err != nil {
+			return false, errors.Wrapf(err, "invalid time zone '%v'", w.TimeZone)
+		}
+	}
+
+	// the window is open when the schedule fired within the last duration
+	for start := now.In(location).Truncate(time.Minute); now.Sub(start) < duration; start = start.Add(-time.Minute) {
+		if schedule.matches(start) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// openMaintenanceWindow returns the name of a maintenance window currently open for the entry
+// with the given ID, or an empty string.
+func (v *Validator) openMaintenanceWindow(id string) string {
+	var (
+		now = expr.Now()
+	)
+
+	for _, w := range v.Validation.Spec.MaintenanceWindows {
+		if len(w.IDs) > 0 && (id == "" || !matchInPatterns(w.IDs, id)) {
+			continue
+		}
+		open, err := windowOpen(w, now)
+		if err != nil {
+			log.Warnf("skipping invalid maintenance window '%v': %v", w.Name, err)
+			continue
+		}
+		if open {
+			return w.Name
+		}
+	}
+	return ""
+}
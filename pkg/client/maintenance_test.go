@@ -0,0 +1,93 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/onsi/gomega"
+)
+
+func Test_CronSchedule(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	c, err := parseCron("*/15 2-4 * * sun,6")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	// sunday 2023-01-01
+	g.Expect(c.matches(time.Date(2023, 1, 1, 2, 30, 0, 0, time.UTC))).To(gomega.BeTrue())
+	g.Expect(c.matches(time.Date(2023, 1, 1, 2, 31, 0, 0, time.UTC))).To(gomega.BeFalse())
+	g.Expect(c.matches(time.Date(2023, 1, 1, 5, 0, 0, 0, time.UTC))).To(gomega.BeFalse())
+	g.Expect(c.matches(time.Date(2023, 1, 7, 4, 45, 0, 0, time.UTC))).To(gomega.BeTrue())
+	g.Expect(c.matches(time.Date(2023, 1, 2, 2, 0, 0, 0, time.UTC))).To(gomega.BeFalse())
+
+	// day of month or day of week
+	c, err = parseCron("0 0 1 * 7")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(c.matches(time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC))).To(gomega.BeTrue())
+	g.Expect(c.matches(time.Date(2023, 2, 5, 0, 0, 0, 0, time.UTC))).To(gomega.BeTrue())
+	g.Expect(c.matches(time.Date(2023, 2, 6, 0, 0, 0, 0, time.UTC))).To(gomega.BeFalse())
+
+	for _, invalid := range []string{"* * * *", "60 * * * *", "* * * foo *", "5-1 * * * *", "*/0 * * * *"} {
+		_, err = parseCron(invalid)
+		g.Expect(err).To(gomega.HaveOccurred(), invalid)
+	}
+}
+
+func Test_MaintenanceWindow(t *testing.T) {
+	g := gomega.NewWithT(t)
+	expr.Now = func() time.Time { return time.Date(2023, 1, 1, 3, 0, 0, 0, time.UTC) }
+	defer func() { expr.Now = time.Now }()
+
+	weekly := v1alpha1.MaintenanceWindow{Name: "patching", IDs: []string{"nodes-*"}, Schedule: "0 2 * * sun", Duration: "2h"}
+	open, err := windowOpen(weekly, expr.Now())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(open).To(gomega.BeTrue())
+	open, _ = windowOpen(weekly, expr.Now().Add(time.Hour))
+	g.Expect(open).To(gomega.BeFalse())
+
+	g.Expect(validateMaintenanceWindows([]v1alpha1.MaintenanceWindow{{Name: "broken", Schedule: "0 2 * * sun"}})).NotTo(gomega.Succeed())
+	g.Expect(validateMaintenanceWindows([]v1alpha1.MaintenanceWindow{{Name: "range", Start: "2023-01-02T00:00:00Z", End: "2023-01-01T00:00:00Z"}})).NotTo(gomega.Succeed())
+
+	dynamic := _fakeDynamicClient()
+	_mockNode(dynamic, "test-node-2", false)
+
+	v := _mockValidator("condition_validation.yaml", dynamic, nil)
+	v.Validation.Spec.Resources[0].ID = "nodes-ready"
+	v.Validation.Spec.MaintenanceWindows = []v1alpha1.MaintenanceWindow{weekly}
+	report, err := v.ValidateWithResult()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(report.Validations[0].Status).To(gomega.Equal(ValidationStatusWarning))
+	g.Expect(report.Validations[0].LastError).To(gomega.HaveSuffix("(maintenance window 'patching')"))
+
+	// the warning is not reported as a failure by the sinks
+	metrics := new(strings.Builder)
+	g.Expect(WritePrometheusMetrics(metrics, report)).To(gomega.Succeed())
+	g.Expect(metrics.String()).To(gomega.ContainSubstring(MetricValidationSuccess + `{cluster="",validation="nodes",id="nodes-ready"} 1`))
+	out, err := junitReport(report)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(out)).NotTo(gomega.ContainSubstring("<failure"))
+	g.Expect(string(out)).To(gomega.ContainSubstring(`<skipped message="warning: `))
+
+	v = _mockValidator("condition_validation.yaml", dynamic, nil)
+	v.Validation.Spec.Resources[0].ID = "pods-running"
+	v.Validation.Spec.MaintenanceWindows = []v1alpha1.MaintenanceWindow{weekly}
+	g.Expect(v.Validate()).NotTo(gomega.Succeed())
+
+	v = _mockValidator("condition_validation.yaml", dynamic, nil)
+	v.Validation.Spec.MaintenanceWindows = []v1alpha1.MaintenanceWindow{{Name: "upgrade", Start: "2023-01-01T00:00:00Z", End: "2023-01-01T06:00:00Z"}}
+	g.Expect(v.Validate()).To(gomega.Succeed())
+}
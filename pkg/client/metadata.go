@@ -0,0 +1,98 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/pager"
+)
+
+// metadataOnly reports whether a resource entry only validates the metadata of its resources,
+// i.e. their existence, names, namespaces, labels and annotations, so they can be listed
+// through the metadata client instead of as full objects.
+func (v *Validator) metadataOnly(resource v1alpha1.ClusterResource) bool {
+	if v.Metadata == nil || v.InformerCache {
+		return false
+	}
+	if len(resource.Fields) > 0 || len(resource.Conditions) > 0 || len(resource.JQ) > 0 || len(resource.GoTemplates) > 0 {
+		return false
+	}
+	for _, agg := range resource.Aggregates {
+		if agg.Path != "" {
+			return false
+		}
+	}
+	if resource.GroupBy != nil {
+		if resource.GroupBy.Path != "" {
+			return false
+		}
+		for _, agg := range resource.GroupBy.Aggregates {
+			if agg.Path != "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// listMetadataResources lists the metadata of the resources of a GVR in a namespace, the items
+// are returned as unstructured objects that only hold apiVersion, kind and metadata.
+func (v *Validator) listMetadataResources(gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) ([]unstructured.Unstructured, error) {
+	var (
+		items = make([]unstructured.Unstructured, 0)
+	)
+
+	err := v.eachMetadataResource(gvr, namespace, opts, func(u unstructured.Unstructured) {
+		items = append(items, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// eachMetadataResource lists the metadata of the resources of a GVR in a namespace in pages, and
+// hands every item to fn as it is decoded.
+func (v *Validator) eachMetadataResource(gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions, fn func(unstructured.Unstructured)) error {
+	p := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		start := time.Now()
+		list, err := v.Metadata.Resource(gvr).Namespace(namespace).List(ctx, opts)
+		v.observeRequest(start, err)
+		return list, err
+	})
+	p.PageSize = listPageSize
+
+	err := p.EachListItem(context.Background(), opts, func(obj runtime.Object) error {
+		m, ok := obj.(*metav1.PartialObjectMetadata)
+		if !ok {
+			return errors.Errorf("unexpected list item type %T", obj)
+		}
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(m)
+		if err != nil {
+			return errors.Wrapf(err, "failed to convert metadata of '%v'", m.GetName())
+		}
+		item := unstructured.Unstructured{Object: u}
+		trimResource(&item)
+		fn(item)
+		return nil
+	})
+	return errors.Wrapf(err, "failed to list metadata of resource '%v'", gvr)
+}
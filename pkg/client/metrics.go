@@ -0,0 +1,261 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/metrics"
+)
+
+// RequestStats summarizes the API requests made by the process, so the validator's own
+// footprint on the API server can be quantified. Requests are keyed by API verb and resource,
+// e.g. "list pods", "get deployments/scale" or "get /readyz" for non-resource URLs.
+type RequestStats struct {
+	// Requests is the number of requests per verb and resource, retries are counted every time
+	// they are sent
+	Requests map[string]int `json:"requests,omitempty"`
+	// Errors is the number of failed requests per verb and resource, including non 2xx responses
+	Errors map[string]int `json:"errors,omitempty"`
+	// Retries is the number of responses per verb and resource that asked client-go to retry,
+	// i.e. a 429 or 5xx response with a Retry-After header
+	Retries map[string]int `json:"retries,omitempty"`
+	// Latency is the total time spent waiting for response headers
+	Latency time.Duration `json:"latency"`
+	// Throttled is the total time requests were delayed by the client-side rate limiter
+	Throttled time.Duration `json:"throttled"`
+}
+
+func (s RequestStats) Total() int {
+	var total int
+	for _, n := range s.Requests {
+		total += n
+	}
+	return total
+}
+
+func (s RequestStats) String() string {
+	return fmt.Sprintf("%v API requests (%v), errors (%v), retries (%v), latency %v, client-side throttling %v",
+		s.Total(), formatCounts(s.Requests), formatCounts(s.Errors), formatCounts(s.Retries),
+		s.Latency.Round(time.Millisecond), s.Throttled.Round(time.Millisecond))
+}
+
+// Since returns the requests recorded after the earlier statistics were taken.
+func (s RequestStats) Since(earlier RequestStats) RequestStats {
+	return RequestStats{
+		Requests:  subtractCounts(s.Requests, earlier.Requests),
+		Errors:    subtractCounts(s.Errors, earlier.Errors),
+		Retries:   subtractCounts(s.Retries, earlier.Retries),
+		Latency:   s.Latency - earlier.Latency,
+		Throttled: s.Throttled - earlier.Throttled,
+	}
+}
+
+func subtractCounts(counts, earlier map[string]int) map[string]int {
+	diff := make(map[string]int)
+	for k, n := range counts {
+		if n-earlier[k] > 0 {
+			diff[k] = n - earlier[k]
+		}
+	}
+	return diff
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	copied := make(map[string]int, len(counts))
+	for k, n := range counts {
+		copied[k] = n
+	}
+	return copied
+}
+
+func formatCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "none"
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%v %v", k, counts[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+type requestRecorder struct {
+	sync.Mutex
+	stats RequestStats
+}
+
+var recorder = &requestRecorder{
+	stats: RequestStats{Requests: make(map[string]int), Errors: make(map[string]int), Retries: make(map[string]int)},
+}
+
+func (r *requestRecorder) record(key string, code int, retry bool, latency time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+	r.stats.Requests[key]++
+	r.stats.Latency += latency
+	if code < 200 || code > 299 {
+		r.stats.Errors[key]++
+	}
+	if retry {
+		r.stats.Retries[key]++
+	}
+}
+
+type rateLimiterLatency struct{}
+
+func (rateLimiterLatency) Observe(_ context.Context, _ string, _ url.URL, latency time.Duration) {
+	recorder.Lock()
+	defer recorder.Unlock()
+	recorder.stats.Throttled += latency
+}
+
+// requestStatsRoundTripper records every request sent to the API server by verb and resource.
+type requestStatsRoundTripper struct {
+	rt http.RoundTripper
+}
+
+// RecordRequests wraps the transport of a config so its requests are included in the request
+// statistics, configs returned by GetKubernetesConfigFor are already wrapped.
+func RecordRequests(config *rest.Config) {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &requestStatsRoundTripper{rt: rt}
+	})
+}
+
+func (r *requestStatsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var (
+		key   = requestKey(req.Method, req.URL)
+		start = time.Now()
+	)
+
+	resp, err := r.rt.RoundTrip(req)
+	if err != nil {
+		recorder.record(key, 0, false, time.Since(start))
+		return resp, err
+	}
+	recorder.record(key, resp.StatusCode, retriedResponse(resp), time.Since(start))
+	return resp, nil
+}
+
+func (r *requestStatsRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return r.rt
+}
+
+// retriedResponse mirrors client-go, which retries 429 and 5xx responses that carry a
+// Retry-After header in seconds.
+func retriedResponse(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return false
+	}
+	_, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	return err == nil
+}
+
+// requestKey returns the API verb and resource of a request as the API server derives them, e.g.
+// "list pods" for GET /api/v1/namespaces/default/pods or "get /readyz" for non-resource URLs.
+func requestKey(method string, u *url.URL) string {
+	var (
+		segments = strings.Split(strings.Trim(u.Path, "/"), "/")
+		verb     = strings.ToLower(method)
+		parts    []string
+	)
+
+	switch {
+	case len(segments) > 2 && segments[0] == "api":
+		parts = segments[2:]
+	case len(segments) > 3 && segments[0] == "apis":
+		parts = segments[3:]
+	default:
+		return fmt.Sprintf("%v %v", verb, u.Path)
+	}
+
+	watch := u.Query().Get("watch") == "true" || u.Query().Get("watch") == "1"
+	if parts[0] == "watch" {
+		watch, parts = true, parts[1:]
+	}
+	if len(parts) > 2 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%v %v", verb, u.Path)
+	}
+
+	var (
+		resource = parts[0]
+		named    = len(parts) > 1
+	)
+	if len(parts) > 2 {
+		resource = fmt.Sprintf("%v/%v", parts[0], parts[2])
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		switch {
+		case watch:
+			verb = "watch"
+		case named:
+			verb = "get"
+		default:
+			verb = "list"
+		}
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		verb = "delete"
+		if !named {
+			verb = "deletecollection"
+		}
+	}
+	return fmt.Sprintf("%v %v", verb, resource)
+}
+
+// RegisterRequestMetrics records the time requests of all clients created by the process are
+// throttled by the client-side rate limiter, client-go only accepts a single registration so it
+// must be called before any other registration.
+func RegisterRequestMetrics() {
+	metrics.Register(metrics.RegisterOpts{
+		RateLimiterLatency: rateLimiterLatency{},
+	})
+}
+
+// RequestStatistics returns a copy of the request statistics recorded so far.
+func RequestStatistics() RequestStats {
+	recorder.Lock()
+	defer recorder.Unlock()
+
+	stats := recorder.stats
+	stats.Requests = copyCounts(recorder.stats.Requests)
+	stats.Errors = copyCounts(recorder.stats.Errors)
+	stats.Retries = copyCounts(recorder.stats.Retries)
+	return stats
+}
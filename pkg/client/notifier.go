@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Failure describes a validation that met its failure threshold.
+type Failure struct {
+	Name     string
+	Required bool
+	Error    ValidationError
+}
+
+// Notifier is told about failed validations as they happen and about the outcome of every run.
+// NotifyFailure is called from the validation goroutines, so notifiers must be safe for
+// concurrent use. Failures during an open maintenance window are not notified.
+type Notifier interface {
+	NotifyFailure(failure Failure) error
+	NotifyCompletion(report Report) error
+}
+
+// NotifierFactory creates a notifier for a target, e.g. a webhook URL or a channel, the target
+// may be empty.
+type NotifierFactory func(target string) (Notifier, error)
+
+var (
+	notifiersMu sync.RWMutex
+	notifiers   = map[string]NotifierFactory{}
+)
+
+// RegisterNotifier makes a notifier available to NewNotifier under the given name, registering
+// a name again replaces its factory.
+func RegisterNotifier(name string, factory NotifierFactory) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers[name] = factory
+}
+
+// Notifiers returns the names of all registered notifiers.
+func Notifiers() []string {
+	notifiersMu.RLock()
+	defer notifiersMu.RUnlock()
+
+	names := make([]string, 0, len(notifiers))
+	for name := range notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewNotifier creates a registered notifier from "name" or "name=target".
+func NewNotifier(spec string) (Notifier, error) {
+	name, target := spec, ""
+	if i := strings.Index(spec, "="); i >= 0 {
+		name, target = spec[:i], spec[i+1:]
+	}
+
+	notifiersMu.RLock()
+	factory, ok := notifiers[name]
+	notifiersMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unknown notifier '%v', available notifiers are %v", name, Notifiers())
+	}
+	return factory(target)
+}
+
+// SpecNotifiers creates the notifiers configured in the notifications of a spec, reading targets
+// given by environment variable at this point.
+func SpecNotifiers(spec *v1alpha1.ClusterValidation) ([]Notifier, error) {
+	var (
+		specNotifiers = make([]Notifier, 0, len(spec.Spec.Notifications))
+	)
+
+	for _, n := range spec.Spec.Notifications {
+		target := n.Target
+		if n.TargetEnv != "" {
+			target = os.Getenv(n.TargetEnv)
+			if target == "" {
+				return nil, errors.Errorf("target of notifier '%v' is not set, environment variable '%v' is empty", n.Name, n.TargetEnv)
+			}
+		}
+
+		notifier, err := NewNotifier(n.Name + "=" + target)
+		if err != nil {
+			return nil, err
+		}
+		specNotifiers = append(specNotifiers, notifier)
+	}
+	return specNotifiers, nil
+}
+
+// validateNotifications rejects notifications without a name or with both a target and a
+// target environment variable.
+func validateNotifications(spec *v1alpha1.ClusterValidation) error {
+	for i, n := range spec.Spec.Notifications {
+		if n.Name == "" {
+			return errors.Errorf("notification %v requires a name", i)
+		}
+		if n.Target != "" && n.TargetEnv != "" {
+			return errors.Errorf("notification '%v' sets both target and targetEnv", n.Name)
+		}
+	}
+	return nil
+}
+
+// notifyFailure tells every notifier about a failed validation, notifier errors are logged and
+// do not change the outcome of the validation.
+func (v *Validator) notifyFailure(name string, required bool, vErr ValidationError) {
+	failure := Failure{Name: name, Required: required, Error: vErr}
+	for _, n := range v.Notifiers {
+		if err := n.NotifyFailure(failure); err != nil {
+			log.Warnf("failed to notify failure of '%v': %v", name, err)
+		}
+	}
+}
+
+func (v *Validator) notifyCompletion(report Report) {
+	for _, n := range v.Notifiers {
+		if err := n.NotifyCompletion(report); err != nil {
+			log.Warnf("failed to notify completion: %v", err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/onsi/gomega"
+)
+
+type _mockNotifier struct {
+	sync.Mutex
+	failures []Failure
+	reports  []Report
+}
+
+func (n *_mockNotifier) NotifyFailure(failure Failure) error {
+	n.Lock()
+	defer n.Unlock()
+	n.failures = append(n.failures, failure)
+	return nil
+}
+
+func (n *_mockNotifier) NotifyCompletion(report Report) error {
+	n.Lock()
+	defer n.Unlock()
+	n.reports = append(n.reports, report)
+	return nil
+}
+
+func Test_Notifiers(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", false, runningContainer)
+	_mockNode(dynamic, "node-1", true)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					ID:         "pods-running",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+				},
+				{
+					Name:       "nodes",
+					APIVersion: "v1",
+				},
+			},
+		},
+	}
+
+	notifier := &_mockNotifier{}
+	v := NewValidator(dynamic, spec, nil)
+	v.Notifiers = []Notifier{notifier}
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	g.Expect(notifier.failures).To(gomega.HaveLen(1))
+	g.Expect(notifier.failures[0].Name).To(gomega.Equal("pods"))
+	g.Expect(notifier.failures[0].Required).To(gomega.BeFalse())
+	g.Expect(notifier.failures[0].Error.ID).To(gomega.Equal("pods-running"))
+	g.Expect(notifier.reports).To(gomega.HaveLen(1))
+	g.Expect(notifier.reports[0].Success).To(gomega.BeTrue())
+	g.Expect(notifier.reports[0].Validations).To(gomega.HaveLen(2))
+}
+
+func Test_NotifierRegistry(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	RegisterNotifier("test", func(target string) (Notifier, error) {
+		return &_mockNotifier{}, nil
+	})
+	n, err := NewNotifier("test=#platform-alerts")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(n).To(gomega.BeAssignableToTypeOf(&_mockNotifier{}))
+	g.Expect(Notifiers()).To(gomega.ContainElement("test"))
+
+	_, err = NewNotifier("carrier-pigeon")
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("unknown notifier 'carrier-pigeon'")))
+}
+
+func Test_SpecNotifiers(t *testing.T) {
+	g := gomega.NewWithT(t)
+	t.Setenv("SLACK_WEBHOOK_URL", "https://hooks.slack.com/services/T000/B000/XXXX")
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Notifications: []v1alpha1.Notification{{Name: "slack", TargetEnv: "SLACK_WEBHOOK_URL"}},
+		},
+	}
+	specNotifiers, err := SpecNotifiers(spec)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(specNotifiers).To(gomega.HaveLen(1))
+	g.Expect(specNotifiers[0].(*SlackNotifier).WebhookURL).To(gomega.Equal("https://hooks.slack.com/services/T000/B000/XXXX"))
+
+	spec.Spec.Notifications[0].TargetEnv = "UNSET_SLACK_WEBHOOK_URL"
+	_, err = SpecNotifiers(spec)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("environment variable 'UNSET_SLACK_WEBHOOK_URL' is empty")))
+
+	spec.Spec.Notifications[0].Target = "https://hooks.slack.com/services/T000/B000/YYYY"
+	g.Expect(validateNotifications(spec)).To(gomega.MatchError("notification 'slack' sets both target and targetEnv"))
+	g.Expect(validateNotifications(&v1alpha1.ClusterValidation{Spec: v1alpha1.ClusterValidationSpec{
+		Notifications: []v1alpha1.Notification{{Target: "x"}},
+	}})).To(gomega.MatchError("notification 0 requires a name"))
+}
+
+func Test_SlackNotifier(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	var (
+		message slackMessage
+		status  = http.StatusOK
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &message)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	_, err := NewNotifier("slack")
+	g.Expect(err).To(gomega.HaveOccurred())
+	n, err := NewNotifier("slack=" + ts.URL)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	field := NewFieldValidationResult(".status.phase")
+	field.ResourceErrors["value 'Pending' does not match [Running]"] = []string{"default/pod-1", "default/pod-2", "default/pod-3", "default/pod-4", "default/pod-5", "default/pod-6"}
+	started := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := Report{
+		Cluster:  v1alpha1.ClusterIdentity{Name: "prod-us-west-2"},
+		Started:  started,
+		Finished: started.Add(90 * time.Second),
+		Error:    "required validation failed",
+		Validations: []ValidationProgress{
+			{Name: "nodes", Status: ValidationStatusSucceeded},
+			{Name: "pods", ID: "pods-running", Status: ValidationStatusFailed, LastError: "resource validation failed", Summary: ValidationSummary{FieldValidation: []FieldValidationResult{field}}},
+		},
+	}
+
+	g.Expect(n.NotifyFailure(Failure{Name: "pods"})).To(gomega.Succeed())
+	g.Expect(message.Text).To(gomega.BeEmpty())
+	g.Expect(n.NotifyCompletion(report)).To(gomega.Succeed())
+	g.Expect(message.Text).To(gomega.Equal(":x: Validation of *prod-us-west-2* failed in 1m30s: required validation failed\n" +
+		"*pods (pods-running)*: resource validation failed\n" +
+		"• field .status.phase: value 'Pending' does not match [Running] on default/pod-1, default/pod-2, default/pod-3, default/pod-4, default/pod-5 and 1 more"))
+
+	report.Success = true
+	report.Validations = report.Validations[:1]
+	g.Expect(n.NotifyCompletion(report)).To(gomega.Succeed())
+	g.Expect(message.Text).To(gomega.Equal(":white_check_mark: Validation of *prod-us-west-2* succeeded in 1m30s"))
+
+	status = http.StatusNotFound
+	g.Expect(n.NotifyCompletion(report)).To(gomega.MatchError(gomega.ContainSubstring("404 Not Found")))
+}
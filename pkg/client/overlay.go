@@ -0,0 +1,185 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	overlayBaseKey  = "base"
+	overlayPatchKey = "$patch"
+	overlayDelete   = "delete"
+)
+
+// loadSpecData reads a spec file and, when it is an overlay, the chain of bases it patches. An
+// overlay names its base relative to itself and patches it: maps are merged, a null value
+// removes a key, and lists of entries are merged by id, or by name for entries without an id.
+// Overlay entries without a match are appended, and an entry with "$patch: delete" removes its
+// match. Other lists are replaced.
+func loadSpecData(path string, opts SignatureOptions, seen map[string]bool) ([]byte, error) {
+	abs := path
+	if !isRemoteSpec(path) {
+		var err error
+		if abs, err = filepath.Abs(path); err != nil {
+			return nil, SpecError{errors.Wrapf(err, "invalid path '%v'", path)}
+		}
+	}
+	if seen[abs] {
+		return nil, SpecError{errors.Errorf("overlay '%v' is its own base", path)}
+	}
+	seen[abs] = true
+
+	data, err := readSpecFile(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return nil, SpecError{errors.Errorf("failed to unmarshal manifest file '%v': %v", path, err)}
+	}
+	base, ok := overlay[overlayBaseKey].(string)
+	if !ok {
+		return data, nil
+	}
+	delete(overlay, overlayBaseKey)
+
+	basePath, err := resolveBase(path, base)
+	if err != nil {
+		return nil, err
+	}
+	// only the top file can have its signature path overridden, bases use the default suffix
+	baseData, err := loadSpecData(basePath, SignatureOptions{PublicKey: opts.PublicKey}, seen)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := make(map[string]interface{})
+	if err := yaml.Unmarshal(baseData, &spec); err != nil {
+		return nil, SpecError{errors.Errorf("failed to unmarshal manifest file '%v': %v", basePath, err)}
+	}
+	log.Infof("applying overlay '%v' to '%v'", path, basePath)
+
+	merged, err := json.Marshal(mergeOverlay(spec, overlay))
+	if err != nil {
+		return nil, SpecError{errors.Wrapf(err, "failed to apply overlay '%v'", path)}
+	}
+	return merged, nil
+}
+
+// resolveBase returns the path of an overlay's base, relative bases are resolved against the
+// directory or URL of the overlay.
+func resolveBase(path, base string) (string, error) {
+	switch {
+	case isRemoteSpec(base) || filepath.IsAbs(base) && !isRemoteSpec(path):
+		return base, nil
+	case isRemoteSpec(path):
+		u, err := url.Parse(path)
+		if err != nil {
+			return "", SpecError{errors.Wrapf(err, "invalid URL '%v'", path)}
+		}
+		ref, err := url.Parse(base)
+		if err != nil {
+			return "", SpecError{errors.Wrapf(err, "invalid base '%v' of '%v'", base, path)}
+		}
+		return u.ResolveReference(ref).String(), nil
+	default:
+		return filepath.Join(filepath.Dir(path), base), nil
+	}
+}
+
+func mergeOverlay(base, overlay interface{}) interface{} {
+	switch o := overlay.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return o
+		}
+		for k, v := range o {
+			if v == nil {
+				delete(b, k)
+				continue
+			}
+			b[k] = mergeOverlay(b[k], v)
+		}
+		return b
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok || !entryList(b) || !entryList(o) {
+			return o
+		}
+		return mergeEntries(b, o)
+	default:
+		return overlay
+	}
+}
+
+// mergeEntries merges a list of overlay entries into the base entries they match.
+func mergeEntries(base, overlay []interface{}) []interface{} {
+	for _, item := range overlay {
+		entry := item.(map[string]interface{})
+		i := matchEntry(base, entry)
+		switch {
+		case entry[overlayPatchKey] == overlayDelete:
+			if i >= 0 {
+				base = append(base[:i], base[i+1:]...)
+			}
+		case i >= 0:
+			base[i] = mergeOverlay(base[i], entry)
+		default:
+			base = append(base, entry)
+		}
+	}
+	return base
+}
+
+func matchEntry(entries []interface{}, entry map[string]interface{}) int {
+	var (
+		key, value = "name", entry["name"]
+	)
+
+	if id, ok := entry["id"]; ok {
+		key, value = "id", id
+	}
+	for i, e := range entries {
+		if fmt.Sprint(e.(map[string]interface{})[key]) == fmt.Sprint(value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// entryList returns true for lists of spec entries, which are identified by an id or a name.
+func entryList(list []interface{}) bool {
+	for _, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := entry["id"]; ok {
+			continue
+		}
+		if _, ok := entry["name"]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// validatePerGroup discovers the groups of a resource entry and validates each of them as a
+// separate validation, with its own thresholds, progress and results. It returns whether the
+// validations of all groups succeeded.
+func (v *Validator) validatePerGroup(r v1alpha1.ClusterResource) bool {
+	defer v.Waiter.Done()
+
+	var (
+		wg     sync.WaitGroup
+		failed int32
+	)
+
+	log.Infof("validating resource '%v' per '%v' group", r.Name, r.PerGroup.Label)
+	if err := v.listDynamicResource(r); err != nil {
+		if !v.singlePass {
+			v.reportError(classifyError(err))
+		}
+		return false
+	}
+
+	groups := resourceGroups(v.getValidationResources(r), r.PerGroup.Label, r.PerGroup.Groups)
+	log.Infof("resource '%v' has %v groups: %v", r.Name, len(groups), groups)
+	for _, group := range groups {
+		v.Waiter.Add(1)
+		wg.Add(1)
+		go func(group string) {
+			defer wg.Done()
+			if !v.validateResourceGroup(r, group) {
+				atomic.AddInt32(&failed, 1)
+			}
+		}(group)
+	}
+	wg.Wait()
+	return atomic.LoadInt32(&failed) == 0
+}
+
+func (v *Validator) validateResourceGroup(r v1alpha1.ClusterResource, group string) bool {
+	var (
+		name  = fmt.Sprintf("%v/%v", r.Name, group)
+		id    = r.ID
+		label = r.PerGroup.Label
+		entry = r
+	)
+
+	if id != "" {
+		id = fmt.Sprintf("%v/%v", r.ID, group)
+	}
+	if cfg, ok := r.PerGroup.Configurations[group]; ok {
+		entry.Configuration = cfg
+	}
+	entry.PerGroup = nil
+
+	evaluate := func() (ValidationSummary, error) {
+		if err := v.listDynamicResource(entry); err != nil {
+			if apierrors.IsTooManyRequests(err) {
+				return ValidationSummary{}, err
+			}
+			return ValidationSummary{}, fatalError{err}
+		}
+
+		members := make([]unstructured.Unstructured, 0)
+		for _, resource := range v.getValidationResources(entry) {
+			if value, ok := resource.GetLabels()[label]; ok && value == group {
+				members = append(members, resource)
+			}
+		}
+		if len(members) == 0 {
+			return ValidationSummary{}, errors.Errorf("no resources with label '%v=%v' matched", label, group)
+		}
+		return v.validateResources(entry, members)
+	}
+
+	onFailure := func(summary ValidationSummary) ValidationError {
+		return ValidationError{
+			ID:                   id,
+			Remediation:          r.Remediation,
+			DocsURL:              r.DocsURL,
+			Owner:                r.Owner,
+			Team:                 r.Team,
+			Runbook:              r.Runbook,
+			Message:              errors.Errorf("failure threshold met for resource '%v' group '%v'", r.Name, group),
+			GVR:                  groupVersionResource(r.APIVersion, r.Name),
+			FieldValidations:     summary.FieldValidation,
+			ConditionValidations: summary.ConditionValidation,
+			AggregateValidations: summary.AggregateValidation,
+		}
+	}
+
+	return v.runValidation(name, id, r.Required, &entry, v.suppressed(name, id, evaluate), onFailure)
+}
+
+// resourceGroups returns the sorted label values of the resources together with the expected groups.
+func resourceGroups(resources []unstructured.Unstructured, label string, expected []string) []string {
+	var (
+		seen   = make(map[string]bool)
+		groups = make([]string, 0)
+	)
+
+	for _, resource := range resources {
+		if value, ok := resource.GetLabels()[label]; ok {
+			seen[value] = true
+		}
+	}
+	for _, group := range expected {
+		seen[group] = true
+	}
+
+	for group := range seen {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// validatePerGroups rejects per group configurations that cannot be evaluated when the spec is loaded.
+func validatePerGroups(spec *v1alpha1.ClusterValidation) error {
+	for _, g := range spec.Spec.Groups {
+		for _, r := range append(g.AllOf, g.AnyOf...) {
+			if r.PerGroup != nil {
+				return errors.Errorf("resource '%v' of group '%v' cannot set perGroup", r.Name, g.Name)
+			}
+		}
+	}
+
+	for _, r := range spec.Spec.Resources {
+		if r.PerGroup == nil {
+			continue
+		}
+		if r.PerGroup.Label == "" {
+			return errors.Errorf("perGroup of resource '%v' requires a label", r.Name)
+		}
+		if r.Sharding != nil || r.Canary != nil {
+			return errors.Errorf("resource '%v' cannot combine perGroup with sharding or canary", r.Name)
+		}
+	}
+	return nil
+}
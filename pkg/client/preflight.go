@@ -0,0 +1,249 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	selfSarGVR = schema.GroupVersionResource{Group: "authorization.k8s.io", Version: "v1", Resource: "selfsubjectaccessreviews"}
+)
+
+const preflightCheckName = "preflight"
+
+// accessRequirement is a permission the validator itself needs, either on a resource or on a
+// non-resource URL such as a cluster endpoint.
+type accessRequirement struct {
+	rule v1alpha1.AccessRule
+	gvr  schema.GroupVersionResource
+	path string
+}
+
+func (a accessRequirement) String() string {
+	if a.path != "" {
+		return fmt.Sprintf("get %v", a.path)
+	}
+	return a.rule.String()
+}
+
+// preflight verifies that the validator's own identity has every permission the spec requires,
+// and reports all missing permissions at once instead of failing validations one by one.
+func (v *Validator) preflight() error {
+	var (
+		result = NewCheckValidationResult(preflightCheckName)
+		seen   = make(map[string]bool)
+	)
+	result.Code = FailureCodeAccessDenied
+
+	for _, req := range v.requiredAccess() {
+		key := req.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		allowed, err := v.reviewSelfAccess(req)
+		if err != nil {
+			return classifyError(ValidationError{Message: errors.Wrap(err, "preflight permission check failed")})
+		}
+		if !allowed {
+			result.ResourceErrors["missing permission"] = append(result.ResourceErrors["missing permission"], key)
+		}
+	}
+
+	if missing := result.ResourceErrors["missing permission"]; len(missing) > 0 {
+		return AccessError{ValidationError{
+			Message:          errors.Errorf("validator is missing permissions required by the spec: %v", strings.Join(missing, ", ")),
+			CheckValidations: []CheckValidationResult{result},
+		}}
+	}
+	return nil
+}
+
+func (v *Validator) reviewSelfAccess(req accessRequirement) (bool, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		TypeMeta: metav1.TypeMeta{Kind: "SelfSubjectAccessReview", APIVersion: "authorization.k8s.io/v1"},
+	}
+	if req.path != "" {
+		review.Spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{Path: req.path, Verb: "get"}
+	} else {
+		review.Spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+			Verb:        req.rule.Verb,
+			Group:       req.rule.Group,
+			Resource:    req.rule.Resource,
+			Subresource: req.rule.Subresource,
+			Namespace:   req.rule.Namespace,
+		}
+	}
+
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(review)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to convert access review")
+	}
+
+	out, err := v.Kubernetes.Resource(selfSarGVR).Create(context.Background(), &unstructured.Unstructured{Object: raw}, metav1.CreateOptions{})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create self subject access review")
+	}
+
+	allowed, _, _ := unstructured.NestedBool(out.Object, "status", "allowed")
+	return allowed, nil
+}
+
+// requiredAccess lists the permissions needed by every resource, endpoint, check and workload test.
+func (v *Validator) requiredAccess() []accessRequirement {
+	var (
+		reqs = make([]accessRequirement, 0)
+	)
+
+	access := func(verb string, gvr schema.GroupVersionResource, namespace string) {
+		reqs = append(reqs, accessRequirement{rule: v1alpha1.AccessRule{Verb: verb, Group: gvr.Group, Resource: gvr.Resource, Namespace: namespace}, gvr: gvr})
+	}
+	listResources := func(resources []v1alpha1.ClusterResource, watch bool) {
+		for _, r := range resources {
+			namespaces := listNamespaces(r)
+			if namespaces == nil || watch {
+				namespaces = []string{""}
+			}
+			for _, ns := range namespaces {
+				access("list", groupVersionResource(r.APIVersion, r.Name), ns)
+			}
+			if watch {
+				access("watch", groupVersionResource(r.APIVersion, r.Name), "")
+			}
+			if r.Sharding != nil {
+				access("list", namespaceGVR, "")
+			}
+		}
+	}
+
+	listResources(v.GetResources(), v.InformerCache)
+	for _, g := range v.GetGroups() {
+		listResources(append(g.AllOf, g.AnyOf...), v.InformerCache)
+	}
+
+	for _, ep := range v.GetEndpointSpec().Cluster {
+		reqs = append(reqs, accessRequirement{path: strings.SplitN(ep.URI, "?", 2)[0]})
+		if ep.Replicas != nil && len(ep.Replicas.Addresses) == 0 {
+			access("get", endpointsGVR, metav1.NamespaceDefault)
+		}
+	}
+
+	for _, c := range v.GetChecks() {
+		switch {
+		case c.CNICoverage != nil, c.DaemonSetCoverage != nil:
+			coverage := c.CNICoverage
+			if coverage == nil {
+				coverage = c.DaemonSetCoverage
+			}
+			namespace := coverage.Namespace
+			if namespace == "" {
+				namespace = metav1.NamespaceSystem
+			}
+			access("get", daemonSetGVR, namespace)
+			access("list", nodeGVR, "")
+			access("list", podGVR, namespace)
+		case c.ServiceEndpoints != nil:
+			access("list", serviceGVR, c.ServiceEndpoints.Namespace)
+			access("list", sliceGVR, c.ServiceEndpoints.Namespace)
+		case c.LoadBalancer != nil:
+			for _, kind := range c.LoadBalancer.GetKinds() {
+				if kind == v1alpha1.LoadBalancerKindIngress {
+					access("list", ingressGVR, c.LoadBalancer.Namespace)
+				} else {
+					access("list", serviceGVR, c.LoadBalancer.Namespace)
+				}
+			}
+		case c.NetworkPolicy != nil:
+			for _, path := range c.NetworkPolicy.Paths {
+				for _, namespace := range []string{path.From.Namespace, path.To.Namespace} {
+					access("create", podGVR, namespace)
+					access("delete", podGVR, namespace)
+				}
+			}
+		case c.PVCProvisioning != nil:
+			access("get", scGVR, "")
+			for _, gvr := range []schema.GroupVersionResource{pvcGVR, podGVR} {
+				access("create", gvr, c.PVCProvisioning.Namespace)
+				access("delete", gvr, c.PVCProvisioning.Namespace)
+			}
+		case c.AccessReview != nil:
+			access("create", sarGVR, "")
+		case c.FeatureGates != nil:
+			if len(c.FeatureGates.Enabled)+len(c.FeatureGates.Disabled) > 0 {
+				reqs = append(reqs, accessRequirement{path: "/metrics"})
+			}
+			if len(c.FeatureGates.KubeletFields) > 0 {
+				access("list", nodeGVR, "")
+				reqs = append(reqs, accessRequirement{rule: v1alpha1.AccessRule{Verb: "get", Resource: "nodes", Subresource: "proxy"}})
+			}
+		case c.OrphanedVolumes != nil:
+			access("list", pvGVR, "")
+		case c.ObjectCount != nil:
+			access("list", groupVersionResource(c.ObjectCount.APIVersion, c.ObjectCount.Resource), "")
+		case c.Script != nil:
+			access("list", groupVersionResource(c.Script.APIVersion, c.Script.Resource), "")
+		case c.TerminatingNamespaces != nil:
+			access("list", namespaceGVR, "")
+		case c.CrashLoop != nil, c.PendingPods != nil, c.FailedPods != nil:
+			access("list", podGVR, "")
+		case c.JobRecency != nil:
+			access("list", cronJobGVR, c.JobRecency.Namespace)
+			access("list", jobGVR, c.JobRecency.Namespace)
+		case c.VersionSkew != nil:
+			reqs = append(reqs, accessRequirement{path: "/version"})
+			access("list", nodeGVR, "")
+		case c.ZoneBalance != nil, c.CapacityMix != nil:
+			access("list", nodeGVR, "")
+		case c.InstanceGroupNodes != nil:
+			access("list", instanceGroupGVR, "")
+			access("list", nodeGVR, "")
+		case c.WebhookCA != nil:
+			for _, gvr := range []schema.GroupVersionResource{validatingWebhookGVR, mutatingWebhookGVR, apiServiceGVR} {
+				access("list", gvr, "")
+			}
+		}
+	}
+
+	for _, t := range v.GetWorkloadTests() {
+		for _, manifest := range t.Manifests {
+			u := unstructured.Unstructured{Object: manifest}
+			namespace := u.GetNamespace()
+			if namespace == "" {
+				namespace = t.Namespace
+			}
+			gvr, err := v.resourceFor(u.GroupVersionKind())
+			if err != nil {
+				// the workload test reports kinds that cannot be mapped when it runs
+				gvr, _ = meta.UnsafeGuessKindToResource(u.GroupVersionKind())
+			}
+			access("create", gvr, namespace)
+			access("delete", gvr, namespace)
+		}
+		listResources(t.Resources, false)
+	}
+
+	return reqs
+}
@@ -0,0 +1,229 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	// slowRequestLatency is the request latency above which the API server is considered under pressure
+	slowRequestLatency = time.Second
+)
+
+const maxPressureFactor = 64
+
+// apiPressure tracks how loaded the API server appears from the latency and throttling of the
+// validator's own requests, as a factor intervals are stretched by.
+type apiPressure struct {
+	sync.Mutex
+	factor float64
+}
+
+// observe doubles the factor on throttled requests, grows it on slow requests and lets it
+// decay back towards 1 on fast ones.
+func (p *apiPressure) observe(latency time.Duration, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.factor < 1 {
+		p.factor = 1
+	}
+
+	switch {
+	case apierrors.IsTooManyRequests(err):
+		p.factor *= 2
+	case latency > slowRequestLatency:
+		p.factor *= 1.5
+	default:
+		p.factor /= 1.5
+	}
+
+	if p.factor < 1 {
+		p.factor = 1
+	}
+	if p.factor > maxPressureFactor {
+		p.factor = maxPressureFactor
+	}
+}
+
+func (p *apiPressure) get() float64 {
+	p.Lock()
+	defer p.Unlock()
+	if p.factor < 1 {
+		return 1
+	}
+	return p.factor
+}
+
+// observeRequest records the latency and outcome of a request started at start.
+func (v *Validator) observeRequest(start time.Time, err error) {
+	v.pressure.observe(time.Since(start), err)
+}
+
+// adaptInterval stretches an interval by the observed API server pressure, bounded by the
+// global maxInterval. Without maxInterval intervals are never changed.
+func (v *Validator) adaptInterval(interval time.Duration) time.Duration {
+	var (
+		cfg = v.GetGlobalConfiguration()
+	)
+
+	if cfg.MaxInterval == "" {
+		return interval
+	}
+
+	max, err := expr.ParseDuration(cfg.MaxInterval)
+	if err != nil {
+		log.Warnf("failed to parse maxInterval '%v', adaptive intervals are disabled", cfg.MaxInterval)
+		return interval
+	}
+	if max <= interval {
+		return interval
+	}
+
+	adapted := time.Duration(float64(interval) * v.pressure.get())
+	if adapted > max {
+		adapted = max
+	}
+	if adapted > interval {
+		log.Debugf("API server under pressure, stretching interval from %v to %v", interval, adapted)
+	}
+	return adapted
+}
+
+// retryInterval grows the interval of a validation after consecutive failed attempts and
+// jitters it, following the backoff and jitter of its own or else the global configuration.
+func retryInterval(interval time.Duration, failures int, resourceCfg, globalCfg v1alpha1.ValidationConfiguration) time.Duration {
+	var (
+		backoff = resourceCfg.Backoff
+		jitter  = resourceCfg.Jitter
+	)
+
+	if backoff == nil {
+		backoff = globalCfg.Backoff
+	}
+	if jitter == 0 {
+		jitter = globalCfg.Jitter
+	}
+
+	if backoff != nil && failures > 1 {
+		interval = backoffInterval(interval, failures, *backoff)
+	}
+	if jitter > 100 {
+		jitter = 100
+	}
+	if jitter > 0 {
+		spread := float64(interval) * float64(jitter) / 100
+		interval += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+	return interval
+}
+
+func backoffInterval(interval time.Duration, failures int, backoff v1alpha1.BackoffConfig) time.Duration {
+	var (
+		factor = backoff.Factor
+	)
+
+	max, err := expr.ParseDuration(backoff.MaxInterval)
+	if err != nil {
+		log.Warnf("failed to parse backoff maxInterval '%v', backoff is disabled", backoff.MaxInterval)
+		return interval
+	}
+	if factor == 0 {
+		factor = 2
+	}
+	if factor <= 1 || max <= interval {
+		return interval
+	}
+
+	for i := 1; i < failures && interval < max; i++ {
+		interval = time.Duration(float64(interval) * factor)
+	}
+	if interval > max {
+		interval = max
+	}
+	return interval
+}
+
+// validateBackoff rejects backoff and jitter settings that would not grow or randomize
+// intervals as configured, in the global configuration and the configuration of every entry.
+func validateBackoff(spec *v1alpha1.ClusterValidation) error {
+	var (
+		names   = []string{"the global configuration"}
+		configs = []v1alpha1.ValidationConfiguration{spec.Spec.Configuration}
+	)
+
+	add := func(name string, cfg v1alpha1.ValidationConfiguration) {
+		names = append(names, name)
+		configs = append(configs, cfg)
+	}
+	addResource := func(r v1alpha1.ClusterResource) {
+		add(fmt.Sprintf("resource '%v'", r.Name), r.Configuration)
+		if r.PerGroup == nil {
+			return
+		}
+		for group, cfg := range r.PerGroup.Configurations {
+			add(fmt.Sprintf("group '%v' of resource '%v'", group, r.Name), cfg)
+		}
+	}
+
+	for _, r := range spec.Spec.Resources {
+		addResource(r)
+	}
+	for _, g := range spec.Spec.Groups {
+		add(fmt.Sprintf("group '%v'", g.Name), g.Configuration)
+		for _, r := range append(g.AllOf, g.AnyOf...) {
+			addResource(r)
+		}
+	}
+	for _, c := range spec.Spec.Checks {
+		add(fmt.Sprintf("check '%v'", c.Name), c.Configuration)
+	}
+	for _, t := range spec.Spec.WorkloadTests {
+		add(fmt.Sprintf("workload test '%v'", t.Name), t.Configuration)
+	}
+	for _, e := range spec.Spec.Endpoints.Cluster {
+		add(fmt.Sprintf("cluster endpoint '%v'", e.Name), e.Configuration)
+	}
+	for _, e := range spec.Spec.Endpoints.HTTP {
+		add(fmt.Sprintf("http endpoint '%v'", e.Name), e.Configuration)
+	}
+
+	for i, cfg := range configs {
+		if cfg.Jitter < 0 || cfg.Jitter > 100 {
+			return errors.Errorf("jitter of %v must be between 0 and 100, got %v", names[i], cfg.Jitter)
+		}
+		if cfg.Backoff == nil {
+			continue
+		}
+		if cfg.Backoff.Factor != 0 && cfg.Backoff.Factor < 1 {
+			return errors.Errorf("backoff factor of %v must be at least 1, got %v", names[i], cfg.Backoff.Factor)
+		}
+		if cfg.Backoff.MaxInterval == "" {
+			return errors.Errorf("backoff of %v requires a maxInterval", names[i])
+		}
+		if max, err := expr.ParseDuration(cfg.Backoff.MaxInterval); err != nil || max <= 0 {
+			return errors.Errorf("backoff maxInterval of %v must be a positive duration, got '%v'", names[i], cfg.Backoff.MaxInterval)
+		}
+	}
+	return nil
+}
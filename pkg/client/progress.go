@@ -0,0 +1,164 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ValidationStatus is the state of a validation within a run.
+type ValidationStatus string
+
+const (
+	ValidationStatusRunning     ValidationStatus = "Running"
+	ValidationStatusSucceeded   ValidationStatus = "Succeeded"
+	ValidationStatusFailed      ValidationStatus = "Failed"
+	ValidationStatusInterrupted ValidationStatus = "Interrupted"
+	// ValidationStatusWarning is a failure during an open maintenance window, or an optional entry
+	// for a resource the cluster does not serve, it does not fail the run
+	ValidationStatusWarning ValidationStatus = "Warning"
+)
+
+// ValidationProgress is the state of a single validation, kept up to date while it runs so an
+// interrupted run can still report how far every validation got.
+type ValidationProgress struct {
+	Name             string
+	ID               string
+	Status           ValidationStatus
+	Attempts         int
+	Successes        int
+	SuccessThreshold int
+	Failures         int
+	FailureThreshold int
+	LastError        string
+	// Suppressed is how many failures of the last attempt were suppressed
+	Suppressed int
+	Summary    ValidationSummary
+	// Started is when the validation started, NextAttempt when it is attempted next while running
+	Started     time.Time
+	NextAttempt time.Time
+	// Duration is the wall-clock time from the start to the last attempt, EvaluationTime the part
+	// of it spent evaluating rather than waiting between attempts
+	Duration       time.Duration
+	EvaluationTime time.Duration
+	// TimeToFirstSuccess is the time from the start to the first successful attempt, zero if none
+	TimeToFirstSuccess time.Duration
+}
+
+// Stop cancels a running validation, Validate returns ErrInterrupted and validations that did
+// not finish yet are reported as interrupted.
+func (v *Validator) Stop() {
+	v.stopOnce.Do(func() {
+		close(v.stop)
+	})
+}
+
+func (v *Validator) stopped() bool {
+	select {
+	case <-v.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep waits for d and returns false when the validation was stopped in the meantime.
+func (v *Validator) sleep(d time.Duration) bool {
+	select {
+	case <-v.stop:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// Progress returns the state of every validation started so far.
+func (v *Validator) Progress() []ValidationProgress {
+	var (
+		stopped  = v.stopped()
+		progress = make([]ValidationProgress, 0)
+	)
+
+	v.RLock()
+	defer v.RUnlock()
+	for _, p := range v.progress {
+		state := *p
+		if stopped && state.Status == ValidationStatusRunning {
+			state.Status = ValidationStatusInterrupted
+		}
+		progress = append(progress, state)
+	}
+	return progress
+}
+
+func (v *Validator) trackProgress(name, id string, successThreshold, failureThreshold int) *ValidationProgress {
+	p := &ValidationProgress{
+		Name:             name,
+		ID:               id,
+		Status:           ValidationStatusRunning,
+		SuccessThreshold: successThreshold,
+		FailureThreshold: failureThreshold,
+		Started:          time.Now(),
+	}
+
+	v.Lock()
+	v.progress = append(v.progress, p)
+	v.Unlock()
+	return p
+}
+
+func (v *Validator) updateProgress(p *ValidationProgress, update func(p *ValidationProgress)) {
+	v.Lock()
+	update(p)
+	v.Unlock()
+}
+
+// logHeartbeat periodically logs a status line per running validation until done is closed, so
+// the overall progress of long convergence waits is visible between attempt logs.
+func (v *Validator) logHeartbeat(done <-chan struct{}) {
+	ticker := time.NewTicker(v.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for _, p := range v.Progress() {
+				if p.Status != ValidationStatusRunning {
+					continue
+				}
+				log.Info(p.heartbeat())
+			}
+		}
+	}
+}
+
+func (p ValidationProgress) heartbeat() string {
+	var (
+		name = p.Name
+		next = "now"
+	)
+
+	if p.ID != "" {
+		name = fmt.Sprintf("%v (%v)", p.Name, p.ID)
+	}
+	if wait := time.Until(p.NextAttempt); wait > 0 {
+		next = "in " + wait.Round(time.Second).String()
+	}
+	return fmt.Sprintf("waiting on '%v': %v/%v successful, %v/%v failed, waited %v, next attempt %v",
+		name, p.Successes, p.SuccessThreshold, p.Failures, p.FailureThreshold, time.Since(p.Started).Round(time.Second), next)
+}
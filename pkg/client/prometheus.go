@@ -0,0 +1,90 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Names of the Prometheus metrics describing a validation run, every metric carries the cluster
+// label and the validation metrics also carry the validation name and id.
+const (
+	MetricRunSuccess          = "cluster_validator_run_success"
+	MetricRunTimestamp        = "cluster_validator_run_timestamp_seconds"
+	MetricRunDuration         = "cluster_validator_run_duration_seconds"
+	MetricValidationSuccess   = "cluster_validator_validation_success"
+	MetricValidationAttempts  = "cluster_validator_validation_attempts"
+	MetricValidationDuration  = "cluster_validator_validation_duration_seconds"
+	MetricValidationFirstPass = "cluster_validator_validation_time_to_first_success_seconds"
+)
+
+// WritePrometheusMetrics writes the metrics of a report in the Prometheus text exposition format.
+func WritePrometheusMetrics(w io.Writer, report Report) error {
+	var (
+		b       = new(strings.Builder)
+		cluster = fmt.Sprintf(`cluster="%v"`, escapeLabel(report.Cluster.Name))
+	)
+
+	gauge := func(name, help string) {
+		fmt.Fprintf(b, "# HELP %v %v\n# TYPE %v gauge\n", name, help, name)
+	}
+
+	gauge(MetricRunSuccess, "Whether the last validation run succeeded.")
+	fmt.Fprintf(b, "%v{%v} %v\n", MetricRunSuccess, cluster, boolValue(report.Success))
+	gauge(MetricRunTimestamp, "When the last validation run finished, in seconds since the epoch.")
+	fmt.Fprintf(b, "%v{%v} %v\n", MetricRunTimestamp, cluster, report.Finished.Unix())
+	gauge(MetricRunDuration, "How long the last validation run took.")
+	fmt.Fprintf(b, "%v{%v} %v\n", MetricRunDuration, cluster, report.Finished.Sub(report.Started).Seconds())
+
+	validations := []struct {
+		name, help string
+		value      func(p ValidationProgress) interface{}
+	}{
+		{MetricValidationSuccess, "Whether the validation succeeded in the last run, failures during a maintenance window count as success.", func(p ValidationProgress) interface{} {
+			return boolValue(p.Status == ValidationStatusSucceeded || p.Status == ValidationStatusWarning)
+		}},
+		{MetricValidationAttempts, "How many attempts the validation made in the last run.", func(p ValidationProgress) interface{} {
+			return p.Attempts
+		}},
+		{MetricValidationDuration, "How long the validation took in the last run.", func(p ValidationProgress) interface{} {
+			return p.Duration.Seconds()
+		}},
+		{MetricValidationFirstPass, "How long the validation took to first succeed in the last run, zero if it never did.", func(p ValidationProgress) interface{} {
+			return p.TimeToFirstSuccess.Seconds()
+		}},
+	}
+	for _, m := range validations {
+		gauge(m.name, m.help)
+		for _, p := range report.Validations {
+			fmt.Fprintf(b, "%v{%v,validation=\"%v\",id=\"%v\"} %v\n", m.name, cluster, escapeLabel(p.Name), escapeLabel(p.ID), m.value(p))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
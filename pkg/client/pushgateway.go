@@ -0,0 +1,95 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PushgatewayJob is the job the metrics of a run are pushed under.
+const PushgatewayJob = "cluster-validator"
+
+func init() {
+	RegisterSink("pushgateway", func(target string) (ResultSink, error) {
+		if target == "" {
+			return nil, errors.New("pushgateway sink requires a URL, e.g. pushgateway=http://pushgateway:9091")
+		}
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, errors.Errorf("invalid pushgateway URL '%v'", target)
+		}
+		return &PushgatewaySink{URL: strings.TrimSuffix(target, "/"), Client: newHTTPClient(nil)}, nil
+	})
+}
+
+// PushgatewaySink pushes the metrics of a run to a Prometheus Pushgateway, for batch runs that
+// exit before they could be scraped. The metrics are grouped by job and cluster and replace the
+// metrics of the previous run of the same cluster.
+type PushgatewaySink struct {
+	URL    string
+	Client *http.Client
+
+	report Report
+}
+
+func (s *PushgatewaySink) Write(report Report) error {
+	s.report = report
+	return nil
+}
+
+func (s *PushgatewaySink) Flush() error {
+	var (
+		body     = new(bytes.Buffer)
+		endpoint = fmt.Sprintf("%v/metrics/job/%v/cluster@base64/%v", s.URL, PushgatewayJob, groupingValue(s.report.Cluster.Name))
+	)
+
+	if err := WritePrometheusMetrics(body, s.report); err != nil {
+		return errors.Wrap(err, "failed to render metrics")
+	}
+
+	req, err := http.NewRequest(http.MethodPut, endpoint, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create pushgateway request")
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to push metrics to '%v'", s.URL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("failed to push metrics to '%v': %v %v", s.URL, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// groupingValue encodes a grouping key value for a pushgateway URL path, base64 allows values
+// containing slashes and the empty value is written as a single padding character.
+func groupingValue(value string) string {
+	if value == "" {
+		return "="
+	}
+	return base64.URLEncoding.EncodeToString([]byte(value))
+}
@@ -0,0 +1,169 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	endpointsGVR = schema.GroupVersionResource{Version: "v1", Resource: "endpoints"}
+)
+
+// probeReplicas gets the URI of a cluster endpoint from every API server replica individually,
+// since a load balancer in front of the replicas can mask one unhealthy member.
+func (v *Validator) probeReplicas(r v1alpha1.ClusterEndpoint, delay time.Duration) (ValidationSummary, error) {
+	var (
+		res = NewClusterEndpointValidationResult(r.Name)
+	)
+	res.ID = r.ID
+
+	addresses, err := v.apiServerReplicas(r.Replicas)
+	if err != nil {
+		res.Errors[r.URI] = err.Error()
+		return ValidationSummary{ClusterEndpointValidation: []ClusterEndpointValidationResult{res}}, err
+	}
+
+	for _, address := range addresses {
+		url := strings.TrimSuffix(address, "/") + r.URI
+		get := func(ctx context.Context) (*bytes.Buffer, error) {
+			return replicaGet(ctx, v.RESTClient, url)
+		}
+
+		var out *bytes.Buffer
+		if delay > 0 {
+			out, err = hedge(delay, get)
+		} else {
+			out, err = get(context.TODO())
+		}
+		if err != nil {
+			res.Errors[url] = err.Error()
+			continue
+		}
+		log.Debugf("replica output for %v: %v", url, out.String())
+		if err := assertJSONResponse(r.JQ, out.Bytes()); err != nil {
+			res.Code = FailureCodeJQMismatch
+			res.Errors[url] = err.Error()
+		}
+	}
+
+	if len(res.Errors) > 0 {
+		return ValidationSummary{ClusterEndpointValidation: []ClusterEndpointValidationResult{res}},
+			errors.Errorf("%v of %v API server replicas failed '%v'", len(res.Errors), len(addresses), r.URI)
+	}
+	return ValidationSummary{}, nil
+}
+
+// apiServerReplicas returns the base URLs of the API server replicas, as configured or resolved
+// from the endpoints of the default/kubernetes Service.
+func (v *Validator) apiServerReplicas(replicas *v1alpha1.APIServerReplicas) ([]string, error) {
+	var (
+		addresses = make([]string, 0)
+	)
+
+	if len(replicas.Addresses) > 0 {
+		return replicas.Addresses, nil
+	}
+
+	endpoints, err := v.Kubernetes.Resource(endpointsGVR).Namespace(metav1.NamespaceDefault).Get(context.Background(), "kubernetes", metav1.GetOptions{})
+	if err != nil {
+		return addresses, errors.Wrap(err, "failed to resolve API server replicas")
+	}
+
+	subsets, _, _ := unstructured.NestedSlice(endpoints.Object, "subsets")
+	for _, s := range subsets {
+		subset, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		port := replicaPort(subset)
+		ips, _, _ := unstructured.NestedSlice(subset, "addresses")
+		for _, a := range ips {
+			address, ok := a.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if ip, _, _ := unstructured.NestedString(address, "ip"); ip != "" {
+				addresses = append(addresses, "https://"+net.JoinHostPort(ip, port))
+			}
+		}
+	}
+
+	if len(addresses) == 0 {
+		return addresses, errors.New("the default/kubernetes endpoints list no API server replicas")
+	}
+	return addresses, nil
+}
+
+// replicaPort returns the https port of an endpoints subset, or its only port.
+func replicaPort(subset map[string]interface{}) string {
+	var (
+		port = "443"
+	)
+
+	ports, _, _ := unstructured.NestedSlice(subset, "ports")
+	for _, p := range ports {
+		entry, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		number, _, _ := unstructured.NestedInt64(entry, "port")
+		name, _, _ := unstructured.NestedString(entry, "name")
+		if name == "https" || len(ports) == 1 {
+			port = strconv.FormatInt(number, 10)
+		}
+	}
+	return port
+}
+
+// replicaGet gets a URL with the credentials and TLS configuration of the REST client, bypassing
+// its host so that a single replica is reached.
+func replicaGet(ctx context.Context, restClient *rest.RESTClient, url string) (*bytes.Buffer, error) {
+	if restClient == nil || restClient.Client == nil {
+		return nil, errors.Errorf("cannot get '%v' without a REST client, e.g. when validating a snapshot", url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := restClient.Client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reach replica")
+	}
+	defer resp.Body.Close()
+
+	buf := new(bytes.Buffer)
+	if _, err := io.Copy(buf, resp.Body); err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.Errorf("replica responded %v: %v", resp.StatusCode, strings.TrimSpace(buf.String()))
+	}
+	return buf, nil
+}
@@ -0,0 +1,136 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	RegisterSink("results", func(path string) (ResultSink, error) {
+		if path == "" {
+			return nil, errors.New("results sink requires a file path, e.g. results=results.json")
+		}
+		return &ResultsSink{Path: path}, nil
+	})
+}
+
+// ResultsSink writes a structured record of every validation of a run to a file, as JSON or
+// as YAML when the path ends with .yaml or .yml. The file is replaced every run.
+type ResultsSink struct {
+	Path string
+
+	report Report
+}
+
+// Results is the record of a validation run as written by the results sink.
+type Results struct {
+	Cluster     v1alpha1.ClusterIdentity
+	Started     time.Time
+	Finished    time.Time
+	Success     bool
+	Error       string
+	Validations []ValidationRecord
+}
+
+// ValidationRecord is the outcome of a single validation, with its attempts, timing and the
+// reasons it failed for.
+type ValidationRecord struct {
+	Name             string
+	ID               string
+	Status           ValidationStatus
+	Started          time.Time
+	Finished         time.Time
+	Attempts         int
+	Successes        int
+	SuccessThreshold int
+	Failures         int
+	FailureThreshold int
+	// Suppressed is how many failures of the last attempt were suppressed
+	Suppressed int
+	Error      string
+	Reasons    []FailureReason
+}
+
+func (s *ResultsSink) Write(report Report) error {
+	s.report = report
+	return nil
+}
+
+func (s *ResultsSink) Flush() error {
+	var (
+		results = NewResults(s.report)
+		out     []byte
+		err     error
+	)
+
+	switch strings.ToLower(filepath.Ext(s.Path)) {
+	case ".yaml", ".yml":
+		out, err = yaml.Marshal(results)
+	default:
+		out, err = json.MarshalIndent(results, "", "\t")
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal results")
+	}
+	if err := ioutil.WriteFile(s.Path, out, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write results to '%v'", s.Path)
+	}
+	return nil
+}
+
+// NewResults records the validations of a report. A validation finished at its last attempt,
+// validations that are still running when the run ends have no finish time. The error of an
+// earlier failed attempt is dropped once a validation succeeds.
+func NewResults(report Report) Results {
+	results := Results{
+		Cluster:     report.Cluster,
+		Started:     report.Started,
+		Finished:    report.Finished,
+		Success:     report.Success,
+		Error:       report.Error,
+		Validations: make([]ValidationRecord, 0),
+	}
+
+	for _, p := range report.Validations {
+		record := ValidationRecord{
+			Name:             p.Name,
+			ID:               p.ID,
+			Status:           p.Status,
+			Started:          p.Started,
+			Attempts:         p.Attempts,
+			Successes:        p.Successes,
+			SuccessThreshold: p.SuccessThreshold,
+			Failures:         p.Failures,
+			FailureThreshold: p.FailureThreshold,
+			Suppressed:       p.Suppressed,
+			Reasons:          summaryReasons(p.Summary),
+		}
+		if p.Status != ValidationStatusSucceeded {
+			record.Error = p.LastError
+		}
+		if p.Status != ValidationStatusRunning {
+			record.Finished = p.Started.Add(p.Duration)
+		}
+		results.Validations = append(results.Validations, record)
+	}
+	return results
+}
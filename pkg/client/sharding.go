@@ -0,0 +1,220 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// namespaceShard is the outcome of listing and validating one partition of namespaces.
+type namespaceShard struct {
+	namespaces []string
+	resources  []unstructured.Unstructured
+	summary    ValidationSummary
+	listErr    error
+	failed     bool
+}
+
+// validateShards validates a namespace-scoped resource entry by partitioning the namespaces in
+// its scope into shards, each listed namespace by namespace and validated on a bounded pool of
+// workers with its own progress report. Aggregates, group aggregates and unique fields span
+// namespaces, so they are evaluated once over the resources of all shards.
+func (v *Validator) validateShards(r v1alpha1.ClusterResource) (ValidationSummary, error) {
+	var (
+		gvr     = groupVersionResource(r.APIVersion, r.Name)
+		summary = ValidationSummary{}
+		all     = make([]unstructured.Unstructured, 0)
+		failed  bool
+	)
+
+	namespaces, err := v.scopedNamespaces(r.Namespaces)
+	if err != nil {
+		if apierrors.IsTooManyRequests(err) {
+			return summary, err
+		}
+		return summary, fatalError{err}
+	}
+
+	perShard, spanning := splitShardedEntry(r)
+	shards := partitionNamespaces(namespaces, r.Sharding.GetShards())
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < r.Sharding.GetWorkers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				shard := shards[i]
+				for _, ns := range shard.namespaces {
+					items, err := v.listSelectedResources(gvr, ns, listOptions(r))
+					if err != nil {
+						shard.listErr = err
+						break
+					}
+					shard.resources = append(shard.resources, scopeResources(r, items)...)
+				}
+				if shard.listErr == nil {
+					summary, err := v.validateResources(perShard, shard.resources)
+					shard.summary, shard.failed = summary, err != nil
+				}
+				logShard(r.Name, i, len(shards), shard)
+			}
+		}()
+	}
+	for i := range shards {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, shard := range shards {
+		if shard.listErr != nil {
+			if apierrors.IsTooManyRequests(shard.listErr) {
+				return summary, shard.listErr
+			}
+			return summary, fatalError{shard.listErr}
+		}
+		if shard.failed {
+			failed = true
+		}
+		all = append(all, shard.resources...)
+		summary.FieldValidation = mergeFieldResults(summary.FieldValidation, shard.summary.FieldValidation)
+		summary.ConditionValidation = mergeConditionResults(summary.ConditionValidation, shard.summary.ConditionValidation)
+	}
+
+	rest, err := v.validateResources(spanning, all)
+	if err != nil {
+		failed = true
+	}
+	summary.FieldValidation = mergeFieldResults(summary.FieldValidation, rest.FieldValidation)
+	summary.AggregateValidation = rest.AggregateValidation
+
+	if failed {
+		return summary, errors.New("failed to validate resources")
+	}
+	return summary, nil
+}
+
+// scopedNamespaces returns the sorted names of the namespaces within a selection scope.
+func (v *Validator) scopedNamespaces(scope *v1alpha1.SelectionScope) ([]string, error) {
+	var (
+		names = make([]string, 0)
+	)
+
+	list, err := v.Kubernetes.Resource(namespaceGVR).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list namespaces")
+	}
+
+	for _, ns := range list.Items {
+		if inSelectionScope(scope, ns.GetName()) {
+			names = append(names, ns.GetName())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// partitionNamespaces splits sorted namespaces into at most n contiguous shards of similar size.
+func partitionNamespaces(namespaces []string, n int) []*namespaceShard {
+	var (
+		shards = make([]*namespaceShard, 0, n)
+	)
+
+	if n > len(namespaces) {
+		n = len(namespaces)
+	}
+	for i := 0; i < n; i++ {
+		shards = append(shards, &namespaceShard{
+			namespaces: namespaces[i*len(namespaces)/n : (i+1)*len(namespaces)/n],
+		})
+	}
+	return shards
+}
+
+// splitShardedEntry splits a resource entry into the validations evaluated per shard and the
+// validations that need the resources of all shards.
+func splitShardedEntry(r v1alpha1.ClusterResource) (perShard, spanning v1alpha1.ClusterResource) {
+	perShard = v1alpha1.ClusterResource{Name: r.Name, ID: r.ID, Conditions: r.Conditions, JQ: r.JQ, GoTemplates: r.GoTemplates}
+	spanning = v1alpha1.ClusterResource{Name: r.Name, ID: r.ID, Aggregates: r.Aggregates, GroupBy: r.GroupBy}
+
+	for _, f := range r.Fields {
+		if f.Unique {
+			spanning.Fields = append(spanning.Fields, f)
+		} else {
+			perShard.Fields = append(perShard.Fields, f)
+		}
+	}
+	return perShard, spanning
+}
+
+func logShard(name string, index, total int, shard *namespaceShard) {
+	switch {
+	case shard.listErr != nil:
+		log.Warnf("shard %v/%v of '%v' failed (%v namespaces) -> %v", index+1, total, name, len(shard.namespaces), shard.listErr)
+	case shard.failed:
+		log.Warnf("shard %v/%v of '%v' has failed validations (%v namespaces, %v resources)", index+1, total, name, len(shard.namespaces), len(shard.resources))
+	default:
+		log.Infof("shard %v/%v of '%v' validated (%v namespaces, %v resources)", index+1, total, name, len(shard.namespaces), len(shard.resources))
+	}
+}
+
+func mergeFieldResults(results, more []FieldValidationResult) []FieldValidationResult {
+	for _, m := range more {
+		merged := false
+		for _, r := range results {
+			if r.FieldPath == m.FieldPath {
+				mergeResourceErrors(r.ResourceErrors, m.ResourceErrors)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			results = append(results, m)
+		}
+	}
+	return results
+}
+
+func mergeConditionResults(results, more []ConditionValidationResult) []ConditionValidationResult {
+	for _, m := range more {
+		merged := false
+		for _, r := range results {
+			if r.Condition == m.Condition {
+				mergeResourceErrors(r.ResourceErrors, m.ResourceErrors)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			results = append(results, m)
+		}
+	}
+	return results
+}
+
+func mergeResourceErrors(into, from map[string][]string) {
+	for reason, names := range from {
+		into[reason] = append(into[reason], names...)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	signatureFileSuffix = ".sig"
+	ociSpecPrefix       = "oci://"
+)
+
+var specHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// SignatureOptions configures the verification of a detached spec signature.
+type SignatureOptions struct {
+	// PublicKey is the path to a PEM encoded public key, verification is enabled when it is set
+	PublicKey string
+	// Signature is the path or URL of the signature, defaults to the spec path with a .sig suffix
+	Signature string
+}
+
+// ParseSignedValidationSpec verifies the detached signature of a spec file or http(s) URL, and of
+// the bases of an overlay, before parsing it. The verified bytes are the ones parsed, so the files cannot
+// change in between.
+func ParseSignedValidationSpec(path string, opts SignatureOptions) (*v1alpha1.ClusterValidation, error) {
+	data, err := loadSpecData(path, opts, make(map[string]bool))
+	if err != nil {
+		return &v1alpha1.ClusterValidation{}, err
+	}
+	return parseValidationSpecData(data)
+}
+
+// readSpecFile reads a spec file or URL and verifies its signature when a public key is given.
+func readSpecFile(path string, opts SignatureOptions) ([]byte, error) {
+	var (
+		signaturePath = opts.Signature
+	)
+
+	data, err := readSpecSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.PublicKey == "" {
+		return data, nil
+	}
+	if signaturePath == "" {
+		signaturePath = path + signatureFileSuffix
+	}
+
+	signature, err := readSpecSource(signaturePath)
+	if err != nil {
+		return nil, SpecError{errors.Errorf("could not read signature '%v': %v", signaturePath, err)}
+	}
+	publicKey, err := ioutil.ReadFile(opts.PublicKey)
+	if err != nil {
+		return nil, SpecError{errors.Errorf("could not read public key '%v': %v", opts.PublicKey, err)}
+	}
+
+	if err := VerifySignature(data, signature, publicKey); err != nil {
+		return nil, SpecError{errors.Wrapf(err, "signature verification of '%v' failed", path)}
+	}
+	log.Infof("verified signature of '%v' with '%v'", path, opts.PublicKey)
+	return data, nil
+}
+
+// isRemoteSpec returns whether a spec path is a URL rather than a local file.
+func isRemoteSpec(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") || strings.HasPrefix(path, ociSpecPrefix)
+}
+
+// readSpecSource reads a local file or downloads an http(s) URL. OCI references are refused
+// rather than read unverified, they must be pulled first, e.g. with `oras pull`.
+func readSpecSource(path string) ([]byte, error) {
+	if strings.HasPrefix(path, ociSpecPrefix) {
+		return nil, SpecError{errors.Errorf("'%v' is an OCI reference, pull the spec and its signature first", path)}
+	}
+	if !isRemoteSpec(path) {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return nil, SpecError{errors.Errorf("path '%v' does not exist", path)}
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, SpecError{errors.Errorf("could not read file '%v': %v", path, err)}
+		}
+		return data, nil
+	}
+
+	resp, err := specHTTPClient.Get(path)
+	if err != nil {
+		return nil, SpecError{errors.Errorf("could not download '%v': %v", path, err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, SpecError{errors.Errorf("could not download '%v': %v", path, resp.Status)}
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, SpecError{errors.Errorf("could not download '%v': %v", path, err)}
+	}
+	return data, nil
+}
+
+// VerifySignature verifies a detached signature over data with a PEM encoded ECDSA, RSA or
+// Ed25519 public key. Signatures are base64 encoded as written by `cosign sign-blob`, or raw.
+func VerifySignature(data, signature, publicKeyPEM []byte) error {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return errors.New("public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse public key")
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature))); err == nil {
+		signature = decoded
+	}
+	digest := sha256.Sum256(data)
+
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], signature) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, data, signature) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return errors.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
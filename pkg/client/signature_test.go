@@ -0,0 +1,117 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func _mockSigningKey(t *testing.T, dir string) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "cosign.pub")
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return key, path
+}
+
+func _mockSign(t *testing.T, key *ecdsa.PrivateKey, data []byte, path string) {
+	digest := sha256.Sum256(data)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(signature)), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_SignedValidationSpec(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+	key, publicKey := _mockSigningKey(t, dir)
+
+	data, err := ioutil.ReadFile(filepath.Join(testBasePath, "template_validation.yaml"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	specPath := filepath.Join(dir, "spec.yaml")
+	g.Expect(ioutil.WriteFile(specPath, data, 0644)).To(gomega.Succeed())
+	_mockSign(t, key, data, specPath+signatureFileSuffix)
+
+	spec, err := ParseSignedValidationSpec(specPath, SignatureOptions{PublicKey: publicKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(spec.Spec.Resources).NotTo(gomega.BeEmpty())
+
+	g.Expect(ioutil.WriteFile(specPath, append(data, []byte("\n# tampered\n")...), 0644)).To(gomega.Succeed())
+	_, err = ParseSignedValidationSpec(specPath, SignatureOptions{PublicKey: publicKey})
+	g.Expect(err).To(gomega.MatchError(ErrSpec))
+	g.Expect(err.Error()).To(gomega.ContainSubstring("invalid signature"))
+
+	_, err = ParseSignedValidationSpec(specPath, SignatureOptions{PublicKey: publicKey, Signature: filepath.Join(dir, "missing.sig")})
+	g.Expect(err).To(gomega.MatchError(ErrSpec))
+}
+
+func Test_SignedRemoteValidationSpec(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+	key, publicKey := _mockSigningKey(t, dir)
+
+	data, err := ioutil.ReadFile(filepath.Join(testBasePath, "template_validation.yaml"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	overlay := []byte("base: base.yaml\nspec:\n  configuration:\n    interval: 7s\n")
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "base.yaml"), data, 0644)).To(gomega.Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "overlay.yaml"), overlay, 0644)).To(gomega.Succeed())
+	_mockSign(t, key, data, filepath.Join(dir, "base.yaml"+signatureFileSuffix))
+	_mockSign(t, key, overlay, filepath.Join(dir, "overlay.yaml"+signatureFileSuffix))
+
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+
+	// the overlay and its base are downloaded and both signatures are verified
+	spec, err := ParseSignedValidationSpec(server.URL+"/overlay.yaml", SignatureOptions{PublicKey: publicKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(spec.Spec.Configuration.Interval).To(gomega.Equal("7s"))
+	g.Expect(spec.Spec.Resources).NotTo(gomega.BeEmpty())
+
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "base.yaml"), append(data, []byte("\n# tampered\n")...), 0644)).To(gomega.Succeed())
+	_, err = ParseSignedValidationSpec(server.URL+"/overlay.yaml", SignatureOptions{PublicKey: publicKey})
+	g.Expect(err).To(gomega.MatchError(ErrSpec))
+	g.Expect(err.Error()).To(gomega.ContainSubstring("invalid signature"))
+
+	_, err = ParseSignedValidationSpec(server.URL+"/missing.yaml", SignatureOptions{PublicKey: publicKey})
+	g.Expect(err).To(gomega.MatchError(ErrSpec))
+	g.Expect(err.Error()).To(gomega.ContainSubstring("404"))
+
+	_, err = ParseSignedValidationSpec("oci://registry.example.com/specs/prod:v1", SignatureOptions{PublicKey: publicKey})
+	g.Expect(err).To(gomega.MatchError(ErrSpec))
+	g.Expect(err.Error()).To(gomega.ContainSubstring("OCI reference"))
+}
@@ -0,0 +1,243 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Report is the outcome of a validation run as handed to result sinks.
+type Report struct {
+	Cluster  v1alpha1.ClusterIdentity
+	Started  time.Time
+	Finished time.Time
+	Success  bool
+	// Error and Codes describe why the run failed, they are empty for a successful run
+	Error string
+	Codes []FailureCode
+	// Summary holds the results of the validations that failed the run
+	Summary     ValidationSummary
+	Validations []ValidationProgress
+	// RequestStats holds the API requests made by the process while the run was in progress
+	RequestStats RequestStats
+}
+
+// ResultSink receives the report of every validation run. Write is called once per run and
+// Flush right after it, so sinks may buffer in Write and publish in Flush.
+type ResultSink interface {
+	Write(report Report) error
+	Flush() error
+}
+
+// SinkFactory creates a sink for a target, e.g. a path or a URL, the target may be empty.
+type SinkFactory func(target string) (ResultSink, error)
+
+var (
+	sinksMu sync.RWMutex
+	sinks   = map[string]SinkFactory{
+		"stdout": func(format string) (ResultSink, error) {
+			if format != "" && format != "json" && format != "yaml" {
+				return nil, errors.Errorf("unsupported stdout format '%v', must be json or yaml", format)
+			}
+			return &WriterSink{Writer: os.Stdout, Format: format}, nil
+		},
+	}
+)
+
+// RegisterSink makes a sink available to NewSink under the given name, registering a name
+// again replaces its factory.
+func RegisterSink(name string, factory SinkFactory) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks[name] = factory
+}
+
+// Sinks returns the names of all registered sinks.
+func Sinks() []string {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+
+	names := make([]string, 0, len(sinks))
+	for name := range sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSink creates a registered sink from "name" or "name=target", e.g. "stdout".
+func NewSink(spec string) (ResultSink, error) {
+	name, target := spec, ""
+	if i := strings.Index(spec, "="); i >= 0 {
+		name, target = spec[:i], spec[i+1:]
+	}
+
+	sinksMu.RLock()
+	factory, ok := sinks[name]
+	sinksMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unknown sink '%v', available sinks are %v", name, Sinks())
+	}
+	return factory(target)
+}
+
+// WriterSink writes every report as indented JSON, or as YAML when Format is "yaml", to a
+// writer, it is the default stdout printer.
+type WriterSink struct {
+	Writer io.Writer
+	Format string
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{Writer: w}
+}
+
+func (s *WriterSink) Write(report Report) error {
+	if s.Format == "yaml" {
+		out, err := yaml.Marshal(report)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal report")
+		}
+		_, err = s.Writer.Write(out)
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal report")
+	}
+	_, err = s.Writer.Write(append(out, '\n'))
+	return err
+}
+
+func (s *WriterSink) Flush() error {
+	return nil
+}
+
+// Report returns the report of a run that started at the given time and ended with err.
+func (v *Validator) Report(started time.Time, err error) Report {
+	report := Report{
+		Cluster:     v.Validation.Spec.Cluster,
+		Started:     started,
+		Finished:    time.Now(),
+		Success:     err == nil,
+		Validations: v.Progress(),
+	}
+	if err != nil {
+		vErr := ToValidationError(err)
+		report.Error = err.Error()
+		if vErr.Message != nil {
+			report.Error = vErr.Message.Error()
+		}
+		report.Codes = vErr.Codes()
+		report.Summary = ValidationSummary{
+			FieldValidation:           vErr.FieldValidations,
+			ConditionValidation:       vErr.ConditionValidations,
+			AggregateValidation:       vErr.AggregateValidations,
+			CheckValidation:           vErr.CheckValidations,
+			ClusterEndpointValidation: vErr.ClusterEndpointValidations,
+			HTTPEndpointValidation:    vErr.HTTPEndpointValidations,
+		}
+	}
+	return report
+}
+
+// FailureReason is a reason a validation failed for, with the resources, URLs or replicas it
+// was found on.
+type FailureReason struct {
+	// Validation names the failed part of the entry, e.g. "field .status.phase"
+	Validation string
+	Reason     string
+	Resources  []string
+}
+
+// summaryReasons flattens the results of a summary into its failure reasons, grouped by reason.
+func summaryReasons(s ValidationSummary) []FailureReason {
+	var (
+		reasons = make([]FailureReason, 0)
+	)
+
+	resourceErrors := func(validation string, errs map[string][]string) {
+		for _, reason := range sortedReasonKeys(errs) {
+			reasons = append(reasons, FailureReason{Validation: validation, Reason: reason, Resources: errs[reason]})
+		}
+	}
+	endpointErrors := func(validation string, errs map[string]string) {
+		byReason := make(map[string][]string)
+		for target, reason := range errs {
+			byReason[reason] = append(byReason[reason], target)
+		}
+		for _, reason := range sortedReasonKeys(byReason) {
+			sort.Strings(byReason[reason])
+			reasons = append(reasons, FailureReason{Validation: validation, Reason: reason, Resources: byReason[reason]})
+		}
+	}
+
+	for _, r := range s.FieldValidation {
+		resourceErrors(fmt.Sprintf("field %v", r.FieldPath), r.ResourceErrors)
+	}
+	for _, r := range s.ConditionValidation {
+		resourceErrors(fmt.Sprintf("condition %v", r.Condition), r.ResourceErrors)
+	}
+	for _, r := range s.AggregateValidation {
+		reasons = append(reasons, FailureReason{Validation: fmt.Sprintf("aggregate %v", r.Aggregate), Reason: r.Error})
+	}
+	for _, r := range s.CheckValidation {
+		if r.Error != "" {
+			reasons = append(reasons, FailureReason{Validation: fmt.Sprintf("check %v", r.Check), Reason: r.Error})
+		}
+		resourceErrors(fmt.Sprintf("check %v", r.Check), r.ResourceErrors)
+	}
+	for _, r := range s.ClusterEndpointValidation {
+		endpointErrors(fmt.Sprintf("endpoint %v", r.Name), r.Errors)
+	}
+	for _, r := range s.HTTPEndpointValidation {
+		endpointErrors(fmt.Sprintf("endpoint %v", r.Name), r.Errors)
+	}
+	return reasons
+}
+
+func sortedReasonKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeReport hands the report of the run to every sink, sink errors are logged and do not
+// change the outcome of the run.
+func (v *Validator) writeReport(report Report) {
+	for _, sink := range v.Sinks {
+		if err := sink.Write(report); err != nil {
+			log.Warnf("failed to write report: %v", err)
+			continue
+		}
+		if err := sink.Flush(); err != nil {
+			log.Warnf("failed to flush report: %v", err)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/onsi/gomega"
+)
+
+type _mockSink struct {
+	reports []Report
+	flushed int
+}
+
+func (s *_mockSink) Write(report Report) error {
+	s.reports = append(s.reports, report)
+	return nil
+}
+
+func (s *_mockSink) Flush() error {
+	s.flushed++
+	return nil
+}
+
+func Test_ResultSinks(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", false, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Cluster:       v1alpha1.ClusterIdentity{Name: "prod-us-west-2"},
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+			},
+		},
+	}
+
+	sink := &_mockSink{}
+	buf := new(bytes.Buffer)
+	v := NewValidator(dynamic, spec, nil)
+	v.Sinks = []ResultSink{sink, NewWriterSink(buf)}
+	g.Expect(v.Validate()).NotTo(gomega.Succeed())
+
+	g.Expect(sink.reports).To(gomega.HaveLen(1))
+	g.Expect(sink.flushed).To(gomega.Equal(1))
+	report := sink.reports[0]
+	g.Expect(report.Success).To(gomega.BeFalse())
+	g.Expect(report.Cluster.Name).To(gomega.Equal("prod-us-west-2"))
+	g.Expect(report.Codes).To(gomega.ConsistOf(FailureCodeFieldMismatch))
+	g.Expect(report.Validations).To(gomega.HaveLen(1))
+	g.Expect(report.Validations[0].Status).To(gomega.Equal(ValidationStatusFailed))
+	g.Expect(report.Finished).NotTo(gomega.BeTemporally("<", report.Started))
+
+	var printed Report
+	g.Expect(json.Unmarshal(buf.Bytes(), &printed)).To(gomega.Succeed())
+	g.Expect(printed.Error).To(gomega.Equal(report.Error))
+}
+
+func Test_ReportRequestStats(t *testing.T) {
+	g := gomega.NewWithT(t)
+	server := _mockServer(t, "ok", 200)
+	defer server.Close()
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 2, FailureThreshold: 1, Interval: "1ms"},
+			Endpoints: v1alpha1.EndpointsSpec{
+				Cluster: []v1alpha1.ClusterEndpoint{{Name: "healthz", URI: "/healthz", Required: true}},
+			},
+		},
+	}
+
+	// the sinks receive the requests of the run along with its results
+	sink := &_mockSink{}
+	v := NewValidator(_fakeDynamicClient(), spec, _mockRESTClient(server.URL))
+	v.Sinks = []ResultSink{sink}
+	g.Expect(v.Validate()).To(gomega.Succeed())
+	g.Expect(sink.reports).To(gomega.HaveLen(1))
+	g.Expect(sink.reports[0].RequestStats.Requests).To(gomega.HaveKeyWithValue("get /healthz", 2))
+	g.Expect(sink.reports[0].RequestStats.Errors).To(gomega.BeEmpty())
+}
+
+func Test_ValidateWithResult(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 2, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					ID:         "pods-running",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+			},
+		},
+	}
+
+	report, err := NewValidator(dynamic, spec, nil).ValidateWithResult()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(report.Success).To(gomega.BeTrue())
+	g.Expect(report.Error).To(gomega.BeEmpty())
+	g.Expect(report.Validations).To(gomega.HaveLen(1))
+	g.Expect(report.Validations[0].ID).To(gomega.Equal("pods-running"))
+	g.Expect(report.Validations[0].Status).To(gomega.Equal(ValidationStatusSucceeded))
+	g.Expect(report.Validations[0].Attempts).To(gomega.Equal(2))
+	g.Expect(report.Validations[0].Duration).To(gomega.BeNumerically(">", 0))
+
+	spec.Spec.Resources[0].Fields[0].Values = []string{"Pending"}
+	report, err = NewValidator(dynamic, spec, nil).ValidateWithResult()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(report.Success).To(gomega.BeFalse())
+	g.Expect(report.Codes).To(gomega.ConsistOf(FailureCodeFieldMismatch))
+	g.Expect(report.Validations[0].Summary.FieldValidation).To(gomega.HaveLen(1))
+	g.Expect(report.Summary.FieldValidation).To(gomega.Equal(report.Validations[0].Summary.FieldValidation))
+}
+
+func Test_SinkRegistry(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	sink, err := NewSink("stdout")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(sink).To(gomega.BeAssignableToTypeOf(&WriterSink{}))
+
+	sink, err = NewSink("stdout=yaml")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	buf := new(bytes.Buffer)
+	sink.(*WriterSink).Writer = buf
+	g.Expect(sink.Write(Report{Success: true})).To(gomega.Succeed())
+	g.Expect(buf.String()).To(gomega.ContainSubstring("Success: true\n"))
+
+	_, err = NewSink("stdout=xml")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	var target string
+	RegisterSink("test", func(t string) (ResultSink, error) {
+		target = t
+		return &_mockSink{}, nil
+	})
+	_, err = NewSink("test=s3://bucket/reports")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(target).To(gomega.Equal("s3://bucket/reports"))
+	g.Expect(Sinks()).To(gomega.ContainElements("stdout", "test"))
+
+	_, err = NewSink("carrier-pigeon")
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("unknown sink 'carrier-pigeon'")))
+}
+
+func Test_JUnitSink(t *testing.T) {
+	g := gomega.NewWithT(t)
+	path := filepath.Join(t.TempDir(), "junit.xml")
+
+	_, err := NewSink("junit")
+	g.Expect(err).To(gomega.HaveOccurred())
+	sink, err := NewSink("junit=" + path)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	field := NewFieldValidationResult(".status.phase")
+	field.ResourceErrors["value 'Pending' does not match [Running]"] = []string{"default/pod-1", "default/pod-2"}
+	started := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := Report{
+		Cluster:  v1alpha1.ClusterIdentity{Name: "prod-us-west-2"},
+		Started:  started,
+		Finished: started.Add(90 * time.Second),
+		Validations: []ValidationProgress{
+			{Name: "nodes", ID: "nodes-ready", Status: ValidationStatusSucceeded, Duration: 1500 * time.Millisecond},
+			{Name: "pods", Status: ValidationStatusFailed, LastError: "resource validation failed", Summary: ValidationSummary{FieldValidation: []FieldValidationResult{field}}},
+			{Name: "healthz", Status: ValidationStatusInterrupted},
+			{Name: "volumes", Status: ValidationStatusSucceeded, Suppressed: 2},
+		},
+	}
+	g.Expect(sink.Write(report)).To(gomega.Succeed())
+	g.Expect(sink.Flush()).To(gomega.Succeed())
+
+	out, err := ioutil.ReadFile(path)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	var suites junitTestSuites
+	g.Expect(xml.Unmarshal(out, &suites)).To(gomega.Succeed())
+	g.Expect(suites.Suites).To(gomega.HaveLen(1))
+
+	suite := suites.Suites[0]
+	g.Expect(suite.Name).To(gomega.Equal("prod-us-west-2"))
+	g.Expect(suite.Tests).To(gomega.Equal(4))
+	g.Expect(suite.Failures).To(gomega.Equal(1))
+	g.Expect(suite.Skipped).To(gomega.Equal(2))
+	g.Expect(suite.Time).To(gomega.Equal("90.000"))
+
+	g.Expect(suite.Cases[0].Name).To(gomega.Equal("nodes [nodes-ready]"))
+	g.Expect(suite.Cases[0].Time).To(gomega.Equal("1.500"))
+	g.Expect(suite.Cases[0].Failure).To(gomega.BeNil())
+	g.Expect(suite.Cases[1].Failure.Message).To(gomega.Equal("resource validation failed"))
+	g.Expect(suite.Cases[1].Failure.Type).To(gomega.Equal("FIELD_MISMATCH"))
+	g.Expect(suite.Cases[1].Failure.Text).To(gomega.Equal("field .status.phase: value 'Pending' does not match [Running]: default/pod-1, default/pod-2"))
+	g.Expect(suite.Cases[2].Skipped.Message).To(gomega.Equal("validation did not finish (interrupted)"))
+	g.Expect(suite.Cases[3].Skipped.Message).To(gomega.Equal("2 failures suppressed"))
+}
+
+func Test_DocumentSink(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+
+	_, err := NewSink("markdown")
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = renderDocument(Report{}, "pdf")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	field := NewFieldValidationResult(".status.phase")
+	field.ResourceErrors["value 'Pending' does not match [Running|Succeeded]"] = []string{"default/pod-1", "default/pod-2"}
+	endpoint := NewHTTPEndpointValidationResult("ingress")
+	endpoint.Errors["https://a.example.com"] = "status 503"
+	endpoint.Errors["https://b.example.com"] = "status 503"
+	started := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := Report{
+		Cluster:  v1alpha1.ClusterIdentity{Name: "prod-us-west-2"},
+		Started:  started,
+		Finished: started.Add(90 * time.Second),
+		Error:    "validation failed",
+		Codes:    []FailureCode{FailureCodeFieldMismatch},
+		Validations: []ValidationProgress{
+			{Name: "nodes", ID: "nodes-ready", Status: ValidationStatusSucceeded, Successes: 3, SuccessThreshold: 3, Suppressed: 1, Duration: 1500 * time.Millisecond},
+			{Name: "pods", Status: ValidationStatusFailed, LastError: "resource validation failed", Failures: 2, FailureThreshold: 2, Summary: ValidationSummary{
+				FieldValidation:        []FieldValidationResult{field},
+				HTTPEndpointValidation: []HTTPEndpointValidationResult{endpoint},
+			}},
+		},
+	}
+
+	for _, format := range []string{DocumentFormatMarkdown, DocumentFormatHTML} {
+		path := filepath.Join(dir, "report."+documentExtension(format))
+		sink, err := NewSink(format + "=" + path)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(sink.Write(report)).To(gomega.Succeed())
+		g.Expect(sink.Flush()).To(gomega.Succeed())
+	}
+
+	md, err := ioutil.ReadFile(filepath.Join(dir, "report.md"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(md)).To(gomega.ContainSubstring("# Cluster validation: prod-us-west-2"))
+	g.Expect(string(md)).To(gomega.ContainSubstring("**Failed**, started 2023-01-01T00:00:00Z, took 1m30s"))
+	g.Expect(string(md)).To(gomega.ContainSubstring("| nodes | nodes-ready | Succeeded (1 suppressed) | 0 | 3/3 | 0/0 | 1.5s |"))
+	g.Expect(string(md)).To(gomega.ContainSubstring(`| field .status.phase | value 'Pending' does not match [Running\|Succeeded] | default/pod-1, default/pod-2 |`))
+	g.Expect(string(md)).To(gomega.ContainSubstring("| endpoint ingress | status 503 | https://a.example.com, https://b.example.com |"))
+	g.Expect(string(md)).NotTo(gomega.ContainSubstring("## nodes"))
+
+	html, err := ioutil.ReadFile(filepath.Join(dir, "report.html"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(html)).To(gomega.ContainSubstring("<h2>pods</h2>"))
+	g.Expect(string(html)).To(gomega.ContainSubstring("<td>value &#39;Pending&#39; does not match [Running|Succeeded]</td>"))
+}
+
+func Test_ResultsSink(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+
+	_, err := NewSink("results")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	field := NewFieldValidationResult(".status.phase")
+	field.ResourceErrors["value 'Pending' does not match [Running]"] = []string{"default/pod-1"}
+	started := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := Report{
+		Started:  started,
+		Finished: started.Add(time.Minute),
+		Validations: []ValidationProgress{
+			{Name: "nodes", Status: ValidationStatusSucceeded, Attempts: 4, Successes: 3, Failures: 1, Suppressed: 1, LastError: "node not ready", Started: started, Duration: 30 * time.Second},
+			{Name: "pods", ID: "pods-running", Status: ValidationStatusFailed, Attempts: 2, Failures: 2, LastError: "resource validation failed", Started: started, Duration: 10 * time.Second,
+				Summary: ValidationSummary{FieldValidation: []FieldValidationResult{field}}},
+			{Name: "healthz", Status: ValidationStatusRunning, Started: started},
+		},
+	}
+
+	for _, name := range []string{"results.json", "results.yaml"} {
+		sink, err := NewSink("results=" + filepath.Join(dir, name))
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(sink.Write(report)).To(gomega.Succeed())
+		g.Expect(sink.Flush()).To(gomega.Succeed())
+	}
+
+	out, err := ioutil.ReadFile(filepath.Join(dir, "results.json"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	var results Results
+	g.Expect(json.Unmarshal(out, &results)).To(gomega.Succeed())
+	g.Expect(results.Validations).To(gomega.HaveLen(3))
+
+	nodes, pods, healthz := results.Validations[0], results.Validations[1], results.Validations[2]
+	g.Expect(nodes.Attempts).To(gomega.Equal(4))
+	g.Expect(nodes.Finished).To(gomega.Equal(started.Add(30 * time.Second)))
+	g.Expect(nodes.Error).To(gomega.BeEmpty())
+	g.Expect(nodes.Suppressed).To(gomega.Equal(1))
+	g.Expect(pods.Status).To(gomega.Equal(ValidationStatusFailed))
+	g.Expect(pods.Error).To(gomega.Equal("resource validation failed"))
+	g.Expect(pods.Reasons).To(gomega.Equal([]FailureReason{
+		{Validation: "field .status.phase", Reason: "value 'Pending' does not match [Running]", Resources: []string{"default/pod-1"}},
+	}))
+	g.Expect(healthz.Finished.IsZero()).To(gomega.BeTrue())
+
+	out, err = ioutil.ReadFile(filepath.Join(dir, "results.yaml"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(out)).To(gomega.ContainSubstring("Name: pods"))
+}
+
+func Test_PushgatewaySink(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	var (
+		method, path, body string
+		status             = http.StatusOK
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(data)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	_, err := NewSink("pushgateway")
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = NewSink("pushgateway=pushgateway:9091")
+	g.Expect(err).To(gomega.HaveOccurred())
+	sink, err := NewSink("pushgateway=" + ts.URL + "/")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	started := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := Report{
+		Cluster:  v1alpha1.ClusterIdentity{Name: "prod/us-west-2"},
+		Started:  started,
+		Finished: started.Add(time.Minute),
+		Success:  true,
+		Validations: []ValidationProgress{
+			{Name: "nodes", ID: "nodes-ready", Status: ValidationStatusSucceeded, Attempts: 2, Duration: 5 * time.Second},
+		},
+	}
+	g.Expect(sink.Write(report)).To(gomega.Succeed())
+	g.Expect(sink.Flush()).To(gomega.Succeed())
+
+	g.Expect(method).To(gomega.Equal(http.MethodPut))
+	g.Expect(path).To(gomega.Equal("/metrics/job/cluster-validator/cluster@base64/cHJvZC91cy13ZXN0LTI="))
+	g.Expect(body).To(gomega.ContainSubstring(`cluster_validator_validation_success{cluster="prod/us-west-2",validation="nodes",id="nodes-ready"} 1`))
+	g.Expect(body).To(gomega.ContainSubstring(`cluster_validator_validation_duration_seconds{cluster="prod/us-west-2",validation="nodes",id="nodes-ready"} 5`))
+
+	status = http.StatusBadRequest
+	g.Expect(sink.Flush()).To(gomega.HaveOccurred())
+	g.Expect(groupingValue("")).To(gomega.Equal("="))
+}
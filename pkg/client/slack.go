@@ -0,0 +1,138 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// slackResourceLimit is how many resources are listed per failure reason in a Slack message
+	slackResourceLimit = 5
+
+	slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+func init() {
+	RegisterNotifier("slack", func(webhookURL string) (Notifier, error) {
+		if webhookURL == "" {
+			return nil, errors.New("slack notifier requires an incoming webhook URL, e.g. slack=https://hooks.slack.com/services/...")
+		}
+		u, err := url.Parse(webhookURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, errors.New("invalid slack webhook URL")
+		}
+		return &SlackNotifier{WebhookURL: webhookURL, Client: newHTTPClient(nil)}, nil
+	})
+}
+
+// SlackNotifier posts a summary of every completed run to a Slack incoming webhook, with the
+// cluster, the outcome and the failing resources of every failed validation grouped by reason.
+// Individual failures are not posted, they are part of the summary of their run.
+type SlackNotifier struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func (n *SlackNotifier) NotifyFailure(failure Failure) error {
+	return nil
+}
+
+func (n *SlackNotifier) NotifyCompletion(report Report) error {
+	body, err := json.Marshal(slackMessage{Text: slackSummary(report)})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal slack message")
+	}
+
+	resp, err := n.Client.Post(n.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// the webhook URL is a secret, it is left out of the error
+		if uErr, ok := err.(*url.Error); ok {
+			err = uErr.Err
+		}
+		return errors.Wrap(err, "failed to post to slack")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("failed to post to slack: %v %v", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// slackSummary formats a report as Slack mrkdwn.
+func slackSummary(report Report) string {
+	var (
+		b       = new(strings.Builder)
+		cluster = report.Cluster.Name
+		took    = report.Finished.Sub(report.Started).Round(time.Second)
+	)
+
+	if cluster == "" {
+		cluster = "cluster"
+	}
+
+	if report.Success {
+		fmt.Fprintf(b, ":white_check_mark: Validation of *%v* succeeded in %v", slackEscaper.Replace(cluster), took)
+	} else {
+		fmt.Fprintf(b, ":x: Validation of *%v* failed in %v", slackEscaper.Replace(cluster), took)
+		if report.Error != "" {
+			fmt.Fprintf(b, ": %v", slackEscaper.Replace(report.Error))
+		}
+	}
+
+	for _, p := range report.Validations {
+		if p.Status != ValidationStatusFailed && p.Status != ValidationStatusWarning {
+			continue
+		}
+		name := p.Name
+		if p.ID != "" {
+			name = fmt.Sprintf("%v (%v)", p.Name, p.ID)
+		}
+		if p.Status == ValidationStatusWarning {
+			name += " (warning)"
+		}
+		fmt.Fprintf(b, "\n*%v*: %v", slackEscaper.Replace(name), slackEscaper.Replace(p.LastError))
+
+		for _, r := range summaryReasons(p.Summary) {
+			fmt.Fprintf(b, "\n• %v: %v", slackEscaper.Replace(r.Validation), slackEscaper.Replace(r.Reason))
+			if len(r.Resources) == 0 {
+				continue
+			}
+			resources := r.Resources
+			if len(resources) > slackResourceLimit {
+				resources = resources[:slackResourceLimit]
+			}
+			fmt.Fprintf(b, " on %v", slackEscaper.Replace(strings.Join(resources, ", ")))
+			if more := len(r.Resources) - len(resources); more > 0 {
+				fmt.Fprintf(b, " and %v more", more)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,232 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+const (
+	snapshotFileSuffix = ".yaml"
+	redactedValue      = "REDACTED"
+)
+
+// snapshotTarget is a resource referenced by the spec and the namespaces to capture it from,
+// an empty namespace captures it from all namespaces.
+type snapshotTarget struct {
+	gvr        schema.GroupVersionResource
+	namespaces map[string]bool
+}
+
+// Snapshot lists every resource the spec reads and writes the raw objects to dir, one file per
+// resource, so the spec can later be evaluated offline against the captured state. Secret
+// values are redacted. It returns the paths of the written files.
+func (v *Validator) Snapshot(dir string) ([]string, error) {
+	var (
+		files = make([]string, 0)
+	)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create snapshot directory '%v'", dir)
+	}
+
+	for _, target := range v.snapshotTargets() {
+		items := make([]interface{}, 0)
+		for _, namespace := range sortedNamespaces(target.namespaces) {
+			resources, err := v.listNamespaceResources(target.gvr, namespace)
+			if err != nil {
+				return files, err
+			}
+			for _, resource := range resources {
+				redactResource(&resource)
+				items = append(items, resource.Object)
+			}
+		}
+
+		list := map[string]interface{}{
+			"apiVersion": target.gvr.GroupVersion().String(),
+			"kind":       snapshotListKind(items),
+			"items":      items,
+		}
+		out, err := yaml.Marshal(list)
+		if err != nil {
+			return files, errors.Wrapf(err, "failed to marshal '%v'", target.gvr)
+		}
+
+		path := filepath.Join(dir, snapshotFileName(target.gvr))
+		if err := ioutil.WriteFile(path, out, 0644); err != nil {
+			return files, errors.Wrapf(err, "failed to write snapshot of '%v'", target.gvr)
+		}
+		log.Infof("captured %v '%v' resources to %v", len(items), target.gvr, path)
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// LoadSnapshot returns a fake dynamic client serving the objects captured by Snapshot, which
+// a Validator can use to evaluate a spec without a cluster.
+func LoadSnapshot(dir string) (*fake.FakeDynamicClient, error) {
+	var (
+		listKinds = make(map[schema.GroupVersionResource]string)
+		lists     = make(map[schema.GroupVersionResource]*unstructured.UnstructuredList)
+	)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read snapshot '%v'", dir)
+	}
+
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), snapshotFileSuffix) {
+			continue
+		}
+		gvr, ok := parseSnapshotFileName(f.Name())
+		if !ok {
+			log.Warnf("skipping unrecognized snapshot file '%v'", f.Name())
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read snapshot file '%v'", f.Name())
+		}
+		list := &unstructured.UnstructuredList{}
+		raw, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse snapshot file '%v'", f.Name())
+		}
+		if err := list.UnmarshalJSON(raw); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse snapshot file '%v'", f.Name())
+		}
+		listKinds[gvr] = list.GetKind()
+		lists[gvr] = list
+	}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	for gvr, list := range lists {
+		for i := range list.Items {
+			item := &list.Items[i]
+			if err := client.Tracker().Create(gvr, item, item.GetNamespace()); err != nil {
+				return nil, errors.Wrapf(err, "failed to load '%v' from snapshot", namespacedName(*item))
+			}
+		}
+	}
+	return client, nil
+}
+
+// snapshotTargets derives the resources to capture from the list and get permissions the
+// spec requires.
+func (v *Validator) snapshotTargets() []snapshotTarget {
+	var (
+		targets = make(map[schema.GroupVersionResource]*snapshotTarget)
+		order   = make([]schema.GroupVersionResource, 0)
+	)
+
+	for _, req := range v.requiredAccess() {
+		if req.path != "" || req.rule.Subresource != "" || (req.rule.Verb != "list" && req.rule.Verb != "get") {
+			continue
+		}
+		target, ok := targets[req.gvr]
+		if !ok {
+			target = &snapshotTarget{gvr: req.gvr, namespaces: make(map[string]bool)}
+			targets[req.gvr] = target
+			order = append(order, req.gvr)
+		}
+		target.namespaces[req.rule.Namespace] = true
+	}
+
+	result := make([]snapshotTarget, 0, len(order))
+	for _, gvr := range order {
+		target := targets[gvr]
+		if target.namespaces[""] {
+			target.namespaces = map[string]bool{"": true}
+		}
+		result = append(result, *target)
+	}
+	return result
+}
+
+func sortedNamespaces(namespaces map[string]bool) []string {
+	keys := make([]string, 0, len(namespaces))
+	for k := range namespaces {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// redactResource replaces secret values so snapshots can be attached to bug reports, including
+// the copy of the whole secret kubectl apply keeps in the last-applied-configuration annotation.
+func redactResource(u *unstructured.Unstructured) {
+	if u.GetKind() != "Secret" {
+		return
+	}
+	if annotations := u.GetAnnotations(); annotations[corev1.LastAppliedConfigAnnotation] != "" {
+		annotations[corev1.LastAppliedConfigAnnotation] = redactedValue
+		u.SetAnnotations(annotations)
+	}
+	for _, field := range []string{"data", "stringData"} {
+		values, found, _ := unstructured.NestedMap(u.Object, field)
+		if !found {
+			continue
+		}
+		for k := range values {
+			values[k] = redactedValue
+		}
+		_ = unstructured.SetNestedMap(u.Object, values, field)
+	}
+}
+
+func snapshotListKind(items []interface{}) string {
+	for _, item := range items {
+		if kind, ok := item.(map[string]interface{})["kind"].(string); ok && kind != "" {
+			return kind + "List"
+		}
+	}
+	return "List"
+}
+
+// snapshotFileName encodes a resource as <resource>.<version>.<group>.yaml, the core group is
+// written as "core".
+func snapshotFileName(gvr schema.GroupVersionResource) string {
+	group := gvr.Group
+	if group == "" {
+		group = "core"
+	}
+	return fmt.Sprintf("%v.%v.%v%v", gvr.Resource, gvr.Version, group, snapshotFileSuffix)
+}
+
+func parseSnapshotFileName(name string) (schema.GroupVersionResource, bool) {
+	parts := strings.SplitN(strings.TrimSuffix(name, snapshotFileSuffix), ".", 3)
+	if len(parts) != 3 {
+		return schema.GroupVersionResource{}, false
+	}
+	if parts[2] == "core" {
+		parts[2] = ""
+	}
+	return schema.GroupVersionResource{Group: parts[2], Version: parts[1], Resource: parts[0]}, true
+}
@@ -0,0 +1,248 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ParseSuppressions reads a suppression list, suppressions without an ID or resources or with
+// an invalid expiry are rejected.
+func ParseSuppressions(path string) ([]v1alpha1.Suppression, error) {
+	var (
+		list = v1alpha1.SuppressionList{}
+	)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, SpecError{errors.Errorf("could not read suppressions '%v': %v", path, err)}
+	}
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, SpecError{errors.Errorf("failed to unmarshal suppressions: %v", err)}
+	}
+
+	for i, s := range list.Suppressions {
+		if s.ID == "" && len(s.Resources) == 0 {
+			return nil, SpecError{errors.Errorf("suppression %v requires an id or resources", i)}
+		}
+		if _, err := suppressionExpiry(s); err != nil {
+			return nil, SpecError{errors.Wrapf(err, "suppression %v", i)}
+		}
+	}
+	return list.Suppressions, nil
+}
+
+func suppressionExpiry(s v1alpha1.Suppression) (time.Time, error) {
+	if s.Expires == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", strings.TrimSpace(s.Expires)); err == nil {
+		return t, nil
+	}
+	return expr.ParseTimestamp(s.Expires)
+}
+
+// activeSuppressions returns the suppressions that have not expired yet.
+func (v *Validator) activeSuppressions() []v1alpha1.Suppression {
+	var (
+		active = make([]v1alpha1.Suppression, 0)
+	)
+
+	for _, s := range v.Suppressions {
+		expires, _ := suppressionExpiry(s)
+		if !expires.IsZero() && expr.Now().After(expires) {
+			log.Debugf("suppression of '%v' expired on %v", suppressionTarget(s), s.Expires)
+			continue
+		}
+		active = append(active, s)
+	}
+	return active
+}
+
+// suppressed wraps the evaluation of the entry with the given ID so known failures are reported
+// as suppressed instead of failing. An attempt whose failures are all suppressed succeeds, the
+// summary counts the suppressed failures either way.
+func (v *Validator) suppressed(name, id string, evaluate func() (ValidationSummary, error)) func() (ValidationSummary, error) {
+	return func() (ValidationSummary, error) {
+		summary, err := evaluate()
+		if err == nil || len(v.Suppressions) == 0 {
+			return summary, err
+		}
+		var fatal fatalError
+		if errors.As(err, &fatal) {
+			return summary, err
+		}
+
+		active := v.activeSuppressions()
+		for _, s := range active {
+			if s.ID != "" && s.ID == id && len(s.Resources) == 0 {
+				log.Warnf("failure of '%v' suppressed: %v", name, suppressionReason(s))
+				return ValidationSummary{Suppressed: summaryFailures(summary)}, nil
+			}
+		}
+
+		if filtered, count := suppressSummary(summary, active); count > 0 {
+			log.Warnf("%v failing resources of '%v' suppressed", count, name)
+			filtered.Suppressed = count
+			if summaryEmpty(filtered) {
+				return filtered, nil
+			}
+			return filtered, err
+		}
+		return summary, err
+	}
+}
+
+// suppressSummary removes suppressed resources and entries from the results of a summary and
+// returns how many were removed.
+func suppressSummary(summary ValidationSummary, suppressions []v1alpha1.Suppression) (ValidationSummary, int) {
+	var (
+		filtered = ValidationSummary{}
+		count    int
+	)
+
+	for _, r := range summary.FieldValidation {
+		var n int
+		if r.ResourceErrors, n = suppressResources(r.ID, r.ResourceErrors, suppressions); len(r.ResourceErrors) > 0 {
+			filtered.FieldValidation = append(filtered.FieldValidation, r)
+		}
+		count += n
+	}
+	for _, r := range summary.ConditionValidation {
+		var n int
+		if r.ResourceErrors, n = suppressResources(r.ID, r.ResourceErrors, suppressions); len(r.ResourceErrors) > 0 {
+			filtered.ConditionValidation = append(filtered.ConditionValidation, r)
+		}
+		count += n
+	}
+	for _, r := range summary.CheckValidation {
+		var n int
+		if r.ResourceErrors, n = suppressResources(r.ID, r.ResourceErrors, suppressions); len(r.ResourceErrors) > 0 || r.Error != "" {
+			filtered.CheckValidation = append(filtered.CheckValidation, r)
+		}
+		count += n
+	}
+	for _, r := range summary.AggregateValidation {
+		if suppressesEntry(r.ID, suppressions) {
+			count++
+			continue
+		}
+		filtered.AggregateValidation = append(filtered.AggregateValidation, r)
+	}
+	for _, r := range summary.ClusterEndpointValidation {
+		if suppressesEntry(r.ID, suppressions) {
+			count++
+			continue
+		}
+		filtered.ClusterEndpointValidation = append(filtered.ClusterEndpointValidation, r)
+	}
+	filtered.HTTPEndpointValidation = summary.HTTPEndpointValidation
+	return filtered, count
+}
+
+// suppressResources removes the suppressed resource names of every failure reason of a result.
+func suppressResources(id string, resourceErrors map[string][]string, suppressions []v1alpha1.Suppression) (map[string][]string, int) {
+	var (
+		remaining = make(map[string][]string)
+		count     int
+	)
+
+	for reason, names := range resourceErrors {
+		for _, name := range names {
+			if suppressesEntry(id, suppressions) || suppressesResource(id, name, suppressions) {
+				count++
+				continue
+			}
+			remaining[reason] = append(remaining[reason], name)
+		}
+	}
+	return remaining, count
+}
+
+func suppressesEntry(id string, suppressions []v1alpha1.Suppression) bool {
+	for _, s := range suppressions {
+		if id != "" && s.ID == id && len(s.Resources) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// suppressesResource matches a namespace/name against the resource patterns, a pattern without
+// a namespace also matches the name alone.
+func suppressesResource(id, name string, suppressions []v1alpha1.Suppression) bool {
+	var (
+		short = name[strings.Index(name, "/")+1:]
+	)
+
+	for _, s := range suppressions {
+		if s.ID != "" && s.ID != id {
+			continue
+		}
+		if matchInPatterns(s.Resources, name) || matchInPatterns(s.Resources, short) {
+			return true
+		}
+	}
+	return false
+}
+
+// summaryFailures counts the failing resources of a summary, a failure without resources counts once.
+func summaryFailures(s ValidationSummary) int {
+	var (
+		count int
+	)
+
+	for _, r := range summaryReasons(s) {
+		if len(r.Resources) == 0 {
+			count++
+			continue
+		}
+		count += len(r.Resources)
+	}
+	if count == 0 {
+		// the entry failed without results, e.g. because it could not be evaluated
+		count = 1
+	}
+	return count
+}
+
+func summaryEmpty(s ValidationSummary) bool {
+	return len(s.FieldValidation) == 0 && len(s.ConditionValidation) == 0 && len(s.AggregateValidation) == 0 &&
+		len(s.CheckValidation) == 0 && len(s.ClusterEndpointValidation) == 0 && len(s.HTTPEndpointValidation) == 0
+}
+
+func suppressionTarget(s v1alpha1.Suppression) string {
+	if s.ID != "" {
+		return s.ID
+	}
+	return strings.Join(s.Resources, ",")
+}
+
+func suppressionReason(s v1alpha1.Suppression) string {
+	reason := s.Reason
+	if reason == "" {
+		reason = "known failure"
+	}
+	if s.Expires != "" {
+		reason += ", until " + s.Expires
+	}
+	return reason
+}
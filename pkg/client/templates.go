@@ -0,0 +1,147 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+var templateParameterRegex = regexp.MustCompile(`\$\{\s*([A-Za-z0-9_.-]+)\s*\}`)
+
+// expandTemplates renders the templates referenced by resource entries and merges the
+// resulting fields, conditions, annotations, aggregates and configuration into them.
+func expandTemplates(spec *v1alpha1.ClusterValidation) error {
+	var (
+		templates = make(map[string]v1alpha1.ValidationTemplate)
+	)
+
+	for _, t := range spec.Spec.Templates {
+		if _, ok := templates[t.Name]; ok {
+			return errors.Errorf("template '%v' is defined more than once", t.Name)
+		}
+		templates[t.Name] = t
+	}
+
+	for i := range spec.Spec.Resources {
+		if err := applyTemplates(&spec.Spec.Resources[i], templates); err != nil {
+			return err
+		}
+	}
+
+	for i := range spec.Spec.Groups {
+		group := &spec.Spec.Groups[i]
+		for j := range group.AllOf {
+			if err := applyTemplates(&group.AllOf[j], templates); err != nil {
+				return err
+			}
+		}
+		for j := range group.AnyOf {
+			if err := applyTemplates(&group.AnyOf[j], templates); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func applyTemplates(r *v1alpha1.ClusterResource, templates map[string]v1alpha1.ValidationTemplate) error {
+	for _, ref := range r.Templates {
+		t, ok := templates[ref.Name]
+		if !ok {
+			return errors.Errorf("resource '%v' references unknown template '%v'", r.Name, ref.Name)
+		}
+
+		rendered, err := renderTemplate(t, ref.Args)
+		if err != nil {
+			return errors.Wrapf(err, "failed to render template '%v' for resource '%v'", ref.Name, r.Name)
+		}
+
+		r.Fields = append(r.Fields, rendered.Fields...)
+		r.Annotations = append(r.Annotations, rendered.Annotations...)
+		r.Conditions = append(r.Conditions, rendered.Conditions...)
+		r.Aggregates = append(r.Aggregates, rendered.Aggregates...)
+
+		if r.Configuration.SuccessThreshold == 0 {
+			r.Configuration.SuccessThreshold = rendered.Configuration.SuccessThreshold
+		}
+		if r.Configuration.FailureThreshold == 0 {
+			r.Configuration.FailureThreshold = rendered.Configuration.FailureThreshold
+		}
+		if r.Configuration.Interval == "" {
+			r.Configuration.Interval = rendered.Configuration.Interval
+		}
+		if r.Configuration.Timeout == "" {
+			r.Configuration.Timeout = rendered.Configuration.Timeout
+		}
+		if r.Configuration.Backoff == nil {
+			r.Configuration.Backoff = rendered.Configuration.Backoff
+		}
+		if r.Configuration.Jitter == 0 {
+			r.Configuration.Jitter = rendered.Configuration.Jitter
+		}
+	}
+	r.Templates = nil
+	return nil
+}
+
+// renderTemplate substitutes ${parameter} references in a template with the given
+// arguments, falling back to parameter defaults.
+func renderTemplate(t v1alpha1.ValidationTemplate, args map[string]string) (v1alpha1.ValidationTemplate, error) {
+	var (
+		values   = make(map[string]string)
+		rendered = v1alpha1.ValidationTemplate{}
+		missing  []string
+	)
+
+	for _, p := range t.Parameters {
+		values[p.Name] = p.Default
+	}
+	for k, v := range args {
+		if _, ok := values[k]; !ok {
+			return rendered, errors.Errorf("unknown template argument '%v'", k)
+		}
+		values[k] = v
+	}
+
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return rendered, err
+	}
+
+	out := templateParameterRegex.ReplaceAllStringFunc(string(raw), func(m string) string {
+		name := templateParameterRegex.FindStringSubmatch(m)[1]
+		val, ok := values[name]
+		if !ok || val == "" {
+			if _, declared := args[name]; !declared {
+				missing = append(missing, name)
+			}
+		}
+		escaped, _ := json.Marshal(val)
+		return strings.Trim(string(escaped), `"`)
+	})
+
+	if len(missing) > 0 {
+		return rendered, errors.Errorf("missing template arguments %v", missing)
+	}
+
+	if err := json.Unmarshal([]byte(out), &rendered); err != nil {
+		return rendered, err
+	}
+	return rendered, nil
+}
@@ -0,0 +1,149 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+var (
+	// transports are shared by every validator with the same transport options, so long running
+	// serve processes keep their connection pools across runs
+	transportsMu sync.Mutex
+	transports   = map[v1alpha1.HTTPTransport]*http.Transport{}
+)
+
+// transportDefaults are used for the options a spec does not set.
+var transportDefaults = v1alpha1.HTTPTransport{
+	Timeout:             "30s",
+	DialTimeout:         "10s",
+	TLSHandshakeTimeout: "10s",
+	KeepAlive:           "30s",
+	IdleConnTimeout:     "90s",
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 10,
+}
+
+// newHTTPClient returns a client using the shared transport of the given options.
+func newHTTPClient(opts *v1alpha1.HTTPTransport) *http.Client {
+	var (
+		o = withTransportDefaults(opts)
+	)
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	transport, ok := transports[o]
+	if !ok {
+		dialer := &net.Dialer{
+			Timeout:   mustDuration(o.DialTimeout),
+			KeepAlive: mustDuration(o.KeepAlive),
+		}
+		transport = &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   mustDuration(o.TLSHandshakeTimeout),
+			DisableKeepAlives:     o.DisableKeepAlives,
+			IdleConnTimeout:       mustDuration(o.IdleConnTimeout),
+			MaxIdleConns:          o.MaxIdleConns,
+			MaxIdleConnsPerHost:   o.MaxIdleConnsPerHost,
+			ExpectContinueTimeout: time.Second,
+			ForceAttemptHTTP2:     !o.DisableHTTP2,
+		}
+		if o.DisableHTTP2 {
+			transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		}
+		transports[o] = transport
+	}
+
+	return &http.Client{
+		Timeout:   mustDuration(o.Timeout),
+		Transport: transport,
+	}
+}
+
+func withTransportDefaults(opts *v1alpha1.HTTPTransport) v1alpha1.HTTPTransport {
+	var (
+		o = transportDefaults
+	)
+
+	if opts == nil {
+		return o
+	}
+	if opts.Timeout != "" {
+		o.Timeout = opts.Timeout
+	}
+	if opts.DialTimeout != "" {
+		o.DialTimeout = opts.DialTimeout
+	}
+	if opts.TLSHandshakeTimeout != "" {
+		o.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+	}
+	if opts.KeepAlive != "" {
+		o.KeepAlive = opts.KeepAlive
+	}
+	if opts.IdleConnTimeout != "" {
+		o.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.MaxIdleConns > 0 {
+		o.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		o.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	o.DisableKeepAlives = opts.DisableKeepAlives
+	o.DisableHTTP2 = opts.DisableHTTP2
+	return o
+}
+
+// validateTransport rejects transport options that cannot be parsed, so newHTTPClient can
+// rely on them.
+func validateTransport(spec *v1alpha1.ClusterValidation) error {
+	var (
+		opts = spec.Spec.Endpoints.Transport
+	)
+
+	if opts == nil {
+		return nil
+	}
+	for name, d := range map[string]string{
+		"timeout":             opts.Timeout,
+		"dialTimeout":         opts.DialTimeout,
+		"tlsHandshakeTimeout": opts.TLSHandshakeTimeout,
+		"keepAlive":           opts.KeepAlive,
+		"idleConnTimeout":     opts.IdleConnTimeout,
+	} {
+		if d == "" {
+			continue
+		}
+		if parsed, err := time.ParseDuration(d); err != nil || parsed < 0 {
+			return errors.Errorf("invalid transport %v '%v'", name, d)
+		}
+	}
+	if opts.MaxIdleConns < 0 || opts.MaxIdleConnsPerHost < 0 {
+		return errors.New("transport maxIdleConns and maxIdleConnsPerHost must not be negative")
+	}
+	return nil
+}
+
+func mustDuration(s string) time.Duration {
+	d, _ := time.ParseDuration(s)
+	return d
+}
@@ -13,32 +13,78 @@ limitations under the License.
 package client
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/fs"
-	"io/ioutil"
 	"net/http"
-	"os"
 	"sync"
 	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/builtin"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 )
 
 type Validator struct {
 	sync.RWMutex
 	Waiter
-	Validation       *v1alpha1.ClusterValidation
-	Kubernetes       dynamic.Interface
-	RESTClient       *rest.RESTClient
-	HTTPClient       *http.Client
-	ClusterResources map[string][]unstructured.Unstructured
+	Validation *v1alpha1.ClusterValidation
+	Kubernetes dynamic.Interface
+	RESTClient *rest.RESTClient
+	HTTPClient *http.Client
+	// Metadata lists the resources of entries that only validate metadata, optional
+	Metadata metadata.Interface
+	// Mapper maps the kinds of workload test manifests to resources, it defaults to a discovery
+	// mapper of the REST client
+	Mapper meta.RESTMapper
+	// ClusterResources holds the last listed resources per GVR, shared by all validations of a GVR
+	ClusterResources map[schema.GroupVersionResource][]unstructured.Unstructured
+	// Preflight verifies the validator's own permissions before any validation starts
+	Preflight bool
+	// InformerCache serves resource validations from watch-backed informer caches instead of
+	// listing every interval, which reduces API server load for long runs
+	InformerCache bool
+	// StreamLists evaluates resource entries while their resources are listed, page by page,
+	// instead of keeping the full lists. Memory then no longer grows with the size of the
+	// cluster, at the cost of one list call per entry instead of a list shared by entries of the
+	// same resource. It is ignored with InformerCache
+	StreamLists bool
+	// EventDriven re-evaluates a failing resource entry as soon as its resources change in the
+	// informer cache instead of waiting for its interval, it requires InformerCache
+	EventDriven bool
+	// Suppressions are known failures reported as suppressed instead of failing
+	Suppressions []v1alpha1.Suppression
+	// CheckpointFile is periodically replaced with the progress of the run while validating
+	CheckpointFile string
+	// Resume continues the validations of a previous run from its checkpoint, validations that
+	// succeeded are not repeated and the counters of unfinished validations are carried over
+	Resume *Checkpoint
+	// Heartbeat is how often a status line is logged per running validation, zero disables it
+	Heartbeat time.Duration
+	// Sinks receive the report of the run when Validate returns
+	Sinks []ResultSink
+	// Notifiers are told about failed validations and the outcome of the run
+	Notifiers []Notifier
+	// ContinueOnError lets all validations run to completion when a required validation fails,
+	// Validate then returns the errors of every failed validation at once
+	ContinueOnError bool
+	// Timeout stops the whole run and fails it with a TimeoutError once it has run this long,
+	// zero leaves it unlimited
+	Timeout time.Duration
+
+	stop      chan struct{}
+	stopOnce  sync.Once
+	progress  []*ValidationProgress
+	lists     map[listKey]*resourceList
+	informers *informerCache
+	pressure  apiPressure
+	workloads map[*workloadSet]bool
+	// singlePass evaluates every validation once and only records its outcome, used to compare targets
+	singlePass bool
 }
 
 type Waiter struct {
@@ -47,49 +93,112 @@ type Waiter struct {
 	errors   chan error
 }
 
+// FailureCode classifies a failed validation result, codes are stable so automation can act on
+// failures without parsing their messages.
+type FailureCode string
+
+const (
+	FailureCodeFieldMismatch       FailureCode = "FIELD_MISMATCH"
+	FailureCodeJQMismatch          FailureCode = "JQ_MISMATCH"
+	FailureCodeTemplateMismatch    FailureCode = "TEMPLATE_MISMATCH"
+	FailureCodeConditionMissing    FailureCode = "CONDITION_MISSING"
+	FailureCodeConditionMismatch   FailureCode = "CONDITION_MISMATCH"
+	FailureCodeAggregateMismatch   FailureCode = "AGGREGATE_MISMATCH"
+	FailureCodeAggregateError      FailureCode = "AGGREGATE_ERROR"
+	FailureCodeCheckFailed         FailureCode = "CHECK_FAILED"
+	FailureCodeWorkloadFailed      FailureCode = "WORKLOAD_FAILED"
+	FailureCodeAccessDenied        FailureCode = "ACCESS_DENIED"
+	FailureCodeEndpointUnreachable FailureCode = "ENDPOINT_UNREACHABLE"
+	FailureCodeUnexpectedStatus    FailureCode = "UNEXPECTED_STATUS"
+)
+
 type ConditionValidationResult struct {
+	ID             string
+	Code           FailureCode
 	Condition      string
 	ResourceErrors map[string][]string
 }
 
 func NewConditionValidationResult(cond string) ConditionValidationResult {
 	return ConditionValidationResult{
+		Code:           FailureCodeConditionMismatch,
 		Condition:      cond,
 		ResourceErrors: make(map[string][]string),
 	}
 }
 
 type FieldValidationResult struct {
+	ID             string
+	Code           FailureCode
 	FieldPath      string
 	ResourceErrors map[string][]string
 }
 
 func NewFieldValidationResult(path string) FieldValidationResult {
 	return FieldValidationResult{
+		Code:           FailureCodeFieldMismatch,
 		FieldPath:      path,
 		ResourceErrors: make(map[string][]string),
 	}
 }
 
+type AggregateValidationResult struct {
+	ID        string
+	Code      FailureCode
+	Group     string
+	Aggregate string
+	Value     float64
+	Error     string
+}
+
+func NewAggregateValidationResult(aggregate string) AggregateValidationResult {
+	return AggregateValidationResult{
+		Code:      FailureCodeAggregateMismatch,
+		Aggregate: aggregate,
+	}
+}
+
+type CheckValidationResult struct {
+	ID             string
+	Code           FailureCode
+	Check          string
+	Error          string
+	ResourceErrors map[string][]string
+}
+
+func NewCheckValidationResult(check string) CheckValidationResult {
+	return CheckValidationResult{
+		Code:           FailureCodeCheckFailed,
+		Check:          check,
+		ResourceErrors: make(map[string][]string),
+	}
+}
+
 type HTTPEndpointValidationResult struct {
+	ID     string
+	Code   FailureCode
 	Errors map[string]string
 	Name   string
 }
 
 func NewHTTPEndpointValidationResult(name string) HTTPEndpointValidationResult {
 	return HTTPEndpointValidationResult{
+		Code:   FailureCodeEndpointUnreachable,
 		Errors: make(map[string]string),
 		Name:   name,
 	}
 }
 
 type ClusterEndpointValidationResult struct {
+	ID     string
+	Code   FailureCode
 	Errors map[string]string
 	Name   string
 }
 
 func NewClusterEndpointValidationResult(name string) ClusterEndpointValidationResult {
 	return ClusterEndpointValidationResult{
+		Code:   FailureCodeEndpointUnreachable,
 		Errors: make(map[string]string),
 		Name:   name,
 	}
@@ -98,20 +207,71 @@ func NewClusterEndpointValidationResult(name string) ClusterEndpointValidationRe
 type ValidationSummary struct {
 	FieldValidation           []FieldValidationResult
 	ConditionValidation       []ConditionValidationResult
+	AggregateValidation       []AggregateValidationResult
+	CheckValidation           []CheckValidationResult
 	ClusterEndpointValidation []ClusterEndpointValidationResult
 	HTTPEndpointValidation    []HTTPEndpointValidationResult
+	// Suppressed is how many failures of the attempt were suppressed and left out of the results
+	Suppressed int `json:",omitempty"`
+}
+
+// setID sets the ID of the results that do not carry the ID of a more specific entry yet.
+func (s *ValidationSummary) setID(id string) {
+	for i := range s.FieldValidation {
+		if s.FieldValidation[i].ID == "" {
+			s.FieldValidation[i].ID = id
+		}
+	}
+	for i := range s.ConditionValidation {
+		if s.ConditionValidation[i].ID == "" {
+			s.ConditionValidation[i].ID = id
+		}
+	}
+	for i := range s.AggregateValidation {
+		if s.AggregateValidation[i].ID == "" {
+			s.AggregateValidation[i].ID = id
+		}
+	}
+	for i := range s.CheckValidation {
+		if s.CheckValidation[i].ID == "" {
+			s.CheckValidation[i].ID = id
+		}
+	}
+	for i := range s.ClusterEndpointValidation {
+		if s.ClusterEndpointValidation[i].ID == "" {
+			s.ClusterEndpointValidation[i].ID = id
+		}
+	}
+	for i := range s.HTTPEndpointValidation {
+		if s.HTTPEndpointValidation[i].ID == "" {
+			s.HTTPEndpointValidation[i].ID = id
+		}
+	}
 }
 
 func (v *Validator) GetValidationObjects() []interface{} {
+	return validationObjects(v.Validation)
+}
+
+// validationObjects returns every top-level entry of a spec that runs as a validation.
+func validationObjects(spec *v1alpha1.ClusterValidation) []interface{} {
 	objs := make([]interface{}, 0)
-	for _, res := range v.GetResources() {
+	for _, res := range spec.Spec.Resources {
 		objs = append(objs, res)
 	}
-	ep := v.GetEndpointSpec()
-	for _, clusterEndpoint := range ep.Cluster {
+	for _, group := range spec.Spec.Groups {
+		objs = append(objs, group)
+	}
+	for _, check := range spec.Spec.Checks {
+		objs = append(objs, check)
+	}
+	for _, test := range spec.Spec.WorkloadTests {
+		objs = append(objs, test)
+	}
+	for _, clusterEndpoint := range spec.Spec.Endpoints.Cluster {
 		objs = append(objs, clusterEndpoint)
 	}
-	for _, httpEndpoint := range ep.HTTP {
+	for _, httpEndpoint := range spec.Spec.Endpoints.HTTP {
 		objs = append(objs, httpEndpoint)
 	}
 	return objs
@@ -121,6 +281,18 @@ func (v *Validator) GetResources() []v1alpha1.ClusterResource {
 	return v.Validation.Spec.Resources
 }
 
+func (v *Validator) GetGroups() []v1alpha1.ValidationGroup {
+	return v.Validation.Spec.Groups
+}
+
+func (v *Validator) GetChecks() []v1alpha1.ClusterCheck {
+	return v.Validation.Spec.Checks
+}
+
+func (v *Validator) GetWorkloadTests() []v1alpha1.WorkloadTest {
+	return v.Validation.Spec.WorkloadTests
+}
+
 func (v *Validator) GetEndpointSpec() v1alpha1.EndpointsSpec {
 	return v.Validation.Spec.Endpoints
 }
@@ -130,18 +302,77 @@ func (v *Validator) GetGlobalConfiguration() v1alpha1.ValidationConfiguration {
 }
 
 func ParseValidationSpec(path string) (*v1alpha1.ClusterValidation, error) {
-	validationSpec := &v1alpha1.ClusterValidation{}
-	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
-		return validationSpec, errors.Errorf("path '%v' does not exist", path)
-	}
+	return ParseSignedValidationSpec(path, SignatureOptions{})
+}
 
-	data, err := ioutil.ReadFile(path)
+func ParsePreset(name string) (*v1alpha1.ClusterValidation, error) {
+	data, err := builtin.Preset(name)
 	if err != nil {
-		return validationSpec, errors.Errorf("could not read file '%v': %v", path, err)
+		return &v1alpha1.ClusterValidation{}, SpecError{err}
 	}
+	return parseValidationSpecData(data)
+}
 
+func parseValidationSpecData(data []byte) (*v1alpha1.ClusterValidation, error) {
+	validationSpec := &v1alpha1.ClusterValidation{}
 	if err := yaml.Unmarshal(data, validationSpec); err != nil {
-		return validationSpec, errors.Errorf("failed to unmarshal manifest file: %v", err)
+		return validationSpec, SpecError{errors.Errorf("failed to unmarshal manifest file: %v", err)}
+	}
+
+	if err := expandBundles(validationSpec); err != nil {
+		return validationSpec, SpecError{errors.Errorf("failed to expand bundles: %v", err)}
+	}
+
+	if err := expandTemplates(validationSpec); err != nil {
+		return validationSpec, SpecError{errors.Errorf("failed to expand templates: %v", err)}
+	}
+
+	if err := validateMaintenanceWindows(validationSpec.Spec.MaintenanceWindows); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateCanaries(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validatePerGroups(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateHedges(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateBackoff(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateTransport(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateScripts(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateJQExpressions(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateTemplateAssertions(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateScopeSelectors(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateDependencies(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
+	}
+
+	if err := validateNotifications(validationSpec); err != nil {
+		return validationSpec, SpecError{err}
 	}
 
 	return validationSpec, nil
@@ -153,38 +384,24 @@ func NewValidator(c dynamic.Interface, m *v1alpha1.ClusterValidation, r *rest.RE
 			finished: make(chan bool),
 			errors:   make(chan error),
 		},
-		Validation: m,
-		Kubernetes: c,
-		RESTClient: r,
-		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		ClusterResources: make(map[string][]unstructured.Unstructured),
+		Validation:       m,
+		Kubernetes:       c,
+		RESTClient:       r,
+		HTTPClient:       newHTTPClient(m.Spec.Endpoints.Transport),
+		ClusterResources: make(map[schema.GroupVersionResource][]unstructured.Unstructured),
+		stop:             make(chan struct{}),
+		lists:            make(map[listKey]*resourceList),
+		workloads:        make(map[*workloadSet]bool),
 	}
 
 	for _, r := range m.Spec.Resources {
-		v.ClusterResources[r.Name] = make([]unstructured.Unstructured, 0)
+		v.ClusterResources[groupVersionResource(r.APIVersion, r.Name)] = make([]unstructured.Unstructured, 0)
+	}
+	for _, g := range m.Spec.Groups {
+		for _, r := range append(g.AllOf, g.AnyOf...) {
+			v.ClusterResources[groupVersionResource(r.APIVersion, r.Name)] = make([]unstructured.Unstructured, 0)
+		}
 	}
 
 	return v
 }
-
-type ValidationError struct {
-	Message                    error
-	GVR                        schema.GroupVersionResource
-	FieldValidations           []FieldValidationResult
-	ConditionValidations       []ConditionValidationResult
-	ClusterEndpointValidations []ClusterEndpointValidationResult
-	HTTPEndpointValidations    []HTTPEndpointValidationResult
-}
-
-func ToValidationError(err error) ValidationError {
-	return err.(ValidationError)
-}
-
-func (e ValidationError) Error() string {
-	fieldValidationResult, _ := json.MarshalIndent(e.FieldValidations, "", "\t")
-	conditionValidationResult, _ := json.MarshalIndent(e.ConditionValidations, "", "\t")
-	return fmt.Sprintf("%v.\nGVR: %s/%s/%s.\nField Validation Results: %s\nCondition Validation Results: %s", e.Message,
-		e.GVR.Group, e.GVR.Version, e.GVR.Resource, string(fieldValidationResult), string(conditionValidationResult))
-}
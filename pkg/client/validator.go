@@ -15,7 +15,10 @@ package client
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,40 +27,100 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/pager"
 )
 
 var (
 	successEmoji = emoji.Sprint(":check_mark_button:")
 	failEmoji    = emoji.Sprint(":fire:")
+
+	// listPageSize is the number of items requested per list call
+	listPageSize int64 = 500
 )
 
 func (v *Validator) Validate() error {
+	_, err := v.ValidateWithResult()
+	return err
+}
+
+// ValidateWithResult validates like Validate and also returns the report of the run, with the
+// attempts, durations and last results of every validation, whether it succeeded or not.
+func (v *Validator) ValidateWithResult() (Report, error) {
+	started, requests := time.Now(), RequestStatistics()
+	err := v.validate()
+	report := v.Report(started, err)
+	report.RequestStats = RequestStatistics().Since(requests)
+	if len(v.Sinks) > 0 || len(v.Notifiers) > 0 {
+		v.writeReport(report)
+		v.notifyCompletion(report)
+	}
+	return report, err
+}
+
+func (v *Validator) validate() error {
 	var (
 		finished bool
+		failures []error
 		objs     = v.GetValidationObjects()
 	)
 
-	for _, obj := range objs {
-		v.Waiter.Add(1)
+	if v.Preflight {
+		if err := v.preflight(); err != nil {
+			return err
+		}
+	}
+	defer v.stopInformers()
+	v.checkResume()
 
-		switch r := obj.(type) {
-		case v1alpha1.ClusterResource:
-			go v.validateClusterResource(r)
-		case v1alpha1.ClusterEndpoint:
-			go v.validateClusterEndpoint(r)
-		case v1alpha1.HTTPEndpoint:
-			//TODO
-			continue
+	if v.Heartbeat > 0 {
+		done := make(chan struct{})
+		go v.logHeartbeat(done)
+		defer close(done)
+	}
 
-		}
+	if v.CheckpointFile != "" {
+		done := make(chan struct{})
+		go v.checkpointPeriodically(done)
+		defer func() {
+			close(done)
+			if err := v.writeCheckpoint(); err != nil {
+				log.Warnf("failed to write checkpoint: %v", err)
+			}
+		}()
+	}
+
+	dependencies := newDependencyGraph(objs)
+	for _, obj := range objs {
+		v.Waiter.Add(1)
+		go v.validateAfterDependencies(dependencies, obj)
 	}
 
 	go func() {
 		v.Waiter.Wait()
 		close(v.Waiter.finished)
 	}()
+	defer func() {
+		if !finished {
+			// validations still running are stopped and waited for, so none of them creates
+			// workloads after the caller cleaned up
+			v.Stop()
+			<-v.Waiter.finished
+		}
+	}()
+
+	var timeout <-chan time.Time
+	if v.Timeout > 0 {
+		timer := time.NewTimer(v.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
 
 	for {
 		if finished {
@@ -67,119 +130,399 @@ func (v *Validator) Validate() error {
 		case <-v.Waiter.finished:
 			finished = true
 		case err := <-v.Waiter.errors:
-			return err
+			if !v.ContinueOnError {
+				return err
+			}
+			failures = append(failures, err)
+		case <-v.stop:
+			return ErrInterrupted
+		case <-timeout:
+			// validations that did not finish are reported as interrupted
+			v.Stop()
+			<-v.Waiter.finished
+			err := TimeoutError{ValidationError{Message: errors.Errorf("validation run timed out after %v", v.Timeout)}}
+			if !v.ContinueOnError {
+				return err
+			}
+			failures = append(failures, err)
+			finished = true
 		}
 	}
 
-	return nil
+	switch len(failures) {
+	case 0:
+		return nil
+	case 1:
+		return failures[0]
+	default:
+		return MultiError{Errors: failures}
+	}
 }
 
-func (v *Validator) validateClusterResource(r v1alpha1.ClusterResource) {
+type validationTarget interface {
+	GetConfiguration() v1alpha1.ValidationConfiguration
+	SuccessThreshold(globalCfg v1alpha1.ValidationConfiguration) int
+	FailureThreshold(globalCfg v1alpha1.ValidationConfiguration) int
+	Interval(globalCfg v1alpha1.ValidationConfiguration) time.Duration
+	ValidationTimeout(globalCfg v1alpha1.ValidationConfiguration) time.Duration
+}
+
+// fatalError aborts the whole validation run instead of counting as a failed attempt.
+type fatalError struct {
+	error
+}
+
+// unservedError is a list error for a resource the cluster does not serve. Like any fatalError it
+// aborts the run for a required entry, an optional entry is skipped with a warning instead.
+type unservedError struct {
+	error
+}
+
+// runValidation repeatedly evaluates a validation until its success or failure threshold
+// is met, and reports a ThresholdError with the results built by onFailure when a required
+// validation fails. A validation that reaches its timeout first is reported as a TimeoutError.
+// It returns whether the validation succeeded.
+func (v *Validator) runValidation(name, id string, required bool, target validationTarget, evaluate func() (ValidationSummary, error), onFailure func(ValidationSummary) ValidationError) bool {
 	defer v.Waiter.Done()
+
 	var (
 		summary                    = ValidationSummary{}
-		resourceName               = r.Name
 		successCount, failureCount int
 		globalCfg                  = v.GetGlobalConfiguration()
-		successThreshold           = r.SuccessThreshold(globalCfg)
-		failureThreshold           = r.FailureThreshold(globalCfg)
+		successThreshold           = target.SuccessThreshold(globalCfg)
+		failureThreshold           = target.FailureThreshold(globalCfg)
+		err                        error
+		woken                      bool
+		lastFailure                time.Time
+		deadline                   time.Time
 	)
-	log.Infof("validating resource '%v'", resourceName)
 
-	for {
-		err := v.listDynamicResource(r)
-		if err != nil {
-			v.Waiter.errors <- err
+	if timeout := target.ValidationTimeout(globalCfg); timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if v.singlePass {
+		successThreshold, failureThreshold, required = 1, 1, false
+	}
+	progress := v.trackProgress(name, id, successThreshold, failureThreshold)
+
+	if resumed := v.resumedProgress(name, id); resumed != nil {
+		switch resumed.Status {
+		case ValidationStatusSucceeded:
+			v.updateProgress(progress, func(p *ValidationProgress) {
+				p.Status, p.Attempts, p.Successes, p.Summary = resumed.Status, resumed.Attempts, resumed.Successes, resumed.Summary
+			})
+			log.Infof("%v resource '%v' validated successfully in the resumed run", successEmoji, name)
+			return true
+		case ValidationStatusRunning, ValidationStatusInterrupted:
+			successCount, failureCount = resumed.Successes, resumed.Failures
+			v.updateProgress(progress, func(p *ValidationProgress) {
+				p.Attempts, p.Successes, p.Failures = resumed.Attempts, successCount, failureCount
+			})
+			log.Infof("resuming validation of '%v' (%v/%v successful, %v/%v failed)", name, successCount, successThreshold, failureCount, failureThreshold)
 		}
+	}
 
-		resources := v.getValidationResources(r)
-
-		if summary, err = v.validateResources(r, resources); err != nil {
-			failureCount++
+	for {
+		// taken before evaluating, so a change during the attempt wakes the next wait right away
+		changes := v.resourceChanges(target)
+		attemptStart := time.Now()
+		if summary, err = evaluate(); err != nil {
+			var (
+				fatal    fatalError
+				unserved unservedError
+			)
+			if errors.As(err, &fatal) && !required && errors.As(fatal.error, &unserved) {
+				log.Warnf("resource '%v' is not served by the cluster, skipping optional validation -> %v", name, unserved.error)
+				v.updateProgress(progress, func(p *ValidationProgress) {
+					p.Status, p.LastError = ValidationStatusWarning, unserved.Error()
+				})
+				return false
+			}
+			if errors.As(err, &fatal) {
+				v.updateProgress(progress, func(p *ValidationProgress) {
+					p.Status, p.LastError = ValidationStatusFailed, fatal.Error()
+					if errors.Is(fatal.error, ErrInterrupted) {
+						p.Status = ValidationStatusInterrupted
+					}
+				})
+				if !v.singlePass {
+					v.reportError(classifyError(fatal.error))
+				}
+				return false
+			}
 			successCount = 0
-			log.Warnf("validation of '%v' failed (%v/%v) -> %v", resourceName, failureCount, failureThreshold, err)
+			if woken && time.Since(lastFailure) < target.Interval(globalCfg) {
+				// a burst of changes must not use up the failure threshold faster than the interval
+				log.Warnf("validation of '%v' still failing after a change (%v/%v) -> %v", name, failureCount, failureThreshold, err)
+			} else {
+				failureCount++
+				lastFailure = time.Now()
+				log.Warnf("validation of '%v' failed (%v/%v) -> %v", name, failureCount, failureThreshold, err)
+			}
 		} else {
 			successCount++
 			failureCount = 0
-			log.Infof("validation of '%v' successful (%v/%v)", resourceName, successCount, successThreshold)
+			log.Infof("validation of '%v' successful (%v/%v)", name, successCount, successThreshold)
 		}
+		timedOut := !deadline.IsZero() && !time.Now().Before(deadline) && successCount < successThreshold
+		v.updateProgress(progress, func(p *ValidationProgress) {
+			p.Attempts++
+			p.Successes, p.Failures, p.Summary = successCount, failureCount, summary
+			p.Suppressed = summary.Suppressed
+			p.Duration = time.Since(p.Started)
+			p.EvaluationTime += time.Since(attemptStart)
+			if err == nil && p.TimeToFirstSuccess == 0 {
+				p.TimeToFirstSuccess = p.Duration
+			}
+			if err != nil {
+				p.LastError = err.Error()
+			}
+			switch {
+			case successCount >= successThreshold:
+				p.Status = ValidationStatusSucceeded
+			case failureCount >= failureThreshold, timedOut:
+				p.Status = ValidationStatusFailed
+			}
+		})
 
 		if successCount >= successThreshold {
 			if !reflect.DeepEqual(summary, ValidationSummary{}) {
 				prettyPrintStruct(summary)
 			}
-			log.Infof("%v resource '%v' validated successfully", successEmoji, resourceName)
-			return
-		} else if failureCount >= failureThreshold {
+			log.Infof("%v resource '%v' validated successfully", successEmoji, name)
+			return true
+		} else if failureCount >= failureThreshold || timedOut {
 			if !reflect.DeepEqual(summary, ValidationSummary{}) {
 				prettyPrintStruct(summary)
 			}
-			if r.Required {
-				v.Waiter.errors <- ValidationError{
-					Message:              errors.Errorf("failure threshold met for resource '%v'", resourceName),
-					GVR:                  groupVersionResource(r.APIVersion, r.Name),
-					FieldValidations:     summary.FieldValidation,
-					ConditionValidations: summary.ConditionValidation,
-				}
+			vErr := onFailure(summary)
+			vErr.Cluster, vErr.ClusterLabels = v.Validation.Spec.Cluster.Name, v.Validation.Spec.Cluster.Labels
+			if timedOut {
+				vErr.Message = errors.Errorf("validation of '%v' timed out after %v", name, target.ValidationTimeout(globalCfg))
 			}
-			log.Warnf("%v resource '%v' validation failed", failEmoji, resourceName)
-			return
+			if window := v.openMaintenanceWindow(vErr.ID); window != "" {
+				log.Warnf("resource '%v' validation failed during maintenance window '%v', reporting as warning", name, window)
+				v.updateProgress(progress, func(p *ValidationProgress) {
+					p.Status = ValidationStatusWarning
+					p.LastError = fmt.Sprintf("%v (maintenance window '%v')", p.LastError, window)
+				})
+				return false
+			}
+			log.Warnf("%v resource '%v' validation failed", failEmoji, name)
+			v.notifyFailure(name, required, vErr)
+			if vErr.Remediation != "" {
+				log.Warnf("remediation for '%v': %v", name, vErr.Remediation)
+			}
+			if vErr.DocsURL != "" {
+				log.Warnf("documentation for '%v': %v", name, vErr.DocsURL)
+			}
+			if owner := vErr.Ownership(); owner != "" {
+				log.Warnf("'%v' is owned by %v", name, owner)
+			}
+			if vErr.Runbook != "" {
+				log.Warnf("runbook for '%v': %v", name, vErr.Runbook)
+			}
+			if required && timedOut {
+				v.reportError(TimeoutError{vErr})
+			} else if required {
+				v.reportError(ThresholdError{vErr})
+			}
+			return false
+		}
+		wait := retryInterval(v.adaptInterval(target.Interval(globalCfg)), failureCount, target.GetConfiguration(), globalCfg)
+		if !deadline.IsZero() && time.Until(deadline) < wait {
+			// the last attempt is made at the deadline
+			wait = time.Until(deadline)
+		}
+		v.updateProgress(progress, func(p *ValidationProgress) {
+			p.NextAttempt = time.Now().Add(wait)
+		})
+		// only failing validations are woken by changes, successes keep their interval so the
+		// success threshold still measures how long the resources stayed healthy
+		if err == nil {
+			changes = nil
+		}
+		var ok bool
+		if woken, ok = v.sleepUntilChange(wait, changes); !ok {
+			return false
 		}
-		time.Sleep(r.Interval(globalCfg))
 	}
 }
 
-func (v *Validator) validateClusterEndpoint(r v1alpha1.ClusterEndpoint) {
-	defer v.Waiter.Done()
+// reportError hands an error to Validate, unless the validation was stopped and Validate no
+// longer waits for it.
+func (v *Validator) reportError(err error) {
+	select {
+	case v.Waiter.errors <- err:
+	case <-v.stop:
+	}
+}
 
-	var (
-		summary                    = ValidationSummary{}
-		resourceName               = r.Name
-		successCount, failureCount int
-		globalCfg                  = v.GetGlobalConfiguration()
-		successThreshold           = r.SuccessThreshold(globalCfg)
-		failureThreshold           = r.FailureThreshold(globalCfg)
-	)
+func (v *Validator) validateClusterResource(r v1alpha1.ClusterResource) bool {
+	log.Infof("validating resource '%v'", r.Name)
+
+	evaluate := func() (ValidationSummary, error) {
+		if r.Sharding != nil && !v.InformerCache {
+			return v.validateShards(r)
+		}
+		var (
+			streamed *resourceEvaluation
+			err      error
+		)
+		if v.streams(r) {
+			streamed, err = v.streamResources(r)
+		} else {
+			err = v.listDynamicResource(r)
+		}
+		if err != nil {
+			// throttling counts as a failed attempt, the next attempt is delayed by the adapted interval
+			if apierrors.IsTooManyRequests(err) {
+				return ValidationSummary{}, err
+			}
+			if apierrors.IsNotFound(err) {
+				return ValidationSummary{}, fatalError{unservedError{err}}
+			}
+			return ValidationSummary{}, fatalError{err}
+		}
+		if streamed != nil {
+			return streamed.summary()
+		}
+		if r.Canary != nil {
+			return v.validateCanary(r, v.getValidationResources(r))
+		}
+		return v.validateResources(r, v.getValidationResources(r))
+	}
 
-	log.Infof("validating cluster endpoint '%v'", resourceName)
+	onFailure := func(summary ValidationSummary) ValidationError {
+		return ValidationError{
+			ID:                   r.ID,
+			Remediation:          r.Remediation,
+			DocsURL:              r.DocsURL,
+			Owner:                r.Owner,
+			Team:                 r.Team,
+			Runbook:              r.Runbook,
+			Message:              errors.Errorf("failure threshold met for resource '%v'", r.Name),
+			GVR:                  groupVersionResource(r.APIVersion, r.Name),
+			FieldValidations:     summary.FieldValidation,
+			ConditionValidations: summary.ConditionValidation,
+			AggregateValidations: summary.AggregateValidation,
+		}
+	}
 
-	for {
-		res := NewClusterEndpointValidationResult(r.Name)
+	return v.runValidation(r.Name, r.ID, r.Required, &r, v.suppressed(r.Name, r.ID, evaluate), onFailure)
+}
 
-		if out, err := rawGet(v.RESTClient, r.URI); err != nil {
-			failureCount++
-			successCount = 0
+func (v *Validator) validateClusterEndpoint(r v1alpha1.ClusterEndpoint) bool {
+	log.Infof("validating cluster endpoint '%v'", r.Name)
+
+	// the hedge delay is validated when the spec is parsed
+	delay, _ := time.ParseDuration(r.Hedge)
+	evaluate := func() (ValidationSummary, error) {
+		if r.Replicas != nil {
+			return v.probeReplicas(r, delay)
+		}
+		out, err := hedgedGet(v.RESTClient, r.URI, delay)
+		if err != nil {
+			res := NewClusterEndpointValidationResult(r.Name)
+			res.ID = r.ID
 			res.Errors[r.URI] = err.Error()
-			log.Warnf("validation of cluster endpoint '%v' failed (%v/%v) -> %v", resourceName, failureCount, failureThreshold, err)
-		} else {
-			successCount++
-			failureCount = 0
-			log.Debugf("rawGet output for %v: %v", r.Name, out.String())
-			log.Infof("validation of '%v' successful (%v/%v)", resourceName, successCount, successThreshold)
+			return ValidationSummary{ClusterEndpointValidation: []ClusterEndpointValidationResult{res}}, err
+		}
+		log.Debugf("rawGet output for %v: %v", r.Name, out.String())
+		if err := assertJSONResponse(r.JQ, out.Bytes()); err != nil {
+			res := NewClusterEndpointValidationResult(r.Name)
+			res.ID = r.ID
+			res.Code = FailureCodeJQMismatch
+			res.Errors[r.URI] = err.Error()
+			return ValidationSummary{ClusterEndpointValidation: []ClusterEndpointValidationResult{res}}, err
 		}
+		return ValidationSummary{}, nil
+	}
 
-		if successCount >= successThreshold {
-			if !reflect.DeepEqual(summary, ValidationSummary{}) {
-				prettyPrintStruct(summary)
-			}
-			log.Infof("%v resource '%v' validated successfully", successEmoji, resourceName)
-			return
-		} else if failureCount >= failureThreshold {
-			summary.ClusterEndpointValidation = append(summary.ClusterEndpointValidation, res)
-			if !reflect.DeepEqual(summary, ValidationSummary{}) {
-				prettyPrintStruct(summary)
-			}
-			if r.Required {
-				v.Waiter.errors <- ValidationError{
-					Message:                    errors.Errorf("failure threshold met for resource '%v'", resourceName),
-					ClusterEndpointValidations: summary.ClusterEndpointValidation,
-				}
-			}
-			log.Warnf("%v resource '%v' validation failed", failEmoji, resourceName)
-			return
+	onFailure := func(summary ValidationSummary) ValidationError {
+		return ValidationError{
+			ID:                         r.ID,
+			Remediation:                r.Remediation,
+			DocsURL:                    r.DocsURL,
+			Owner:                      r.Owner,
+			Team:                       r.Team,
+			Runbook:                    r.Runbook,
+			Message:                    errors.Errorf("failure threshold met for resource '%v'", r.Name),
+			ClusterEndpointValidations: summary.ClusterEndpointValidation,
+		}
+	}
+
+	return v.runValidation(r.Name, r.ID, r.Required, &r, v.suppressed(r.Name, r.ID, evaluate), onFailure)
+}
+
+func (v *Validator) validateHTTPEndpoint(r v1alpha1.HTTPEndpoint) bool {
+	log.Infof("validating http endpoint '%v'", r.Name)
+
+	// the hedge delay is validated when the spec is parsed
+	delay, _ := time.ParseDuration(r.Hedge)
+	evaluate := func() (ValidationSummary, error) {
+		res := NewHTTPEndpointValidationResult(r.Name)
+		res.ID = r.ID
+
+		status, err := v.hedgedHTTPStatus(r.URL, delay)
+		if err != nil {
+			res.Errors[r.URL] = err.Error()
+			return ValidationSummary{HTTPEndpointValidation: []HTTPEndpointValidationResult{res}}, err
+		}
+		if !expectedStatus(r.Codes, status) {
+			err = errors.Errorf("request to %v returned unexpected status %v", r.URL, status)
+			res.Code = FailureCodeUnexpectedStatus
+			res.Errors[r.URL] = err.Error()
+			return ValidationSummary{HTTPEndpointValidation: []HTTPEndpointValidationResult{res}}, err
+		}
+		return ValidationSummary{}, nil
+	}
+
+	onFailure := func(summary ValidationSummary) ValidationError {
+		return ValidationError{
+			ID:                      r.ID,
+			Remediation:             r.Remediation,
+			DocsURL:                 r.DocsURL,
+			Owner:                   r.Owner,
+			Team:                    r.Team,
+			Runbook:                 r.Runbook,
+			Message:                 errors.Errorf("failure threshold met for resource '%v'", r.Name),
+			HTTPEndpointValidations: summary.HTTPEndpointValidation,
+		}
+	}
+
+	return v.runValidation(r.Name, r.ID, r.Required, &r, v.suppressed(r.Name, r.ID, evaluate), onFailure)
+}
+
+// getHTTPStatus requests the URL with the shared HTTP client and returns the status code of the response.
+func (v *Validator) getHTTPStatus(ctx context.Context, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid request to %v", url)
+	}
+
+	resp, err := v.HTTPClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "request to %v failed", url)
+	}
+	defer resp.Body.Close()
+
+	// drain the body so the connection is returned to the pool
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// expectedStatus reports whether status is one of codes, or below 400 when no codes are given.
+func expectedStatus(codes []int, status int) bool {
+	if len(codes) == 0 {
+		return status < http.StatusBadRequest
+	}
+	for _, code := range codes {
+		if status == code {
+			return true
 		}
-		time.Sleep(r.Interval(globalCfg))
 	}
+	return false
 }
 
 func (v *Validator) getValidationResources(resource v1alpha1.ClusterResource) []unstructured.Unstructured {
@@ -188,171 +531,476 @@ func (v *Validator) getValidationResources(resource v1alpha1.ClusterResource) []
 		validationResources = make([]unstructured.Unstructured, 0)
 	)
 
-	v.RLock()
-	for _, r := range v.ClusterResources[resource.Name] {
-
-		var (
-			namespace = r.GetNamespace()
-			name      = r.GetName()
-		)
-
-		if !inSelectionScope(resource.Namespaces, namespace) {
-			continue
+	if v.InformerCache {
+		items, err := v.cachedResources(groupVersionResource(resource.APIVersion, resource.Name))
+		if err != nil {
+			log.Warnf("failed to read resource '%v' from informer cache: %v", resource.Name, err)
 		}
+		return scopeResources(resource, items)
+	}
 
-		if !inSelectionScope(resource.Names, name) {
-			continue
+	v.RLock()
+	if key := v.resourceListKey(resource); key.partial() {
+		if l, ok := v.lists[key]; ok {
+			validationResources = scopeResources(resource, l.items)
 		}
-
-		validationResources = append(validationResources, r)
+	} else {
+		validationResources = scopeResources(resource, v.ClusterResources[key.gvr])
 	}
 	v.RUnlock()
 
 	return validationResources
 }
 
-func (v *Validator) validateResources(r v1alpha1.ClusterResource, resources []unstructured.Unstructured) (ValidationSummary, error) {
-
+// scopeResources returns the resources within the namespace and name scope of a resource entry.
+func scopeResources(resource v1alpha1.ClusterResource, items []unstructured.Unstructured) []unstructured.Unstructured {
 	var (
-		summary = ValidationSummary{}
-		failed  bool
+		scoped = make([]unstructured.Unstructured, 0)
 	)
 
-	fields := v.validateFields(r, resources)
-	if len(fields) > 0 {
-		summary.FieldValidation = fields
-		failed = true
+	for _, r := range items {
+		if inResourceScope(resource, r) {
+			scoped = append(scoped, r)
+		}
+	}
+
+	return scoped
+}
+
+// inResourceScope reports whether a resource is within the namespace, name, annotation, label
+// and field scope of a resource entry.
+func inResourceScope(resource v1alpha1.ClusterResource, r unstructured.Unstructured) bool {
+	return inSelectionScope(resource.Namespaces, r.GetNamespace()) &&
+		inSelectionScope(resource.Names, r.GetName()) &&
+		inAnnotationScope(resource.Annotations, r.GetAnnotations()) &&
+		inLabelScope(resource.Labels, r.GetLabels()) &&
+		inFieldScope(resource.FieldSelector, r)
+}
+
+// inAnnotationScope reports whether the annotations satisfy every selector. A selector without
+// an operator requires the key to exist, or to equal the value when one is given.
+func inAnnotationScope(selectors []v1alpha1.AnnotationSelector, annotations map[string]string) bool {
+	for _, s := range selectors {
+		value, ok := annotations[s.Key]
+		if !ok {
+			return false
+		}
+		if s.GetOperator() == v1alpha1.AnnotationOperatorEqual && value != s.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// inLabelScope reports whether the labels match the label selector, a nil selector matches everything.
+func inLabelScope(selector *metav1.LabelSelector, set map[string]string) bool {
+	if selector == nil {
+		return true
+	}
+	// the selector is validated when the spec is parsed
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(labels.Set(set))
+}
+
+// inFieldScope reports whether the resource matches the field selector. Fields are read at
+// their dotted path, so the selector is also applied to informer caches and snapshots.
+func inFieldScope(selector string, r unstructured.Unstructured) bool {
+	if selector == "" {
+		return true
+	}
+	// the selector is validated when the spec is parsed
+	s, err := fields.ParseSelector(selector)
+	if err != nil {
+		return false
+	}
+
+	set := fields.Set{}
+	for _, req := range s.Requirements() {
+		val, found, err := unstructured.NestedFieldNoCopy(r.Object, strings.Split(req.Field, ".")...)
+		if err == nil && found && val != nil {
+			set[req.Field] = fmt.Sprintf("%v", val)
+		}
 	}
+	return s.Matches(set)
+}
 
-	conditions := v.validateConditions(r, resources)
-	if len(conditions) > 0 {
-		summary.ConditionValidation = conditions
-		failed = true
+// validateScopeSelectors rejects annotation selectors without a key or with an unknown operator,
+// and label and field selectors that cannot be parsed.
+func validateScopeSelectors(spec *v1alpha1.ClusterValidation) error {
+	resources := append([]v1alpha1.ClusterResource{}, spec.Spec.Resources...)
+	for _, g := range spec.Spec.Groups {
+		resources = append(append(resources, g.AllOf...), g.AnyOf...)
 	}
 
-	if failed {
-		return summary, errors.New("failed to validate resources")
+	for _, r := range resources {
+		if _, err := metav1.LabelSelectorAsSelector(r.Labels); err != nil {
+			return errors.Wrapf(err, "invalid label selector of resource '%v'", r.Name)
+		}
+		if _, err := fields.ParseSelector(r.FieldSelector); err != nil {
+			return errors.Wrapf(err, "invalid field selector of resource '%v'", r.Name)
+		}
+		for _, s := range r.Annotations {
+			if s.Key == "" {
+				return errors.Errorf("annotation selector of resource '%v' requires a key", r.Name)
+			}
+			switch s.Operator {
+			case "", v1alpha1.AnnotationOperatorExists, v1alpha1.AnnotationOperatorEqual:
+			default:
+				return errors.Errorf("annotation selector '%v' of resource '%v' has unsupported operator '%v'", s.Key, r.Name, s.Operator)
+			}
+		}
 	}
+	return nil
+}
 
-	return summary, nil
+func (v *Validator) validateResources(r v1alpha1.ClusterResource, resources []unstructured.Unstructured) (ValidationSummary, error) {
+	e := newResourceEvaluation(r)
+	for _, resource := range resources {
+		e.add(resource)
+	}
+	return e.summary()
 }
 
 func (v *Validator) validateConditions(r v1alpha1.ClusterResource, resources []unstructured.Unstructured) []ConditionValidationResult {
+	e := newResourceEvaluation(v1alpha1.ClusterResource{Conditions: r.Conditions})
+	for _, resource := range resources {
+		e.add(resource)
+	}
+	return failedConditions(e.conditions)
+}
+
+// matchCondition adds the reasons a resource does not have a condition to its result.
+func matchCondition(cond v1alpha1.ResourceCondition, resource unstructured.Unstructured, result *ConditionValidationResult) {
 	var (
-		failedValidations = make([]ConditionValidationResult, 0)
+		conditionStatus = cond.Status
+		conditionType   = cond.Type
+		JSONPath        = cond.Path
+		name            = namespacedName(resource)
+		conditionMatch  bool
 	)
 
-	for _, cond := range r.Conditions {
+	if cond.GracePeriod != "" {
+		grace, err := inGracePeriod(resource, cond.GracePeriod)
+		if err != nil {
+			reason := fmt.Sprintf("condition '%v' has invalid grace period: %v", result.Condition, err)
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+			return
+		}
+		if grace {
+			return
+		}
+	}
 
-		var (
-			conditionStatus = cond.Status
-			conditionType   = cond.Type
-			JSONPath        = cond.Path
-			conditionStr    = fmt.Sprintf("%v=%v", conditionType, conditionStatus)
-			result          = NewConditionValidationResult(conditionStr)
-		)
+	conditions, err := getJsonPathResults(resource, JSONPath)
+	if err != nil {
+		reason := fmt.Sprintf("type mismatch in path %v: %v", JSONPath, err)
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+	}
 
-		for _, resource := range resources {
-			var (
-				name           = namespacedName(resource)
-				conditionMatch bool
-			)
+	if len(conditions) == 0 {
+		result.Code = FailureCodeConditionMissing
+		reason := fmt.Sprintf("conditions not found in resource path %v", JSONPath)
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+	}
 
-			conditions, ok, err := unstructuredSlicePath(resource, JSONPath)
-			if err != nil {
-				reason := fmt.Sprintf("type mismatch in path %v: %v", JSONPath, err)
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		tp, found := condition["type"]
+		if !found {
+			continue
+		}
+		condType, ok := tp.(string)
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(condType, conditionType) {
+			// a missing or non-string status does not match any required status
+			status, _ := condition["status"].(string)
+			conditionMatch = true
+			if !strings.EqualFold(status, string(conditionStatus)) {
+				reason := fmt.Sprintf("found conditions status '%v' does not match required status '%v'", status, conditionStatus)
 				result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
 			}
+		}
+	}
 
-			if !ok {
-				reason := fmt.Sprintf("conditions not found in resource path %v", JSONPath)
-				result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
-			}
+	if !conditionMatch {
+		result.Code = FailureCodeConditionMissing
+		reason := fmt.Sprintf("condition type '%v' was not found in resource path %v", conditionType, JSONPath)
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+	}
+}
 
-			for _, c := range conditions {
-				condition, ok := c.(map[string]interface{})
-				if !ok {
-					continue
-				}
-				tp, found := condition["type"]
-				if !found {
-					continue
-				}
-				condType, ok := tp.(string)
-				if !ok {
-					continue
-				}
-				if strings.EqualFold(condType, conditionType) {
-					status := condition["status"].(string)
-					conditionMatch = true
-					if !strings.EqualFold(status, string(conditionStatus)) {
-						reason := fmt.Sprintf("found conditions status '%v' does not match required status '%v'", status, conditionStatus)
-						result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
-					}
-				}
-			}
+func (v *Validator) validateFields(r v1alpha1.ClusterResource, resources []unstructured.Unstructured) []FieldValidationResult {
+	e := newResourceEvaluation(v1alpha1.ClusterResource{Name: r.Name, Fields: r.Fields})
+	for _, resource := range resources {
+		e.add(resource)
+	}
+	return e.failedFields()
+}
 
-			if !conditionMatch {
-				reason := fmt.Sprintf("condition type '%v' was not found in resource path %v", conditionType, JSONPath)
-				result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
-			}
+// matchField adds the reasons a resource does not match a field to its result, and records the
+// values of a unique field with their owners.
+func matchField(field v1alpha1.FieldSelector, resource unstructured.Unstructured, result *FieldValidationResult, owners map[string][]string) {
+	var (
+		name = namespacedName(resource)
+	)
+
+	values, err := getJsonPathValues(resource, field.GetPath())
+	if err != nil {
+		reason := fmt.Sprintf("field '%v' has type mismatch: %v", field.Path, err)
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+	}
+
+	for _, reason := range matchFieldValues(field, field.GetValues(), values) {
+		result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
+	}
+
+	if field.EqualsPath != "" {
+		if reason := matchFieldPath(resource, field, values); reason != "" {
+			result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
 		}
+	}
 
-		if len(result.ResourceErrors) > 0 {
-			failedValidations = append(failedValidations, result)
+	if field.Unique {
+		// a value repeated within one resource, e.g. in a list, is not a duplicate
+		seen := make(map[string]bool)
+		for _, val := range values {
+			if val != "" && !seen[val] {
+				seen[val] = true
+				owners[val] = append(owners[val], name)
+			}
 		}
 	}
+}
 
-	return failedValidations
+// resourceList is a list call for a GVR shared by all validations of that GVR and selectors.
+type resourceList struct {
+	done    chan struct{}
+	fetched time.Time
+	err     error
+	// items holds the listed resources of partial lists, full lists are kept in
+	// ClusterResources instead
+	items []unstructured.Unstructured
 }
 
-func (v *Validator) validateFields(r v1alpha1.ClusterResource, resources []unstructured.Unstructured) []FieldValidationResult {
-	var (
-		failedValidations = make([]FieldValidationResult, 0)
-	)
+func (l *resourceList) inFlight() bool {
+	select {
+	case <-l.done:
+		return false
+	default:
+		return true
+	}
+}
 
-	for _, field := range r.Fields {
+// listKey identifies the list call of a resource entry, entries with the same selectors that
+// list the same representation share it.
+type listKey struct {
+	gvr           schema.GroupVersionResource
+	labelSelector string
+	fieldSelector string
+	metadataOnly  bool
+	namespaces    string
+}
 
-		var (
-			JSONPath   = field.GetPath()
-			pathValues = field.GetValues()
-			result     = NewFieldValidationResult(field.Path)
-		)
+func (v *Validator) resourceListKey(resource v1alpha1.ClusterResource) listKey {
+	opts := listOptions(resource)
+	return listKey{
+		gvr:           groupVersionResource(resource.APIVersion, resource.Name),
+		labelSelector: opts.LabelSelector,
+		fieldSelector: opts.FieldSelector,
+		metadataOnly:  v.metadataOnly(resource),
+		namespaces:    strings.Join(listNamespaces(resource), ","),
+	}
+}
 
-		for _, resource := range resources {
-			var name = namespacedName(resource)
+// partial reports whether the list holds less than the full objects of the GVR, because it is
+// filtered by the API server, limited to some namespaces or only holds their metadata.
+func (k listKey) partial() bool {
+	return k.labelSelector != "" || k.fieldSelector != "" || k.metadataOnly || k.namespaces != ""
+}
 
-			val, err := getJsonPathValue(resource, JSONPath)
-			if err != nil {
-				reason := fmt.Sprintf("field '%v' has type mismatch: %v", field.Path, err)
-				result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
-			}
+// listNamespaces returns the namespaces to list a resource entry in one by one, when its
+// namespace scope only includes literal namespaces. Listing them individually only requires
+// permission to list in those namespaces instead of cluster-wide. It returns nil when the entry
+// has to be listed in all namespaces.
+func listNamespaces(resource v1alpha1.ClusterResource) []string {
+	var (
+		namespaces = make([]string, 0)
+	)
 
-			if !matchInPatterns(pathValues, val) {
-				reason := fmt.Sprintf("JSONPath values '%v' not matching '%v' in resources", pathValues, val)
-				result.ResourceErrors[reason] = append(result.ResourceErrors[reason], name)
-			}
+	if resource.Namespaces == nil || len(resource.Namespaces.Include) == 0 {
+		return nil
+	}
+	for _, ns := range resource.Namespaces.Include {
+		if ns == "" || strings.ContainsAny(ns, `*?[]{}\`) {
+			return nil
 		}
-
-		if len(result.ResourceErrors) > 0 {
-			failedValidations = append(failedValidations, result)
+		if inSelectionScope(resource.Namespaces, ns) {
+			namespaces = append(namespaces, ns)
 		}
 	}
+	sort.Strings(namespaces)
+	return namespaces
+}
 
-	return failedValidations
+// listOptions translates the label and field selectors of a resource entry into list options,
+// so the API server only returns the resources the entry validates.
+func listOptions(resource v1alpha1.ClusterResource) metav1.ListOptions {
+	var (
+		opts = metav1.ListOptions{FieldSelector: resource.FieldSelector}
+	)
+
+	if resource.Labels != nil {
+		// the selector is validated when the spec is parsed
+		if selector, err := metav1.LabelSelectorAsSelector(resource.Labels); err == nil && !selector.Empty() {
+			opts.LabelSelector = selector.String()
+		}
+	}
+	return opts
 }
 
+// listDynamicResource refreshes the resources of a GVR in ClusterResources, or in its own list
+// when the entry has selectors or only needs metadata. Validations of the same GVR and selectors share a single
+// list call, a list that is in flight or younger than the interval of the resource entry is
+// reused instead of listing again.
 func (v *Validator) listDynamicResource(resource v1alpha1.ClusterResource) error {
 	var (
-		gvr = groupVersionResource(resource.APIVersion, resource.Name)
+		key    = v.resourceListKey(resource)
+		maxAge = resource.Interval(v.GetGlobalConfiguration())
 	)
 
-	resources, err := v.Kubernetes.Resource(gvr).List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return errors.Wrapf(err, "failed to list dynamic resource '%v'", gvr)
+	if v.InformerCache {
+		_, err := v.cachedInformer(key.gvr)
+		return err
 	}
+
+	v.Lock()
+	if l, ok := v.lists[key]; ok && (l.inFlight() || time.Since(l.fetched) < maxAge) {
+		v.Unlock()
+		<-l.done
+		return l.err
+	}
+	l := &resourceList{done: make(chan struct{})}
+	if previous, ok := v.lists[key]; ok {
+		l.items = previous.items
+	}
+	v.lists[key] = l
+	v.Unlock()
+
+	items, err := v.listResources(resource)
+
 	v.Lock()
-	v.ClusterResources[resource.Name] = resources.Items
+	l.fetched, l.err = time.Now(), err
+	if err == nil {
+		if key.partial() {
+			l.items = items
+		} else {
+			v.ClusterResources[key.gvr] = items
+		}
+	}
 	v.Unlock()
+	close(l.done)
+	return err
+}
+
+// listResources lists the resources of an entry in pages of listPageSize items, trimming every
+// item as it is decoded.
+func (v *Validator) listResources(resource v1alpha1.ClusterResource) ([]unstructured.Unstructured, error) {
+	var (
+		items = make([]unstructured.Unstructured, 0)
+	)
+
+	err := v.eachResource(resource, func(u unstructured.Unstructured) {
+		items = append(items, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// eachResource lists the resources of an entry in pages of listPageSize items and hands every
+// item to fn as it is decoded, trimmed. Items are not kept, fn decides what to hold on to.
+func (v *Validator) eachResource(resource v1alpha1.ClusterResource, fn func(unstructured.Unstructured)) error {
+	if v.metadataOnly(resource) {
+		return eachNamespaceResource(resource, v.eachMetadataResource, fn)
+	}
+	return eachNamespaceResource(resource, v.eachSelectedResource, fn)
+}
+
+// eachNamespaceResource lists the resources of an entry with each, in every namespace the entry
+// includes by name, or once across all namespaces.
+func eachNamespaceResource(resource v1alpha1.ClusterResource, each func(schema.GroupVersionResource, string, metav1.ListOptions, func(unstructured.Unstructured)) error, fn func(unstructured.Unstructured)) error {
+	var (
+		gvr        = groupVersionResource(resource.APIVersion, resource.Name)
+		opts       = listOptions(resource)
+		namespaces = listNamespaces(resource)
+	)
+
+	if namespaces == nil {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	for _, ns := range namespaces {
+		if err := each(gvr, ns, opts, fn); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// listNamespaceResources lists the resources of a GVR in a single namespace, or in all
+// namespaces for metav1.NamespaceAll.
+func (v *Validator) listNamespaceResources(gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
+	return v.listSelectedResources(gvr, namespace, metav1.ListOptions{})
+}
+
+// listSelectedResources lists the resources of a GVR in a namespace that match the label and
+// field selectors of opts.
+func (v *Validator) listSelectedResources(gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) ([]unstructured.Unstructured, error) {
+	var (
+		items = make([]unstructured.Unstructured, 0)
+	)
+
+	err := v.eachSelectedResource(gvr, namespace, opts, func(u unstructured.Unstructured) {
+		items = append(items, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// eachSelectedResource lists the resources of a GVR in a namespace that match the selectors of
+// opts in pages, and hands every item to fn as it is decoded.
+func (v *Validator) eachSelectedResource(gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions, fn func(unstructured.Unstructured)) error {
+	p := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		start := time.Now()
+		list, err := v.Kubernetes.Resource(gvr).Namespace(namespace).List(ctx, opts)
+		v.observeRequest(start, err)
+		return list, err
+	})
+	p.PageSize = listPageSize
+
+	err := p.EachListItem(context.Background(), opts, func(obj runtime.Object) error {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return errors.Errorf("unexpected list item type %T", obj)
+		}
+		trimResource(u)
+		fn(*u)
+		return nil
+	})
+	return errors.Wrapf(err, "failed to list dynamic resource '%v'", gvr)
+}
+
+// trimResource drops metadata that is never validated but often makes up most of an object.
+func trimResource(u *unstructured.Unstructured) {
+	u.SetManagedFields(nil)
+}
@@ -13,30 +13,61 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/builtin"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
 	"github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	metadatafake "k8s.io/client-go/metadata/fake"
 	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
 	testingutil "k8s.io/client-go/util/testing"
 )
 
 var (
-	testBasePath = "test-files"
-	NamespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
-	NodeGVR      = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
-	PodGVR       = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
-	DogGVR       = schema.GroupVersionResource{Group: "animals.io", Version: "v1alpha1", Resource: "dogs"}
+	testBasePath  = "test-files"
+	NamespaceGVR  = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	NodeGVR       = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	PodGVR        = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	DogGVR        = schema.GroupVersionResource{Group: "animals.io", Version: "v1alpha1", Resource: "dogs"}
+	SecretGVR     = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	DaemonSetGVR  = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}
+	ServiceGVR    = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	SliceGVR      = schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}
+	IngressGVR    = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	PVGVR         = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}
+	PVCGVR        = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	SCGVR         = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}
+	JobGVR        = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	CronJobGVR    = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}
+	DeploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	CRDGVR        = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+	ValidatingWebhookGVR = schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"}
+	MutatingWebhookGVR   = schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"}
+	APIServiceGVR        = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+	PrometheusGVR        = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheuses"}
 
 	runningContainer = corev1.ContainerState{
 		Running: &corev1.ContainerStateRunning{
@@ -44,6 +75,14 @@ var (
 		},
 	}
 
+	startedContainer = func(age time.Duration) corev1.ContainerState {
+		return corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{
+				StartedAt: metav1.Time{Time: time.Now().Add(-age)},
+			},
+		}
+	}
+
 	terminatedContainer = corev1.ContainerState{
 		Terminated: &corev1.ContainerStateTerminated{
 			Reason: "Evicted",
@@ -53,10 +92,28 @@ var (
 
 func _fakeDynamicClient() *fake.FakeDynamicClient {
 	return fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
-		NamespaceGVR: "NamespaceList",
-		NodeGVR:      "NodeList",
-		PodGVR:       "PodList",
-		DogGVR:       "DogList",
+		NamespaceGVR:  "NamespaceList",
+		NodeGVR:       "NodeList",
+		PodGVR:        "PodList",
+		DogGVR:        "DogList",
+		SecretGVR:     "SecretList",
+		DaemonSetGVR:  "DaemonSetList",
+		ServiceGVR:    "ServiceList",
+		SliceGVR:      "EndpointSliceList",
+		IngressGVR:    "IngressList",
+		PVGVR:         "PersistentVolumeList",
+		PVCGVR:        "PersistentVolumeClaimList",
+		SCGVR:         "StorageClassList",
+		JobGVR:        "JobList",
+		CronJobGVR:    "CronJobList",
+		DeploymentGVR: "DeploymentList",
+		CRDGVR:        "CustomResourceDefinitionList",
+
+		ValidatingWebhookGVR: "ValidatingWebhookConfigurationList",
+		MutatingWebhookGVR:   "MutatingWebhookConfigurationList",
+		APIServiceGVR:        "APIServiceList",
+		instanceGroupGVR:     "InstanceGroupList",
+		PrometheusGVR:        "PrometheusList",
 	})
 }
 
@@ -77,23 +134,31 @@ func _mockValidator(file string, cl *fake.FakeDynamicClient, testServer *httptes
 
 	var restClient *rest.RESTClient
 	if testServer != nil {
-		cfg := &rest.Config{
-			Host: testServer.URL,
-			ContentConfig: rest.ContentConfig{
-				GroupVersion:         &corev1.SchemeGroupVersion,
-				NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
-			},
-			Username: "user",
-			Password: "pass",
-		}
+		restClient = _mockRESTClient(testServer.URL)
+	}
 
-		restClient, err = rest.RESTClientFor(cfg)
-		if err != nil {
-			panic(err)
-		}
+	v := NewValidator(cl, spec, restClient)
+	v.Mapper = _mockRESTMapper()
+	return v
+}
+
+func _mockRESTClient(host string) *rest.RESTClient {
+	cfg := &rest.Config{
+		Host: host,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &corev1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+		Username: "user",
+		Password: "pass",
 	}
 
-	return NewValidator(cl, spec, restClient)
+	RecordRequests(cfg)
+	restClient, err := rest.RESTClientFor(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return restClient
 }
 
 func _mockNamespace(cl *fake.FakeDynamicClient, name string, active bool) {
@@ -133,6 +198,10 @@ func _mockNamespace(cl *fake.FakeDynamicClient, name string, active bool) {
 }
 
 func _mockNode(cl *fake.FakeDynamicClient, name string, ready bool) {
+	_mockLabeledNode(cl, name, ready, nil)
+}
+
+func _mockLabeledNode(cl *fake.FakeDynamicClient, name string, ready bool, labels map[string]string) {
 	var condition corev1.ConditionStatus
 	if ready {
 		condition = corev1.ConditionTrue
@@ -146,7 +215,8 @@ func _mockNode(cl *fake.FakeDynamicClient, name string, ready bool) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name:   name,
+			Labels: labels,
 		},
 		Status: corev1.NodeStatus{
 			Conditions: []corev1.NodeCondition{
@@ -250,6 +320,34 @@ func _mockDog(cl *fake.FakeDynamicClient, name, namespace, phase string) {
 	}
 }
 
+func _mockSecret(cl *fake.FakeDynamicClient, name, namespace string, data map[string][]byte) {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: data,
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(secret)
+	if err != nil {
+		panic(err)
+	}
+
+	unstructuredObj := &unstructured.Unstructured{
+		Object: obj,
+	}
+
+	_, err = cl.Resource(SecretGVR).Namespace(namespace).Create(context.Background(), unstructuredObj, metav1.CreateOptions{})
+	if err != nil {
+		panic(err)
+	}
+}
+
 func Test_PositiveFieldValidation(t *testing.T) {
 	g := gomega.NewWithT(t)
 	gomega.RegisterTestingT(t)
@@ -345,6 +443,85 @@ func Test_NegativeScopeValidation(t *testing.T) {
 	g.Expect(err).To(gomega.HaveOccurred())
 }
 
+func Test_AnnotationScopeValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	pod := func(name string, annotations map[string]string) unstructured.Unstructured {
+		u := unstructured.Unstructured{Object: map[string]interface{}{}}
+		u.SetName(name)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	items := []unstructured.Unstructured{
+		pod("unannotated", nil),
+		pod("team-payments", map[string]string{"team": "payments", "validate": ""}),
+		pod("team-search", map[string]string{"team": "search"}),
+	}
+	scopedNames := func(selectors ...v1alpha1.AnnotationSelector) []string {
+		names := make([]string, 0)
+		for _, r := range scopeResources(v1alpha1.ClusterResource{Annotations: selectors}, items) {
+			names = append(names, r.GetName())
+		}
+		return names
+	}
+
+	g.Expect(scopedNames()).To(gomega.HaveLen(3))
+	g.Expect(scopedNames(v1alpha1.AnnotationSelector{Key: "team"})).To(gomega.Equal([]string{"team-payments", "team-search"}))
+	g.Expect(scopedNames(v1alpha1.AnnotationSelector{Key: "validate", Operator: v1alpha1.AnnotationOperatorExists})).To(gomega.Equal([]string{"team-payments"}))
+	g.Expect(scopedNames(v1alpha1.AnnotationSelector{Key: "team", Value: "search"})).To(gomega.Equal([]string{"team-search"}))
+	g.Expect(scopedNames(v1alpha1.AnnotationSelector{Key: "team", Value: "payments", Operator: v1alpha1.AnnotationOperatorEqual})).To(gomega.Equal([]string{"team-payments"}))
+	g.Expect(scopedNames(v1alpha1.AnnotationSelector{Key: "team"}, v1alpha1.AnnotationSelector{Key: "validate"})).To(gomega.Equal([]string{"team-payments"}))
+
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "test-pod-1", "default", false, terminatedContainer)
+	spec, err := parseValidationSpecData([]byte("spec:\n  configuration:\n    successThreshold: 1\n    failureThreshold: 1\n    interval: 1ms\n  resources:\n  - name: pods\n    apiVersion: v1\n    annotations:\n    - key: team\n    fields:\n    - path: .status.phase\n      values: [Running]\n    required: true\n"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(NewValidator(dynamic, spec, nil).Validate()).To(gomega.Succeed())
+
+	_, err = parseValidationSpecData([]byte("spec:\n  resources:\n  - name: pods\n    apiVersion: v1\n    annotations:\n    - key: team\n      operator: In\n"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+}
+
+func Test_LabelScopeValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	pod := func(name string, labels map[string]string) unstructured.Unstructured {
+		u := unstructured.Unstructured{Object: map[string]interface{}{}}
+		u.SetName(name)
+		u.SetLabels(labels)
+		return u
+	}
+	items := []unstructured.Unstructured{
+		pod("unlabeled", nil),
+		pod("system", map[string]string{"tier": "system", "app": "coredns"}),
+		pod("frontend", map[string]string{"tier": "frontend", "app": "web"}),
+	}
+	scopedNames := func(selector *metav1.LabelSelector) []string {
+		names := make([]string, 0)
+		for _, r := range scopeResources(v1alpha1.ClusterResource{Labels: selector}, items) {
+			names = append(names, r.GetName())
+		}
+		return names
+	}
+
+	g.Expect(scopedNames(nil)).To(gomega.HaveLen(3))
+	g.Expect(scopedNames(&metav1.LabelSelector{MatchLabels: map[string]string{"tier": "system"}})).To(gomega.Equal([]string{"system"}))
+	g.Expect(scopedNames(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"coredns", "web"}},
+		{Key: "tier", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"system"}},
+	}})).To(gomega.Equal([]string{"frontend"}))
+	g.Expect(scopedNames(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "tier", Operator: metav1.LabelSelectorOpDoesNotExist},
+	}})).To(gomega.Equal([]string{"unlabeled"}))
+
+	spec, err := parseValidationSpecData([]byte("spec:\n  resources:\n  - name: pods\n    apiVersion: v1\n    labels:\n      matchLabels:\n        tier: system\n"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(spec.Spec.Resources[0].Labels.MatchLabels).To(gomega.HaveKeyWithValue("tier", "system"))
+
+	_, err = parseValidationSpecData([]byte("spec:\n  resources:\n  - name: pods\n    apiVersion: v1\n    labels:\n      matchExpressions:\n      - key: tier\n        operator: In\n"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+}
+
 func Test_PositiveCustomValidation(t *testing.T) {
 	g := gomega.NewWithT(t)
 	gomega.RegisterTestingT(t)
@@ -414,3 +591,2070 @@ func Test_NegativeEndpointValidation(t *testing.T) {
 	err := v.Validate()
 	g.Expect(err).To(gomega.HaveOccurred())
 }
+
+func Test_PositiveFieldAgeValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_age.yaml", dynamic, nil)
+	_mockPod(dynamic, "test-pod-1", "test-namespace-1", true, startedContainer(10*time.Minute))
+	_mockPod(dynamic, "test-pod-2", "test-namespace-1", true, startedContainer(30*time.Minute))
+	err := v.Validate()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func Test_NegativeFieldAgeValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_age.yaml", dynamic, nil)
+	_mockPod(dynamic, "test-pod-1", "test-namespace-1", true, startedContainer(10*time.Minute))
+	_mockPod(dynamic, "test-pod-2", "test-namespace-1", true, startedContainer(2*time.Hour))
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_PositiveFieldDecodeValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_decode.yaml", dynamic, nil)
+	_mockSecret(dynamic, "bootstrap-token-abcdef", "kube-system", map[string][]byte{
+		"token-id": []byte("abcdef"),
+		"ca.crt":   []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"),
+	})
+	err := v.Validate()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func Test_NegativeFieldDecodeValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_decode.yaml", dynamic, nil)
+	_mockSecret(dynamic, "bootstrap-token-abcdef", "kube-system", map[string][]byte{
+		"token-id": []byte("abcdef"),
+		"ca.crt":   []byte("not-a-certificate"),
+	})
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_PositiveFieldFilterValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_filter.yaml", dynamic, nil)
+	_mockNode(dynamic, "test-node-1", true)
+	_mockNode(dynamic, "test-node-2", true)
+	err := v.Validate()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func Test_NegativeFieldFilterValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_filter.yaml", dynamic, nil)
+	_mockNode(dynamic, "test-node-1", true)
+	_mockNode(dynamic, "test-node-2", false)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_PositiveFieldQuantifierValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_quantifier.yaml", dynamic, nil)
+	_mockNode(dynamic, "test-node-1", true)
+	_mockNode(dynamic, "test-node-2", true)
+	err := v.Validate()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func Test_NegativeFieldQuantifierValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_quantifier.yaml", dynamic, nil)
+	_mockNode(dynamic, "test-node-1", true)
+	_mockNode(dynamic, "test-node-2", false)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_PositiveFieldUniqueValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_unique.yaml", dynamic, nil)
+	_mockDog(dynamic, "test-dog-1", "test-namespace-1", "woof")
+	_mockDog(dynamic, "test-dog-2", "test-namespace-2", "bark")
+	err := v.Validate()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func Test_NegativeFieldUniqueValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("field_validation_unique.yaml", dynamic, nil)
+	_mockDog(dynamic, "test-dog-1", "test-namespace-1", "woof")
+	_mockDog(dynamic, "test-dog-2", "test-namespace-2", "woof")
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_FieldUniqueValidationWithinResource(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	for _, name := range []string{"web-0", "api-0"} {
+		_mockObject(dynamic, PodGVR, &corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: name},
+				{Name: "init", Image: name},
+			}},
+		})
+	}
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{{
+				Name:       "pods",
+				APIVersion: "v1",
+				Fields:     []v1alpha1.FieldSelector{{Path: "{.spec.containers[*].image}", Unique: true}},
+				Required:   true,
+			}},
+		},
+	}
+
+	// both pods repeat their own image, which does not make it a duplicate across pods
+	g.Expect(NewValidator(dynamic, spec, nil).Validate()).To(gomega.Succeed())
+
+	_mockObject(dynamic, PodGVR, &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "web-0"}}},
+	})
+	err := NewValidator(dynamic, spec, nil).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).FieldValidations[0].ResourceErrors).To(gomega.Equal(map[string][]string{
+		"value 'web-0' at '{.spec.containers[*].image}' is not unique across resources": {"default/web-0", "default/web-1"},
+	}))
+}
+
+func Test_PositiveAggregateValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("aggregate_validation.yaml", dynamic, nil)
+	_mockNode(dynamic, "test-node-1", true)
+	_mockNode(dynamic, "test-node-2", true)
+	_mockNode(dynamic, "test-node-3", false)
+	err := v.Validate()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func Test_NegativeAggregateValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("aggregate_validation.yaml", dynamic, nil)
+	_mockNode(dynamic, "test-node-1", true)
+	_mockNode(dynamic, "test-node-2", false)
+	_mockNode(dynamic, "test-node-3", false)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).AggregateValidations).To(gomega.HaveLen(1))
+}
+
+func Test_AggregateFunctions(t *testing.T) {
+	g := gomega.NewWithT(t)
+	resources := make([]unstructured.Unstructured, 0)
+	for _, cpu := range []string{"2", "500m", "1500m"} {
+		u := unstructured.Unstructured{Object: map[string]interface{}{}}
+		_ = unstructured.SetNestedField(u.Object, cpu, "status", "allocatable", "cpu")
+		resources = append(resources, u)
+	}
+
+	for function, expected := range map[v1alpha1.AggregateFunction]float64{
+		v1alpha1.AggregateFunctionSum: 4,
+		v1alpha1.AggregateFunctionAvg: 4.0 / 3.0,
+		v1alpha1.AggregateFunctionMin: 0.5,
+		v1alpha1.AggregateFunctionMax: 2,
+	} {
+		value, err := _aggregate(v1alpha1.AggregateSelector{Function: function, Path: ".status.allocatable.cpu"}, resources)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(value).To(gomega.BeNumerically("~", expected, 0.001))
+	}
+}
+
+func _aggregate(agg v1alpha1.AggregateSelector, resources []unstructured.Unstructured) (float64, error) {
+	state := newAggregateState(agg)
+	for _, resource := range resources {
+		state.add(resource)
+	}
+	return state.value()
+}
+
+func Test_PositiveGroupByValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("group_by_validation.yaml", dynamic, nil)
+	_mockLabeledNode(dynamic, "test-node-1", true, map[string]string{"topology.kubernetes.io/zone": "zone-a"})
+	_mockLabeledNode(dynamic, "test-node-2", false, map[string]string{"topology.kubernetes.io/zone": "zone-a"})
+	_mockLabeledNode(dynamic, "test-node-3", true, map[string]string{"topology.kubernetes.io/zone": "zone-b"})
+	err := v.Validate()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func Test_NegativeGroupByValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("group_by_validation.yaml", dynamic, nil)
+	_mockLabeledNode(dynamic, "test-node-1", true, map[string]string{"topology.kubernetes.io/zone": "zone-a"})
+	_mockLabeledNode(dynamic, "test-node-2", false, map[string]string{"topology.kubernetes.io/zone": "zone-b"})
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).AggregateValidations).To(gomega.HaveLen(1))
+	g.Expect(ToValidationError(err).AggregateValidations[0].Group).To(gomega.Equal("zone-b"))
+}
+
+func Test_PositiveGroupValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("group_validation.yaml", dynamic, nil)
+	_mockDog(dynamic, "test-dog-1", "test-namespace-1", "woof")
+	err := v.Validate()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func Test_NegativeGroupValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("group_validation.yaml", dynamic, nil)
+	_mockDog(dynamic, "test-dog-1", "test-namespace-1", "bla")
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).FieldValidations).To(gomega.HaveLen(1))
+	g.Expect(ToValidationError(err).AggregateValidations).To(gomega.HaveLen(1))
+}
+
+func Test_TemplateExpansion(t *testing.T) {
+	g := gomega.NewWithT(t)
+	spec, err := ParseValidationSpec(filepath.Join(testBasePath, "template_validation.yaml"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	conditions := spec.Spec.Resources[0].Conditions
+	g.Expect(conditions).To(gomega.HaveLen(2))
+	g.Expect(conditions[0].Type).To(gomega.Equal("Ready"))
+	g.Expect(string(conditions[1].Status)).To(gomega.Equal("True"))
+
+	_, err = renderTemplate(spec.Spec.Templates[0], map[string]string{"status": "False"})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_NegativeTemplateValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterTestingT(t)
+	dynamic := _fakeDynamicClient()
+	v := _mockValidator("template_validation.yaml", dynamic, nil)
+	_mockNode(dynamic, "test-node-1", true)
+	_mockNode(dynamic, "test-node-2", false)
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_BundleExpansion(t *testing.T) {
+	g := gomega.NewWithT(t)
+	spec, err := ParseValidationSpec(filepath.Join(testBasePath, "bundle_validation.yaml"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(spec.Spec.Resources).To(gomega.HaveLen(2))
+	g.Expect(spec.Spec.Groups).To(gomega.HaveLen(1))
+	for _, member := range spec.Spec.Groups[0].AnyOf {
+		g.Expect(member.Fields).NotTo(gomega.BeEmpty())
+	}
+}
+
+func Test_PresetsParse(t *testing.T) {
+	g := gomega.NewWithT(t)
+	for _, name := range builtin.Presets() {
+		spec, err := ParsePreset(name)
+		g.Expect(err).NotTo(gomega.HaveOccurred(), name)
+		g.Expect(spec.Spec.Resources).NotTo(gomega.BeEmpty(), name)
+	}
+}
+
+func Test_FieldEqualsPath(t *testing.T) {
+	g := gomega.NewWithT(t)
+	u := unstructured.Unstructured{Object: map[string]interface{}{}}
+	_ = unstructured.SetNestedField(u.Object, int64(3), "spec", "eks", "minSize")
+	_ = unstructured.SetNestedField(u.Object, int64(3), "status", "currentMin")
+	field := v1alpha1.FieldSelector{Path: ".status.currentMin", EqualsPath: ".spec.eks.minSize"}
+
+	values, err := getJsonPathValues(u, field.GetPath())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(matchFieldPath(u, field, values)).To(gomega.BeEmpty())
+
+	_ = unstructured.SetNestedField(u.Object, int64(2), "status", "currentMin")
+	values, err = getJsonPathValues(u, field.GetPath())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(matchFieldPath(u, field, values)).NotTo(gomega.BeEmpty())
+}
+
+func Test_ConditionGracePeriod(t *testing.T) {
+	g := gomega.NewWithT(t)
+	v := &Validator{}
+	u := unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetName("default-abcde")
+	_ = unstructured.SetNestedSlice(u.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "Unknown"},
+	}, "status", "conditions")
+	r := v1alpha1.ClusterResource{
+		Name:       "nodeclaims",
+		Conditions: []v1alpha1.ResourceCondition{{Path: "status.conditions", Type: "Ready", Status: "True", GracePeriod: "15m"}},
+	}
+
+	u.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-5 * time.Minute)))
+	g.Expect(v.validateConditions(r, []unstructured.Unstructured{u})).To(gomega.BeEmpty())
+
+	u.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-30 * time.Minute)))
+	g.Expect(v.validateConditions(r, []unstructured.Unstructured{u})).To(gomega.HaveLen(1))
+}
+
+func Test_ConditionWithoutStatus(t *testing.T) {
+	g := gomega.NewWithT(t)
+	v := &Validator{}
+	u := unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetName("default-abcde")
+	_ = unstructured.SetNestedSlice(u.Object, []interface{}{
+		map[string]interface{}{"type": "Ready"},
+	}, "status", "conditions")
+	r := v1alpha1.ClusterResource{
+		Name:       "nodeclaims",
+		Conditions: []v1alpha1.ResourceCondition{{Path: "status.conditions", Type: "Ready", Status: "True"}},
+	}
+
+	results := v.validateConditions(r, []unstructured.Unstructured{u})
+	g.Expect(results).To(gomega.HaveLen(1))
+	g.Expect(results[0].ResourceErrors).To(gomega.HaveKey("found conditions status '' does not match required status 'True'"))
+}
+
+func Test_AggregateCountOlderThan(t *testing.T) {
+	g := gomega.NewWithT(t)
+	resources := make([]unstructured.Unstructured, 0)
+	for _, age := range []time.Duration{0, 2 * time.Minute, 30 * time.Minute} {
+		u := unstructured.Unstructured{Object: map[string]interface{}{}}
+		if age > 0 {
+			ts := metav1.NewTime(time.Now().Add(-age))
+			u.SetDeletionTimestamp(&ts)
+		}
+		resources = append(resources, u)
+	}
+
+	value, err := _aggregate(v1alpha1.AggregateSelector{Function: v1alpha1.AggregateFunctionCount, Path: ".metadata.deletionTimestamp"}, resources)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(value).To(gomega.BeEquivalentTo(2))
+
+	value, err = _aggregate(v1alpha1.AggregateSelector{Function: v1alpha1.AggregateFunctionCount, Path: ".metadata.deletionTimestamp", OlderThan: "10m"}, resources)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(value).To(gomega.BeEquivalentTo(1))
+}
+
+func Test_SharedResourceList(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+	_mockPod(dynamic, "pod-2", "kube-system", true, runningContainer)
+
+	pods := func(namespace string) v1alpha1.ClusterResource {
+		return v1alpha1.ClusterResource{
+			Name:       "pods",
+			APIVersion: "v1",
+			Namespaces: &v1alpha1.SelectionScope{Include: []string{namespace}},
+			Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+			Required:   true,
+		}
+	}
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 2, FailureThreshold: 1, Interval: "1m"},
+			Resources:     []v1alpha1.ClusterResource{pods("default*"), pods("kube-*"), pods("*")},
+		},
+	}
+
+	// the validations wait a full interval between attempts, so all first attempts share one
+	// cluster-wide list, entries scoped to literal namespaces are listed in those namespaces instead
+	go func() { _ = NewValidator(dynamic, spec, nil).Validate() }()
+	podLists := func() int {
+		var lists int
+		for _, action := range dynamic.Actions() {
+			if action.GetVerb() == "list" && action.GetResource() == PodGVR {
+				lists++
+			}
+		}
+		return lists
+	}
+	g.Eventually(podLists).Should(gomega.Equal(1))
+	g.Consistently(podLists, "200ms").Should(gomega.Equal(1))
+}
+
+func Test_ServerSideSelectors(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+	_mockPod(dynamic, "pod-2", "default", false, terminatedContainer)
+	system := &unstructured.Unstructured{}
+	system.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "Pod"})
+	system.SetName("coredns")
+	system.SetNamespace("kube-system")
+	system.SetLabels(map[string]string{"tier": "system"})
+	g.Expect(unstructured.SetNestedField(system.Object, "Running", "status", "phase")).To(gomega.Succeed())
+	g.Expect(dynamic.Tracker().Create(PodGVR, system, "kube-system")).To(gomega.Succeed())
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:          "pods",
+					APIVersion:    "v1",
+					Labels:        &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "system"}},
+					FieldSelector: "status.phase=Running",
+					Aggregates:    []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: "==", Value: "1"}},
+					Required:      true,
+				},
+				{
+					Name:       "pods",
+					APIVersion: "v1",
+					Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: "==", Value: "3"}},
+					Required:   true,
+				},
+			},
+		},
+	}
+
+	g.Expect(NewValidator(dynamic, spec, nil).Validate()).To(gomega.Succeed())
+
+	restrictions := make([]string, 0)
+	for _, action := range dynamic.Actions() {
+		if list, ok := action.(clienttesting.ListAction); ok && action.GetResource() == PodGVR {
+			restrictions = append(restrictions, fmt.Sprintf("%v|%v", list.GetListRestrictions().Labels, list.GetListRestrictions().Fields))
+		}
+	}
+	g.Expect(restrictions).To(gomega.ConsistOf("tier=system|status.phase=Running", "|"))
+
+	// field selectors are also applied to the listed resources, e.g. when read from an informer cache
+	items, err := NewValidator(dynamic, spec, nil).listNamespaceResources(PodGVR, metav1.NamespaceAll)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	running := scopeResources(v1alpha1.ClusterResource{FieldSelector: "status.phase=Running,metadata.namespace!=kube-system"}, items)
+	g.Expect(running).To(gomega.HaveLen(1))
+	g.Expect(running[0].GetName()).To(gomega.Equal("pod-1"))
+
+	_, err = parseValidationSpecData([]byte("spec:\n  resources:\n  - name: pods\n    apiVersion: v1\n    fieldSelector: status.phase\n"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+}
+
+func Test_MetadataOnlyListing(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+
+	metaScheme := runtime.NewScheme()
+	g.Expect(metav1.AddMetaToScheme(metaScheme)).To(gomega.Succeed())
+	partialPod := func(name string, labels map[string]string) runtime.Object {
+		return &metav1.PartialObjectMetadata{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		}
+	}
+	meta := metadatafake.NewSimpleMetadataClient(metaScheme,
+		partialPod("pod-1", map[string]string{"tier": "system"}),
+		partialPod("pod-2", map[string]string{"tier": "system"}),
+		partialPod("pod-3", nil),
+	)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					APIVersion: "v1",
+					Labels:     &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "system"}},
+					Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: "==", Value: "2"}},
+					Required:   true,
+				},
+				{
+					Name:       "pods",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: "==", Value: "1"}},
+					Required:   true,
+				},
+			},
+		},
+	}
+
+	v := NewValidator(dynamic, spec, nil)
+	v.Metadata = meta
+	g.Expect(v.Validate()).To(gomega.Succeed())
+	g.Expect(meta.Actions()).To(gomega.HaveLen(1))
+	g.Expect(meta.Actions()[0].GetVerb()).To(gomega.Equal("list"))
+
+	// without a metadata client every entry lists full objects
+	v = NewValidator(dynamic, spec, nil)
+	g.Expect(v.metadataOnly(spec.Spec.Resources[0])).To(gomega.BeFalse())
+	g.Expect(v.Validate()).NotTo(gomega.Succeed())
+}
+
+func Test_NamespacedListing(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+	_mockPod(dynamic, "pod-2", "team-a", true, runningContainer)
+	_mockPod(dynamic, "pod-3", "team-b", false, terminatedContainer)
+	dynamic.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == metav1.NamespaceAll {
+			return true, nil, apierrors.NewForbidden(PodGVR.GroupResource(), "", errors.New("cluster-wide list is not allowed"))
+		}
+		return false, nil, nil
+	})
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{{
+				Name:       "pods",
+				APIVersion: "v1",
+				Namespaces: &v1alpha1.SelectionScope{Include: []string{"team-a", "default"}},
+				Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+				Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: "==", Value: "2"}},
+				Required:   true,
+			}},
+		},
+	}
+
+	g.Expect(NewValidator(dynamic, spec, nil).Validate()).To(gomega.Succeed())
+
+	namespaces := make([]string, 0)
+	for _, action := range dynamic.Actions() {
+		if action.GetVerb() == "list" && action.GetResource() == PodGVR {
+			namespaces = append(namespaces, action.GetNamespace())
+		}
+	}
+	g.Expect(namespaces).To(gomega.Equal([]string{"default", "team-a"}))
+
+	// globs need a cluster-wide list
+	spec.Spec.Resources[0].Namespaces.Include = []string{"team-*"}
+	g.Expect(listNamespaces(spec.Spec.Resources[0])).To(gomega.BeNil())
+	g.Expect(NewValidator(dynamic, spec, nil).Validate()).NotTo(gomega.Succeed())
+}
+
+func Test_InformerCacheValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{{
+				Name:       "pods",
+				APIVersion: "v1",
+				Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+				Required:   true,
+			}},
+		},
+	}
+	v := NewValidator(dynamic, spec, nil)
+	v.InformerCache = true
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	verbs := make([]string, 0)
+	for _, action := range dynamic.Actions() {
+		if action.GetResource() == PodGVR && action.GetVerb() != "create" {
+			verbs = append(verbs, action.GetVerb())
+		}
+	}
+	g.Expect(verbs).To(gomega.ConsistOf("list", "watch"))
+
+	_mockPod(dynamic, "pod-2", "default", false, runningContainer)
+	v = NewValidator(dynamic, spec, nil)
+	v.InformerCache = true
+	g.Expect(v.Validate()).NotTo(gomega.Succeed())
+}
+
+func Test_EventDrivenValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", false, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 3, Interval: "1m"},
+			Resources: []v1alpha1.ClusterResource{{
+				Name:       "pods",
+				APIVersion: "v1",
+				Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+				Required:   true,
+			}},
+		},
+	}
+	v := NewValidator(dynamic, spec, nil)
+	v.InformerCache, v.EventDriven = true, true
+
+	result := make(chan error)
+	go func() { result <- v.Validate() }()
+	g.Eventually(func() int {
+		progress := v.Progress()
+		if len(progress) == 0 {
+			return 0
+		}
+		return progress[0].Failures
+	}).Should(gomega.Equal(1))
+
+	// the pod becomes ready long before the interval of a minute is up, it is updated until the
+	// change reaches the watch of the fake client, which may start after the first update
+	var generation int64
+	g.Eventually(func() error {
+		pod, err := dynamic.Resource(PodGVR).Namespace("default").Get(context.Background(), "pod-1", metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		// the fake client does not bump resource versions, updates without one are ignored
+		generation++
+		pod.SetResourceVersion(fmt.Sprint(generation))
+		g.Expect(unstructured.SetNestedField(pod.Object, "Running", "status", "phase")).To(gomega.Succeed())
+		_, err = dynamic.Resource(PodGVR).Namespace("default").Update(context.Background(), pod, metav1.UpdateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		select {
+		case err := <-result:
+			return err
+		case <-time.After(100 * time.Millisecond):
+			return errors.New("validation did not finish")
+		}
+	}, "5s").Should(gomega.Succeed())
+	g.Expect(v.Progress()[0].Failures).To(gomega.Equal(0))
+	g.Expect(v.Progress()[0].Status).To(gomega.Equal(ValidationStatusSucceeded))
+}
+
+func Test_ListResourcesTrimsItems(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockObject(dynamic, PodGVR, &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:          "pod-1",
+			Namespace:     "default",
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply}},
+		},
+	})
+
+	v := NewValidator(dynamic, &v1alpha1.ClusterValidation{}, nil)
+	items, err := v.listResources(v1alpha1.ClusterResource{Name: "pods", APIVersion: "v1"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(items).To(gomega.HaveLen(1))
+	g.Expect(items[0].GetName()).To(gomega.Equal("pod-1"))
+	g.Expect(items[0].GetManagedFields()).To(gomega.BeEmpty())
+}
+
+func Test_StreamListsValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	for _, name := range []string{"pod-1", "pod-2", "pod-3"} {
+		_mockPod(dynamic, name, "default", true, runningContainer)
+	}
+	_mockPod(dynamic, "pod-4", "kube-system", false, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					APIVersion: "v1",
+					Names:      &v1alpha1.SelectionScope{Include: []string{"*"}, Exclude: []string{"pod-3"}},
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Conditions: []v1alpha1.ResourceCondition{{Path: "status.conditions", Type: "Ready", Status: corev1.ConditionTrue}},
+					Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: ">=", Value: "4"}},
+					GroupBy: &v1alpha1.GroupBySelector{
+						Path:       ".metadata.namespace",
+						Groups:     []string{"monitoring"},
+						Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: ">=", Value: "1"}},
+					},
+					Required: true,
+				},
+			},
+		},
+	}
+
+	listed := NewValidator(dynamic, spec, nil)
+	listErr := listed.Validate()
+	g.Expect(listErr).To(gomega.HaveOccurred())
+
+	streamed := NewValidator(dynamic, spec, nil)
+	streamed.StreamLists = true
+	streamErr := streamed.Validate()
+	g.Expect(streamErr).To(gomega.HaveOccurred())
+
+	// streaming reports the same results without keeping the listed resources
+	expected, actual := ToValidationError(listErr), ToValidationError(streamErr)
+	g.Expect(actual.FieldValidations).To(gomega.Equal(expected.FieldValidations))
+	g.Expect(actual.ConditionValidations).To(gomega.Equal(expected.ConditionValidations))
+	g.Expect(actual.AggregateValidations).To(gomega.Equal(expected.AggregateValidations))
+	g.Expect(actual.AggregateValidations).To(gomega.HaveLen(2))
+	g.Expect(actual.FieldValidations[0].ResourceErrors).To(gomega.ContainElement([]string{"kube-system/pod-4"}))
+	g.Expect(streamed.ClusterResources[PodGVR]).To(gomega.BeEmpty())
+	g.Expect(streamed.lists).To(gomega.BeEmpty())
+}
+
+func Test_AdaptiveInterval(t *testing.T) {
+	g := gomega.NewWithT(t)
+	v := NewValidator(_fakeDynamicClient(), &v1alpha1.ClusterValidation{}, nil)
+	throttled := apierrors.NewTooManyRequests("slow down", 1)
+
+	v.pressure.observe(0, throttled)
+	g.Expect(v.adaptInterval(time.Second)).To(gomega.Equal(time.Second))
+
+	v.Validation.Spec.Configuration.MaxInterval = "5s"
+	g.Expect(v.adaptInterval(time.Second)).To(gomega.Equal(2 * time.Second))
+
+	v.pressure.observe(0, throttled)
+	v.pressure.observe(2*time.Second, nil)
+	g.Expect(v.adaptInterval(time.Second)).To(gomega.Equal(5 * time.Second))
+
+	for i := 0; i < 10; i++ {
+		v.pressure.observe(time.Millisecond, nil)
+	}
+	g.Expect(v.adaptInterval(time.Second)).To(gomega.Equal(time.Second))
+}
+
+func Test_RetryInterval(t *testing.T) {
+	g := gomega.NewWithT(t)
+	global := v1alpha1.ValidationConfiguration{Backoff: &v1alpha1.BackoffConfig{MaxInterval: "10s"}}
+	entry := v1alpha1.ValidationConfiguration{}
+
+	g.Expect(retryInterval(time.Second, 0, entry, global)).To(gomega.Equal(time.Second))
+	g.Expect(retryInterval(time.Second, 1, entry, global)).To(gomega.Equal(time.Second))
+	g.Expect(retryInterval(time.Second, 2, entry, global)).To(gomega.Equal(2 * time.Second))
+	g.Expect(retryInterval(time.Second, 4, entry, global)).To(gomega.Equal(8 * time.Second))
+	g.Expect(retryInterval(time.Second, 50, entry, global)).To(gomega.Equal(10 * time.Second))
+
+	// the backoff of an entry overrides the global one
+	entry.Backoff = &v1alpha1.BackoffConfig{Factor: 3, MaxInterval: "1m"}
+	g.Expect(retryInterval(time.Second, 3, entry, global)).To(gomega.Equal(9 * time.Second))
+	entry.Backoff = &v1alpha1.BackoffConfig{Factor: 1.5, MaxInterval: "1m"}
+	g.Expect(retryInterval(time.Second, 3, entry, global)).To(gomega.Equal(2250 * time.Millisecond))
+
+	entry = v1alpha1.ValidationConfiguration{Jitter: 20}
+	for i := 0; i < 100; i++ {
+		g.Expect(retryInterval(time.Second, 0, entry, v1alpha1.ValidationConfiguration{})).To(gomega.And(
+			gomega.BeNumerically(">=", 800*time.Millisecond),
+			gomega.BeNumerically("<=", 1200*time.Millisecond),
+		))
+	}
+
+	for spec, message := range map[string]string{
+		"spec:\n  configuration:\n    backoff:\n      factor: 0.5\n      maxInterval: 1m\n":                      "backoff factor of the global configuration must be at least 1, got 0.5",
+		"spec:\n  configuration:\n    backoff:\n      factor: 2\n":                                               "backoff of the global configuration requires a maxInterval",
+		"spec:\n  resources:\n  - name: nodes\n    configuration:\n      backoff:\n        maxInterval: later\n": "backoff maxInterval of resource 'nodes' must be a positive duration, got 'later'",
+		"spec:\n  endpoints:\n    http:\n    - name: docs\n      configuration:\n        jitter: 150\n":          "jitter of http endpoint 'docs' must be between 0 and 100, got 150",
+	} {
+		_, err := parseValidationSpecData([]byte(spec))
+		g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+		g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(message)))
+	}
+}
+
+func Test_RequestStatistics(t *testing.T) {
+	g := gomega.NewWithT(t)
+	before := RequestStatistics()
+
+	ok := _mockServer(t, "ok", 200)
+	defer ok.Close()
+	failing := _mockServer(t, "failed", 500)
+	defer failing.Close()
+
+	var attempts int32
+	retried := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer retried.Close()
+
+	_, err := rawGet(_mockRESTClient(ok.URL), "/readyz")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = rawGet(_mockRESTClient(failing.URL), "/readyz")
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = rawGet(_mockRESTClient(retried.URL), "/api/v1/namespaces/default/pods")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	stats := RequestStatistics().Since(before)
+	g.Expect(stats.Requests).To(gomega.And(gomega.HaveKeyWithValue("get /readyz", 2), gomega.HaveKeyWithValue("list pods", 2)))
+	g.Expect(stats.Errors).To(gomega.And(gomega.HaveKeyWithValue("get /readyz", 1), gomega.HaveKeyWithValue("list pods", 1)))
+	g.Expect(stats.Retries).To(gomega.HaveKeyWithValue("list pods", 1))
+	g.Expect(stats.String()).To(gomega.ContainSubstring("retries (list pods 1)"))
+}
+
+func Test_RequestKey(t *testing.T) {
+	g := gomega.NewWithT(t)
+	tests := []struct {
+		method string
+		uri    string
+		key    string
+	}{
+		{"GET", "/api/v1/namespaces/default/pods", "list pods"},
+		{"GET", "/api/v1/namespaces/default/pods?watch=true", "watch pods"},
+		{"GET", "/api/v1/watch/namespaces/default/pods", "watch pods"},
+		{"GET", "/api/v1/namespaces/default/pods/web-0", "get pods"},
+		{"GET", "/api/v1/namespaces/default/pods/web-0/log", "get pods/log"},
+		{"GET", "/api/v1/namespaces", "list namespaces"},
+		{"GET", "/api/v1/namespaces/default", "get namespaces"},
+		{"GET", "/apis/apps/v1/deployments", "list deployments"},
+		{"PUT", "/apis/apps/v1/namespaces/default/deployments/web/scale", "update deployments/scale"},
+		{"POST", "/apis/batch/v1/namespaces/default/jobs", "create jobs"},
+		{"PATCH", "/apis/batch/v1/namespaces/default/jobs/smoke", "patch jobs"},
+		{"DELETE", "/apis/batch/v1/namespaces/default/jobs/smoke", "delete jobs"},
+		{"DELETE", "/apis/batch/v1/namespaces/default/jobs", "deletecollection jobs"},
+		{"GET", "/apis/apps/v1", "get /apis/apps/v1"},
+		{"GET", "/readyz", "get /readyz"},
+	}
+	for _, tc := range tests {
+		u, err := url.Parse(tc.uri)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(requestKey(tc.method, u)).To(gomega.Equal(tc.key), tc.uri)
+	}
+}
+
+func Test_ShardedValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	for _, ns := range []string{"team-a", "team-b", "team-c", "team-d", "kube-system"} {
+		_mockNamespace(dynamic, ns, true)
+		_mockPod(dynamic, "pod-1", ns, true, runningContainer)
+	}
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{{
+				Name:       "pods",
+				APIVersion: "v1",
+				Namespaces: &v1alpha1.SelectionScope{Include: []string{"team-*"}},
+				Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+				Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: "==", Value: "4"}},
+				Sharding:   &v1alpha1.ShardingConfig{Shards: 3, Workers: 2},
+				Required:   true,
+			}},
+		},
+	}
+	g.Expect(NewValidator(dynamic, spec, nil).Validate()).To(gomega.Succeed())
+
+	namespaces := make([]string, 0)
+	for _, action := range dynamic.Actions() {
+		if action.GetVerb() == "list" && action.GetResource() == PodGVR {
+			namespaces = append(namespaces, action.GetNamespace())
+		}
+	}
+	g.Expect(namespaces).To(gomega.ConsistOf("team-a", "team-b", "team-c", "team-d"))
+
+	_mockPod(dynamic, "pod-2", "team-c", false, runningContainer)
+	err := NewValidator(dynamic, spec, nil).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	fields := ToValidationError(err).FieldValidations
+	g.Expect(fields).To(gomega.HaveLen(1))
+	g.Expect(fields[0].ResourceErrors).To(gomega.ContainElement(gomega.ConsistOf("team-c/pod-2")))
+	g.Expect(ToValidationError(err).AggregateValidations).To(gomega.HaveLen(1))
+}
+
+func Test_PartitionNamespaces(t *testing.T) {
+	g := gomega.NewWithT(t)
+	shards := partitionNamespaces([]string{"a", "b", "c", "d", "e"}, 2)
+	g.Expect(shards).To(gomega.HaveLen(2))
+	g.Expect(shards[0].namespaces).To(gomega.Equal([]string{"a", "b"}))
+	g.Expect(shards[1].namespaces).To(gomega.Equal([]string{"c", "d", "e"}))
+	g.Expect(partitionNamespaces([]string{"a"}, 4)).To(gomega.HaveLen(1))
+}
+
+func Test_ErrorClasses(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	_, err := ParseValidationSpec(filepath.Join(testBasePath, "missing.yaml"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+	var specErr SpecError
+	g.Expect(errors.As(err, &specErr)).To(gomega.BeTrue())
+
+	dynamic := _fakeDynamicClient()
+	_mockNode(dynamic, "test-node-2", false)
+	err = _mockValidator("condition_validation.yaml", dynamic, nil).Validate()
+	g.Expect(errors.Is(err, ErrThreshold)).To(gomega.BeTrue())
+	g.Expect(errors.Is(err, ErrAccess)).To(gomega.BeFalse())
+	var thresholdErr ThresholdError
+	g.Expect(errors.As(err, &thresholdErr)).To(gomega.BeTrue())
+	g.Expect(thresholdErr.ConditionValidations).NotTo(gomega.BeEmpty())
+
+	dynamic = _fakeDynamicClient()
+	dynamic.PrependReactor("list", "nodes", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "", errors.New("denied"))
+	})
+	err = _mockValidator("condition_validation.yaml", dynamic, nil).Validate()
+	g.Expect(errors.Is(err, ErrAccess)).To(gomega.BeTrue())
+	g.Expect(ToValidationError(err).Message).To(gomega.HaveOccurred())
+
+	err = classifyError(apierrors.NewTimeoutError("list", 1))
+	g.Expect(errors.Is(err, ErrTimeout)).To(gomega.BeTrue())
+	g.Expect(ToValidationError(errors.New("plain")).Message).To(gomega.MatchError("plain"))
+}
+
+func Test_FailureCodes(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", false, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Cluster:       v1alpha1.ClusterIdentity{Name: "prod-us-west-2", Labels: map[string]string{"env": "prod"}},
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Groups: []v1alpha1.ValidationGroup{{
+				Name:    "pods",
+				ID:      "pods-healthy",
+				DocsURL: "https://runbooks.example.com/pods",
+				Owner:   "payments-oncall",
+				Team:    "payments",
+				AllOf: []v1alpha1.ClusterResource{
+					{
+						Name:       "pods",
+						ID:         "pods-running",
+						APIVersion: "v1",
+						Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					},
+					{
+						Name:       "pods",
+						APIVersion: "v1",
+						Conditions: []v1alpha1.ResourceCondition{{Type: "Ready", Status: "True", Path: ".status.conditions"}},
+					},
+				},
+				Required: true,
+			}},
+		},
+	}
+
+	err := NewValidator(dynamic, spec, nil).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	vErr := ToValidationError(err)
+	g.Expect(vErr.ID).To(gomega.Equal("pods-healthy"))
+	g.Expect(vErr.DocsURL).To(gomega.Equal("https://runbooks.example.com/pods"))
+	g.Expect(vErr.Ownership()).To(gomega.Equal("payments-oncall (payments)"))
+	g.Expect(vErr.Cluster).To(gomega.Equal("prod-us-west-2"))
+	g.Expect(vErr.ClusterLabels).To(gomega.HaveKeyWithValue("env", "prod"))
+	g.Expect(vErr.Error()).To(gomega.ContainSubstring("Cluster: prod-us-west-2"))
+	g.Expect(vErr.Codes()).To(gomega.ConsistOf(FailureCodeFieldMismatch, FailureCodeConditionMissing))
+	g.Expect(vErr.FieldValidations[0].ID).To(gomega.Equal("pods-running"))
+	g.Expect(vErr.ConditionValidations[0].ID).To(gomega.Equal("pods-healthy"))
+}
+
+func Test_ContinueOnError(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", false, runningContainer)
+	_mockNode(dynamic, "node-1", false)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					ID:         "pods-running",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+				{
+					Name:       "nodes",
+					ID:         "nodes-ready",
+					APIVersion: "v1",
+					Conditions: []v1alpha1.ResourceCondition{{Type: "Ready", Status: "True", Path: ".status.conditions"}},
+					Required:   true,
+				},
+			},
+		},
+	}
+
+	v := NewValidator(dynamic, spec, nil)
+	v.ContinueOnError = true
+	err := v.Validate()
+	g.Expect(errors.Is(err, ErrThreshold)).To(gomega.BeTrue())
+
+	var mErr MultiError
+	g.Expect(errors.As(err, &mErr)).To(gomega.BeTrue())
+	g.Expect(mErr.Errors).To(gomega.HaveLen(2))
+	for _, p := range v.Progress() {
+		g.Expect(p.Status).To(gomega.Equal(ValidationStatusFailed))
+	}
+
+	vErr := ToValidationError(err)
+	g.Expect(vErr.Codes()).To(gomega.ConsistOf(FailureCodeFieldMismatch, FailureCodeConditionMismatch))
+	g.Expect(vErr.Message.Error()).To(gomega.And(
+		gomega.ContainSubstring("failure threshold met for resource 'pods'"),
+		gomega.ContainSubstring("failure threshold met for resource 'nodes'"),
+	))
+
+	spec.Spec.Resources = spec.Spec.Resources[:1]
+	v = NewValidator(dynamic, spec, nil)
+	v.ContinueOnError = true
+	g.Expect(errors.As(v.Validate(), &mErr)).To(gomega.BeFalse())
+}
+
+func Test_ValidationTimeout(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", false, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1000, Interval: "1h", Timeout: "1h"},
+			Resources: []v1alpha1.ClusterResource{{
+				Name:          "pods",
+				APIVersion:    "v1",
+				Configuration: v1alpha1.ValidationConfiguration{Timeout: "50ms"},
+				Fields:        []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+				Required:      true,
+			}},
+		},
+	}
+
+	// the resource timeout overrides the global one and cuts the interval short
+	v := NewValidator(dynamic, spec, nil)
+	err := v.Validate()
+	g.Expect(errors.Is(err, ErrTimeout)).To(gomega.BeTrue())
+	g.Expect(ToValidationError(err).Message.Error()).To(gomega.Equal("validation of 'pods' timed out after 50ms"))
+	g.Expect(ToValidationError(err).Codes()).To(gomega.ConsistOf(FailureCodeFieldMismatch))
+	g.Expect(v.Progress()[0].Status).To(gomega.Equal(ValidationStatusFailed))
+	g.Expect(v.Progress()[0].Attempts).To(gomega.Equal(2))
+
+	// the run timeout stops every validation that did not finish
+	spec.Spec.Resources[0].Configuration.Timeout = ""
+	v = NewValidator(dynamic, spec, nil)
+	v.Timeout = 50 * time.Millisecond
+	err = v.Validate()
+	g.Expect(errors.Is(err, ErrTimeout)).To(gomega.BeTrue())
+	g.Expect(ToValidationError(err).Message.Error()).To(gomega.Equal("validation run timed out after 50ms"))
+	g.Expect(v.Progress()[0].Status).To(gomega.Equal(ValidationStatusInterrupted))
+}
+
+func Test_DependsOn(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", false, runningContainer)
+	_mockNode(dynamic, "node-1", true)
+
+	nodes := v1alpha1.ClusterResource{
+		Name:       "nodes",
+		ID:         "nodes-ready",
+		APIVersion: "v1",
+		Conditions: []v1alpha1.ResourceCondition{{Type: "Ready", Status: "True", Path: ".status.conditions"}},
+		Required:   true,
+	}
+	pods := v1alpha1.ClusterResource{
+		Name:       "pods",
+		ID:         "pods-running",
+		APIVersion: "v1",
+		DependsOn:  []string{"nodes-ready"},
+		Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+	}
+	endpoint := v1alpha1.ClusterEndpoint{Name: "healthz", URI: "/healthz", DependsOn: []string{"pods-running"}, Required: true}
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 2, FailureThreshold: 1, Interval: "10ms"},
+			Resources:     []v1alpha1.ClusterResource{pods, nodes},
+			Endpoints:     v1alpha1.EndpointsSpec{Cluster: []v1alpha1.ClusterEndpoint{endpoint}},
+		},
+	}
+
+	// the pods wait for the nodes, and the endpoint is skipped because the pods failed
+	v := NewValidator(dynamic, spec, nil)
+	err := v.Validate()
+	g.Expect(errors.Is(err, ErrDependency)).To(gomega.BeTrue())
+	g.Expect(ToValidationError(err).Message.Error()).To(gomega.Equal("validation of 'healthz' skipped, dependency 'pods-running' did not succeed"))
+
+	progress := make(map[string]ValidationProgress)
+	for _, p := range v.Progress() {
+		progress[p.Name] = p
+	}
+	g.Expect(progress["nodes"].Status).To(gomega.Equal(ValidationStatusSucceeded))
+	g.Expect(progress["pods"].Status).To(gomega.Equal(ValidationStatusFailed))
+	g.Expect(progress["pods"].Started).To(gomega.BeTemporally(">=", progress["nodes"].Started.Add(progress["nodes"].Duration)))
+	g.Expect(progress["healthz"].Status).To(gomega.Equal(ValidationStatusFailed))
+	g.Expect(progress["healthz"].Attempts).To(gomega.BeZero())
+
+	for _, manifest := range []string{
+		"spec:\n  resources:\n  - name: pods\n    apiVersion: v1\n    dependsOn: [missing]\n",
+		"spec:\n  resources:\n  - name: pods\n    id: a\n    apiVersion: v1\n    dependsOn: [b]\n  - name: nodes\n    id: b\n    apiVersion: v1\n    dependsOn: [a]\n",
+		"spec:\n  resources:\n  - name: pods\n    id: a\n    apiVersion: v1\n  groups:\n  - name: group\n    allOf:\n    - name: nodes\n      apiVersion: v1\n      dependsOn: [a]\n",
+	} {
+		_, err := parseValidationSpecData([]byte(manifest))
+		g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue(), manifest)
+	}
+}
+
+func Test_Suppressions(t *testing.T) {
+	g := gomega.NewWithT(t)
+	expr.Now = func() time.Time { return time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC) }
+	defer func() { expr.Now = time.Now }()
+
+	suppressions, err := ParseSuppressions(filepath.Join(testBasePath, "suppressions.yaml"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(suppressions).To(gomega.HaveLen(2))
+
+	dynamic := _fakeDynamicClient()
+	_mockNode(dynamic, "test-node-2", false)
+	_mockNode(dynamic, "test-node-3", true)
+
+	v := _mockValidator("condition_validation.yaml", dynamic, nil)
+	v.Suppressions = suppressions
+	g.Expect(v.Validate()).To(gomega.Succeed())
+	g.Expect(v.Progress()[0].Status).To(gomega.Equal(ValidationStatusSucceeded))
+	g.Expect(v.Progress()[0].Suppressed).To(gomega.Equal(1))
+
+	// the node suppression expired
+	expr.Now = func() time.Time { return time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC) }
+	v = _mockValidator("condition_validation.yaml", dynamic, nil)
+	v.Suppressions = suppressions
+	g.Expect(v.Validate()).NotTo(gomega.Succeed())
+
+	// failures of other resources are not suppressed
+	_mockNode(dynamic, "test-node-4", false)
+	expr.Now = func() time.Time { return time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC) }
+	v = _mockValidator("condition_validation.yaml", dynamic, nil)
+	v.Suppressions = suppressions
+	err = v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).ConditionValidations[0].ResourceErrors).To(gomega.ContainElement(gomega.ConsistOf("test-node-4")))
+
+	// a suppression by ID suppresses the whole entry
+	v = _mockValidator("condition_validation.yaml", dynamic, nil)
+	v.Validation.Spec.Resources[0].ID = "nodes-ready"
+	v.Suppressions = []v1alpha1.Suppression{{ID: "nodes-ready"}}
+	g.Expect(v.Validate()).To(gomega.Succeed())
+	g.Expect(v.Progress()[0].Suppressed).To(gomega.Equal(2))
+
+	_, err = ParseSuppressions(filepath.Join(testBasePath, "missing.yaml"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+}
+
+func Test_StopValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+	_mockPod(dynamic, "pod-2", "default", false, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1000, FailureThreshold: 1000, Interval: "5ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					ID:         "pods-running",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+				{
+					Name:          "pods",
+					APIVersion:    "v1",
+					Names:         &v1alpha1.SelectionScope{Include: []string{"pod-1"}},
+					Fields:        []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1},
+					Required:      true,
+				},
+			},
+		},
+	}
+
+	v := NewValidator(dynamic, spec, nil)
+	done := make(chan error)
+	go func() { done <- v.Validate() }()
+
+	attempts := func() int {
+		for _, p := range v.Progress() {
+			if p.ID == "pods-running" {
+				return p.Attempts
+			}
+		}
+		return 0
+	}
+	g.Eventually(attempts).Should(gomega.BeNumerically(">=", 2))
+	v.Stop()
+
+	var err error
+	g.Eventually(done).Should(gomega.Receive(&err))
+	g.Expect(err).To(gomega.MatchError(ErrInterrupted))
+
+	statuses := make(map[string]ValidationStatus)
+	for _, p := range v.Progress() {
+		statuses[p.ID] = p.Status
+		if p.ID == "pods-running" {
+			g.Expect(p.Failures).To(gomega.BeNumerically(">=", 2))
+			g.Expect(p.Summary.FieldValidation).NotTo(gomega.BeEmpty())
+		}
+	}
+	g.Expect(statuses).To(gomega.Equal(map[string]ValidationStatus{
+		"pods-running": ValidationStatusInterrupted,
+		"":             ValidationStatusSucceeded,
+	}))
+}
+
+func Test_CheckpointResume(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 10, Interval: "5ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					ID:         "pods-running",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+				{
+					Name:       "nodes",
+					APIVersion: "v1",
+					Required:   true,
+				},
+			},
+		},
+	}
+
+	v := NewValidator(dynamic, spec, nil)
+	v.CheckpointFile = checkpoint
+	v.Resume = &Checkpoint{
+		SpecDigest: specDigest(spec),
+		Progress: []ValidationProgress{
+			{Name: "pods", ID: "pods-running", Status: ValidationStatusInterrupted, Attempts: 4, Successes: 2, Failures: 2},
+			{Name: "nodes", Status: ValidationStatusSucceeded, Attempts: 3, Successes: 3},
+		},
+	}
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	written, err := ReadCheckpoint(checkpoint)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	progress := make(map[string]ValidationProgress)
+	for _, p := range written.Progress {
+		progress[p.Name] = p
+	}
+	g.Expect(progress["pods"].Status).To(gomega.Equal(ValidationStatusSucceeded))
+	g.Expect(progress["pods"].Attempts).To(gomega.Equal(5))
+	g.Expect(progress["pods"].Successes).To(gomega.Equal(3))
+	g.Expect(progress["nodes"].Status).To(gomega.Equal(ValidationStatusSucceeded))
+	g.Expect(progress["nodes"].Attempts).To(gomega.Equal(3))
+}
+
+func Test_CheckpointResumeChangedSpec(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 10, Interval: "5ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+			},
+		},
+	}
+	resume := &Checkpoint{
+		SpecDigest: specDigest(spec),
+		Progress:   []ValidationProgress{{Name: "pods", Status: ValidationStatusSucceeded, Attempts: 3, Successes: 3}},
+	}
+
+	// the pods entry now checks a different phase, its progress must not carry over
+	spec.Spec.Resources[0].Fields[0].Values = []string{"Succeeded"}
+	spec.Spec.Configuration.FailureThreshold = 2
+	v := NewValidator(dynamic, spec, nil)
+	v.CheckpointFile = checkpoint
+	v.Resume = resume
+	g.Expect(v.Validate()).NotTo(gomega.Succeed())
+	g.Expect(v.Resume).To(gomega.BeNil())
+
+	written, err := ReadCheckpoint(checkpoint)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(written.SpecDigest).To(gomega.Equal(specDigest(spec)))
+	g.Expect(written.Progress).To(gomega.HaveLen(1))
+	g.Expect(written.Progress[0].Status).To(gomega.Equal(ValidationStatusFailed))
+}
+
+func Test_Snapshot(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+	_mockPod(dynamic, "pod-2", "kube-system", false, runningContainer)
+	_mockSecret(dynamic, "token", "default", map[string][]byte{"token": []byte("secret")})
+	_mockObject(dynamic, SecretGVR, &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "applied", Namespace: "default", Annotations: map[string]string{
+			corev1.LastAppliedConfigAnnotation: `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"applied","namespace":"default"},"stringData":{"password":"hunter2"}}`,
+			"owner":                            "platform",
+		}},
+		StringData: map[string]string{"password": "hunter2"},
+	})
+	dir := t.TempDir()
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "5ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+				{
+					Name:       "secrets",
+					APIVersion: "v1",
+				},
+			},
+		},
+	}
+
+	files, err := NewValidator(dynamic, spec, nil).Snapshot(dir)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(files).To(gomega.ConsistOf(filepath.Join(dir, "pods.v1.core.yaml"), filepath.Join(dir, "secrets.v1.core.yaml")))
+
+	offline, err := LoadSnapshot(dir)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	secret, err := offline.Resource(SecretGVR).Namespace("default").Get(context.Background(), "token", metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(secret.Object["data"]).To(gomega.Equal(map[string]interface{}{"token": redactedValue}))
+
+	// kubectl apply keeps the whole secret in an annotation
+	applied, err := offline.Resource(SecretGVR).Namespace("default").Get(context.Background(), "applied", metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(applied.GetAnnotations()).To(gomega.Equal(map[string]string{corev1.LastAppliedConfigAnnotation: redactedValue, "owner": "platform"}))
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "secrets.v1.core.yaml"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(raw)).NotTo(gomega.ContainSubstring("hunter2"))
+
+	err = NewValidator(offline, spec, nil).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ToValidationError(err).FieldValidations).To(gomega.HaveLen(1))
+}
+
+func Test_GenerateBaseline(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockNode(dynamic, "node-1", true)
+	_mockNode(dynamic, "node-2", true)
+	_mockNode(dynamic, "node-3", false)
+	_mockDaemonSet(dynamic, "aws-node", "kube-system", 2)
+	_mockDaemonSet(dynamic, "node-exporter", "monitoring", 2)
+	for _, d := range []struct{ name, namespace, status string }{
+		{"coredns", "kube-system", "True"},
+		{"metrics-server", "kube-system", "False"},
+		{"prometheus", "monitoring", "True"},
+	} {
+		_mockObject(dynamic, DeploymentGVR, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": d.name, "namespace": d.namespace},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Available", "status": d.status}},
+			},
+		}})
+	}
+	_mockObject(dynamic, CRDGVR, &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "dogs.animals.io"},
+		"spec": map[string]interface{}{
+			"group":    "animals.io",
+			"names":    map[string]interface{}{"plural": "dogs", "kind": "Dog"},
+			"versions": []interface{}{map[string]interface{}{"name": "v1alpha1", "served": true, "storage": true}},
+		},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Established", "status": "True"}},
+		},
+	}})
+	_mockObject(dynamic, DogGVR, &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "animals.io/v1alpha1",
+		"kind":       "Dog",
+		"metadata":   map[string]interface{}{"name": "rex", "namespace": "default"},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+		},
+	}})
+
+	spec, err := GenerateBaseline(dynamic, BaselineOptions{Name: "baseline", Namespaces: []string{"kube-system", "monitoring"}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	resources := make(map[string]v1alpha1.ClusterResource)
+	for _, r := range spec.Spec.Resources {
+		key := r.Name
+		if r.ID != "" {
+			key = r.ID
+		}
+		g.Expect(resources).NotTo(gomega.HaveKey(key))
+		resources[key] = r
+	}
+	g.Expect(resources).To(gomega.HaveLen(6))
+	g.Expect(resources["nodes"].Names.Exclude).To(gomega.Equal([]string{"node-3"}))
+	g.Expect(resources["nodes"].Aggregates[0].Value).To(gomega.Equal("2"))
+	g.Expect(resources["deployments-kube-system"].Names.Exclude).To(gomega.Equal([]string{"metrics-server"}))
+	g.Expect(resources["deployments-monitoring"].Namespaces.Include).To(gomega.Equal([]string{"monitoring"}))
+	g.Expect(resources["daemonsets-kube-system"].Names.Include).To(gomega.Equal([]string{"aws-node"}))
+	g.Expect(resources["daemonsets-monitoring"].Names.Include).To(gomega.Equal([]string{"node-exporter"}))
+	g.Expect(resources["dogs"].APIVersion).To(gomega.Equal("animals.io/v1alpha1"))
+	g.Expect(resources["dogs"].Kind).To(gomega.Equal("Dog"))
+
+	spec.Spec.Configuration = v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "5ms"}
+	g.Expect(NewValidator(dynamic, spec, nil).Validate()).To(gomega.Succeed())
+}
+
+func Test_DiffTargets(t *testing.T) {
+	g := gomega.NewWithT(t)
+	before := _fakeDynamicClient()
+	_mockPod(before, "pod-1", "default", true, runningContainer)
+	_mockNode(before, "node-1", true)
+	after := _fakeDynamicClient()
+	_mockPod(after, "pod-1", "default", false, runningContainer)
+	_mockPod(after, "pod-2", "default", true, runningContainer)
+	_mockNode(after, "node-1", true)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 3, Interval: "5ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					ID:         "pods-running",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+				{
+					Name:       "nodes",
+					APIVersion: "v1",
+					Conditions: []v1alpha1.ResourceCondition{{Path: "status.conditions", Type: "Ready", Status: corev1.ConditionTrue}},
+					Required:   true,
+				},
+			},
+		},
+	}
+
+	diff, err := DiffTargets(spec, DiffTarget{Name: "before", Kubernetes: before}, DiffTarget{Name: "after", Kubernetes: after})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(diff.Empty()).To(gomega.BeFalse())
+	g.Expect(diff.Validations).To(gomega.HaveLen(1))
+	g.Expect(diff.Validations[0].ID).To(gomega.Equal("pods-running"))
+	g.Expect(diff.Validations[0].Before).To(gomega.Equal(ValidationStatusSucceeded))
+	g.Expect(diff.Validations[0].After).To(gomega.Equal(ValidationStatusFailed))
+	g.Expect(diff.Fields).To(gomega.Equal([]FieldDiff{
+		{Resource: "pods", Object: "default/pod-1", Path: ".status.phase", Before: "Running", After: "Pending"},
+		{Resource: "pods", Object: "default/pod-2", Path: ".status.phase", Before: missingValue, After: "Running"},
+	}))
+
+	same, err := DiffTargets(spec, DiffTarget{Name: "before", Kubernetes: before}, DiffTarget{Name: "again", Kubernetes: before})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(same.Empty()).To(gomega.BeTrue())
+}
+
+func Test_CanaryValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	canary := map[string]string{"pool": "canary"}
+	r := v1alpha1.ClusterResource{
+		Name:       "nodes",
+		APIVersion: "v1",
+		Conditions: []v1alpha1.ResourceCondition{{Path: "status.conditions", Type: "Ready", Status: corev1.ConditionTrue}},
+		Aggregates: []v1alpha1.AggregateSelector{{Function: v1alpha1.AggregateFunctionCount, Operator: ">=", Value: "3"}},
+		Canary:     &v1alpha1.CanaryConfig{LabelSelector: "pool=canary"},
+	}
+	validate := func(nodes ...unstructured.Unstructured) (ValidationSummary, error) {
+		v := NewValidator(_fakeDynamicClient(), &v1alpha1.ClusterValidation{}, nil)
+		return v.validateCanary(r, nodes)
+	}
+	node := func(name string, ready bool, labels map[string]string) unstructured.Unstructured {
+		dynamic := _fakeDynamicClient()
+		_mockLabeledNode(dynamic, name, ready, labels)
+		u, err := dynamic.Resource(NodeGVR).Get(context.Background(), name, metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return *u
+	}
+
+	summary, err := validate(node("node-1", false, canary), node("node-2", false, nil), node("node-3", true, nil))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("canary of 1/3 resources failed")))
+	g.Expect(summary.ConditionValidation).To(gomega.HaveLen(1))
+	g.Expect(summary.ConditionValidation[0].ResourceErrors).To(gomega.HaveLen(1))
+	g.Expect(summary.AggregateValidation).To(gomega.BeEmpty())
+
+	summary, err = validate(node("node-1", true, canary), node("node-2", false, nil), node("node-3", true, nil))
+	g.Expect(err).To(gomega.MatchError("failed to validate resources"))
+	g.Expect(summary.ConditionValidation).To(gomega.HaveLen(1))
+
+	_, err = validate(node("node-1", true, canary), node("node-2", true, nil), node("node-3", true, nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	nodes := make([]unstructured.Unstructured, 0)
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, node(fmt.Sprintf("node-%v", i), true, nil))
+	}
+	subset, err := canaryResources(&v1alpha1.CanaryConfig{Percent: 25}, nodes)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(subset).To(gomega.HaveLen(3))
+	again, _ := canaryResources(&v1alpha1.CanaryConfig{Percent: 25}, append(nodes[5:], nodes[:5]...))
+	g.Expect(again).To(gomega.Equal(subset))
+
+	invalid := &v1alpha1.ClusterValidation{Spec: v1alpha1.ClusterValidationSpec{Resources: []v1alpha1.ClusterResource{
+		{Name: "nodes", Canary: &v1alpha1.CanaryConfig{Percent: 150}},
+	}}}
+	g.Expect(validateCanaries(invalid)).To(gomega.HaveOccurred())
+}
+
+func Test_SpecOverlays(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		g.Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(gomega.Succeed())
+		g.Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(gomega.Succeed())
+		return path
+	}
+
+	write("base.yaml", `apiVersion: v1alpha1
+kind: ClusterValidator
+metadata:
+  name: base
+spec:
+  configuration:
+    successThreshold: 3
+    failureThreshold: 10
+    interval: 2s
+  resources:
+  - name: nodes
+    apiVersion: v1
+    required: true
+  - name: pods
+    id: system-pods
+    apiVersion: v1
+    namespaces:
+      include:
+      - kube-system
+    required: true
+  - name: pods
+    id: app-pods
+    apiVersion: v1
+    required: false
+  endpoints:
+    http:
+    - name: ingress
+      url: https://staging.example.com/healthz
+      codes:
+      - 200
+`)
+	prod := write("overlays/prod.yaml", `base: ../base.yaml
+metadata:
+  name: prod
+spec:
+  configuration:
+    interval: 10s
+  resources:
+  - id: system-pods
+    namespaces:
+      include:
+      - kube-system
+      - monitoring
+  - id: app-pods
+    $patch: delete
+  - name: nodes
+    configuration:
+      failureThreshold: 60
+  - name: namespaces
+    apiVersion: v1
+    required: true
+  endpoints:
+    http:
+    - name: ingress
+      url: https://prod.example.com/healthz
+`)
+
+	spec, err := ParseValidationSpec(prod)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(spec.Name).To(gomega.Equal("prod"))
+	g.Expect(spec.Spec.Configuration).To(gomega.Equal(v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 10, Interval: "10s"}))
+	g.Expect(spec.Spec.Resources).To(gomega.HaveLen(3))
+	g.Expect(spec.Spec.Resources[0].Name).To(gomega.Equal("nodes"))
+	g.Expect(spec.Spec.Resources[0].Configuration.FailureThreshold).To(gomega.Equal(60))
+	g.Expect(spec.Spec.Resources[0].Required).To(gomega.BeTrue())
+	g.Expect(spec.Spec.Resources[1].ID).To(gomega.Equal("system-pods"))
+	g.Expect(spec.Spec.Resources[1].Namespaces.Include).To(gomega.Equal([]string{"kube-system", "monitoring"}))
+	g.Expect(spec.Spec.Resources[2].Name).To(gomega.Equal("namespaces"))
+	g.Expect(spec.Spec.Endpoints.HTTP[0].URL).To(gomega.Equal("https://prod.example.com/healthz"))
+	g.Expect(spec.Spec.Endpoints.HTTP[0].Codes).To(gomega.Equal([]int{200}))
+
+	cycle := write("cycle.yaml", "base: cycle.yaml\n")
+	_, err = ParseValidationSpec(cycle)
+	g.Expect(err).To(gomega.MatchError(ErrSpec))
+}
+
+func Test_Heartbeat(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", false, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1000, Interval: "1h"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					ID:         "pods-running",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+			},
+		},
+	}
+
+	v := NewValidator(dynamic, spec, nil)
+	v.Heartbeat = 5 * time.Millisecond
+	done := make(chan error)
+	go func() { done <- v.Validate() }()
+
+	running := func() ValidationProgress {
+		for _, p := range v.Progress() {
+			return p
+		}
+		return ValidationProgress{}
+	}
+	g.Eventually(func() time.Time { return running().NextAttempt }).ShouldNot(gomega.BeZero())
+	g.Expect(running().heartbeat()).To(gomega.MatchRegexp(`^waiting on 'pods \(pods-running\)': 0/1 successful, 1/1000 failed, waited \d+s, next attempt in (1h0m0s|59m59s)$`))
+
+	v.Stop()
+	g.Eventually(done).Should(gomega.Receive())
+}
+
+func Test_ValidationTimings(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockPod(dynamic, "pod-1", "default", true, runningContainer)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 3, FailureThreshold: 3, Interval: "10ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "pods",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+					Required:   true,
+				},
+			},
+		},
+	}
+
+	v := NewValidator(dynamic, spec, nil)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+
+	progress := v.Progress()
+	g.Expect(progress).To(gomega.HaveLen(1))
+	p := progress[0]
+	g.Expect(p.Attempts).To(gomega.Equal(3))
+	g.Expect(p.Duration).To(gomega.BeNumerically(">=", 20*time.Millisecond))
+	g.Expect(p.EvaluationTime).To(gomega.BeNumerically("<", p.Duration))
+	g.Expect(p.TimeToFirstSuccess).To(gomega.BeNumerically(">", 0))
+	g.Expect(p.TimeToFirstSuccess).To(gomega.BeNumerically("<", 10*time.Millisecond))
+}
+
+func Test_HedgedRequests(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	var calls int32
+	slowFirst := func(ctx context.Context) (*bytes.Buffer, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return bytes.NewBufferString("ok"), nil
+	}
+	out, err := hedge(5*time.Millisecond, slowFirst)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(out.String()).To(gomega.Equal("ok"))
+	g.Expect(atomic.LoadInt32(&calls)).To(gomega.Equal(int32(2)))
+
+	calls = 0
+	failFirst := func(ctx context.Context) (*bytes.Buffer, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return bytes.NewBufferString("ok"), nil
+	}
+	out, err = hedge(time.Hour, failFirst)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(out.String()).To(gomega.Equal("ok"))
+
+	alwaysFail := func(ctx context.Context) (*bytes.Buffer, error) {
+		return nil, errors.New("connection refused")
+	}
+	_, err = hedge(time.Millisecond, alwaysFail)
+	g.Expect(err).To(gomega.MatchError("connection refused"))
+
+	_, err = parseValidationSpecData([]byte("spec:\n  endpoints:\n    cluster:\n    - name: readyz\n      uri: /readyz\n      hedge: soon\n"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	v := NewValidator(_fakeDynamicClient(), &v1alpha1.ClusterValidation{}, nil)
+	status, err := v.hedgedHTTPStatus(server.URL, 5*time.Millisecond)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(status).To(gomega.Equal(http.StatusOK))
+	g.Expect(atomic.LoadInt32(&requests)).To(gomega.Equal(int32(2)))
+
+	_, err = parseValidationSpecData([]byte("spec:\n  endpoints:\n    http:\n    - name: docs\n      url: http://example.com\n      hedge: -1s\n"))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("hedge of http endpoint 'docs' must be a positive duration")))
+}
+
+func Test_APIServerReplicas(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer healthy.Close()
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "[-]etcd failed", http.StatusInternalServerError)
+	}))
+	defer unhealthy.Close()
+
+	r, err := NewRESTClient(&rest.Config{Host: healthy.URL})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	endpoint := v1alpha1.ClusterEndpoint{
+		Name:     "readyz",
+		URI:      "/readyz",
+		Replicas: &v1alpha1.APIServerReplicas{Addresses: []string{healthy.URL, unhealthy.URL}},
+	}
+	v := NewValidator(_fakeDynamicClient(), &v1alpha1.ClusterValidation{}, r)
+	summary, err := v.probeReplicas(endpoint, 0)
+	g.Expect(err).To(gomega.MatchError("1 of 2 API server replicas failed '/readyz'"))
+	g.Expect(summary.ClusterEndpointValidation[0].Errors).To(gomega.HaveKeyWithValue(unhealthy.URL+"/readyz", gomega.ContainSubstring("etcd failed")))
+
+	dynamic := _fakeDynamicClient()
+	endpoints := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Endpoints",
+		"metadata":   map[string]interface{}{"name": "kubernetes", "namespace": "default"},
+		"subsets": []interface{}{map[string]interface{}{
+			"addresses": []interface{}{
+				map[string]interface{}{"ip": "10.0.1.10"},
+				map[string]interface{}{"ip": "10.0.2.10"},
+			},
+			"ports": []interface{}{map[string]interface{}{"name": "https", "port": int64(6443)}},
+		}},
+	}}
+	g.Expect(dynamic.Tracker().Create(endpointsGVR, endpoints, "default")).To(gomega.Succeed())
+
+	v = NewValidator(dynamic, &v1alpha1.ClusterValidation{}, r)
+	addresses, err := v.apiServerReplicas(&v1alpha1.APIServerReplicas{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(addresses).To(gomega.Equal([]string{"https://10.0.1.10:6443", "https://10.0.2.10:6443"}))
+}
+
+func Test_HTTPTransport(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	spec, err := parseValidationSpecData([]byte("spec:\n  endpoints:\n    transport:\n      timeout: 2s\n      maxIdleConnsPerHost: 50\n      disableHTTP2: true\n"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	v1, v2 := NewValidator(nil, spec, nil), NewValidator(nil, spec, nil)
+	g.Expect(v1.HTTPClient.Timeout).To(gomega.Equal(2 * time.Second))
+	g.Expect(v1.HTTPClient.Transport).To(gomega.BeIdenticalTo(v2.HTTPClient.Transport))
+
+	transport := v1.HTTPClient.Transport.(*http.Transport)
+	g.Expect(transport.MaxIdleConnsPerHost).To(gomega.Equal(50))
+	g.Expect(transport.MaxIdleConns).To(gomega.Equal(100))
+	g.Expect(transport.ForceAttemptHTTP2).To(gomega.BeFalse())
+	g.Expect(transport.TLSNextProto).NotTo(gomega.BeNil())
+
+	defaults := NewValidator(nil, &v1alpha1.ClusterValidation{}, nil)
+	g.Expect(defaults.HTTPClient.Timeout).To(gomega.Equal(30 * time.Second))
+	g.Expect(defaults.HTTPClient.Transport).NotTo(gomega.BeIdenticalTo(v1.HTTPClient.Transport))
+	g.Expect(defaults.HTTPClient.Transport.(*http.Transport).ForceAttemptHTTP2).To(gomega.BeTrue())
+
+	_, err = parseValidationSpecData([]byte("spec:\n  endpoints:\n    transport:\n      idleConnTimeout: forever\n"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+}
+
+func Test_JQAssertions(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockNamespace(dynamic, "default", true)
+	_mockNamespace(dynamic, "old", false)
+
+	server := _mockServer(t, `{"major": "1", "minor": "27", "platform": "linux/amd64"}`, http.StatusOK)
+	defer server.Close()
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "namespaces",
+					APIVersion: "v1",
+					Required:   true,
+					JQ: []v1alpha1.JQAssertion{
+						{Expression: `.status.phase == "Active"`, Message: "namespace is active"},
+						{Expression: `.metadata.name | length > 0`},
+					},
+				},
+			},
+			Endpoints: v1alpha1.EndpointsSpec{
+				Cluster: []v1alpha1.ClusterEndpoint{
+					{Name: "version", URI: "/version", Required: true, JQ: []v1alpha1.JQAssertion{{Expression: `(.minor | tonumber) >= 25`}}},
+				},
+			},
+		},
+	}
+
+	err := NewValidator(dynamic, spec, _mockRESTClient(server.URL)).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	fields := ToValidationError(err).FieldValidations
+	g.Expect(fields).To(gomega.HaveLen(1))
+	g.Expect(fields[0].Code).To(gomega.Equal(FailureCodeJQMismatch))
+	g.Expect(fields[0].FieldPath).To(gomega.Equal("namespace is active"))
+	g.Expect(fields[0].ResourceErrors).To(gomega.Equal(map[string][]string{"jq assertion yielded false": {"old"}}))
+
+	spec.Spec.Resources[0].JQ = spec.Spec.Resources[0].JQ[1:]
+	g.Expect(NewValidator(dynamic, spec, _mockRESTClient(server.URL)).Validate()).To(gomega.Succeed())
+
+	spec.Spec.Resources = nil
+	spec.Spec.Endpoints.Cluster[0].JQ = []v1alpha1.JQAssertion{{Expression: `.platform | startswith("windows")`}}
+	err = NewValidator(dynamic, spec, _mockRESTClient(server.URL)).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	endpoint := ToValidationError(err).ClusterEndpointValidations[0]
+	g.Expect(endpoint.Code).To(gomega.Equal(FailureCodeJQMismatch))
+	g.Expect(endpoint.Errors["/version"]).To(gomega.ContainSubstring(`'.platform | startswith("windows")' jq assertion yielded false`))
+
+	_, err = parseValidationSpecData([]byte("spec:\n  resources:\n  - name: nodes\n    apiVersion: v1\n    jq:\n    - expression: '.status |'\n"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+}
+
+func Test_GoTemplateAssertions(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockNamespace(dynamic, "default", true)
+	_mockNamespace(dynamic, "old", false)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "namespaces",
+					APIVersion: "v1",
+					Required:   true,
+					GoTemplates: []v1alpha1.GoTemplateAssertion{
+						{Template: `{{ .status.phase | lower }}`, Expected: "active", Message: "namespace is active"},
+						{Template: `{{ hasPrefix "d" .metadata.name }}`},
+					},
+				},
+			},
+		},
+	}
+
+	err := NewValidator(dynamic, spec, nil).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	fields := ToValidationError(err).FieldValidations
+	g.Expect(fields).To(gomega.HaveLen(2))
+	g.Expect(fields[0].Code).To(gomega.Equal(FailureCodeTemplateMismatch))
+	g.Expect(fields[0].FieldPath).To(gomega.Equal("namespace is active"))
+	g.Expect(fields[0].ResourceErrors).To(gomega.Equal(map[string][]string{"template rendered 'terminating', expected 'active'": {"old"}}))
+	g.Expect(fields[1].ResourceErrors).To(gomega.Equal(map[string][]string{"template rendered 'false'": {"old"}}))
+
+	spec.Spec.Resources[0].GoTemplates = []v1alpha1.GoTemplateAssertion{
+		{Template: `{{ if eq .kind "Namespace" }}{{ len .metadata.name }}{{ end }}`},
+		{Template: `{{ dig "metadata" "labels" "team" "none" . }}`, Expected: "none"},
+	}
+	g.Expect(NewValidator(dynamic, spec, nil).Validate()).To(gomega.Succeed())
+
+	_, err = parseValidationSpecData([]byte("spec:\n  resources:\n  - name: nodes\n    apiVersion: v1\n    goTemplates:\n    - template: '{{ .status'\n"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+}
+
+func Test_PerGroupValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := _fakeDynamicClient()
+	_mockLabeledNode(dynamic, "node-1", true, map[string]string{"node.kubernetes.io/instancegroup": "system"})
+	_mockLabeledNode(dynamic, "node-2", true, map[string]string{"node.kubernetes.io/instancegroup": "gpu"})
+	_mockLabeledNode(dynamic, "node-3", false, map[string]string{"node.kubernetes.io/instancegroup": "gpu"})
+	_mockNode(dynamic, "node-4", false)
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "nodes",
+					ID:         "nodes-ready",
+					APIVersion: "v1",
+					Required:   true,
+					Conditions: []v1alpha1.ResourceCondition{{Path: "status.conditions", Type: "Ready", Status: corev1.ConditionTrue}},
+					PerGroup: &v1alpha1.PerGroupConfig{
+						Label:  "node.kubernetes.io/instancegroup",
+						Groups: []string{"system", "batch"},
+						Configurations: map[string]v1alpha1.ValidationConfiguration{
+							"gpu": {SuccessThreshold: 1, FailureThreshold: 2, Interval: "1ms"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// a failed required validation stops the run, the groups all finish when it continues
+	v := NewValidator(dynamic, spec, nil)
+	v.ContinueOnError = true
+	err := v.Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(errors.Is(err, ErrThreshold)).To(gomega.BeTrue())
+
+	g.Eventually(func() map[string]ValidationStatus {
+		statuses := make(map[string]ValidationStatus)
+		for _, p := range v.Progress() {
+			statuses[p.ID] = p.Status
+		}
+		return statuses
+	}).Should(gomega.Equal(map[string]ValidationStatus{
+		"nodes-ready/batch":  ValidationStatusFailed,
+		"nodes-ready/gpu":    ValidationStatusFailed,
+		"nodes-ready/system": ValidationStatusSucceeded,
+	}))
+	for _, p := range v.Progress() {
+		g.Expect(p.Name).To(gomega.HavePrefix("nodes/"))
+		if p.ID == "nodes-ready/gpu" {
+			g.Expect(p.FailureThreshold).To(gomega.Equal(2))
+			g.Expect(p.Summary.ConditionValidation[0].ResourceErrors).To(gomega.HaveLen(1))
+		}
+		if p.ID == "nodes-ready/batch" {
+			g.Expect(p.LastError).To(gomega.Equal("no resources with label 'node.kubernetes.io/instancegroup=batch' matched"))
+		}
+	}
+
+	_, err = parseValidationSpecData([]byte("spec:\n  resources:\n  - name: nodes\n    apiVersion: v1\n    perGroup:\n      groups: [gpu]\n"))
+	g.Expect(errors.Is(err, ErrSpec)).To(gomega.BeTrue())
+}
+
+func Test_HTTPEndpointValidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/teapot" {
+			w.WriteHeader(http.StatusTeapot)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{SuccessThreshold: 2, FailureThreshold: 2, Interval: "1ms"},
+			Endpoints: v1alpha1.EndpointsSpec{
+				HTTP: []v1alpha1.HTTPEndpoint{
+					{Name: "healthz", URL: server.URL + "/healthz", Required: true},
+				},
+			},
+		},
+	}
+
+	v := NewValidator(_fakeDynamicClient(), spec, nil)
+	g.Expect(v.Validate()).To(gomega.Succeed())
+	g.Expect(atomic.LoadInt32(&requests)).To(gomega.Equal(int32(2)))
+	g.Expect(v.Progress()[0].Status).To(gomega.Equal(ValidationStatusSucceeded))
+
+	spec.Spec.Endpoints.HTTP[0] = v1alpha1.HTTPEndpoint{Name: "teapot", ID: "teapot", URL: server.URL + "/teapot", Required: true}
+	err := NewValidator(_fakeDynamicClient(), spec, nil).Validate()
+	g.Expect(errors.Is(err, ErrThreshold)).To(gomega.BeTrue())
+	endpoint := ToValidationError(err).HTTPEndpointValidations[0]
+	g.Expect(endpoint.ID).To(gomega.Equal("teapot"))
+	g.Expect(endpoint.Code).To(gomega.Equal(FailureCodeUnexpectedStatus))
+	g.Expect(endpoint.Errors[server.URL+"/teapot"]).To(gomega.ContainSubstring("unexpected status 418"))
+
+	spec.Spec.Endpoints.HTTP[0].Codes = []int{http.StatusTeapot}
+	g.Expect(NewValidator(_fakeDynamicClient(), spec, nil).Validate()).To(gomega.Succeed())
+
+	spec.Spec.Endpoints.HTTP[0] = v1alpha1.HTTPEndpoint{Name: "unreachable", URL: "http://127.0.0.1:1/healthz", Required: true}
+	err = NewValidator(_fakeDynamicClient(), spec, nil).Validate()
+	g.Expect(errors.Is(err, ErrThreshold)).To(gomega.BeTrue())
+	g.Expect(ToValidationError(err).HTTPEndpointValidations[0].Code).To(gomega.Equal(FailureCodeEndpointUnreachable))
+}
+
+func Test_TestExpression(t *testing.T) {
+	g := gomega.NewWithT(t)
+	pod := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web-0", "labels": map[string]interface{}{"team": "payments"}},
+		"spec": map[string]interface{}{"containers": []interface{}{
+			map[string]interface{}{"name": "web", "resources": map[string]interface{}{"limits": map[string]interface{}{"memory": "512Mi"}}},
+		}},
+		"status": map[string]interface{}{"phase": "Running", "restartCount": int64(2)},
+	}
+
+	g.Expect(TestExpression(ExpressionLanguageJQ, `.status.phase == "Running"`, "", pod)).To(gomega.Succeed())
+	g.Expect(TestExpression(ExpressionLanguageJQ, `.status.restartCount < 1`, "", pod)).To(gomega.MatchError(gomega.ContainSubstring("yielded false")))
+	g.Expect(TestExpression(ExpressionLanguageJQ, `.status.phase ==`, "", pod)).To(gomega.MatchError(gomega.ContainSubstring("invalid jq expression")))
+	g.Expect(TestExpression(ExpressionLanguageJQ, `.status.phase`, "Running", pod)).NotTo(gomega.Succeed())
+
+	// jq has the expression helpers too
+	g.Expect(TestExpression(ExpressionLanguageJQ, `labelValue("team") == "payments"`, "", pod)).To(gomega.Succeed())
+	g.Expect(TestExpression(ExpressionLanguageJQ, `hasAnnotation("owner")`, "", pod)).To(gomega.MatchError(gomega.ContainSubstring("yielded false")))
+	g.Expect(TestExpression(ExpressionLanguageJQ, `.spec.containers[0].resources.limits.memory | compareQuantity("1Gi") == -1`, "", pod)).To(gomega.Succeed())
+	g.Expect(TestExpression(ExpressionLanguageJQ, `.spec.containers[0].resources.limits.memory | quantity > 500000000`, "", pod)).To(gomega.Succeed())
+	g.Expect(TestExpression(ExpressionLanguageJQ, `"30m" | duration == 1800`, "", pod)).To(gomega.Succeed())
+	g.Expect(TestExpression(ExpressionLanguageJQ, `"v1.25.3-eks-1" | semverCompare("1.24.0") == 1`, "", pod)).To(gomega.Succeed())
+	g.Expect(TestExpression(ExpressionLanguageJQ, `"2006-01-02T15:04:05Z" | olderThan("1h")`, "", pod)).To(gomega.Succeed())
+	g.Expect(TestExpression(ExpressionLanguageJQ, `.status.restartCount | quantity`, "", pod)).To(gomega.MatchError(gomega.ContainSubstring("quantity cannot be applied to number")))
+	g.Expect(TestExpression(ExpressionLanguageJQ, `"1Gi" | compareQuantity(1)`, "", pod)).To(gomega.MatchError(gomega.ContainSubstring("compareQuantity expects string arguments")))
+	g.Expect(jqFunctions).To(gomega.HaveLen(len(expr.Funcs())))
+
+	// Go templates have the expression helpers
+	g.Expect(TestExpression(ExpressionLanguageGoTemplate, `{{ label . "team" }}`, "payments", pod)).To(gomega.Succeed())
+	g.Expect(TestExpression(ExpressionLanguageGoTemplate, `{{ $m := (index .spec.containers 0).resources.limits.memory }}{{ eq (compareQuantity $m "1Gi") -1 }}`, "", pod)).To(gomega.Succeed())
+	g.Expect(TestExpression(ExpressionLanguageGoTemplate, `{{ .status.phase }}`, "Pending", pod)).To(gomega.MatchError("template rendered 'Running', expected 'Pending'"))
+	g.Expect(TestExpression(ExpressionLanguageGoTemplate, `{{ hasLabel . "owner" }}`, "", pod)).To(gomega.MatchError(gomega.ContainSubstring("rendered 'false'")))
+
+	g.Expect(TestExpression("cel", "true", "", pod)).To(gomega.MatchError(gomega.ContainSubstring("unknown expression language")))
+}
@@ -0,0 +1,316 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validatortest provides fakes for testing code that embeds a client.Validator: a
+// builder for fake dynamic clients, canned nodes, pods and namespaces in common states, a
+// fake API server for cluster endpoints and a ResultRecorder to assert on reported results.
+//
+// To replay recorded cluster state instead, see the clienttest package.
+package validatortest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	// SingleAttempt is a configuration that decides every validation on its first attempt
+	SingleAttempt = v1alpha1.ValidationConfiguration{SuccessThreshold: 1, FailureThreshold: 1, Interval: "1ms"}
+
+	// ListKinds are the list kinds of the built-in resources every built client can list
+	ListKinds = map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "namespaces"}:                                               "NamespaceList",
+		{Version: "v1", Resource: "nodes"}:                                                    "NodeList",
+		{Version: "v1", Resource: "pods"}:                                                     "PodList",
+		{Version: "v1", Resource: "services"}:                                                 "ServiceList",
+		{Version: "v1", Resource: "secrets"}:                                                  "SecretList",
+		{Version: "v1", Resource: "configmaps"}:                                               "ConfigMapList",
+		{Version: "v1", Resource: "persistentvolumes"}:                                        "PersistentVolumeList",
+		{Version: "v1", Resource: "persistentvolumeclaims"}:                                   "PersistentVolumeClaimList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}:                               "DeploymentList",
+		{Group: "apps", Version: "v1", Resource: "daemonsets"}:                                "DaemonSetList",
+		{Group: "apps", Version: "v1", Resource: "statefulsets"}:                              "StatefulSetList",
+		{Group: "batch", Version: "v1", Resource: "jobs"}:                                     "JobList",
+		{Group: "batch", Version: "v1", Resource: "cronjobs"}:                                 "CronJobList",
+		{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}:                "EndpointSliceList",
+		{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}:                    "IngressList",
+		{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}:                  "StorageClassList",
+		{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}: "CustomResourceDefinitionList",
+	}
+)
+
+// ClientBuilder builds a fake dynamic client seeded with objects.
+type ClientBuilder struct {
+	listKinds map[schema.GroupVersionResource]string
+	objects   []runtime.Object
+}
+
+// NewClientBuilder returns a builder for a client that can list the ListKinds resources.
+func NewClientBuilder() *ClientBuilder {
+	b := &ClientBuilder{listKinds: make(map[schema.GroupVersionResource]string)}
+	for gvr, kind := range ListKinds {
+		b.listKinds[gvr] = kind
+	}
+	return b
+}
+
+// WithListKind makes a custom resource listable, e.g. dogs.animals.io as "DogList".
+func (b *ClientBuilder) WithListKind(gvr schema.GroupVersionResource, listKind string) *ClientBuilder {
+	b.listKinds[gvr] = listKind
+	return b
+}
+
+// WithObjects seeds the client with objects, typed objects must set their TypeMeta.
+func (b *ClientBuilder) WithObjects(objects ...runtime.Object) *ClientBuilder {
+	b.objects = append(b.objects, objects...)
+	return b
+}
+
+// Build returns the client, it panics when an object cannot be converted to an unstructured object.
+func (b *ClientBuilder) Build() *fake.FakeDynamicClient {
+	var (
+		objects = make([]runtime.Object, 0, len(b.objects))
+	)
+
+	for _, obj := range b.objects {
+		if _, ok := obj.(*unstructured.Unstructured); ok {
+			objects = append(objects, obj)
+			continue
+		}
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			panic(err)
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: u})
+	}
+	return fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), b.listKinds, objects...)
+}
+
+// NewAPIServer serves the given response bodies by request path, e.g. "/readyz", and answers
+// 404 for any other path. The server is closed when the test finishes.
+func NewAPIServer(t testing.TB, responses map[string]string) *rest.RESTClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, ok := responses[req.URL.Path]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	restClient, err := rest.RESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &corev1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create REST client: %v", err)
+	}
+	return restClient
+}
+
+// ReadyNode returns a node with a true Ready condition.
+func ReadyNode(name string, labels map[string]string) *corev1.Node {
+	return node(name, labels, corev1.ConditionTrue)
+}
+
+// NotReadyNode returns a node with a false Ready condition.
+func NotReadyNode(name string, labels map[string]string) *corev1.Node {
+	return node(name, labels, corev1.ConditionFalse)
+}
+
+// CordonedNode returns a ready node that is marked unschedulable.
+func CordonedNode(name string, labels map[string]string) *corev1.Node {
+	n := node(name, labels, corev1.ConditionTrue)
+	n.Spec.Unschedulable = true
+	n.Spec.Taints = []corev1.Taint{{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}}
+	return n
+}
+
+func node(name string, labels map[string]string, ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, CreationTimestamp: metav1.Now()},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+		},
+	}
+}
+
+// ActiveNamespace returns a namespace in the Active phase.
+func ActiveNamespace(name string) *corev1.Namespace {
+	return namespace(name, corev1.NamespaceActive)
+}
+
+// TerminatingNamespace returns a namespace in the Terminating phase.
+func TerminatingNamespace(name string) *corev1.Namespace {
+	ns := namespace(name, corev1.NamespaceTerminating)
+	ns.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	return ns
+}
+
+func namespace(name string, phase corev1.NamespacePhase) *corev1.Namespace {
+	return &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{Kind: "Namespace", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Now()},
+		Status:     corev1.NamespaceStatus{Phase: phase},
+	}
+}
+
+// RunningPod returns a running pod with a ready container.
+func RunningPod(name, namespace string) *corev1.Pod {
+	p := pod(name, namespace, corev1.PodRunning)
+	p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	p.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "main",
+		Ready: true,
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.Now()}},
+	}}
+	return p
+}
+
+// PendingPod returns a pod that cannot be scheduled.
+func PendingPod(name, namespace string) *corev1.Pod {
+	p := pod(name, namespace, corev1.PodPending)
+	p.Status.Conditions = []corev1.PodCondition{{
+		Type:   corev1.PodScheduled,
+		Status: corev1.ConditionFalse,
+		Reason: corev1.PodReasonUnschedulable,
+	}}
+	return p
+}
+
+// CrashLoopingPod returns a running pod whose container is waiting in CrashLoopBackOff after restarts.
+func CrashLoopingPod(name, namespace string, restarts int32) *corev1.Pod {
+	p := pod(name, namespace, corev1.PodRunning)
+	p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}}
+	p.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:         "main",
+		RestartCount: restarts,
+		State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+	return p
+}
+
+// FailedPod returns a pod in the Failed phase with the given reason, e.g. "Evicted".
+func FailedPod(name, namespace, reason string) *corev1.Pod {
+	p := pod(name, namespace, corev1.PodFailed)
+	p.Status.Reason = reason
+	return p
+}
+
+func pod(name, namespace string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: metav1.Now()},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "busybox"}}},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+// ResultRecorder is a result sink and notifier that keeps every report and failure, add it to
+// the Sinks and Notifiers of a validator. It is safe for concurrent use.
+type ResultRecorder struct {
+	sync.Mutex
+	reports  []client.Report
+	failures []client.Failure
+}
+
+func NewResultRecorder() *ResultRecorder {
+	return &ResultRecorder{}
+}
+
+// Record adds the recorder to the sinks and notifiers of a validator and returns the validator.
+func (r *ResultRecorder) Record(v *client.Validator) *client.Validator {
+	v.Sinks = append(v.Sinks, r)
+	v.Notifiers = append(v.Notifiers, r)
+	return v
+}
+
+func (r *ResultRecorder) Write(report client.Report) error {
+	r.Lock()
+	defer r.Unlock()
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func (r *ResultRecorder) Flush() error {
+	return nil
+}
+
+func (r *ResultRecorder) NotifyFailure(failure client.Failure) error {
+	r.Lock()
+	defer r.Unlock()
+	r.failures = append(r.failures, failure)
+	return nil
+}
+
+func (r *ResultRecorder) NotifyCompletion(report client.Report) error {
+	return nil
+}
+
+// Reports returns the reports of all recorded runs.
+func (r *ResultRecorder) Reports() []client.Report {
+	r.Lock()
+	defer r.Unlock()
+	return append([]client.Report(nil), r.reports...)
+}
+
+// Failures returns the failed validations of all recorded runs in the order they failed.
+func (r *ResultRecorder) Failures() []client.Failure {
+	r.Lock()
+	defer r.Unlock()
+	return append([]client.Failure(nil), r.failures...)
+}
+
+// Last returns the report of the last recorded run, or nil when no run was recorded.
+func (r *ResultRecorder) Last() *client.Report {
+	r.Lock()
+	defer r.Unlock()
+	if len(r.reports) == 0 {
+		return nil
+	}
+	report := r.reports[len(r.reports)-1]
+	return &report
+}
+
+// Status returns the status of a validation in the last recorded run, by id or name.
+func (r *ResultRecorder) Status(validation string) client.ValidationStatus {
+	last := r.Last()
+	if last == nil {
+		return ""
+	}
+	for _, p := range last.Validations {
+		if p.ID == validation || p.Name == validation {
+			return p.Status
+		}
+	}
+	return ""
+}
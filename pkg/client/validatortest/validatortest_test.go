@@ -0,0 +1,69 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validatortest
+
+import (
+	"testing"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ValidatorIntegration(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dynamic := NewClientBuilder().
+		WithObjects(ReadyNode("node-1", nil), NotReadyNode("node-2", nil), CordonedNode("node-3", nil)).
+		WithObjects(ActiveNamespace("default"), TerminatingNamespace("old")).
+		WithObjects(RunningPod("app-1", "default"), CrashLoopingPod("app-2", "default", 5)).
+		Build()
+	restClient := NewAPIServer(t, map[string]string{"/readyz": "ok"})
+
+	spec := &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: SingleAttempt,
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:       "nodes",
+					ID:         "nodes-ready",
+					APIVersion: "v1",
+					Required:   true,
+					Conditions: []v1alpha1.ResourceCondition{{Path: "status.conditions", Type: "Ready", Status: corev1.ConditionTrue}},
+				},
+				{
+					Name:       "pods",
+					APIVersion: "v1",
+					Fields:     []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"Running"}}},
+				},
+			},
+			Endpoints: v1alpha1.EndpointsSpec{
+				Cluster: []v1alpha1.ClusterEndpoint{{Name: "readyz", URI: "/readyz"}},
+			},
+		},
+	}
+
+	recorder := NewResultRecorder()
+	err := recorder.Record(client.NewValidator(dynamic, spec, restClient)).Validate()
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	g.Expect(recorder.Reports()).To(gomega.HaveLen(1))
+	g.Expect(recorder.Last().Success).To(gomega.BeFalse())
+	g.Expect(recorder.Failures()).To(gomega.HaveLen(1))
+	g.Expect(recorder.Failures()[0].Name).To(gomega.Equal("nodes"))
+	g.Expect(recorder.Failures()[0].Error.ConditionValidations[0].ResourceErrors).To(gomega.Equal(map[string][]string{
+		"found conditions status 'False' does not match required status 'True'": {"node-2"},
+	}))
+	g.Expect(recorder.Status("nodes-ready")).To(gomega.Equal(client.ValidationStatusFailed))
+}
@@ -0,0 +1,222 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+)
+
+const (
+	// WorkloadLabel marks every object created by active checks so leftovers can be found and removed.
+	WorkloadLabel = "cluster-validator.keikoproj.io/workload"
+)
+
+var (
+	// workloadPollInterval is how often created objects are polled while waiting on them.
+	workloadPollInterval = time.Second
+	// workloadStartupTimeout bounds how long probe pods may take to be scheduled and started.
+	workloadStartupTimeout = 2 * time.Minute
+	// workloadImage is used by probe pods unless a check overrides it.
+	workloadImage = "busybox:1.36"
+)
+
+type createdObject struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+}
+
+// workloadSet creates objects for active checks and deletes all of them on cleanup,
+// in reverse creation order. Objects are not created once the set was cleaned up.
+type workloadSet struct {
+	sync.Mutex
+	v       *Validator
+	created []createdObject
+	closed  bool
+}
+
+func (v *Validator) newWorkloadSet() *workloadSet {
+	w := &workloadSet{v: v}
+	v.Lock()
+	v.workloads[w] = true
+	v.Unlock()
+	return w
+}
+
+// CleanupWorkloads deletes the objects of all active checks and workload tests that have
+// not been cleaned up yet, it should be called before exiting while validation is running.
+func (v *Validator) CleanupWorkloads() {
+	v.RLock()
+	sets := make([]*workloadSet, 0, len(v.workloads))
+	for w := range v.workloads {
+		sets = append(sets, w)
+	}
+	v.RUnlock()
+
+	for _, w := range sets {
+		w.cleanup()
+	}
+}
+
+// workloadName returns a unique object name for the given prefix.
+func workloadName(prefix string) string {
+	return fmt.Sprintf("cluster-validator-%v-%v", prefix, utilrand.String(5))
+}
+
+// create converts a typed object to unstructured, labels it and creates it. The object is
+// created and recorded under the lock, so a concurrent cleanup either deletes it or prevents it.
+func (w *workloadSet) create(gvr schema.GroupVersionResource, obj runtime.Object) (*unstructured.Unstructured, error) {
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert workload object")
+	}
+
+	u := &unstructured.Unstructured{Object: raw}
+	labels := u.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[WorkloadLabel] = "true"
+	u.SetLabels(labels)
+
+	w.Lock()
+	defer w.Unlock()
+
+	if w.closed {
+		return nil, errors.Errorf("not creating %v '%v', workloads were cleaned up", gvr.Resource, namespacedName(*u))
+	}
+	out, err := w.v.Kubernetes.Resource(gvr).Namespace(u.GetNamespace()).Create(context.Background(), u, metav1.CreateOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create %v '%v'", gvr.Resource, namespacedName(*u))
+	}
+	w.created = append(w.created, createdObject{gvr: gvr, namespace: u.GetNamespace(), name: u.GetName()})
+	return out, nil
+}
+
+// recreate creates an object with a fixed name, such as a workload test manifest. An object of
+// the same name that is still being deleted, e.g. by the previous attempt, is waited for first.
+func (w *workloadSet) recreate(gvr schema.GroupVersionResource, u *unstructured.Unstructured, timeout time.Duration) (*unstructured.Unstructured, error) {
+	var (
+		deadline = time.Now().Add(timeout)
+	)
+
+	for {
+		out, err := w.create(gvr, u)
+		if !apierrors.IsAlreadyExists(errors.Cause(err)) {
+			return out, err
+		}
+
+		existing, getErr := w.v.Kubernetes.Resource(gvr).Namespace(u.GetNamespace()).Get(context.Background(), u.GetName(), metav1.GetOptions{})
+		if getErr != nil && !apierrors.IsNotFound(getErr) {
+			return nil, errors.Wrapf(getErr, "failed to get existing %v '%v'", gvr.Resource, namespacedName(*u))
+		}
+		if getErr == nil && existing.GetDeletionTimestamp() == nil {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("timed out after %v waiting for %v '%v' to be deleted", timeout, gvr.Resource, namespacedName(*u))
+		}
+		log.Debugf("waiting for %v '%v' to be deleted before creating it", gvr.Resource, namespacedName(*u))
+		if !w.v.sleep(workloadPollInterval) {
+			return nil, ErrInterrupted
+		}
+	}
+}
+
+// resourceFor maps a kind to its resource with the discovery RESTMapper, which knows irregular
+// plurals and custom resources that guessing from the kind gets wrong.
+func (v *Validator) resourceFor(gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
+	v.Lock()
+	if v.Mapper == nil && v.RESTClient != nil {
+		v.Mapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discovery.NewDiscoveryClient(v.RESTClient)))
+	}
+	mapper := v.Mapper
+	v.Unlock()
+
+	if mapper == nil {
+		return schema.GroupVersionResource{}, errors.Errorf("cannot map kind '%v' to a resource without a REST client", gvk.Kind)
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, errors.Wrapf(err, "failed to map kind '%v' to a resource", gvk.Kind)
+	}
+	return mapping.Resource, nil
+}
+
+// waitFor polls an object until done reports true, done errors or the timeout expires.
+func (w *workloadSet) waitFor(gvr schema.GroupVersionResource, namespace, name string, timeout time.Duration, done func(*unstructured.Unstructured) (bool, error)) (*unstructured.Unstructured, error) {
+	var (
+		deadline = time.Now().Add(timeout)
+	)
+
+	for {
+		u, err := w.v.Kubernetes.Resource(gvr).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %v '%v/%v'", gvr.Resource, namespace, name)
+		}
+
+		ok, err := done(u)
+		if err != nil {
+			return u, err
+		}
+		if ok {
+			return u, nil
+		}
+
+		if time.Now().After(deadline) {
+			return u, errors.Errorf("timed out after %v waiting for %v '%v/%v'", timeout, gvr.Resource, namespace, name)
+		}
+		if !w.v.sleep(workloadPollInterval) {
+			return u, ErrInterrupted
+		}
+	}
+}
+
+// cleanup deletes every created object, errors are logged since cleanup must not fail a check.
+func (w *workloadSet) cleanup() {
+	var (
+		propagation = metav1.DeletePropagationBackground
+	)
+
+	w.Lock()
+	defer w.Unlock()
+
+	for i := len(w.created) - 1; i >= 0; i-- {
+		obj := w.created[i]
+		err := w.v.Kubernetes.Resource(obj.gvr).Namespace(obj.namespace).Delete(context.Background(), obj.name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Warnf("failed to clean up %v '%v/%v': %v", obj.gvr.Resource, obj.namespace, obj.name, err)
+		}
+	}
+	w.created = nil
+	w.closed = true
+
+	w.v.Lock()
+	delete(w.v.workloads, w)
+	w.v.Unlock()
+}
@@ -0,0 +1,133 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/expr"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// validateWorkloadTest runs a workload test, every attempt applies the manifests, waits
+// for the resource validations to pass and deletes the manifests again.
+func (v *Validator) validateWorkloadTest(t v1alpha1.WorkloadTest) bool {
+	log.Infof("validating workload test '%v'", t.Name)
+
+	evaluate := func() (ValidationSummary, error) {
+		return v.evaluateWorkloadTest(t)
+	}
+
+	onFailure := func(summary ValidationSummary) ValidationError {
+		return ValidationError{
+			ID:                   t.ID,
+			Remediation:          t.Remediation,
+			DocsURL:              t.DocsURL,
+			Owner:                t.Owner,
+			Team:                 t.Team,
+			Runbook:              t.Runbook,
+			Message:              errors.Errorf("failure threshold met for workload test '%v'", t.Name),
+			FieldValidations:     summary.FieldValidation,
+			ConditionValidations: summary.ConditionValidation,
+			AggregateValidations: summary.AggregateValidation,
+			CheckValidations:     summary.CheckValidation,
+		}
+	}
+
+	return v.runValidation(t.Name, t.ID, t.Required, &t, v.suppressed(t.Name, t.ID, evaluate), onFailure)
+}
+
+func (v *Validator) evaluateWorkloadTest(t v1alpha1.WorkloadTest) (ValidationSummary, error) {
+	var (
+		w       = v.newWorkloadSet()
+		timeout = 5 * time.Minute
+		result  = NewCheckValidationResult(t.Name)
+	)
+	defer w.cleanup()
+	result.ID, result.Code = t.ID, FailureCodeWorkloadFailed
+
+	if t.Timeout != "" {
+		d, err := expr.ParseDuration(t.Timeout)
+		if err != nil {
+			return ValidationSummary{}, fatalError{errors.Wrapf(err, "invalid timeout for workload test '%v'", t.Name)}
+		}
+		timeout = d
+	}
+	deadline := time.Now().Add(timeout)
+
+	for i, manifest := range t.Manifests {
+		u := &unstructured.Unstructured{Object: runtime.DeepCopyJSON(manifest)}
+		if u.GetName() == "" || u.GetKind() == "" || u.GetAPIVersion() == "" {
+			return ValidationSummary{}, fatalError{errors.Errorf("manifest %v of workload test '%v' requires apiVersion, kind and metadata.name", i, t.Name)}
+		}
+		if u.GetNamespace() == "" && t.Namespace != "" {
+			u.SetNamespace(t.Namespace)
+		}
+
+		gvr, err := v.resourceFor(u.GroupVersionKind())
+		if err == nil {
+			_, err = w.recreate(gvr, u, time.Until(deadline))
+		}
+		if err != nil {
+			result.Error = err.Error()
+			return ValidationSummary{CheckValidation: []CheckValidationResult{result}}, err
+		}
+	}
+
+	for {
+		summary, err := v.evaluateWorkloadResources(t)
+		if err == nil {
+			return ValidationSummary{}, nil
+		}
+		if time.Now().After(deadline) {
+			summary.setID(t.ID)
+			return summary, errors.Wrapf(err, "workload test '%v' did not pass within %v", t.Name, timeout)
+		}
+		log.Debugf("workload test '%v' not ready yet -> %v", t.Name, err)
+		if !v.sleep(workloadPollInterval) {
+			return summary, fatalError{ErrInterrupted}
+		}
+	}
+}
+
+func (v *Validator) evaluateWorkloadResources(t v1alpha1.WorkloadTest) (ValidationSummary, error) {
+	var (
+		summary = ValidationSummary{}
+		failed  []string
+	)
+
+	for _, r := range t.Resources {
+		items, err := v.listResources(r)
+		if err != nil {
+			result := NewCheckValidationResult(t.Name)
+			result.ID, result.Code = t.ID, FailureCodeWorkloadFailed
+			result.Error = err.Error()
+			return ValidationSummary{CheckValidation: []CheckValidationResult{result}}, err
+		}
+
+		s, err := v.validateResources(r, scopeResources(r, items))
+		if err != nil {
+			failed = append(failed, r.Name)
+			mergeSummary(&summary, s)
+		}
+	}
+
+	if len(failed) > 0 {
+		return summary, errors.Errorf("resources %v failed to validate", failed)
+	}
+	return summary, nil
+}
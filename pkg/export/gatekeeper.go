@@ -0,0 +1,401 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	GatekeeperTemplateName   = "clustervalidatorchecks"
+	GatekeeperConstraintKind = "ClusterValidatorChecks"
+)
+
+const gatekeeperRego = `package clustervalidatorchecks
+
+violation[{"msg": msg}] {
+  in_scope
+  field := input.parameters.fields[_]
+  value := object.get(input.review.object, field.path, "")
+  not value_matches(field.values, value)
+  msg := sprintf("field '%v' value '%v' does not match any of %v", [field.name, value, field.values])
+}
+
+in_scope {
+  not out_of_scope
+}
+
+out_of_scope {
+  annotation := input.parameters.annotations[_]
+  not annotation_matches(annotation)
+}
+
+annotation_matches(annotation) {
+  annotation.operator == "Exists"
+  _ = input.review.object.metadata.annotations[annotation.key]
+}
+
+annotation_matches(annotation) {
+  annotation.operator == "Equal"
+  input.review.object.metadata.annotations[annotation.key] == annotation.value
+}
+
+out_of_scope {
+  input.parameters.names
+  not name_included
+}
+
+out_of_scope {
+  pattern := input.parameters.names.exclude[_]
+  glob.match(lower(pattern), [], lower(input.review.object.metadata.name))
+}
+
+name_included {
+  pattern := input.parameters.names.include[_]
+  glob.match(lower(pattern), [], lower(input.review.object.metadata.name))
+}
+
+value_matches(patterns, value) {
+  pattern := patterns[_]
+  glob.match(lower(pattern), null, lower(sprintf("%v", [value])))
+}
+`
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+	// globChars are the characters that make a scope pattern a glob
+	globChars = "*?[]{}\\"
+
+	irregularKinds = map[string]string{
+		"configmaps":                      "ConfigMap",
+		"clusterrolebindings":             "ClusterRoleBinding",
+		"clusterroles":                    "ClusterRole",
+		"cronjobs":                        "CronJob",
+		"csidrivers":                      "CSIDriver",
+		"csinodes":                        "CSINode",
+		"customresourcedefinitions":       "CustomResourceDefinition",
+		"daemonsets":                      "DaemonSet",
+		"endpoints":                       "Endpoints",
+		"endpointslices":                  "EndpointSlice",
+		"horizontalpodautoscalers":        "HorizontalPodAutoscaler",
+		"mutatingwebhookconfigurations":   "MutatingWebhookConfiguration",
+		"networkpolicies":                 "NetworkPolicy",
+		"persistentvolumeclaims":          "PersistentVolumeClaim",
+		"persistentvolumes":               "PersistentVolume",
+		"poddisruptionbudgets":            "PodDisruptionBudget",
+		"priorityclasses":                 "PriorityClass",
+		"replicasets":                     "ReplicaSet",
+		"rolebindings":                    "RoleBinding",
+		"serviceaccounts":                 "ServiceAccount",
+		"statefulsets":                    "StatefulSet",
+		"storageclasses":                  "StorageClass",
+		"validatingwebhookconfigurations": "ValidatingWebhookConfiguration",
+		"volumeattachments":               "VolumeAttachment",
+	}
+)
+
+type gatekeeperField struct {
+	Name   string   `json:"name"`
+	Path   []string `json:"path"`
+	Values []string `json:"values"`
+}
+
+type gatekeeperAnnotation struct {
+	Key      string `json:"key"`
+	Value    string `json:"value,omitempty"`
+	Operator string `json:"operator"`
+}
+
+// gatekeeperNames holds the name scope of a resource entry, it is matched in Rego as the
+// constraint match cannot express globs and exclusions.
+type gatekeeperNames struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+type gatekeeperParameters struct {
+	Fields      []gatekeeperField      `json:"fields,omitempty"`
+	Annotations []gatekeeperAnnotation `json:"annotations,omitempty"`
+	Names       *gatekeeperNames       `json:"names,omitempty"`
+}
+
+type gatekeeperKinds struct {
+	APIGroups []string `json:"apiGroups"`
+	Kinds     []string `json:"kinds"`
+}
+
+type gatekeeperMatch struct {
+	Kinds              []gatekeeperKinds     `json:"kinds"`
+	Namespaces         []string              `json:"namespaces,omitempty"`
+	ExcludedNamespaces []string              `json:"excludedNamespaces,omitempty"`
+	Name               string                `json:"name,omitempty"`
+	LabelSelector      *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// Gatekeeper renders the field validations of a spec as a ConstraintTemplate followed by one
+// Constraint per resource entry, annotation selectors limit the objects a constraint applies to.
+func Gatekeeper(spec *v1alpha1.ClusterValidation) ([]byte, error) {
+	var (
+		docs = []interface{}{gatekeeperTemplate()}
+	)
+
+	for i, r := range spec.Spec.Resources {
+		params := gatekeeperParametersFor(r)
+		if len(params.Fields) == 0 {
+			log.Warnf("resource '%v' has no exportable field validations, skipping", r.Name)
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(r.APIVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid apiVersion for resource '%v'", r.Name)
+		}
+		match, err := gatekeeperMatchFor(r, gv.Group)
+		if err != nil {
+			return nil, err
+		}
+
+		docs = append(docs, map[string]interface{}{
+			"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+			"kind":       GatekeeperConstraintKind,
+			"metadata": map[string]interface{}{
+				"name": constraintName(spec.GetName(), r.Name, i),
+			},
+			"spec": map[string]interface{}{
+				"match":      match,
+				"parameters": params,
+			},
+		})
+	}
+
+	if len(docs) == 1 {
+		return nil, errors.New("spec contains no field validations to export")
+	}
+
+	buf := new(bytes.Buffer)
+	for i, doc := range docs {
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal gatekeeper manifest")
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(out)
+	}
+	return buf.Bytes(), nil
+}
+
+func gatekeeperTemplate() map[string]interface{} {
+	stringArray := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "string"},
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "templates.gatekeeper.sh/v1",
+		"kind":       "ConstraintTemplate",
+		"metadata": map[string]interface{}{
+			"name": GatekeeperTemplateName,
+		},
+		"spec": map[string]interface{}{
+			"crd": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"names": map[string]interface{}{
+						"kind": GatekeeperConstraintKind,
+					},
+					"validation": map[string]interface{}{
+						"openAPIV3Schema": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"fields": map[string]interface{}{
+									"type": "array",
+									"items": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"name":   map[string]interface{}{"type": "string"},
+											"path":   stringArray,
+											"values": stringArray,
+										},
+									},
+								},
+								"names": map[string]interface{}{
+									"type": "object",
+									"properties": map[string]interface{}{
+										"include": stringArray,
+										"exclude": stringArray,
+									},
+								},
+								"annotations": map[string]interface{}{
+									"type": "array",
+									"items": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"key":      map[string]interface{}{"type": "string"},
+											"value":    map[string]interface{}{"type": "string"},
+											"operator": map[string]interface{}{"type": "string"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"targets": []interface{}{
+				map[string]interface{}{
+					"target": "admission.k8s.gatekeeper.sh",
+					"rego":   gatekeeperRego,
+				},
+			},
+		},
+	}
+}
+
+func gatekeeperParametersFor(r v1alpha1.ClusterResource) gatekeeperParameters {
+	var (
+		params = gatekeeperParameters{}
+	)
+
+	for _, f := range r.Fields {
+		path, ok := regoPath(f.GetPath())
+		if !ok {
+			log.Warnf("field path '%v' of resource '%v' cannot be expressed as a gatekeeper path, skipping", f.GetPath(), r.Name)
+			continue
+		}
+		params.Fields = append(params.Fields, gatekeeperField{
+			Name:   f.Path,
+			Path:   path,
+			Values: f.GetValues(),
+		})
+	}
+
+	for _, a := range r.Annotations {
+		params.Annotations = append(params.Annotations, gatekeeperAnnotation{
+			Key:      a.Key,
+			Value:    a.Value,
+			Operator: string(a.GetOperator()),
+		})
+	}
+
+	if r.Names != nil {
+		params.Names = &gatekeeperNames{Include: r.Names.Include, Exclude: r.Names.Exclude}
+	}
+	return params
+}
+
+// gatekeeperMatchFor returns the constraint match of a resource entry. Gatekeeper only matches
+// namespaces by name, prefix or suffix, so other namespace patterns cannot be exported.
+func gatekeeperMatchFor(r v1alpha1.ClusterResource, group string) (gatekeeperMatch, error) {
+	var (
+		kind  = r.Kind
+		match = gatekeeperMatch{}
+	)
+
+	if kind == "" {
+		kind = kindForResource(r.Name)
+	}
+	match.Kinds = []gatekeeperKinds{{APIGroups: []string{group}, Kinds: []string{kind}}}
+
+	if r.Namespaces != nil {
+		for _, ns := range append(append([]string{}, r.Namespaces.Include...), r.Namespaces.Exclude...) {
+			if ns != "*" && !gatekeeperNamespacePattern(ns) {
+				return match, errors.Errorf("namespace pattern '%v' of resource '%v' cannot be expressed in a constraint match, only a '*' prefix or suffix is supported", ns, r.Name)
+			}
+		}
+		for _, ns := range r.Namespaces.Include {
+			if ns != "*" {
+				match.Namespaces = append(match.Namespaces, ns)
+			}
+		}
+		match.ExcludedNamespaces = r.Namespaces.Exclude
+	}
+
+	match.LabelSelector = r.Labels
+
+	// a single name without exclusions also narrows the match, globs and exclusions are
+	// evaluated by the Rego with the names parameter
+	if r.Names != nil && len(r.Names.Include) == 1 && len(r.Names.Exclude) == 0 && !strings.ContainsAny(r.Names.Include[0], globChars) {
+		match.Name = r.Names.Include[0]
+	}
+
+	return match, nil
+}
+
+// gatekeeperNamespacePattern returns whether a namespace pattern is a name, or a name with a
+// single '*' as prefix or suffix, which is what Gatekeeper matches namespaces with.
+func gatekeeperNamespacePattern(pattern string) bool {
+	name := strings.TrimSuffix(strings.TrimPrefix(pattern, "*"), "*")
+	if len(name) < len(pattern)-1 {
+		return false
+	}
+	return name != "" && !strings.ContainsAny(name, globChars)
+}
+
+func regoPath(jsonPath string) ([]string, bool) {
+	path := strings.TrimSuffix(strings.TrimPrefix(jsonPath, "{"), "}")
+	if strings.ContainsAny(path, "[]*?@$") {
+		return nil, false
+	}
+
+	segments := strings.FieldsFunc(path, func(c rune) bool {
+		return c == '.'
+	})
+	if len(segments) == 0 {
+		return nil, false
+	}
+	return segments, true
+}
+
+func kindForResource(resource string) string {
+	resource = strings.ToLower(resource)
+	if kind, ok := irregularKinds[resource]; ok {
+		return kind
+	}
+
+	singular := resource
+	switch {
+	case strings.HasSuffix(resource, "ies"):
+		singular = strings.TrimSuffix(resource, "ies") + "y"
+	case strings.HasSuffix(resource, "sses"), strings.HasSuffix(resource, "xes"),
+		strings.HasSuffix(resource, "ches"), strings.HasSuffix(resource, "shes"):
+		singular = strings.TrimSuffix(resource, "es")
+	case strings.HasSuffix(resource, "s"):
+		singular = strings.TrimSuffix(resource, "s")
+	}
+
+	if singular == "" {
+		return resource
+	}
+	return strings.ToUpper(singular[:1]) + singular[1:]
+}
+
+func constraintName(specName, resource string, index int) string {
+	name := fmt.Sprintf("%v-%v-%v", specName, resource, index)
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(name) > 63 {
+		name = strings.Trim(name[:63], "-")
+	}
+	return name
+}
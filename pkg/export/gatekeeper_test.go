@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func _mockSpec(resources ...v1alpha1.ClusterResource) *v1alpha1.ClusterValidation {
+	return &v1alpha1.ClusterValidation{
+		ObjectMeta: metav1.ObjectMeta{Name: "export-test"},
+		Spec: v1alpha1.ClusterValidationSpec{
+			Resources: resources,
+		},
+	}
+}
+
+func Test_GatekeeperExport(t *testing.T) {
+	g := gomega.NewWithT(t)
+	spec := _mockSpec(
+		v1alpha1.ClusterResource{
+			Name:       "deployments",
+			APIVersion: "apps/v1",
+			Namespaces: &v1alpha1.SelectionScope{Include: []string{"kube-system"}},
+			Labels:     &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "system"}},
+			Fields: []v1alpha1.FieldSelector{
+				{Path: ".spec.template.spec.priorityClassName", Values: []string{"system-*"}},
+				{Path: ".status.conditions[*].type"},
+			},
+			Annotations: []v1alpha1.AnnotationSelector{
+				{Key: "owner"},
+			},
+		},
+		v1alpha1.ClusterResource{
+			Name:       "nodes",
+			APIVersion: "v1",
+		},
+	)
+
+	out, err := Gatekeeper(spec)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	docs := strings.Split(string(out), "---\n")
+	g.Expect(docs).To(gomega.HaveLen(2))
+
+	constraint := map[string]interface{}{}
+	g.Expect(yaml.Unmarshal([]byte(docs[1]), &constraint)).To(gomega.Succeed())
+	g.Expect(constraint["kind"]).To(gomega.Equal(GatekeeperConstraintKind))
+
+	s := constraint["spec"].(map[string]interface{})
+	match := s["match"].(map[string]interface{})
+	g.Expect(match["namespaces"]).To(gomega.ConsistOf("kube-system"))
+	g.Expect(match["labelSelector"]).To(gomega.Equal(map[string]interface{}{"matchLabels": map[string]interface{}{"tier": "system"}}))
+	kinds := match["kinds"].([]interface{})[0].(map[string]interface{})
+	g.Expect(kinds["kinds"]).To(gomega.ConsistOf("Deployment"))
+	g.Expect(kinds["apiGroups"]).To(gomega.ConsistOf("apps"))
+
+	params := s["parameters"].(map[string]interface{})
+	g.Expect(params["fields"]).To(gomega.HaveLen(1))
+	g.Expect(params["annotations"]).To(gomega.ConsistOf(map[string]interface{}{"key": "owner", "operator": "Exists"}))
+}
+
+func Test_GatekeeperExportScope(t *testing.T) {
+	g := gomega.NewWithT(t)
+	fields := []v1alpha1.FieldSelector{{Path: ".spec.replicas", Values: []string{"3"}}}
+
+	constraint := func(r v1alpha1.ClusterResource) map[string]interface{} {
+		out, err := Gatekeeper(_mockSpec(r))
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		constraint := map[string]interface{}{}
+		g.Expect(yaml.Unmarshal([]byte(strings.Split(string(out), "---\n")[1]), &constraint)).To(gomega.Succeed())
+		return constraint["spec"].(map[string]interface{})
+	}
+
+	// a single name is matched by the constraint
+	s := constraint(v1alpha1.ClusterResource{Name: "deployments", APIVersion: "apps/v1", Fields: fields,
+		Names: &v1alpha1.SelectionScope{Include: []string{"coredns"}}})
+	g.Expect(s["match"]).To(gomega.HaveKeyWithValue("name", "coredns"))
+
+	// globs and exclusions are kept as parameters for the Rego
+	s = constraint(v1alpha1.ClusterResource{Name: "deployments", APIVersion: "apps/v1", Fields: fields,
+		Namespaces: &v1alpha1.SelectionScope{Include: []string{"*"}, Exclude: []string{"kube-*"}},
+		Names:      &v1alpha1.SelectionScope{Include: []string{"web-*"}, Exclude: []string{"web-canary"}}})
+	g.Expect(s["match"]).NotTo(gomega.HaveKey("name"))
+	g.Expect(s["match"]).To(gomega.HaveKeyWithValue("excludedNamespaces", gomega.ConsistOf("kube-*")))
+	g.Expect(s["parameters"]).To(gomega.HaveKeyWithValue("names", map[string]interface{}{
+		"include": []interface{}{"web-*"},
+		"exclude": []interface{}{"web-canary"},
+	}))
+
+	// namespace globs gatekeeper cannot match are refused
+	_, err := Gatekeeper(_mockSpec(v1alpha1.ClusterResource{Name: "deployments", APIVersion: "apps/v1", Fields: fields,
+		Namespaces: &v1alpha1.SelectionScope{Include: []string{"team-?"}}}))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("namespace pattern 'team-?'")))
+	_, err = Gatekeeper(_mockSpec(v1alpha1.ClusterResource{Name: "deployments", APIVersion: "apps/v1", Fields: fields,
+		Namespaces: &v1alpha1.SelectionScope{Include: []string{"*-team-*"}}}))
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_GatekeeperExportNothingToExport(t *testing.T) {
+	g := gomega.NewWithT(t)
+	_, err := Gatekeeper(_mockSpec(v1alpha1.ClusterResource{Name: "nodes", APIVersion: "v1"}))
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_KindForResource(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(kindForResource("pods")).To(gomega.Equal("Pod"))
+	g.Expect(kindForResource("ingresses")).To(gomega.Equal("Ingress"))
+	g.Expect(kindForResource("daemonsets")).To(gomega.Equal("DaemonSet"))
+	g.Expect(kindForResource("policies")).To(gomega.Equal("Policy"))
+}
@@ -0,0 +1,162 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/pkg/errors"
+)
+
+const (
+	grafanaPanelWidth   = 6
+	grafanaPanelHeight  = 4
+	grafanaPanelsPerRow = 24 / grafanaPanelWidth
+)
+
+// grafanaDatasource refers to the datasource template variable of the dashboard.
+var grafanaDatasource = map[string]interface{}{"type": "prometheus", "uid": "${datasource}"}
+
+// Grafana renders a dashboard with the outcome of the last run and one panel per validation of
+// the spec, driven by the metrics served by the serve command.
+func Grafana(spec *v1alpha1.ClusterValidation) ([]byte, error) {
+	var (
+		validations = specValidations(spec)
+		panels      = make([]interface{}, 0, len(validations)+2)
+		title       = "Cluster Validator"
+	)
+
+	if len(validations) == 0 {
+		return nil, errors.New("spec contains no validations to export")
+	}
+	if spec.GetName() != "" {
+		title = fmt.Sprintf("Cluster Validator: %v", spec.GetName())
+	}
+
+	panels = append(panels,
+		grafanaStatPanel(1, "Last run", fmt.Sprintf(`%v{cluster=~"$cluster"}`, client.MetricRunSuccess), 0, 0, 12),
+		grafanaTimeSeriesPanel(2, "Run duration", fmt.Sprintf(`%v{cluster=~"$cluster"}`, client.MetricRunDuration), 12, 0, 12),
+	)
+	for i, val := range validations {
+		var (
+			x = (i % grafanaPanelsPerRow) * grafanaPanelWidth
+			y = grafanaPanelHeight + (i/grafanaPanelsPerRow)*grafanaPanelHeight
+		)
+		query := fmt.Sprintf(`%v{cluster=~"$cluster",%v}`, client.MetricValidationSuccess, val.selector())
+		panel := grafanaStatPanel(i+3, fmt.Sprintf("%v: %v", val.Kind, val.Name), query, x, y, grafanaPanelWidth)
+		panel["description"] = fmt.Sprintf("Whether %v '%v' succeeded in the last run", strings.ToLower(val.Kind), val.Name)
+		panels = append(panels, panel)
+	}
+
+	dashboard := map[string]interface{}{
+		"title":         title,
+		"uid":           grafanaUID(spec.GetName()),
+		"tags":          []string{"cluster-validator"},
+		"schemaVersion": 36,
+		"refresh":       "1m",
+		"time":          map[string]interface{}{"from": "now-24h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{
+					"name":  "datasource",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+				map[string]interface{}{
+					"name":       "cluster",
+					"type":       "query",
+					"datasource": grafanaDatasource,
+					"query":      fmt.Sprintf("label_values(%v, cluster)", client.MetricRunSuccess),
+					"includeAll": true,
+					"multi":      true,
+					"refresh":    2,
+				},
+			},
+		},
+		"panels": panels,
+	}
+
+	out, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal grafana dashboard")
+	}
+	return out, nil
+}
+
+func grafanaStatPanel(id int, title, expr string, x, y, width int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"type":       "stat",
+		"title":      title,
+		"datasource": grafanaDatasource,
+		"gridPos":    map[string]interface{}{"x": x, "y": y, "w": width, "h": grafanaPanelHeight},
+		"targets": []interface{}{
+			map[string]interface{}{"refId": "A", "datasource": grafanaDatasource, "expr": expr, "legendFormat": "{{cluster}}"},
+		},
+		"fieldConfig": map[string]interface{}{
+			"defaults": map[string]interface{}{
+				"mappings": []interface{}{
+					map[string]interface{}{
+						"type": "value",
+						"options": map[string]interface{}{
+							"0": map[string]interface{}{"text": "Failing", "color": "red"},
+							"1": map[string]interface{}{"text": "Passing", "color": "green"},
+						},
+					},
+				},
+				"color": map[string]interface{}{"mode": "thresholds"},
+				"thresholds": map[string]interface{}{
+					"mode": "absolute",
+					"steps": []interface{}{
+						map[string]interface{}{"color": "red", "value": nil},
+						map[string]interface{}{"color": "green", "value": 1},
+					},
+				},
+			},
+		},
+		"options": map[string]interface{}{
+			"colorMode":     "background",
+			"graphMode":     "none",
+			"reduceOptions": map[string]interface{}{"calcs": []string{"lastNotNull"}},
+		},
+	}
+}
+
+func grafanaTimeSeriesPanel(id int, title, expr string, x, y, width int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"type":       "timeseries",
+		"title":      title,
+		"datasource": grafanaDatasource,
+		"gridPos":    map[string]interface{}{"x": x, "y": y, "w": width, "h": grafanaPanelHeight},
+		"targets": []interface{}{
+			map[string]interface{}{"refId": "A", "datasource": grafanaDatasource, "expr": expr, "legendFormat": "{{cluster}}"},
+		},
+		"fieldConfig": map[string]interface{}{
+			"defaults": map[string]interface{}{"unit": "s"},
+		},
+	}
+}
+
+// grafanaUID derives a stable dashboard uid from the spec name, within the 40 characters Grafana allows.
+func grafanaUID(specName string) string {
+	uid := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower("cluster-validator-"+specName), "-"), "-")
+	if len(uid) > 40 {
+		uid = strings.Trim(uid[:40], "-")
+	}
+	return uid
+}
@@ -0,0 +1,53 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/onsi/gomega"
+)
+
+func Test_GrafanaExport(t *testing.T) {
+	g := gomega.NewWithT(t)
+	spec := _mockSpec(
+		v1alpha1.ClusterResource{Name: "nodes", APIVersion: "v1"},
+		v1alpha1.ClusterResource{Name: "deployments", APIVersion: "apps/v1", ID: "CV-1"},
+	)
+	spec.Spec.Checks = []v1alpha1.ClusterCheck{{Name: "failed-pods"}}
+
+	out, err := Grafana(spec)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	dashboard := map[string]interface{}{}
+	g.Expect(json.Unmarshal(out, &dashboard)).To(gomega.Succeed())
+	g.Expect(dashboard["title"]).To(gomega.Equal("Cluster Validator: export-test"))
+	g.Expect(dashboard["uid"]).To(gomega.Equal("cluster-validator-export-test"))
+
+	panels := dashboard["panels"].([]interface{})
+	g.Expect(panels).To(gomega.HaveLen(5))
+
+	exprOf := func(i int) string {
+		panel := panels[i].(map[string]interface{})
+		return panel["targets"].([]interface{})[0].(map[string]interface{})["expr"].(string)
+	}
+	g.Expect(exprOf(0)).To(gomega.Equal(`cluster_validator_run_success{cluster=~"$cluster"}`))
+	g.Expect(exprOf(2)).To(gomega.Equal(`cluster_validator_validation_success{cluster=~"$cluster",validation="nodes"}`))
+	g.Expect(exprOf(3)).To(gomega.Equal(`cluster_validator_validation_success{cluster=~"$cluster",id="CV-1"}`))
+	g.Expect(panels[4].(map[string]interface{})["title"]).To(gomega.Equal("Check: failed-pods"))
+
+	_, err = Grafana(_mockSpec())
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -0,0 +1,142 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/pkg/errors"
+)
+
+const (
+	PrometheusRuleGroup = "cluster-validator"
+)
+
+type prometheusRule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// PrometheusRules renders a PrometheusRule with one alert per validation of a spec, firing when
+// the validation has been failing for longer than its failure threshold allows.
+func PrometheusRules(spec *v1alpha1.ClusterValidation) ([]byte, error) {
+	var (
+		validations = specValidations(spec)
+		rules       = make([]prometheusRule, 0, len(validations))
+	)
+
+	if len(validations) == 0 {
+		return nil, errors.New("spec contains no validations to export")
+	}
+
+	for _, val := range validations {
+		rule := prometheusRule{
+			Alert: alertName(val),
+			Expr:  fmt.Sprintf("%v{%v} == 0", client.MetricValidationSuccess, val.selector()),
+			For:   promDuration(val.Threshold),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("%v '%v' is failing on cluster {{ $labels.cluster }}", val.Kind, val.Name),
+				"description": fmt.Sprintf("%v '%v' is failing.", val.Kind, val.Name),
+			},
+		}
+		if rule.For != "" {
+			rule.Annotations["description"] = fmt.Sprintf("%v '%v' has been failing for longer than %v.", val.Kind, val.Name, rule.For)
+		}
+		if val.Required {
+			rule.Labels["severity"] = "critical"
+		}
+		if val.Team != "" {
+			rule.Labels["team"] = val.Team
+		}
+		if val.Remediation != "" {
+			rule.Annotations["description"] += " " + val.Remediation
+		}
+		if val.Runbook != "" {
+			rule.Annotations["runbook_url"] = val.Runbook
+		}
+		rules = append(rules, rule)
+	}
+
+	out, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata": map[string]interface{}{
+			"name": constraintName("cluster-validator", spec.GetName(), 0),
+		},
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name":  PrometheusRuleGroup,
+					"rules": rules,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal prometheus rules")
+	}
+	return out, nil
+}
+
+// alertName converts the id, or the name, of a validation to a CamelCase alert name.
+func alertName(val validation) string {
+	var (
+		name = val.ID
+		b    = strings.Builder{}
+	)
+
+	if name == "" {
+		name = val.Name
+	}
+	b.WriteString("ClusterValidator")
+	for _, word := range strings.FieldsFunc(name, func(c rune) bool {
+		return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	b.WriteString("Failing")
+	return b.String()
+}
+
+// promDuration formats a duration the way Prometheus parses it, e.g. "1h30m", rounded to seconds.
+func promDuration(d time.Duration) string {
+	var (
+		b = strings.Builder{}
+	)
+
+	d = d.Round(time.Second)
+	if d <= 0 {
+		return ""
+	}
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{{"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}} {
+		if n := d / unit.size; n > 0 {
+			fmt.Fprintf(&b, "%d%v", n, unit.suffix)
+			d -= n * unit.size
+		}
+	}
+	return b.String()
+}
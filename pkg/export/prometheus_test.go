@@ -0,0 +1,83 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/onsi/gomega"
+)
+
+func Test_PrometheusRulesExport(t *testing.T) {
+	g := gomega.NewWithT(t)
+	spec := _mockSpec(
+		v1alpha1.ClusterResource{
+			Name:          "nodes",
+			APIVersion:    "v1",
+			Required:      true,
+			Team:          "platform",
+			Runbook:       "https://runbooks.example.com/nodes",
+			Configuration: v1alpha1.ValidationConfiguration{FailureThreshold: 30, Interval: "10s"},
+		},
+	)
+	spec.Spec.Checks = []v1alpha1.ClusterCheck{{Name: "failed-pods", ID: "cv-failed-pods"}}
+	spec.Spec.Configuration = v1alpha1.ValidationConfiguration{FailureThreshold: 3, Interval: "1m"}
+
+	out, err := PrometheusRules(spec)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	manifest := struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			Groups []struct {
+				Name  string           `json:"name"`
+				Rules []prometheusRule `json:"rules"`
+			} `json:"groups"`
+		} `json:"spec"`
+	}{}
+	g.Expect(yaml.Unmarshal(out, &manifest)).To(gomega.Succeed())
+	g.Expect(manifest.Kind).To(gomega.Equal("PrometheusRule"))
+	g.Expect(manifest.Spec.Groups).To(gomega.HaveLen(1))
+
+	rules := manifest.Spec.Groups[0].Rules
+	g.Expect(rules).To(gomega.HaveLen(2))
+	g.Expect(rules[0].Alert).To(gomega.Equal("ClusterValidatorNodesFailing"))
+	g.Expect(rules[0].Expr).To(gomega.Equal(`cluster_validator_validation_success{validation="nodes"} == 0`))
+	g.Expect(rules[0].For).To(gomega.Equal("5m"))
+	g.Expect(rules[0].Labels).To(gomega.Equal(map[string]string{"severity": "critical", "team": "platform"}))
+	g.Expect(rules[0].Annotations).To(gomega.HaveKeyWithValue("runbook_url", "https://runbooks.example.com/nodes"))
+
+	g.Expect(rules[1].Alert).To(gomega.Equal("ClusterValidatorCvFailedPodsFailing"))
+	g.Expect(rules[1].Expr).To(gomega.Equal(`cluster_validator_validation_success{id="cv-failed-pods"} == 0`))
+	g.Expect(rules[1].For).To(gomega.Equal("3m"))
+	g.Expect(rules[1].Labels).To(gomega.HaveKeyWithValue("severity", "warning"))
+
+	spec.Spec.Resources[0].PerGroup = &v1alpha1.PerGroupConfig{Label: "node.kubernetes.io/instancegroup"}
+	spec.Spec.Checks = nil
+	out, err = PrometheusRules(spec)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(out)).To(gomega.ContainSubstring(`cluster_validator_validation_success{validation=~"nodes/.+"} == 0`))
+
+	_, err = PrometheusRules(_mockSpec())
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_PromDuration(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(promDuration(90 * time.Minute)).To(gomega.Equal("1h30m"))
+	g.Expect(promDuration(1500 * time.Millisecond)).To(gomega.Equal("2s"))
+	g.Expect(promDuration(0)).To(gomega.BeEmpty())
+}
@@ -0,0 +1,99 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+)
+
+// validation is a validation of a spec as it appears in the metrics of the serve command.
+type validation struct {
+	Kind        string
+	Name        string
+	ID          string
+	Required    bool
+	Team        string
+	Remediation string
+	Runbook     string
+	// Threshold is how long the validation keeps failing before the run reports it failed
+	Threshold time.Duration
+	// PerGroup is set when the validation is reported once per group, as "<name>/<group>"
+	PerGroup bool
+}
+
+type validationTarget interface {
+	FailureThreshold(globalCfg v1alpha1.ValidationConfiguration) int
+	Interval(globalCfg v1alpha1.ValidationConfiguration) time.Duration
+}
+
+// specValidations lists every validation of the spec in the order the spec declares them.
+func specValidations(spec *v1alpha1.ClusterValidation) []validation {
+	var (
+		validations = make([]validation, 0)
+		globalCfg   = spec.GetConfiguration()
+	)
+
+	threshold := func(target validationTarget) time.Duration {
+		return time.Duration(target.FailureThreshold(globalCfg)) * target.Interval(globalCfg)
+	}
+
+	for i := range spec.Spec.Resources {
+		r := &spec.Spec.Resources[i]
+		validations = append(validations, validation{"Resource", r.Name, r.ID, r.Required, r.Team, r.Remediation, r.Runbook, threshold(r), r.PerGroup != nil})
+	}
+	for i := range spec.Spec.Groups {
+		g := &spec.Spec.Groups[i]
+		validations = append(validations, validation{"Group", g.Name, g.ID, g.Required, g.Team, g.Remediation, g.Runbook, threshold(g), false})
+	}
+	for i := range spec.Spec.Checks {
+		c := &spec.Spec.Checks[i]
+		validations = append(validations, validation{"Check", c.Name, c.ID, c.Required, c.Team, c.Remediation, c.Runbook, threshold(c), false})
+	}
+	for i := range spec.Spec.WorkloadTests {
+		w := &spec.Spec.WorkloadTests[i]
+		validations = append(validations, validation{"Workload test", w.Name, w.ID, w.Required, w.Team, w.Remediation, w.Runbook, threshold(w), false})
+	}
+	for i := range spec.Spec.Endpoints.Cluster {
+		e := &spec.Spec.Endpoints.Cluster[i]
+		validations = append(validations, validation{"Endpoint", e.Name, e.ID, e.Required, e.Team, e.Remediation, e.Runbook, threshold(e), false})
+	}
+	for i := range spec.Spec.Endpoints.HTTP {
+		e := &spec.Spec.Endpoints.HTTP[i]
+		validations = append(validations, validation{"HTTP endpoint", e.Name, e.ID, e.Required, e.Team, e.Remediation, e.Runbook, threshold(e), false})
+	}
+	return validations
+}
+
+// selector matches the metrics of the validation by id when one is set and by name otherwise,
+// and the metrics of all of its groups when it is reported per group.
+func (v validation) selector() string {
+	if v.PerGroup && v.ID != "" {
+		return fmt.Sprintf(`id=~"%v/.+"`, promLabelValue(regexp.QuoteMeta(v.ID)))
+	}
+	if v.PerGroup {
+		return fmt.Sprintf(`validation=~"%v/.+"`, promLabelValue(regexp.QuoteMeta(v.Name)))
+	}
+	if v.ID != "" {
+		return fmt.Sprintf(`id="%v"`, promLabelValue(v.ID))
+	}
+	return fmt.Sprintf(`validation="%v"`, promLabelValue(v.Name))
+}
+
+func promLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,196 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package expr provides the helper functions available to expression based
+// validations. Every helper is a plain Go function so it can be registered
+// with any evaluator and exercised directly in unit tests.
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Now is the clock used by time based helpers, tests may replace it.
+var Now = time.Now
+
+// Funcs returns the helper library keyed by the name it is exposed as inside expressions.
+func Funcs() map[string]interface{} {
+	return map[string]interface{}{
+		"quantity":        Quantity,
+		"compareQuantity": CompareQuantity,
+		"duration":        ParseDuration,
+		"timestamp":       ParseTimestamp,
+		"since":           Since,
+		"until":           Until,
+		"olderThan":       OlderThan,
+		"newerThan":       NewerThan,
+		"semver":          Semver,
+		"semverCompare":   SemverCompare,
+		"label":           Label,
+		"hasLabel":        HasLabel,
+		"annotation":      Annotation,
+		"hasAnnotation":   HasAnnotation,
+	}
+}
+
+// Quantity parses a Kubernetes quantity such as "500m" or "16Gi" into a float.
+func Quantity(s string) (float64, error) {
+	q, err := resource.ParseQuantity(strings.TrimSpace(s))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid quantity '%v'", s)
+	}
+	return q.AsApproximateFloat64(), nil
+}
+
+// CompareQuantity returns -1, 0 or 1 when quantity a is less than, equal to or greater than b.
+func CompareQuantity(a, b string) (int, error) {
+	qa, err := resource.ParseQuantity(strings.TrimSpace(a))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid quantity '%v'", a)
+	}
+	qb, err := resource.ParseQuantity(strings.TrimSpace(b))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid quantity '%v'", b)
+	}
+	return qa.Cmp(qb), nil
+}
+
+// ParseDuration parses a Go duration, additionally accepting a days suffix (e.g. "7d").
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, errors.Errorf("invalid duration '%v'", s)
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.Errorf("invalid duration '%v'", s)
+	}
+	return d, nil
+}
+
+// ParseTimestamp parses an RFC3339 timestamp as found in Kubernetes object fields.
+func ParseTimestamp(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid timestamp '%v'", s)
+	}
+	return t, nil
+}
+
+// Since returns the time elapsed since the given timestamp.
+func Since(timestamp string) (time.Duration, error) {
+	t, err := ParseTimestamp(timestamp)
+	if err != nil {
+		return 0, err
+	}
+	return Now().Sub(t), nil
+}
+
+// Until returns the time remaining until the given timestamp.
+func Until(timestamp string) (time.Duration, error) {
+	t, err := ParseTimestamp(timestamp)
+	if err != nil {
+		return 0, err
+	}
+	return t.Sub(Now()), nil
+}
+
+// OlderThan reports whether the timestamp is further in the past than the given duration.
+func OlderThan(timestamp, duration string) (bool, error) {
+	age, err := Since(timestamp)
+	if err != nil {
+		return false, err
+	}
+	d, err := ParseDuration(duration)
+	if err != nil {
+		return false, err
+	}
+	return age > d, nil
+}
+
+// NewerThan reports whether the timestamp is within the given duration of now.
+func NewerThan(timestamp, duration string) (bool, error) {
+	age, err := Since(timestamp)
+	if err != nil {
+		return false, err
+	}
+	d, err := ParseDuration(duration)
+	if err != nil {
+		return false, err
+	}
+	return age <= d, nil
+}
+
+// Semver normalizes a version string, dropping a leading "v" and any vendor suffix (e.g. "v1.25.3-eks-1" -> "1.25.3").
+func Semver(s string) (string, error) {
+	v, err := version.ParseGeneric(strings.TrimSpace(s))
+	if err != nil {
+		return "", errors.Errorf("invalid version '%v'", s)
+	}
+	return v.String(), nil
+}
+
+// SemverCompare returns -1, 0 or 1 when version a is lower than, equal to or higher than b.
+func SemverCompare(a, b string) (int, error) {
+	va, err := version.ParseGeneric(strings.TrimSpace(a))
+	if err != nil {
+		return 0, errors.Errorf("invalid version '%v'", a)
+	}
+	vb, err := version.ParseGeneric(strings.TrimSpace(b))
+	if err != nil {
+		return 0, errors.Errorf("invalid version '%v'", b)
+	}
+	return va.Compare(vb.String())
+}
+
+// Label returns the value of a label on an object, or an empty string.
+func Label(obj map[string]interface{}, key string) string {
+	return metadataValue(obj, "labels", key)
+}
+
+// HasLabel reports whether the object carries the label.
+func HasLabel(obj map[string]interface{}, key string) bool {
+	_, found, _ := unstructured.NestedString(obj, "metadata", "labels", key)
+	return found
+}
+
+// Annotation returns the value of an annotation on an object, or an empty string.
+func Annotation(obj map[string]interface{}, key string) string {
+	return metadataValue(obj, "annotations", key)
+}
+
+// HasAnnotation reports whether the object carries the annotation.
+func HasAnnotation(obj map[string]interface{}, key string) bool {
+	_, found, _ := unstructured.NestedString(obj, "metadata", "annotations", key)
+	return found
+}
+
+func metadataValue(obj map[string]interface{}, field, key string) string {
+	val, found, err := unstructured.NestedFieldNoCopy(obj, "metadata", field, key)
+	if err != nil || !found || val == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", val)
+}
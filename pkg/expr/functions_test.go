@@ -0,0 +1,99 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+var (
+	testObject = map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "test-node-1",
+			"labels": map[string]interface{}{
+				"topology.kubernetes.io/zone": "us-west-2a",
+			},
+			"annotations": map[string]interface{}{
+				"owner": "platform",
+			},
+		},
+	}
+)
+
+func _fixedClock(t *testing.T, now time.Time) {
+	Now = func() time.Time { return now }
+	t.Cleanup(func() { Now = time.Now })
+}
+
+func Test_Quantity(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	v, err := Quantity("500m")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(v).To(gomega.BeNumerically("~", 0.5))
+
+	c, err := CompareQuantity("16Gi", "8Gi")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(c).To(gomega.Equal(1))
+
+	_, err = Quantity("lots")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_DurationHelpers(t *testing.T) {
+	g := gomega.NewWithT(t)
+	_fixedClock(t, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	d, err := ParseDuration("2d")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(d).To(gomega.Equal(48 * time.Hour))
+
+	since, err := Since("2023-01-01T23:00:00Z")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(since).To(gomega.Equal(time.Hour))
+
+	older, err := OlderThan("2023-01-01T00:00:00Z", "10m")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(older).To(gomega.BeTrue())
+
+	newer, err := NewerThan("2023-01-01T00:00:00Z", "10m")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(newer).To(gomega.BeFalse())
+
+	_, err = Since("yesterday")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_SemverCompare(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	c, err := SemverCompare("v1.25.3-eks-123", "1.26.0")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(c).To(gomega.Equal(-1))
+
+	v, err := Semver("v1.27.1+k3s1")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(v).To(gomega.Equal("1.27.1"))
+}
+
+func Test_MetadataAccessors(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(Label(testObject, "topology.kubernetes.io/zone")).To(gomega.Equal("us-west-2a"))
+	g.Expect(HasLabel(testObject, "missing")).To(gomega.BeFalse())
+	g.Expect(Annotation(testObject, "owner")).To(gomega.Equal("platform"))
+	g.Expect(HasAnnotation(testObject, "owner")).To(gomega.BeTrue())
+	g.Expect(Funcs()).To(gomega.HaveKey("semverCompare"))
+}
@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	HealthzPath = "/healthz"
+	ReadyzPath  = "/readyz"
+
+	apiProbeTimeout = 5 * time.Second
+)
+
+var (
+	namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+)
+
+// HealthResponse is the body of the liveness and readiness endpoints.
+type HealthResponse struct {
+	Healthy        bool       `json:"healthy"`
+	Message        string     `json:"message,omitempty"`
+	SpecLoaded     bool       `json:"specLoaded"`
+	APIReachable   *bool      `json:"apiReachable,omitempty"`
+	RunningSince   *time.Time `json:"runningSince,omitempty"`
+	LastEvaluation *time.Time `json:"lastEvaluation,omitempty"`
+	LastSuccess    *bool      `json:"lastSuccess,omitempty"`
+}
+
+// healthState tracks the runs of a server for its health endpoints, it has its own lock so
+// probes are answered while a run holds the server lock.
+type healthState struct {
+	sync.RWMutex
+	runningSince   time.Time
+	lastEvaluation time.Time
+	lastSuccess    bool
+}
+
+func (h *healthState) started() {
+	h.Lock()
+	defer h.Unlock()
+	h.runningSince = time.Now()
+}
+
+func (h *healthState) finished(err error) {
+	h.Lock()
+	defer h.Unlock()
+	h.runningSince = time.Time{}
+	h.lastEvaluation = time.Now()
+	h.lastSuccess = err == nil
+}
+
+// response returns the run state shared by both endpoints.
+func (h *healthState) response(specLoaded bool) HealthResponse {
+	h.RLock()
+	defer h.RUnlock()
+
+	resp := HealthResponse{Healthy: true, SpecLoaded: specLoaded}
+	if !h.runningSince.IsZero() {
+		since := h.runningSince
+		resp.RunningSince = &since
+	}
+	if !h.lastEvaluation.IsZero() {
+		last, success := h.lastEvaluation, h.lastSuccess
+		resp.LastEvaluation, resp.LastSuccess = &last, &success
+	}
+	return resp
+}
+
+// handleHealthz reports the server as not live when a run has been going for longer than
+// MaxRunDuration, so Kubernetes restarts a wedged validator instead of it going stale.
+func (s *Server) handleHealthz(w http.ResponseWriter, req *http.Request) {
+	resp := s.health.response(s.Spec != nil)
+	if s.MaxRunDuration > 0 && resp.RunningSince != nil && time.Since(*resp.RunningSince) > s.MaxRunDuration {
+		resp.Healthy = false
+		resp.Message = "validation has been running for longer than " + s.MaxRunDuration.String()
+	}
+	writeHealth(w, resp)
+}
+
+// handleReadyz reports the server as ready when its spec is loaded and the API server is reachable.
+func (s *Server) handleReadyz(w http.ResponseWriter, req *http.Request) {
+	resp := s.health.response(s.Spec != nil)
+	if !resp.SpecLoaded {
+		resp.Healthy = false
+		resp.Message = "validation spec is not loaded"
+		writeHealth(w, resp)
+		return
+	}
+
+	reachable := true
+	if err := s.probeAPI(); err != nil {
+		reachable = false
+		resp.Healthy = false
+		resp.Message = "API server is not reachable: " + err.Error()
+	}
+	resp.APIReachable = &reachable
+	writeHealth(w, resp)
+}
+
+// probeAPI checks the API server is reachable, through its readyz endpoint when a REST client
+// is available and otherwise by listing a single namespace.
+func (s *Server) probeAPI() error {
+	ctx, cancel := context.WithTimeout(context.Background(), apiProbeTimeout)
+	defer cancel()
+
+	if s.RESTClient != nil {
+		return s.RESTClient.Get().AbsPath(ReadyzPath).Do(ctx).Error()
+	}
+	_, err := s.Kubernetes.Resource(namespaceGVR).List(ctx, metav1.ListOptions{Limit: 1})
+	return err
+}
+
+func writeHealth(w http.ResponseWriter, resp HealthResponse) {
+	code := http.StatusOK
+	if !resp.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Warnf("failed to write health response: %v", err)
+	}
+}
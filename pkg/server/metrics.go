@@ -0,0 +1,56 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	log "github.com/sirupsen/logrus"
+)
+
+const MetricsPath = "/metrics"
+
+// lastReport keeps the report of the last run for the metrics endpoint, it has its own lock so
+// scrapes are answered while a run holds the server lock.
+type lastReport struct {
+	sync.RWMutex
+	report *client.Report
+}
+
+func (l *lastReport) set(report client.Report) {
+	l.Lock()
+	defer l.Unlock()
+	l.report = &report
+}
+
+func (l *lastReport) get() *client.Report {
+	l.RLock()
+	defer l.RUnlock()
+	return l.report
+}
+
+// handleMetrics serves the metrics of the last run in the Prometheus text format, nothing is
+// served before the first run finished.
+func (s *Server) handleMetrics(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	report := s.report.get()
+	if report == nil {
+		return
+	}
+	if err := client.WritePrometheusMetrics(w, *report); err != nil {
+		log.Warnf("failed to write metrics: %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server runs cluster-validator as a long-lived daemon that executes the
+// validation spec on demand, e.g. as a hook between keikoproj upgrade-manager batches.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	ValidatePath = "/validate"
+
+	RollingUpgradeResultAnnotation = "cluster-validator.keikoproj.io/result"
+	RollingUpgradeTimeAnnotation   = "cluster-validator.keikoproj.io/validated-at"
+)
+
+var (
+	RollingUpgradeGVR = schema.GroupVersionResource{Group: "upgrademgr.keikoproj.io", Version: "v1alpha1", Resource: "rollingupgrades"}
+)
+
+type Server struct {
+	sync.Mutex
+	Spec       *v1alpha1.ClusterValidation
+	Kubernetes dynamic.Interface
+	RESTClient *rest.RESTClient
+	Address    string
+	Preflight  bool
+	// Metadata is passed on to the validator of every run for metadata-only lists, optional
+	Metadata metadata.Interface
+	// InformerCache is passed on to the validator of every run
+	InformerCache bool
+	// StreamLists is passed on to the validator of every run
+	StreamLists bool
+	// Suppressions are passed on to the validator of every run
+	Suppressions []v1alpha1.Suppression
+	// Sinks receive the report of every run
+	Sinks []client.ResultSink
+	// Notifiers are passed on to the validator of every run
+	Notifiers []client.Notifier
+	// MaxRunDuration is how long a run may take before the liveness endpoint fails, zero disables it
+	MaxRunDuration time.Duration
+	// Debounce is how long Watch waits after a change before re-validating, to batch changes
+	Debounce time.Duration
+	// RollingUpgradeNamespaces are the namespaces whose RollingUpgrades /validate may annotate,
+	// annotating is refused when empty
+	RollingUpgradeNamespaces []string
+
+	health healthState
+	report lastReport
+}
+
+type ValidationResponse struct {
+	Success     bool                 `json:"success"`
+	Message     string               `json:"message,omitempty"`
+	ID          string               `json:"id,omitempty"`
+	Codes       []client.FailureCode `json:"codes,omitempty"`
+	Remediation string               `json:"remediation,omitempty"`
+	DocsURL     string               `json:"docsURL,omitempty"`
+	Owner       string               `json:"owner,omitempty"`
+	Team        string               `json:"team,omitempty"`
+	Runbook     string               `json:"runbook,omitempty"`
+	// Cluster and ClusterLabels identify the validated cluster
+	Cluster       string            `json:"cluster,omitempty"`
+	ClusterLabels map[string]string `json:"clusterLabels,omitempty"`
+}
+
+func NewServer(spec *v1alpha1.ClusterValidation, c dynamic.Interface, r *rest.RESTClient, address string) *Server {
+	return &Server{
+		Spec:       spec,
+		Kubernetes: c,
+		RESTClient: r,
+		Address:    address,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, s.handleValidate)
+	mux.HandleFunc(HealthzPath, s.handleHealthz)
+	mux.HandleFunc(ReadyzPath, s.handleReadyz)
+	mux.HandleFunc(MetricsPath, s.handleMetrics)
+	return mux
+}
+
+func (s *Server) Start() error {
+	log.Infof("serving validation hook on %v%v, health on %v and %v, metrics on %v", s.Address, ValidatePath, HealthzPath, ReadyzPath, MetricsPath)
+	return http.ListenAndServe(s.Address, s.Handler())
+}
+
+// Run executes the validation spec once, concurrent calls are serialized.
+func (s *Server) Run() error {
+	return s.run(s.Spec, false)
+}
+
+// run executes a spec, the report of a partial run only updates the validations it re-ran.
+func (s *Server) run(spec *v1alpha1.ClusterValidation, partial bool) error {
+	s.Lock()
+	defer s.Unlock()
+
+	v := client.NewValidator(s.Kubernetes, spec, s.RESTClient)
+	v.Metadata = s.Metadata
+	v.Preflight = s.Preflight
+	v.InformerCache = s.InformerCache
+	v.StreamLists = s.StreamLists
+	v.Suppressions = s.Suppressions
+	v.Sinks = s.Sinks
+	v.Notifiers = s.Notifiers
+
+	s.health.started()
+	report, err := v.ValidateWithResult()
+	// a failed run already stopped and drained its validations, stopping again releases a
+	// successful one, the validator is not reused
+	v.Stop()
+	v.CleanupWorkloads()
+	s.health.finished(err)
+	if partial {
+		report = mergeReport(s.report.get(), report, requiredValidations(s.Spec))
+	}
+	s.report.set(report)
+	return err
+}
+
+// handleValidate runs the spec on POST and responds with 200 on success and 412 on failure.
+// When the rollingupgrade and namespace query parameters are provided, the outcome is recorded
+// as annotations on that upgrade-manager RollingUpgrade, requests for a namespace that is not
+// in RollingUpgradeNamespaces are refused with 403.
+func (s *Server) handleValidate(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var (
+		resp           = ValidationResponse{Success: true, Cluster: s.Spec.Spec.Cluster.Name, ClusterLabels: s.Spec.Spec.Cluster.Labels}
+		code           = http.StatusOK
+		rollingUpgrade = req.URL.Query().Get("rollingupgrade")
+		namespace      = req.URL.Query().Get("namespace")
+	)
+
+	if rollingUpgrade != "" && !s.rollingUpgradeAllowed(namespace) {
+		http.Error(w, fmt.Sprintf("annotating rollingupgrades in namespace '%v' is not allowed", namespace), http.StatusForbidden)
+		return
+	}
+
+	if err := s.Run(); err != nil {
+		vErr := client.ToValidationError(err)
+		resp = ValidationResponse{
+			Success:       false,
+			Message:       validationMessage(err),
+			ID:            vErr.ID,
+			Codes:         vErr.Codes(),
+			Remediation:   vErr.Remediation,
+			DocsURL:       vErr.DocsURL,
+			Owner:         vErr.Owner,
+			Team:          vErr.Team,
+			Runbook:       vErr.Runbook,
+			Cluster:       resp.Cluster,
+			ClusterLabels: resp.ClusterLabels,
+		}
+		code = http.StatusPreconditionFailed
+	}
+
+	if rollingUpgrade != "" {
+		if err := s.annotateRollingUpgrade(namespace, rollingUpgrade, resp); err != nil {
+			log.Warnf("failed to annotate rollingupgrade '%v/%v': %v", namespace, rollingUpgrade, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Warnf("failed to write validation response: %v", err)
+	}
+}
+
+// rollingUpgradeAllowed reports whether RollingUpgrades in a namespace may be annotated.
+func (s *Server) rollingUpgradeAllowed(namespace string) bool {
+	for _, ns := range s.RollingUpgradeNamespaces {
+		if namespace != "" && ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) annotateRollingUpgrade(namespace, name string, resp ValidationResponse) error {
+	var (
+		result = "success"
+	)
+
+	if !resp.Success {
+		result = "failed"
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				RollingUpgradeResultAnnotation: result,
+				RollingUpgradeTimeAnnotation:   time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Kubernetes.Resource(RollingUpgradeGVR).Namespace(namespace).Patch(context.Background(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return errors.Wrap(err, "failed to patch rollingupgrade")
+}
+
+func validationMessage(err error) string {
+	return fmt.Sprintf("%v", client.ToValidationError(err).Message)
+}
@@ -0,0 +1,276 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var (
+	NamespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+)
+
+func _fakeDynamicClient() *fake.FakeDynamicClient {
+	return fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		NamespaceGVR:      "NamespaceList",
+		RollingUpgradeGVR: "RollingUpgradeList",
+	})
+}
+
+func _mockSpec() *v1alpha1.ClusterValidation {
+	return &v1alpha1.ClusterValidation{
+		Spec: v1alpha1.ClusterValidationSpec{
+			Configuration: v1alpha1.ValidationConfiguration{
+				SuccessThreshold: 2,
+				FailureThreshold: 2,
+				Interval:         "1ms",
+			},
+			Resources: []v1alpha1.ClusterResource{
+				{
+					Name:        "namespaces",
+					ID:          "namespaces-active",
+					Remediation: "check for namespaces stuck in Terminating",
+					Team:        "platform",
+					APIVersion:  "v1",
+					Fields:      []v1alpha1.FieldSelector{{Path: ".status.phase", Values: []string{"active"}}},
+					Required:    true,
+				},
+			},
+		},
+	}
+}
+
+func _mockObject(cl *fake.FakeDynamicClient, gvr schema.GroupVersionResource, kind, namespace, name string, fields map[string]interface{}) {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(gvr.GroupVersion().String())
+	u.SetKind(kind)
+	u.SetName(name)
+	u.SetNamespace(namespace)
+	for k, v := range fields {
+		u.Object[k] = v
+	}
+	if _, err := cl.Resource(gvr).Namespace(namespace).Create(context.Background(), u, metav1.CreateOptions{}); err != nil {
+		panic(err)
+	}
+}
+
+func Test_ValidateHook(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockObject(dynamic, NamespaceGVR, "Namespace", "", "default", map[string]interface{}{"status": map[string]interface{}{"phase": "Active"}})
+	_mockObject(dynamic, RollingUpgradeGVR, "RollingUpgrade", "instance-manager", "upgrade-1", nil)
+
+	_mockObject(dynamic, RollingUpgradeGVR, "RollingUpgrade", "kube-system", "upgrade-2", nil)
+
+	s := NewServer(_mockSpec(), dynamic, nil, "")
+	s.RollingUpgradeNamespaces = []string{"instance-manager"}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + ValidatePath)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusMethodNotAllowed))
+
+	resp, err = http.Post(ts.URL+ValidatePath+"?rollingupgrade=upgrade-2&namespace=kube-system", "", nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusForbidden))
+	ru, err := dynamic.Resource(RollingUpgradeGVR).Namespace("kube-system").Get(context.Background(), "upgrade-2", metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ru.GetAnnotations()).To(gomega.BeEmpty())
+
+	resp, err = http.Post(ts.URL+ValidatePath+"?rollingupgrade=upgrade-1&namespace=instance-manager", "", nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+
+	ru, err = dynamic.Resource(RollingUpgradeGVR).Namespace("instance-manager").Get(context.Background(), "upgrade-1", metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ru.GetAnnotations()).To(gomega.HaveKeyWithValue(RollingUpgradeResultAnnotation, "success"))
+}
+
+func Test_ValidateHookFailure(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockObject(dynamic, NamespaceGVR, "Namespace", "", "default", map[string]interface{}{"status": map[string]interface{}{"phase": "Terminating"}})
+
+	ts := httptest.NewServer(NewServer(_mockSpec(), dynamic, nil, "").Handler())
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+ValidatePath, "", nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusPreconditionFailed))
+
+	var body ValidationResponse
+	g.Expect(json.NewDecoder(resp.Body).Decode(&body)).To(gomega.Succeed())
+	g.Expect(body.ID).To(gomega.Equal("namespaces-active"))
+	g.Expect(body.Codes).To(gomega.ConsistOf(client.FailureCodeFieldMismatch))
+	g.Expect(body.Remediation).To(gomega.Equal("check for namespaces stuck in Terminating"))
+	g.Expect(body.Team).To(gomega.Equal("platform"))
+}
+
+func Test_MetricsEndpoint(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	_mockObject(dynamic, NamespaceGVR, "Namespace", "", "default", map[string]interface{}{"status": map[string]interface{}{"phase": "Active"}})
+
+	ts := httptest.NewServer(NewServer(_mockSpec(), dynamic, nil, "").Handler())
+	defer ts.Close()
+
+	scrape := func() string {
+		resp, err := http.Get(ts.URL + MetricsPath)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		defer resp.Body.Close()
+		g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+		body, err := io.ReadAll(resp.Body)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return string(body)
+	}
+	g.Expect(scrape()).To(gomega.BeEmpty())
+
+	resp, err := http.Post(ts.URL+ValidatePath, "", nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+
+	metrics := scrape()
+	g.Expect(metrics).To(gomega.ContainSubstring(client.MetricRunSuccess + `{cluster=""} 1`))
+	g.Expect(metrics).To(gomega.ContainSubstring(client.MetricValidationSuccess + `{cluster="",validation="namespaces",id="namespaces-active"} 1`))
+}
+
+func Test_WatchRevalidation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		NamespaceGVR:                      "NamespaceList",
+		{Version: "v1", Resource: "pods"}: "PodList",
+	})
+	_mockObject(dynamic, NamespaceGVR, "Namespace", "", "default", map[string]interface{}{"status": map[string]interface{}{"phase": "Active"}})
+
+	spec := _mockSpec()
+	spec.Spec.Checks = []v1alpha1.ClusterCheck{{Name: "failed-pods", FailedPods: &v1alpha1.FailedPodsCheck{}}}
+	s := NewServer(spec, dynamic, nil, "")
+	s.Debounce = 10 * time.Millisecond
+	g.Expect(s.Run()).To(gomega.Succeed())
+	g.Expect(s.report.get().Validations).To(gomega.HaveLen(2))
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		_ = s.Watch(stop)
+	}()
+
+	// the spec is re-validated without a request once the namespace starts terminating, the
+	// namespace is updated until the watch has synced and picks up the change
+	terminate := func() {
+		ns, err := dynamic.Resource(NamespaceGVR).Get(context.Background(), "default", metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		ns.SetResourceVersion(time.Now().String())
+		ns.Object["status"] = map[string]interface{}{"phase": "Terminating"}
+		_, err = dynamic.Resource(NamespaceGVR).Update(context.Background(), ns, metav1.UpdateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+	g.Eventually(func() client.ValidationStatus {
+		terminate()
+		for _, p := range s.report.get().Validations {
+			if p.ID == "namespaces-active" {
+				return p.Status
+			}
+		}
+		return ""
+	}, 5*time.Second, 50*time.Millisecond).Should(gomega.Equal(client.ValidationStatusFailed))
+
+	// the check was not affected by the change and keeps its result from the full run
+	report := s.report.get()
+	g.Expect(report.Validations).To(gomega.HaveLen(2))
+	g.Expect(report.Validations[0].Name).To(gomega.Equal("failed-pods"))
+	g.Expect(report.Success).To(gomega.BeFalse())
+}
+
+func Test_MergeReport(t *testing.T) {
+	g := gomega.NewWithT(t)
+	required := map[string]bool{"nodes/": true, "pods/": false}
+	last := &client.Report{Success: false, Error: "nodes failed", Validations: []client.ValidationProgress{
+		{Name: "nodes", Status: client.ValidationStatusFailed},
+		{Name: "pods", Status: client.ValidationStatusFailed},
+	}}
+
+	merged := mergeReport(last, client.Report{Success: true, Validations: []client.ValidationProgress{
+		{Name: "pods", Status: client.ValidationStatusSucceeded},
+	}}, required)
+	g.Expect(merged.Success).To(gomega.BeFalse())
+	g.Expect(merged.Error).To(gomega.Equal("nodes failed"))
+	g.Expect(merged.Validations).To(gomega.HaveLen(2))
+
+	merged = mergeReport(last, client.Report{Success: true, Validations: []client.ValidationProgress{
+		{Name: "nodes", Status: client.ValidationStatusSucceeded},
+	}}, required)
+	g.Expect(merged.Success).To(gomega.BeTrue())
+	g.Expect(merged.Validations).To(gomega.HaveLen(2))
+}
+
+func Test_HealthEndpoints(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dynamic := _fakeDynamicClient()
+	s := NewServer(_mockSpec(), dynamic, nil, "")
+	s.MaxRunDuration = time.Minute
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	probe := func(path string) (int, HealthResponse) {
+		resp, err := http.Get(srv.URL + path)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		defer resp.Body.Close()
+		health := HealthResponse{}
+		g.Expect(json.NewDecoder(resp.Body).Decode(&health)).To(gomega.Succeed())
+		return resp.StatusCode, health
+	}
+
+	code, health := probe(ReadyzPath)
+	g.Expect(code).To(gomega.Equal(http.StatusOK))
+	g.Expect(*health.APIReachable).To(gomega.BeTrue())
+	g.Expect(health.LastEvaluation).To(gomega.BeNil())
+
+	_mockObject(dynamic, NamespaceGVR, "Namespace", "", "default", map[string]interface{}{"status": map[string]interface{}{"phase": "Active"}})
+	g.Expect(s.Run()).To(gomega.Succeed())
+	code, health = probe(HealthzPath)
+	g.Expect(code).To(gomega.Equal(http.StatusOK))
+	g.Expect(health.LastEvaluation).NotTo(gomega.BeNil())
+	g.Expect(*health.LastSuccess).To(gomega.BeTrue())
+
+	s.health.started()
+	s.health.runningSince = time.Now().Add(-2 * time.Minute)
+	code, health = probe(HealthzPath)
+	g.Expect(code).To(gomega.Equal(http.StatusServiceUnavailable))
+	g.Expect(health.Message).To(gomega.ContainSubstring("longer than 1m0s"))
+
+	dynamic.PrependReactor("list", "namespaces", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	code, health = probe(ReadyzPath)
+	g.Expect(code).To(gomega.Equal(http.StatusServiceUnavailable))
+	g.Expect(*health.APIReachable).To(gomega.BeFalse())
+}
@@ -0,0 +1,241 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/keikoproj/cluster-validator/pkg/api/v1alpha1"
+	"github.com/keikoproj/cluster-validator/pkg/client"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// revalidation batches the GVRs that changed within the debounce window of the first change.
+type revalidation struct {
+	sync.Mutex
+	pending map[schema.GroupVersionResource]bool
+	timer   *time.Timer
+}
+
+// Watch watches the resources validated by the spec and re-runs the resource entries and
+// groups validating a changed resource once Debounce passed since the first change, until
+// stop is closed. Changes during a run are batched into the next run.
+func (s *Server) Watch(stop <-chan struct{}) error {
+	var (
+		gvrs    = watchedResources(s.Spec)
+		factory = dynamicinformer.NewDynamicSharedInformerFactory(s.Kubernetes, 0)
+		synced  = make([]cache.InformerSynced, 0, len(gvrs))
+		pending = &revalidation{pending: make(map[schema.GroupVersionResource]bool)}
+		ready   = make(chan struct{})
+	)
+
+	if len(gvrs) == 0 {
+		return errors.New("spec contains no resources to watch")
+	}
+
+	for _, gvr := range gvrs {
+		gvr := gvr
+		changed := func() {
+			select {
+			case <-ready:
+				s.changed(pending, gvr)
+			default:
+				// initial list of the informer
+			}
+		}
+		informer := factory.ForResource(gvr).Informer()
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) { changed() },
+			UpdateFunc: func(old, new interface{}) {
+				if resourceVersion(old) != resourceVersion(new) {
+					changed()
+				}
+			},
+			DeleteFunc: func(obj interface{}) { changed() },
+		})
+		synced = append(synced, informer.HasSynced)
+	}
+
+	factory.Start(stop)
+	if !cache.WaitForCacheSync(stop, synced...) {
+		return errors.New("failed to sync watched resources")
+	}
+	close(ready)
+	log.Infof("watching %v resources, re-validating %v after a change", len(gvrs), s.Debounce)
+	<-stop
+	return nil
+}
+
+func (s *Server) changed(r *revalidation, gvr schema.GroupVersionResource) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.pending[gvr] = true
+	if r.timer != nil {
+		return
+	}
+	r.timer = time.AfterFunc(s.Debounce, func() {
+		r.Lock()
+		changed := r.pending
+		r.pending = make(map[schema.GroupVersionResource]bool)
+		r.timer = nil
+		r.Unlock()
+
+		spec := affectedSpec(s.Spec, changed)
+		log.Infof("watched resources changed, re-validating %v resources and %v groups", len(spec.Spec.Resources), len(spec.Spec.Groups))
+		if err := s.run(spec, true); err != nil {
+			log.Warnf("re-validation failed: %v", err)
+		}
+	})
+}
+
+// watchedResources returns the GVRs of the resource entries of a spec, including group members.
+func watchedResources(spec *v1alpha1.ClusterValidation) []schema.GroupVersionResource {
+	var (
+		seen = make(map[schema.GroupVersionResource]bool)
+		gvrs = make([]schema.GroupVersionResource, 0)
+	)
+
+	add := func(resources []v1alpha1.ClusterResource) {
+		for _, r := range resources {
+			gvr, err := resourceGVR(r)
+			if err != nil {
+				log.Warnf("not watching resource '%v': %v", r.Name, err)
+				continue
+			}
+			if !seen[gvr] {
+				seen[gvr] = true
+				gvrs = append(gvrs, gvr)
+			}
+		}
+	}
+
+	add(spec.Spec.Resources)
+	for _, g := range spec.Spec.Groups {
+		add(g.AllOf)
+		add(g.AnyOf)
+	}
+	return gvrs
+}
+
+// affectedSpec copies a spec keeping only the resource entries and groups that validate one of
+// the changed GVRs.
+func affectedSpec(spec *v1alpha1.ClusterValidation, changed map[schema.GroupVersionResource]bool) *v1alpha1.ClusterValidation {
+	var (
+		affected = *spec
+	)
+
+	validates := func(resources []v1alpha1.ClusterResource) bool {
+		for _, r := range resources {
+			if gvr, err := resourceGVR(r); err == nil && changed[gvr] {
+				return true
+			}
+		}
+		return false
+	}
+
+	affected.Spec.Resources = nil
+	for _, r := range spec.Spec.Resources {
+		if validates([]v1alpha1.ClusterResource{r}) {
+			affected.Spec.Resources = append(affected.Spec.Resources, r)
+		}
+	}
+	affected.Spec.Groups = nil
+	for _, g := range spec.Spec.Groups {
+		if validates(g.AllOf) || validates(g.AnyOf) {
+			affected.Spec.Groups = append(affected.Spec.Groups, g)
+		}
+	}
+	affected.Spec.Checks = nil
+	affected.Spec.WorkloadTests = nil
+	affected.Spec.Endpoints = v1alpha1.EndpointsSpec{}
+	return &affected
+}
+
+// requiredValidations maps the name/id key of every validation of a spec to whether it is required.
+func requiredValidations(spec *v1alpha1.ClusterValidation) map[string]bool {
+	var (
+		required = make(map[string]bool)
+	)
+
+	for _, r := range spec.Spec.Resources {
+		required[r.Name+"/"+r.ID] = r.Required
+	}
+	for _, g := range spec.Spec.Groups {
+		required[g.Name+"/"+g.ID] = g.Required
+	}
+	for _, c := range spec.Spec.Checks {
+		required[c.Name+"/"+c.ID] = c.Required
+	}
+	for _, t := range spec.Spec.WorkloadTests {
+		required[t.Name+"/"+t.ID] = t.Required
+	}
+	for _, e := range spec.Spec.Endpoints.Cluster {
+		required[e.Name+"/"+e.ID] = e.Required
+	}
+	for _, e := range spec.Spec.Endpoints.HTTP {
+		required[e.Name+"/"+e.ID] = e.Required
+	}
+	return required
+}
+
+func resourceGVR(r v1alpha1.ClusterResource) (schema.GroupVersionResource, error) {
+	gv, err := schema.ParseGroupVersion(r.APIVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, errors.Wrapf(err, "invalid apiVersion '%v'", r.APIVersion)
+	}
+	return gv.WithResource(r.Name), nil
+}
+
+func resourceVersion(obj interface{}) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetResourceVersion()
+}
+
+// mergeReport updates the report of the last run with the validations re-run in a partial run.
+// The merged run still fails when a required validation that was not re-run failed before.
+func mergeReport(last *client.Report, partial client.Report, required map[string]bool) client.Report {
+	var (
+		merged = partial
+		rerun  = make(map[string]bool)
+	)
+
+	if last == nil {
+		return partial
+	}
+
+	for _, p := range partial.Validations {
+		rerun[p.Name+"/"+p.ID] = true
+	}
+	merged.Validations = make([]client.ValidationProgress, 0, len(last.Validations)+len(partial.Validations))
+	for _, p := range last.Validations {
+		if rerun[p.Name+"/"+p.ID] {
+			continue
+		}
+		merged.Validations = append(merged.Validations, p)
+		if p.Status == client.ValidationStatusFailed && required[p.Name+"/"+p.ID] && merged.Success {
+			merged.Success, merged.Error, merged.Codes = false, last.Error, last.Codes
+		}
+	}
+	merged.Validations = append(merged.Validations, partial.Validations...)
+	return merged
+}